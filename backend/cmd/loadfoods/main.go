@@ -0,0 +1,81 @@
+// Command loadfoods loads a USDA FoodData Central CSV export into the nutrients table, the
+// food composition data recipe nutrition is computed from.
+//
+// Usage:
+//
+//	loadfoods DIR
+//
+// DIR is an unpacked FoodData Central CSV download, such as SR Legacy or Foundation Foods,
+// containing food.csv and food_nutrient.csv. Foods already loaded are updated in place, so
+// ingredients stay mapped to them. It connects to DATABASE_URL like the server and applies
+// pending migrations first.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/database/sqlite"
+	"gorecipes/backend/internal/nutrition"
+)
+
+const usage = "usage: loadfoods DIR (a FoodData Central CSV export with food.csv and food_nutrient.csv)"
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) != 2 {
+		log.Fatal(usage)
+	}
+	dir := os.Args[1]
+
+	foodFile, err := os.Open(filepath.Join(dir, "food.csv"))
+	if err != nil {
+		log.Fatalf("Failed to open food list: %v", err)
+	}
+	defer foodFile.Close()
+	nutrientFile, err := os.Open(filepath.Join(dir, "food_nutrient.csv"))
+	if err != nil {
+		log.Fatalf("Failed to open food nutrients: %v", err)
+	}
+	defer nutrientFile.Close()
+
+	foods, err := nutrition.ParseFDC(foodFile, nutrientFile)
+	if err != nil {
+		log.Fatalf("Failed to read FoodData Central export: %v", err)
+	}
+	if len(foods) == 0 {
+		log.Fatalf("No foods with nutrients found in %s", dir)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable not set")
+	}
+	var repos database.Repositories
+	if sqlite.IsURL(dbURL) {
+		store, err := sqlite.Open(dbURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize SQLite database: %v", err)
+		}
+		defer store.Close()
+		repos = store.Repositories()
+	} else {
+		if err := database.InitPostgreSQLDB(dbURL); err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer database.ClosePostgreSQLDB()
+		repos = database.PostgresRepositories()
+	}
+	// A full dataset takes longer to store than a request may
+	database.QueryTimeout = 0
+
+	n, err := repos.Nutrition.UpsertFoods(context.Background(), foods)
+	if err != nil {
+		log.Fatalf("Failed to store foods: %v", err)
+	}
+	fmt.Printf("Loaded %d foods from %s.\n", n, dir)
+}
@@ -0,0 +1,110 @@
+// Command migrate manages the database schema with the migrations embedded in the backend.
+//
+// Usage:
+//
+//	migrate up        Apply every pending migration
+//	migrate down [N]  Roll back the N most recently applied migrations (default 1)
+//	migrate status    List migrations and when each was applied
+//	migrate redo      Roll back the most recent migration and apply it again
+//
+// It connects to DATABASE_URL, using the SQLite migrations for a sqlite: URL and the
+// PostgreSQL ones otherwise. The server also runs "up" when it starts.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/database/sqlite"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+const usage = "usage: migrate up | down [N] | status | redo"
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable not set")
+	}
+	var db *sql.DB
+	var err error
+	if sqlite.IsURL(dbURL) {
+		db, err = sqlite.OpenDB(dbURL)
+	} else {
+		db, err = sql.Open("postgres", dbURL)
+	}
+	if err != nil {
+		log.Fatalf("Failed to open database connection: %v", err)
+	}
+	defer db.Close()
+
+	var migrator *database.Migrator
+	if sqlite.IsURL(dbURL) {
+		migrator, err = sqlite.NewMigrator(db)
+	} else {
+		migrator, err = database.NewMigrator(db)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	switch command := os.Args[1]; command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations.")
+		}
+	case "down":
+		n := 1
+		if len(os.Args) > 2 {
+			if n, err = strconv.Atoi(os.Args[2]); err != nil || n < 1 {
+				log.Fatalf("Invalid number of migrations to roll back %q: must be a positive integer", os.Args[2])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if len(rolledBack) < n {
+			fmt.Printf("Rolled back %d migrations; none are left to roll back.\n", len(rolledBack))
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Local().Format(time.DateTime)
+			}
+			name := status.Name
+			if status.Missing {
+				name = "(file missing)"
+			}
+			fmt.Printf("%-16d %-45s %s\n", status.Version, name, state)
+		}
+	case "redo":
+		if _, err := migrator.Redo(ctx); err != nil {
+			log.Fatalf("Redo failed: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q\n%s", command, usage)
+	}
+}
@@ -14,17 +14,27 @@ package main
 import (
 	"context" // Import context
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "gorecipes/backend/docs" // Import generated docs
 	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/database/sqlite"
+	"gorecipes/backend/internal/handlers"
+	"gorecipes/backend/internal/images"
 	"gorecipes/backend/internal/router"
+	"gorecipes/backend/internal/storage"
 )
 
+// trashPurgeInterval is how often recipes past the trash retention period are purged.
+const trashPurgeInterval = time.Hour
+
 func main() {
 	log.Println("Starting Go Recipes API...") // Changed from fmt.Println for consistency
 
@@ -38,26 +48,146 @@ func main() {
 		log.Printf("Using default DATABASE_URL: %s (Ensure this is correctly configured for your environment)", dbURL)
 	}
 
-	// Initialize Database with retry logic
-	var dbErr error
-	for i := 0; i < 5; i++ {
-		dbErr = database.InitPostgreSQLDB(dbURL)
-		if dbErr == nil {
-			break // Success
+	// Initialize Database: a sqlite: URL selects the SQLite backend, anything else PostgreSQL
+	var repos database.Repositories
+	var sqliteStore *sqlite.Store
+	if sqlite.IsURL(dbURL) {
+		var err error
+		sqliteStore, err = sqlite.Open(dbURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize SQLite database: %v", err)
+		}
+		repos = sqliteStore.Repositories()
+	} else {
+		// Retry, as PostgreSQL may still be starting up
+		var dbErr error
+		for i := 0; i < 5; i++ {
+			dbErr = database.InitPostgreSQLDB(dbURL)
+			if dbErr == nil {
+				break // Success
+			}
+			log.Printf("Failed to initialize database (attempt %d/5): %v. Retrying in 5 seconds...", i+1, dbErr)
+			time.Sleep(5 * time.Second)
+		}
+		if dbErr != nil {
+			log.Fatalf("Failed to initialize database after several attempts: %v", dbErr)
+		}
+		repos = database.PostgresRepositories()
+	}
+
+	// Query timeout: statements running longer than this are canceled and answered with 504
+	if queryTimeout := os.Getenv("DB_QUERY_TIMEOUT"); queryTimeout != "" {
+		timeout, err := time.ParseDuration(queryTimeout)
+		if err != nil || timeout < 0 {
+			log.Fatalf("Invalid DB_QUERY_TIMEOUT %q: must be a non-negative duration such as 30s (0 disables it)", queryTimeout)
 		}
-		log.Printf("Failed to initialize database (attempt %d/5): %v. Retrying in 5 seconds...", i+1, dbErr)
-		time.Sleep(5 * time.Second)
+		database.QueryTimeout = timeout
 	}
-	if dbErr != nil {
-		log.Fatalf("Failed to initialize database after several attempts: %v", dbErr)
+	if database.QueryTimeout > 0 {
+		log.Printf("Database queries time out after %s", database.QueryTimeout)
+	} else {
+		log.Println("Database query timeout is disabled")
 	}
 
 	// Seed the database with sample data
 
 	// defer database.CloseDB() // Will call this explicitly on shutdown
 
+	// Trash retention: deleted recipes are purged after this many days
+	if retentionDays := os.Getenv("TRASH_RETENTION_DAYS"); retentionDays != "" {
+		days, err := strconv.Atoi(retentionDays)
+		if err != nil || days < 0 {
+			log.Fatalf("Invalid TRASH_RETENTION_DAYS %q: must be a non-negative whole number", retentionDays)
+		}
+		handlers.TrashRetention = time.Duration(days) * 24 * time.Hour
+	}
+	log.Printf("Deleted recipes are kept in the trash for %s", handlers.TrashRetention)
+
+	// Image pipeline: format and quality of stored photo variants
+	format, err := images.ParseFormat(os.Getenv("IMAGE_FORMAT"))
+	if err != nil {
+		log.Fatalf("Invalid IMAGE_FORMAT: %v", err)
+	}
+	if format == images.FormatWebP && !images.WebPAvailable() {
+		log.Printf("IMAGE_FORMAT=webp but the cwebp encoder is not installed; storing photos as JPEG")
+		format = images.FormatJPEG
+	}
+	images.OutputFormat = format
+	if quality := os.Getenv("IMAGE_QUALITY"); quality != "" {
+		q, err := strconv.Atoi(quality)
+		if err != nil || q < 1 || q > 100 {
+			log.Fatalf("Invalid IMAGE_QUALITY %q: must be a whole number from 1 to 100", quality)
+		}
+		images.Quality = q
+	}
+	log.Printf("Photos are stored as %s (quality %d)", images.OutputFormat, images.Quality)
+
+	// Photo storage: a local directory, or an S3-compatible bucket shared by every replica
+	switch storeKind := strings.ToLower(os.Getenv("PHOTO_STORE")); storeKind {
+	case "", "local":
+		dir := os.Getenv("PHOTO_STORE_DIR")
+		if dir == "" {
+			dir = "uploads/images" // Relative to backend directory
+		}
+		store, err := storage.NewLocalStore(dir, handlers.PhotoURLPath)
+		if err != nil {
+			log.Fatalf("Failed to set up local photo store: %v", err)
+		}
+		handlers.PhotoStore = store
+		log.Printf("Photos are kept in directory %s", dir)
+	case "s3":
+		cfg := storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			BaseURL:   os.Getenv("S3_PUBLIC_URL"),
+		}
+		if useSSL := os.Getenv("S3_USE_SSL"); useSSL != "" {
+			if cfg.UseSSL, err = strconv.ParseBool(useSSL); err != nil {
+				log.Fatalf("Invalid S3_USE_SSL %q: must be true or false", useSSL)
+			}
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = handlers.PhotoURLPath // Streamed from the bucket by the backend
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		store, err := storage.NewS3Store(ctx, cfg)
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to set up S3 photo store: %v", err)
+		}
+		handlers.PhotoStore = store
+		log.Printf("Photos are kept in S3 bucket %s at %s", cfg.Bucket, cfg.Endpoint)
+	default:
+		log.Fatalf("Invalid PHOTO_STORE %q: must be local or s3", storeKind)
+	}
+
+	// Every request context derives from baseCtx, so canceling it aborts the queries of
+	// requests still running when the server is forced to shut down.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	trashPurger := &handlers.RecipeHandler{Recipes: repos.Recipes}
+	stopPurge := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := trashPurger.PurgeExpiredTrash(baseCtx); err != nil {
+				log.Printf("[Trash] Scheduled purge failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-stopPurge:
+				return
+			}
+		}
+	}()
+
 	// Initialize Gin router using the setup function
-	appRouter := router.SetupRouter()
+	appRouter := router.SetupRouter(repos)
 
 	// Start the server
 	port := os.Getenv("PORT") // Use environment variable for port
@@ -66,8 +196,9 @@ func main() {
 	}
 
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: appRouter,
+		Addr:        ":" + port,
+		Handler:     appRouter,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	// Start server in a goroutine so that it doesn't block.
@@ -86,6 +217,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	close(stopPurge)
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the requests it is currently handling
@@ -93,9 +225,18 @@ func main() {
 	defer cancel() // Release resources if main completes before timeout
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+		cancelRequests() // Cancel the in-flight queries of the remaining requests
+		srv.Close()
 	}
 
 	log.Println("Server exiting")
-	database.ClosePostgreSQLDB() // Close DB after server has shut down
+	// Close DB after server has shut down
+	if sqliteStore != nil {
+		if err := sqliteStore.Close(); err != nil {
+			log.Printf("Error closing SQLite database: %v", err)
+		}
+	} else {
+		database.ClosePostgreSQLDB()
+	}
 }
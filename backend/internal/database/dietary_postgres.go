@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SetIngredientDietaryFlags replaces the dietary flags an ingredient carries itself.
+// The flags must be known ones, as returned by dietary.NormalizeFlags.
+func SetIngredientDietaryFlags(ctx context.Context, ingredientID string, flags []string) (*models.IngredientDietaryFlags, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, ingredientID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", ingredientID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM ingredient_dietary_flags WHERE ingredient_id = $1`, ingredientID); err != nil {
+		return nil, fmt.Errorf("failed to clear dietary flags of ingredient %s: %w", ingredientID, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_dietary_flags (ingredient_id, flag, created_at)
+		SELECT $1, unnest($2::varchar[]), $3`, ingredientID, pq.Array(flags), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set dietary flags of ingredient %s: %w", ingredientID, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for dietary flags: %w", err)
+	}
+	return &models.IngredientDietaryFlags{IngredientID: ingredientID, Flags: append([]string{}, flags...)}, nil
+}
+
+// GetRecipeDietary fetches the dietary flags of a recipe, derived from its ingredients and
+// their ancestors in the taxonomy and corrected by its overrides. Returns nil for recipes
+// that are missing or in the trash.
+func GetRecipeDietary(ctx context.Context, recipeID string) (*models.RecipeDietary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var exists bool
+	err := DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)`, recipeID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipe with ID %s: %w", recipeID, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	result := &models.RecipeDietary{RecipeID: recipeID}
+	if result.Flags, err = queryRecipeDietaryFlags(ctx, recipeID); err != nil {
+		return nil, err
+	}
+	if result.Overrides, err = queryRecipeDietaryOverrides(ctx, recipeID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// queryRecipeDietaryFlags fetches a recipe's flags from the recipe_dietary_flags view.
+func queryRecipeDietaryFlags(ctx context.Context, recipeID string) ([]string, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT flag FROM recipe_dietary_flags WHERE recipe_id = $1`, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dietary flags of recipe %s: %w", recipeID, err)
+	}
+	defer rows.Close()
+
+	flags := []string{}
+	for rows.Next() {
+		var flag string
+		if err := rows.Scan(&flag); err != nil {
+			return nil, fmt.Errorf("failed to scan dietary flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dietary flag rows: %w", err)
+	}
+	dietary.SortFlags(flags)
+	return flags, nil
+}
+
+// queryRecipeDietaryOverrides fetches a recipe's overrides.
+func queryRecipeDietaryOverrides(ctx context.Context, recipeID string) (map[string]bool, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT flag, present FROM recipe_dietary_overrides WHERE recipe_id = $1`, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dietary overrides of recipe %s: %w", recipeID, err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]bool)
+	for rows.Next() {
+		var flag string
+		var present bool
+		if err := rows.Scan(&flag, &present); err != nil {
+			return nil, fmt.Errorf("failed to scan dietary override: %w", err)
+		}
+		overrides[flag] = present
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dietary override rows: %w", err)
+	}
+	return overrides, nil
+}
+
+// SetRecipeDietaryOverrides replaces the manual corrections of a recipe's dietary flags and
+// returns its flags with them applied. The flags must be known ones.
+func SetRecipeDietaryOverrides(ctx context.Context, recipeID string, overrides map[string]bool) (*models.RecipeDietary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)`, recipeID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipe with ID %s: %w", recipeID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM recipe_dietary_overrides WHERE recipe_id = $1`, recipeID); err != nil {
+		return nil, fmt.Errorf("failed to clear dietary overrides of recipe %s: %w", recipeID, err)
+	}
+	now := time.Now().UTC()
+	for flag, present := range overrides {
+		_, err = tx.ExecContext(ctx, `INSERT INTO recipe_dietary_overrides (recipe_id, flag, present, created_at)
+			VALUES ($1, $2, $3, $4)`, recipeID, flag, present, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to override dietary flag %s of recipe %s: %w", flag, recipeID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for dietary overrides: %w", err)
+	}
+	return GetRecipeDietary(ctx, recipeID)
+}
+
+// GetAllIngredientDietaryFlags fetches the flags of every flagged ingredient, for export and
+// administration.
+func GetAllIngredientDietaryFlags(ctx context.Context) ([]models.IngredientDietaryFlags, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT ingredient_id, flag FROM ingredient_dietary_flags ORDER BY ingredient_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredient dietary flags: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.IngredientDietaryFlags{}
+	for rows.Next() {
+		var ingredientID, flag string
+		if err := rows.Scan(&ingredientID, &flag); err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient dietary flag: %w", err)
+		}
+		if n := len(result); n == 0 || result[n-1].IngredientID != ingredientID {
+			result = append(result, models.IngredientDietaryFlags{IngredientID: ingredientID})
+		}
+		result[len(result)-1].Flags = append(result[len(result)-1].Flags, flag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient dietary flag rows: %w", err)
+	}
+	for _, flagged := range result {
+		dietary.SortFlags(flagged.Flags)
+	}
+	return result, nil
+}
+
+// GetAllRecipeDietaryOverrides fetches the overrides of every recipe that has any, including
+// recipes in the trash, for export.
+func GetAllRecipeDietaryOverrides(ctx context.Context) ([]models.RecipeDietaryOverrides, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT recipe_id, flag, present FROM recipe_dietary_overrides ORDER BY recipe_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipe dietary overrides: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.RecipeDietaryOverrides{}
+	for rows.Next() {
+		var recipeID, flag string
+		var present bool
+		if err := rows.Scan(&recipeID, &flag, &present); err != nil {
+			return nil, fmt.Errorf("failed to scan recipe dietary override: %w", err)
+		}
+		if n := len(result); n == 0 || result[n-1].RecipeID != recipeID {
+			result = append(result, models.RecipeDietaryOverrides{RecipeID: recipeID, Overrides: make(map[string]bool)})
+		}
+		result[len(result)-1].Overrides[flag] = present
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe dietary override rows: %w", err)
+	}
+	return result, nil
+}
+
+// insertImportedIngredientDietaryFlagsTx adds the flags from an import file to its imported
+// ingredient, keeping flags it already carries. Unknown flags are skipped.
+// Operates within a transaction.
+func insertImportedIngredientDietaryFlagsTx(ctx context.Context, tx *sql.Tx, flagged models.IngredientDietaryFlags, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[flagged.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", flagged.IngredientID)
+	}
+	for _, flag := range flagged.Flags {
+		if !dietary.IsFlag(flag) {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO ingredient_dietary_flags (ingredient_id, flag, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (ingredient_id, flag) DO NOTHING`, dbIngredientID, flag, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("failed to flag ingredient DB ID %s with %s: %w", dbIngredientID, flag, err)
+		}
+	}
+	return nil
+}
+
+// insertImportedRecipeDietaryOverridesTx adds the overrides from an import file to its imported
+// recipe, keeping overrides it already has. Unknown flags are skipped.
+// Operates within a transaction.
+func insertImportedRecipeDietaryOverridesTx(ctx context.Context, tx *sql.Tx, overrides models.RecipeDietaryOverrides, recipeOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, ok := recipeOriginalIDToDbIDMap[overrides.RecipeID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", overrides.RecipeID)
+	}
+	for flag, present := range overrides.Overrides {
+		if !dietary.IsFlag(flag) {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO recipe_dietary_overrides (recipe_id, flag, present, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (recipe_id, flag) DO NOTHING`, dbRecipeID, flag, present, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("failed to override dietary flag %s of recipe DB ID %s: %w", flag, dbRecipeID, err)
+		}
+	}
+	return nil
+}
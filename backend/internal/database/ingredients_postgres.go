@@ -0,0 +1,523 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SearchIngredients suggests ingredients for an autocomplete query, matching their names and
+// aliases. Names starting with the query come first, then names with a word starting with it,
+// then names similar to it by trigram matching; within each group the ingredients used by the
+// most live recipes come first.
+func SearchIngredients(ctx context.Context, query string, limit int) ([]models.IngredientSuggestion, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	query = NormalizeIngredientName(query)
+	if query == "" {
+		return []models.IngredientSuggestion{}, nil
+	}
+
+	sqlQuery := `WITH names AS (
+			SELECT id AS ingredient_id, normalized_name AS matched, NULL::text AS alias FROM ingredients
+			UNION ALL
+			SELECT ingredient_id, normalized_name, name FROM ingredient_aliases
+		), matches AS (
+			SELECT DISTINCT ON (ingredient_id) ingredient_id, alias,
+				CASE WHEN matched LIKE $1 || '%' ESCAPE '\' THEN 0
+					WHEN matched LIKE '% ' || $1 || '%' ESCAPE '\' THEN 1
+					ELSE 2 END AS tier,
+				similarity(matched, $2) AS score
+			FROM names
+			WHERE matched LIKE $1 || '%' ESCAPE '\' OR matched LIKE '% ' || $1 || '%' ESCAPE '\' OR matched % $2
+			ORDER BY ingredient_id, tier, score DESC, alias IS NOT NULL
+		)
+		SELECT i.id, i.name, COALESCE(m.alias, ''),
+			(SELECT COUNT(DISTINCT ri.recipe_id) FROM recipe_ingredients ri JOIN recipes r ON r.id = ri.recipe_id
+				WHERE ri.ingredient_id = i.id AND r.deleted_at IS NULL) AS usage_count
+		FROM matches m
+		JOIN ingredients i ON i.id = m.ingredient_id
+		ORDER BY m.tier, usage_count DESC, m.score DESC, i.name
+		LIMIT $3`
+
+	rows, err := DB.QueryContext(ctx, sqlQuery, EscapeLike(query), query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.IngredientSuggestion{}
+	for rows.Next() {
+		var suggestion models.IngredientSuggestion
+		if err := rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.MatchedAlias, &suggestion.UsageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient suggestions: %w", err)
+	}
+	return suggestions, nil
+}
+
+// ingredientSummarySelect selects the columns scanned by scanIngredientSummary. Queries must
+// alias the ingredients table as "i".
+const ingredientSummarySelect = `SELECT i.id, i.name, i.normalized_name, i.parent_id, i.created_at, i.updated_at,
+		(SELECT COUNT(DISTINCT ri.recipe_id) FROM recipe_ingredients ri JOIN recipes r ON r.id = ri.recipe_id
+			WHERE ri.ingredient_id = i.id AND r.deleted_at IS NULL),
+		COALESCE((SELECT array_agg(a.name ORDER BY a.name) FROM ingredient_aliases a WHERE a.ingredient_id = i.id), '{}')
+	FROM ingredients i`
+
+// scanIngredientSummary scans a row selected with ingredientSummarySelect.
+func scanIngredientSummary(row interface{ Scan(...interface{}) error }) (models.IngredientSummary, error) {
+	var summary models.IngredientSummary
+	var parentID sql.NullString
+	var aliases pq.StringArray
+	err := row.Scan(&summary.ID, &summary.Name, &summary.NormalizedName, &parentID, &summary.CreatedAt, &summary.UpdatedAt,
+		&summary.UsageCount, &aliases)
+	summary.ParentID = parentID.String
+	summary.Aliases = []string(aliases)
+	return summary, err
+}
+
+// GetIngredientSummaries retrieves every ingredient with its usage count and aliases, grouped
+// by the taxonomy: each ingredient is followed by the ingredients below it.
+func GetIngredientSummaries(ctx context.Context) ([]models.IngredientSummary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, ingredientSummarySelect+` ORDER BY LOWER(i.name) ASC, i.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.IngredientSummary{}
+	for rows.Next() {
+		summary, err := scanIngredientSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient row: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient rows: %w", err)
+	}
+	return GroupByHierarchy(summaries), nil
+}
+
+// getIngredientSummaryTx retrieves one ingredient with its usage count and aliases.
+// Operates within a transaction.
+func getIngredientSummaryTx(ctx context.Context, tx *sql.Tx, id string) (*models.IngredientSummary, error) {
+	summary, err := scanIngredientSummary(tx.QueryRowContext(ctx, ingredientSummarySelect+` WHERE i.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ingredient with ID %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", id, err)
+	}
+	return &summary, nil
+}
+
+// RenameIngredient changes the name of an ingredient. The previous name becomes an alias, so
+// ingredient lines using it keep linking to the ingredient. A name that is already another
+// ingredient's name or alias is rejected.
+func RenameIngredient(ctx context.Context, id string, name string) (*models.IngredientSummary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM ingredients WHERE id = $1 FOR UPDATE`, id).Scan(&oldName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ingredient with ID %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", id, err)
+	}
+
+	var aliasOf string
+	err = tx.QueryRowContext(ctx, `SELECT ingredient_id FROM ingredient_aliases WHERE normalized_name = normalize_ingredient_name($1)`, name).Scan(&aliasOf)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check aliases for '%s': %w", name, err)
+	}
+	if err == nil && aliasOf != id {
+		return nil, fmt.Errorf("'%s' already exists as an alias of another ingredient", name)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE ingredients SET name = $1 WHERE id = $2`, name, id); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("ingredient named '%s' already exists", name)
+		}
+		return nil, fmt.Errorf("failed to rename ingredient with ID %s: %w", id, err)
+	}
+	// The new name no longer needs an alias; the old one gets one unless it only differed in case.
+	_, err = tx.ExecContext(ctx, `DELETE FROM ingredient_aliases WHERE normalized_name = normalize_ingredient_name($1)`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove alias '%s': %w", name, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aliases (id, ingredient_id, name, created_at)
+		SELECT $1::uuid, $2::uuid, $3::text, $4::timestamptz
+		WHERE normalize_ingredient_name($3) <> normalize_ingredient_name($5)
+		ON CONFLICT (normalized_name) DO NOTHING`, uuid.NewString(), id, oldName, time.Now().UTC(), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add alias '%s': %w", oldName, err)
+	}
+
+	summary, err := getIngredientSummaryTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient rename: %w", err)
+	}
+	return summary, nil
+}
+
+// MergeIngredients folds the source ingredients into the target: their recipe links, aliases
+// and child ingredients move to the target, their names become aliases of it, and they are
+// deleted. Every recipe line is kept, so a recipe that used several of the ingredients names
+// the target on each of those lines. Returns the target and the recipes that were relinked.
+func MergeIngredients(ctx context.Context, targetID string, sourceIDs []string) (*models.IngredientMergeResult, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	sourceIDs, err := MergeSourceIDs(targetID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	allIDs := append([]string{targetID}, sourceIDs...)
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM ingredients WHERE id = ANY($1) FOR UPDATE`, pq.Array(allIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ingredients for merge: %w", err)
+	}
+	found := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan ingredient ID: %w", err)
+		}
+		found[id] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient rows: %w", err)
+	}
+	for _, id := range allIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("ingredient with ID %s not found", id)
+		}
+	}
+
+	rows, err = tx.QueryContext(ctx, `SELECT DISTINCT recipe_id FROM recipe_ingredients
+		WHERE ingredient_id = ANY($1) ORDER BY recipe_id`, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipes using the merged ingredients: %w", err)
+	}
+	affectedRecipeIDs := []string{}
+	for rows.Next() {
+		var recipeID string
+		if err := rows.Scan(&recipeID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan recipe ID: %w", err)
+		}
+		affectedRecipeIDs = append(affectedRecipeIDs, recipeID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe rows: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE recipe_ingredients SET ingredient_id = $1 WHERE ingredient_id = ANY($2)`, targetID, pq.Array(sourceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to relink recipes to ingredient %s: %w", targetID, err)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE ingredient_aliases SET ingredient_id = $1 WHERE ingredient_id = ANY($2)`, targetID, pq.Array(sourceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to move aliases to ingredient %s: %w", targetID, err)
+	}
+	if err = reparentMergedChildrenTx(ctx, tx, targetID, sourceIDs); err != nil {
+		return nil, err
+	}
+	// The target keeps its food; otherwise it takes the most recently mapped one of the sources
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_foods (ingredient_id, food_id, grams_per_ml, grams_per_piece, updated_at)
+		SELECT $1::uuid, food_id, grams_per_ml, grams_per_piece, updated_at
+		FROM ingredient_foods WHERE ingredient_id = ANY($2)
+		ORDER BY updated_at DESC LIMIT 1
+		ON CONFLICT (ingredient_id) DO NOTHING`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to move food mapping to ingredient %s: %w", targetID, err)
+	}
+	if err = clearIngredientNutritionTx(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+	// The target carries every dietary flag of the merged ingredients, erring on the safe side
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_dietary_flags (ingredient_id, flag, created_at)
+		SELECT $1::uuid, flag, MIN(created_at) FROM ingredient_dietary_flags WHERE ingredient_id = ANY($2)
+		GROUP BY flag
+		ON CONFLICT (ingredient_id, flag) DO NOTHING`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to move dietary flags to ingredient %s: %w", targetID, err)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE substitutions SET ingredient_id = $1 WHERE ingredient_id = ANY($2)`, targetID, pq.Array(sourceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to move substitutions to ingredient %s: %w", targetID, err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE substitution_replacements SET ingredient_id = $1 WHERE ingredient_id = ANY($2)`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to relink substitutions to ingredient %s: %w", targetID, err)
+	}
+	// The target keeps its pantry item; otherwise it takes the most recently updated one of the sources
+	_, err = tx.ExecContext(ctx, `UPDATE pantry_items SET ingredient_id = $1
+		WHERE id = (SELECT id FROM pantry_items WHERE ingredient_id = ANY($2) ORDER BY updated_at DESC LIMIT 1)
+			AND NOT EXISTS (SELECT 1 FROM pantry_items WHERE ingredient_id = $1)`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to move pantry item to ingredient %s: %w", targetID, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO pantry_staples (ingredient_id, created_at)
+		SELECT $1::uuid, MIN(created_at) FROM pantry_staples WHERE ingredient_id = ANY($2)
+		HAVING COUNT(*) > 0
+		ON CONFLICT (ingredient_id) DO NOTHING`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to move pantry staple to ingredient %s: %w", targetID, err)
+	}
+	// The target keeps its aisle; otherwise it takes the most recently set one of the sources
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aisles (ingredient_id, aisle, updated_at)
+		SELECT $1::uuid, aisle, updated_at FROM ingredient_aisles WHERE ingredient_id = ANY($2)
+		ORDER BY updated_at DESC LIMIT 1
+		ON CONFLICT (ingredient_id) DO NOTHING`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to move aisle to ingredient %s: %w", targetID, err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE shopping_list_items SET ingredient_id = $1 WHERE ingredient_id = ANY($2)`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to relink shopping list items to ingredient %s: %w", targetID, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aliases (id, ingredient_id, name, created_at)
+		SELECT DISTINCT ON (i.normalized_name) uuid_generate_v4(), $1::uuid, i.name, $3::timestamptz
+		FROM ingredients i
+		WHERE i.id = ANY($2) AND i.normalized_name <> (SELECT normalized_name FROM ingredients WHERE id = $1)
+		ORDER BY i.normalized_name, i.name
+		ON CONFLICT (normalized_name) DO UPDATE SET ingredient_id = EXCLUDED.ingredient_id`,
+		targetID, pq.Array(sourceIDs), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record merged names as aliases: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM ingredients WHERE id = ANY($1)`, pq.Array(sourceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to delete merged ingredients: %w", err)
+	}
+
+	summary, err := getIngredientSummaryTx(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient merge: %w", err)
+	}
+	return &models.IngredientMergeResult{IngredientSummary: *summary, AffectedRecipeIDs: affectedRecipeIDs}, nil
+}
+
+// reparentMergedChildrenTx moves the children of merged ingredients under the target. Should
+// that make the target its own ancestor, because it sat below one of the merged ingredients,
+// the target is detached from its parent. Operates within a transaction.
+func reparentMergedChildrenTx(ctx context.Context, tx *sql.Tx, targetID string, sourceIDs []string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE ingredients SET parent_id = $1 WHERE parent_id = ANY($2) AND id <> $1`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return fmt.Errorf("failed to move child ingredients to ingredient %s: %w", targetID, err)
+	}
+	var parentID sql.NullString
+	if err = tx.QueryRowContext(ctx, `SELECT parent_id FROM ingredients WHERE id = $1`, targetID).Scan(&parentID); err != nil {
+		return fmt.Errorf("failed to query parent of ingredient %s: %w", targetID, err)
+	}
+	if !parentID.Valid {
+		return nil
+	}
+	cycle, err := isIngredientAncestorTx(ctx, tx, targetID, parentID.String)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		if _, err = tx.ExecContext(ctx, `UPDATE ingredients SET parent_id = NULL WHERE id = $1`, targetID); err != nil {
+			return fmt.Errorf("failed to detach ingredient %s from its parent: %w", targetID, err)
+		}
+	}
+	return nil
+}
+
+// isIngredientAncestorTx reports whether ancestorID is id itself or one of the ingredients
+// above it in the taxonomy. Operates within a transaction.
+func isIngredientAncestorTx(ctx context.Context, tx *sql.Tx, ancestorID string, id string) (bool, error) {
+	var found bool
+	err := tx.QueryRowContext(ctx, `WITH RECURSIVE chain(id) AS (
+			SELECT $2::uuid
+			UNION
+			SELECT i.parent_id FROM ingredients i JOIN chain c ON i.id = c.id WHERE i.parent_id IS NOT NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE id = $1::uuid)`, ancestorID, id).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("failed to query ancestors of ingredient %s: %w", id, err)
+	}
+	return found, nil
+}
+
+// SetIngredientParent places an ingredient below another in the taxonomy, or makes it a root
+// when parentID is empty. An ingredient cannot be placed below itself or its descendants.
+func SetIngredientParent(ctx context.Context, id string, parentID string) (*models.IngredientSummary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize taxonomy changes, so two concurrent moves cannot form a cycle together
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE ingredients IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("failed to lock ingredients: %w", err)
+	}
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", id, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingredient with ID %s not found", id)
+	}
+	if parentID != "" {
+		if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, parentID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", parentID, err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("parent ingredient with ID %s not found", parentID)
+		}
+		cycle, err := isIngredientAncestorTx(ctx, tx, id, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, fmt.Errorf("cannot place ingredient %s below itself or one of its descendants", id)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE ingredients SET parent_id = $1 WHERE id = $2`, nullIfEmpty(parentID), id); err != nil {
+		return nil, fmt.Errorf("failed to set parent of ingredient %s: %w", id, err)
+	}
+
+	summary, err := getIngredientSummaryTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient parent: %w", err)
+	}
+	return summary, nil
+}
+
+// GetAllIngredientAliases fetches every ingredient alias, for export.
+func GetAllIngredientAliases(ctx context.Context) ([]models.IngredientAlias, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT id, ingredient_id, name, normalized_name, created_at
+		FROM ingredient_aliases ORDER BY ingredient_id ASC, name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying ingredient_aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []models.IngredientAlias
+	for rows.Next() {
+		var alias models.IngredientAlias
+		if err := rows.Scan(&alias.ID, &alias.IngredientID, &alias.Name, &alias.NormalizedName, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning ingredient alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient alias rows: %w", err)
+	}
+	return aliases, nil
+}
+
+// insertImportedAliasTx adds an alias from an import file to its imported ingredient,
+// keeping its ID and timestamp. An alias whose name is already taken is skipped.
+// Operates within a transaction.
+func insertImportedAliasTx(ctx context.Context, tx *sql.Tx, alias models.IngredientAlias, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[alias.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", alias.IngredientID)
+	}
+	newID, err := importedIDTx(ctx, tx, "ingredient_aliases", alias.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aliases (id, ingredient_id, name, created_at)
+		SELECT $1::uuid, $2::uuid, $3::text, $4::timestamptz
+		WHERE normalize_ingredient_name($3) <> (SELECT normalized_name FROM ingredients WHERE id = $2)
+		ON CONFLICT (normalized_name) DO NOTHING`, newID, dbIngredientID, alias.Name, timeOrNow(alias.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert alias '%s' for ingredient DB ID %s: %w", alias.Name, dbIngredientID, err)
+	}
+	return nil
+}
+
+// setImportedIngredientParentTx places an imported ingredient below its parent from the
+// import file. An ingredient that already has a parent keeps it, and a parent that would
+// make the taxonomy circular is skipped. Operates within a transaction.
+func setImportedIngredientParentTx(ctx context.Context, tx *sql.Tx, ingredient models.Ingredient, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID := ingredientOriginalIDToDbIDMap[ingredient.ID]
+	dbParentID, ok := ingredientOriginalIDToDbIDMap[ingredient.ParentID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original parent ingredient ID '%s'", ingredient.ParentID)
+	}
+	cycle, err := isIngredientAncestorTx(ctx, tx, dbIngredientID, dbParentID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		log.Printf("Skipping parent %s of ingredient %s: it would make the taxonomy circular", dbParentID, dbIngredientID)
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE ingredients SET parent_id = $1 WHERE id = $2 AND parent_id IS NULL`, dbParentID, dbIngredientID)
+	if err != nil {
+		return fmt.Errorf("failed to set parent of ingredient DB ID %s: %w", dbIngredientID, err)
+	}
+	return nil
+}
+
+// resolveIngredientTx returns ingredientID if that ingredient exists, or else the ID of the
+// ingredient named name, created if missing. Operates within a transaction.
+func resolveIngredientTx(ctx context.Context, tx *sql.Tx, ingredientID string, name string) (string, error) {
+	if ingredientID == "" {
+		return getOrCreateIngredientByNameTx(ctx, tx, name)
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, ingredientID).Scan(&exists); err != nil {
+		return "", fmt.Errorf("failed to query ingredient with ID %s: %w", ingredientID, err)
+	}
+	if !exists {
+		return "", fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+	return ingredientID, nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+)
+
+// CreateComment stores a new comment on a recipe.
+func (s *Store) CreateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipes[comment.RecipeID]; !ok {
+		return nil, fmt.Errorf("failed to insert comment: recipe ID %s does not exist", comment.RecipeID)
+	}
+	if _, exists := s.comments[comment.ID]; exists {
+		return nil, fmt.Errorf("failed to insert comment: duplicate ID %s", comment.ID)
+	}
+	comment.CreatedAt = time.Now().UTC()
+	comment.UpdatedAt = comment.CreatedAt
+	stored := comment
+	s.comments[comment.ID] = &stored
+	return &comment, nil
+}
+
+// GetCommentsByRecipeID returns the comments on a recipe, oldest first.
+func (s *Store) GetCommentsByRecipeID(ctx context.Context, recipeID string) ([]models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if comment.RecipeID == recipeID {
+			comments = append(comments, *comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+// GetCommentByID returns a single comment.
+func (s *Store) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.comments[commentID]
+	if !ok {
+		return nil, fmt.Errorf("comment with ID %s not found", commentID)
+	}
+	comment := *stored
+	return &comment, nil
+}
+
+// UpdateComment replaces the content of an existing comment.
+func (s *Store) UpdateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.comments[comment.ID]
+	if !ok {
+		return nil, fmt.Errorf("comment with ID %s not found for update", comment.ID)
+	}
+	stored.Content = comment.Content
+	stored.UpdatedAt = time.Now().UTC()
+	updated := *stored
+	return &updated, nil
+}
+
+// GetAllComments returns every comment, oldest first, for export.
+func (s *Store) GetAllComments(ctx context.Context) ([]models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		comments = append(comments, *comment)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+// DeleteComment removes a comment.
+func (s *Store) DeleteComment(ctx context.Context, commentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return fmt.Errorf("comment with ID %s not found for deletion", commentID)
+	}
+	delete(s.comments, commentID)
+	return nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+	"sort"
+)
+
+// addIngredientDietaryFlags adds known flags to those an ingredient carries itself.
+func (s *Store) addIngredientDietaryFlags(ingredientID string, flags []string) {
+	for _, flag := range flags {
+		if !dietary.IsFlag(flag) {
+			continue
+		}
+		if s.ingredientDietaryFlags[ingredientID] == nil {
+			s.ingredientDietaryFlags[ingredientID] = make(map[string]bool)
+		}
+		s.ingredientDietaryFlags[ingredientID][flag] = true
+	}
+}
+
+// recipeDietaryFlags returns the flags of a recipe: those of its ingredients and the
+// ingredients above them in the taxonomy, after the recipe's overrides.
+func (s *Store) recipeDietaryFlags(recipeID string) []string {
+	derived := make(map[string]bool)
+	for _, link := range s.recipeIngredients[recipeID] {
+		visited := make(map[string]bool)
+		for id := link.IngredientID; id != "" && !visited[id]; {
+			visited[id] = true
+			for flag := range s.ingredientDietaryFlags[id] {
+				derived[flag] = true
+			}
+			ingredient := s.ingredients[id]
+			if ingredient == nil {
+				break
+			}
+			id = ingredient.ParentID
+		}
+	}
+	flags := make([]string, 0, len(derived))
+	for flag := range derived {
+		flags = append(flags, flag)
+	}
+	return dietary.Apply(flags, s.recipeDietaryOverrides[recipeID])
+}
+
+// recipeDietary returns the flags and overrides of a recipe.
+func (s *Store) recipeDietary(recipeID string) *models.RecipeDietary {
+	overrides := make(map[string]bool)
+	for flag, present := range s.recipeDietaryOverrides[recipeID] {
+		overrides[flag] = present
+	}
+	return &models.RecipeDietary{RecipeID: recipeID, Flags: s.recipeDietaryFlags(recipeID), Overrides: overrides}
+}
+
+// SetIngredientDietaryFlags replaces the dietary flags an ingredient carries itself.
+func (s *Store) SetIngredientDietaryFlags(ctx context.Context, ingredientID string, flags []string) (*models.IngredientDietaryFlags, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ingredients[ingredientID] == nil {
+		return nil, fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+	delete(s.ingredientDietaryFlags, ingredientID)
+	s.addIngredientDietaryFlags(ingredientID, flags)
+	return &models.IngredientDietaryFlags{IngredientID: ingredientID, Flags: append([]string{}, flags...)}, nil
+}
+
+// GetRecipeDietary returns the dietary flags of a recipe, or nil if it is missing or in the trash.
+func (s *Store) GetRecipeDietary(ctx context.Context, recipeID string) (*models.RecipeDietary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(recipeID); !ok {
+		return nil, nil
+	}
+	return s.recipeDietary(recipeID), nil
+}
+
+// SetRecipeDietaryOverrides replaces the manual corrections of a recipe's dietary flags and
+// returns its flags with them applied.
+func (s *Store) SetRecipeDietaryOverrides(ctx context.Context, recipeID string, overrides map[string]bool) (*models.RecipeDietary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(recipeID); !ok {
+		return nil, fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+	delete(s.recipeDietaryOverrides, recipeID)
+	if len(overrides) > 0 {
+		s.recipeDietaryOverrides[recipeID] = make(map[string]bool, len(overrides))
+		for flag, present := range overrides {
+			s.recipeDietaryOverrides[recipeID][flag] = present
+		}
+	}
+	return s.recipeDietary(recipeID), nil
+}
+
+// GetAllIngredientDietaryFlags returns the flags of every flagged ingredient, by ingredient ID.
+func (s *Store) GetAllIngredientDietaryFlags(ctx context.Context) ([]models.IngredientDietaryFlags, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.IngredientDietaryFlags{}
+	for ingredientID, set := range s.ingredientDietaryFlags {
+		flagged := models.IngredientDietaryFlags{IngredientID: ingredientID}
+		for flag := range set {
+			flagged.Flags = append(flagged.Flags, flag)
+		}
+		dietary.SortFlags(flagged.Flags)
+		result = append(result, flagged)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].IngredientID < result[j].IngredientID })
+	return result, nil
+}
+
+// GetAllRecipeDietaryOverrides returns the overrides of every recipe that has any, including
+// recipes in the trash, by recipe ID.
+func (s *Store) GetAllRecipeDietaryOverrides(ctx context.Context) ([]models.RecipeDietaryOverrides, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.RecipeDietaryOverrides{}
+	for recipeID, set := range s.recipeDietaryOverrides {
+		overrides := make(map[string]bool, len(set))
+		for flag, present := range set {
+			overrides[flag] = present
+		}
+		result = append(result, models.RecipeDietaryOverrides{RecipeID: recipeID, Overrides: overrides})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RecipeID < result[j].RecipeID })
+	return result, nil
+}
@@ -0,0 +1,463 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetAllIngredients returns every ingredient, ordered by name.
+func (s *Store) GetAllIngredients(ctx context.Context) ([]models.Ingredient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ingredients []models.Ingredient
+	for _, ingredient := range s.ingredients {
+		ingredients = append(ingredients, *ingredient)
+	}
+	sort.Slice(ingredients, func(i, j int) bool { return ingredients[i].Name < ingredients[j].Name })
+	return ingredients, nil
+}
+
+// GetAllRecipeIngredients returns every recipe-ingredient link, by recipe and in sort order.
+func (s *Store) GetAllRecipeIngredients(ctx context.Context) ([]models.RecipeIngredient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recipeIDs []string
+	for recipeID := range s.recipeIngredients {
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+	sort.Strings(recipeIDs)
+
+	var links []models.RecipeIngredient
+	for _, recipeID := range recipeIDs {
+		links = append(links, s.recipeIngredients[recipeID]...)
+	}
+	return links, nil
+}
+
+// SearchIngredients suggests ingredients whose normalized name or an alias starts with the
+// query, has a word starting with it or is similar to it, ranked like the PostgreSQL query.
+func (s *Store) SearchIngredients(ctx context.Context, query string, limit int) ([]models.IngredientSuggestion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query = database.NormalizeIngredientName(query)
+	if query == "" {
+		return []models.IngredientSuggestion{}, nil
+	}
+
+	type match struct {
+		suggestion models.IngredientSuggestion
+		tier       int
+		score      float64
+	}
+	// Each ingredient matches by its best name, its own name winning ties with an alias
+	best := make(map[string]match)
+	consider := func(ingredient *models.Ingredient, normalized string, alias string) {
+		m := match{
+			suggestion: models.IngredientSuggestion{ID: ingredient.ID, Name: ingredient.Name, MatchedAlias: alias},
+			tier:       2,
+			score:      database.TrigramSimilarity(normalized, query),
+		}
+		switch {
+		case strings.HasPrefix(normalized, query):
+			m.tier = 0
+		case strings.Contains(normalized, " "+query):
+			m.tier = 1
+		case m.score < database.TrigramSimilarityThreshold:
+			return
+		}
+		if current, ok := best[ingredient.ID]; ok {
+			if current.tier < m.tier || current.tier == m.tier && current.score >= m.score {
+				return
+			}
+		}
+		best[ingredient.ID] = m
+	}
+	for _, ingredient := range s.ingredients {
+		consider(ingredient, ingredient.NormalizedName, "")
+	}
+	for _, alias := range s.ingredientAliases {
+		if ingredient, ok := s.ingredients[alias.IngredientID]; ok {
+			consider(ingredient, alias.NormalizedName, alias.Name)
+		}
+	}
+
+	var matches []match
+	for _, m := range best {
+		m.suggestion.UsageCount = s.ingredientUsage(m.suggestion.ID)
+		matches = append(matches, m)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		if a.suggestion.UsageCount != b.suggestion.UsageCount {
+			return a.suggestion.UsageCount > b.suggestion.UsageCount
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.suggestion.Name < b.suggestion.Name
+	})
+
+	suggestions := []models.IngredientSuggestion{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].suggestion)
+	}
+	return suggestions, nil
+}
+
+// ingredientUsage counts the live recipes linked to an ingredient.
+func (s *Store) ingredientUsage(ingredientID string) int {
+	count := 0
+	for recipeID, links := range s.recipeIngredients {
+		if recipe, ok := s.recipes[recipeID]; !ok || recipe.DeletedAt != nil {
+			continue
+		}
+		for _, link := range links {
+			if link.IngredientID == ingredientID {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// aliasByNormalizedName returns the alias with the given normalized name, or nil.
+func (s *Store) aliasByNormalizedName(normalized string) *models.IngredientAlias {
+	for _, alias := range s.ingredientAliases {
+		if alias.NormalizedName == normalized {
+			return alias
+		}
+	}
+	return nil
+}
+
+// ingredientSummary returns an ingredient with its usage count and aliases.
+func (s *Store) ingredientSummary(ingredient *models.Ingredient) models.IngredientSummary {
+	summary := models.IngredientSummary{
+		Ingredient: *ingredient,
+		UsageCount: s.ingredientUsage(ingredient.ID),
+		Aliases:    []string{},
+	}
+	for _, alias := range s.ingredientAliases {
+		if alias.IngredientID == ingredient.ID {
+			summary.Aliases = append(summary.Aliases, alias.Name)
+		}
+	}
+	sort.Strings(summary.Aliases)
+	return summary
+}
+
+// GetIngredientSummaries returns every ingredient with its usage count and aliases, grouped
+// by the taxonomy: each ingredient is followed by the ingredients below it.
+func (s *Store) GetIngredientSummaries(ctx context.Context) ([]models.IngredientSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := []models.IngredientSummary{}
+	for _, ingredient := range s.ingredients {
+		summaries = append(summaries, s.ingredientSummary(ingredient))
+	}
+	return database.GroupByHierarchy(summaries), nil
+}
+
+// RenameIngredient changes the name of an ingredient, keeping the previous name as an alias.
+func (s *Store) RenameIngredient(ctx context.Context, id string, name string) (*models.IngredientSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ingredient, ok := s.ingredients[id]
+	if !ok {
+		return nil, fmt.Errorf("ingredient with ID %s not found", id)
+	}
+	normalized := database.NormalizeIngredientName(name)
+	alias := s.aliasByNormalizedName(normalized)
+	if alias != nil && alias.IngredientID != id {
+		return nil, fmt.Errorf("'%s' already exists as an alias of another ingredient", name)
+	}
+	for _, other := range s.ingredients {
+		if other.ID != id && other.Name == name {
+			return nil, fmt.Errorf("ingredient named '%s' already exists", name)
+		}
+	}
+
+	if alias != nil {
+		delete(s.ingredientAliases, alias.ID)
+	}
+	if ingredient.NormalizedName != normalized && s.aliasByNormalizedName(ingredient.NormalizedName) == nil {
+		oldName := &models.IngredientAlias{
+			ID:             uuid.NewString(),
+			IngredientID:   id,
+			Name:           ingredient.Name,
+			NormalizedName: ingredient.NormalizedName,
+			CreatedAt:      time.Now().UTC(),
+		}
+		s.ingredientAliases[oldName.ID] = oldName
+	}
+	ingredient.Name = name
+	ingredient.NormalizedName = normalized
+	ingredient.UpdatedAt = time.Now().UTC()
+
+	summary := s.ingredientSummary(ingredient)
+	return &summary, nil
+}
+
+// MergeIngredients folds the source ingredients into the target: their recipe links, aliases
+// and child ingredients move to the target, their names become aliases of it, and they are
+// deleted. Every recipe line is kept. Returns the target and the recipes that were relinked.
+func (s *Store) MergeIngredients(ctx context.Context, targetID string, sourceIDs []string) (*models.IngredientMergeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sourceIDs, err := database.MergeSourceIDs(targetID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range append([]string{targetID}, sourceIDs...) {
+		if s.ingredients[id] == nil {
+			return nil, fmt.Errorf("ingredient with ID %s not found", id)
+		}
+	}
+	target := s.ingredients[targetID]
+	merged := make(map[string]bool)
+	for _, id := range sourceIDs {
+		merged[id] = true
+	}
+
+	affectedRecipeIDs := []string{}
+	for recipeID, links := range s.recipeIngredients {
+		relinked := false
+		for i := range links {
+			if merged[links[i].IngredientID] {
+				links[i].IngredientID = targetID
+				relinked = true
+			}
+		}
+		if relinked {
+			affectedRecipeIDs = append(affectedRecipeIDs, recipeID)
+		}
+	}
+	sort.Strings(affectedRecipeIDs)
+
+	for _, alias := range s.ingredientAliases {
+		if merged[alias.IngredientID] {
+			alias.IngredientID = targetID
+		}
+	}
+	// Children of merged ingredients move under the target, which is detached from its
+	// parent should that be merged or end up below the target
+	for _, ingredient := range s.ingredients {
+		if merged[ingredient.ParentID] && ingredient.ID != targetID {
+			ingredient.ParentID = targetID
+		}
+	}
+	if merged[target.ParentID] || s.isIngredientAncestor(targetID, target.ParentID) {
+		target.ParentID = ""
+	}
+	// The target keeps its food; otherwise it takes the most recently mapped one of the sources
+	if s.ingredientFoods[targetID] == nil {
+		var latest *models.IngredientFood
+		for _, id := range sourceIDs {
+			if mapping := s.ingredientFoods[id]; mapping != nil && (latest == nil || mapping.UpdatedAt.After(latest.UpdatedAt)) {
+				latest = mapping
+			}
+		}
+		if latest != nil {
+			mapping := *latest
+			mapping.IngredientID = targetID
+			s.ingredientFoods[targetID] = &mapping
+		}
+	}
+	s.clearIngredientNutrition(targetID)
+	// The target carries every dietary flag of the merged ingredients, erring on the safe side
+	for _, id := range sourceIDs {
+		for flag := range s.ingredientDietaryFlags[id] {
+			s.addIngredientDietaryFlags(targetID, []string{flag})
+		}
+	}
+	for _, sub := range s.substitutions {
+		if merged[sub.IngredientID] {
+			sub.IngredientID = targetID
+		}
+		for i := range sub.Replacements {
+			if merged[sub.Replacements[i].IngredientID] {
+				sub.Replacements[i].IngredientID = targetID
+			}
+		}
+	}
+	// The target keeps its pantry item; otherwise it takes the most recently updated one of the sources
+	var ownedByTarget bool
+	var latestItem *models.PantryItem
+	for _, item := range s.pantryItems {
+		if item.IngredientID == targetID {
+			ownedByTarget = true
+		} else if merged[item.IngredientID] && (latestItem == nil || item.UpdatedAt.After(latestItem.UpdatedAt)) {
+			latestItem = item
+		}
+	}
+	if !ownedByTarget && latestItem != nil {
+		latestItem.IngredientID = targetID
+	}
+	for id, item := range s.pantryItems {
+		if merged[item.IngredientID] {
+			delete(s.pantryItems, id)
+		}
+	}
+	for _, id := range sourceIDs {
+		if since, ok := s.pantryStaples[id]; ok {
+			if current, ok := s.pantryStaples[targetID]; !ok || since.Before(current) {
+				s.pantryStaples[targetID] = since
+			}
+			delete(s.pantryStaples, id)
+		}
+	}
+	// The target keeps its aisle; otherwise it takes the most recently set one of the sources
+	if s.ingredientAisles[targetID] == nil {
+		var latest *models.IngredientAisle
+		for _, id := range sourceIDs {
+			if aisle := s.ingredientAisles[id]; aisle != nil && (latest == nil || aisle.UpdatedAt.After(latest.UpdatedAt)) {
+				latest = aisle
+			}
+		}
+		if latest != nil {
+			aisle := *latest
+			aisle.IngredientID = targetID
+			s.ingredientAisles[targetID] = &aisle
+		}
+	}
+	for _, items := range s.shoppingListItems {
+		for i := range items {
+			if merged[items[i].IngredientID] {
+				items[i].IngredientID = targetID
+			}
+		}
+	}
+	for _, id := range sourceIDs {
+		source := s.ingredients[id]
+		if source.NormalizedName != target.NormalizedName {
+			if alias := s.aliasByNormalizedName(source.NormalizedName); alias != nil {
+				alias.IngredientID = targetID
+			} else {
+				alias := &models.IngredientAlias{
+					ID:             uuid.NewString(),
+					IngredientID:   targetID,
+					Name:           source.Name,
+					NormalizedName: source.NormalizedName,
+					CreatedAt:      time.Now().UTC(),
+				}
+				s.ingredientAliases[alias.ID] = alias
+			}
+		}
+		delete(s.ingredients, id)
+		delete(s.ingredientFoods, id)
+		delete(s.ingredientDietaryFlags, id)
+		delete(s.ingredientAisles, id)
+	}
+
+	return &models.IngredientMergeResult{IngredientSummary: s.ingredientSummary(target), AffectedRecipeIDs: affectedRecipeIDs}, nil
+}
+
+// isIngredientAncestor reports whether ancestorID is id itself or one of the ingredients
+// above it in the taxonomy.
+func (s *Store) isIngredientAncestor(ancestorID string, id string) bool {
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		if id == ancestorID {
+			return true
+		}
+		seen[id] = true
+		ingredient, ok := s.ingredients[id]
+		if !ok {
+			break
+		}
+		id = ingredient.ParentID
+	}
+	return false
+}
+
+// SetIngredientParent places an ingredient below another in the taxonomy, or makes it a root
+// when parentID is empty. An ingredient cannot be placed below itself or its descendants.
+func (s *Store) SetIngredientParent(ctx context.Context, id string, parentID string) (*models.IngredientSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ingredient, ok := s.ingredients[id]
+	if !ok {
+		return nil, fmt.Errorf("ingredient with ID %s not found", id)
+	}
+	if parentID != "" {
+		if s.ingredients[parentID] == nil {
+			return nil, fmt.Errorf("parent ingredient with ID %s not found", parentID)
+		}
+		if s.isIngredientAncestor(id, parentID) {
+			return nil, fmt.Errorf("cannot place ingredient %s below itself or one of its descendants", id)
+		}
+	}
+	ingredient.ParentID = parentID
+	ingredient.UpdatedAt = time.Now().UTC()
+
+	summary := s.ingredientSummary(ingredient)
+	return &summary, nil
+}
+
+// descendantIngredients returns the IDs of the given ingredients and every ingredient below
+// them in the taxonomy.
+func (s *Store) descendantIngredients(ids map[string]bool) map[string]bool {
+	expanded := make(map[string]bool, len(ids))
+	for id := range ids {
+		expanded[id] = true
+	}
+	for added := true; added; {
+		added = false
+		for _, ingredient := range s.ingredients {
+			if !expanded[ingredient.ID] && expanded[ingredient.ParentID] {
+				expanded[ingredient.ID] = true
+				added = true
+			}
+		}
+	}
+	return expanded
+}
+
+// GetAllIngredientAliases returns every ingredient alias, by ingredient and name.
+func (s *Store) GetAllIngredientAliases(ctx context.Context) ([]models.IngredientAlias, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var aliases []models.IngredientAlias
+	for _, alias := range s.ingredientAliases {
+		aliases = append(aliases, *alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].IngredientID != aliases[j].IngredientID {
+			return aliases[i].IngredientID < aliases[j].IngredientID
+		}
+		return aliases[i].Name < aliases[j].Name
+	})
+	return aliases, nil
+}
+
+// resolveIngredient returns ingredientID if that ingredient exists, or else the ID of the
+// ingredient named name, created if missing.
+func (s *Store) resolveIngredient(ingredientID string, name string) (string, error) {
+	if ingredientID == "" {
+		return s.getOrCreateIngredientByName(name), nil
+	}
+	if s.ingredients[ingredientID] == nil {
+		return "", fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+	return ingredientID, nil
+}
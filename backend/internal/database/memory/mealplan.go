@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dateOnly truncates t to midnight UTC of its calendar date, like a DATE column.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// sortMealPlanEntries orders entries by date, then by meal slot, then by their order within
+// the meal and creation time.
+func sortMealPlanEntries(entries []models.MealPlanEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		if ri, rj := models.MealSlotRank(entries[i].Slot), models.MealSlotRank(entries[j].Slot); ri != rj {
+			return ri < rj
+		}
+		if entries[i].Slot != entries[j].Slot {
+			return entries[i].Slot < entries[j].Slot
+		}
+		if entries[i].SortOrder != entries[j].SortOrder {
+			return entries[i].SortOrder < entries[j].SortOrder
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+}
+
+// plannedMealPlanEntry reports whether the store holds entry's ID, or its recipe or title for
+// the same meal.
+func (s *Store) plannedMealPlanEntry(entry *models.MealPlanEntry) bool {
+	return s.mealPlanEntries[entry.ID] != nil || s.mealPlanConflict(entry)
+}
+
+// mealPlanConflict reports whether another entry plans entry's recipe or title for the same meal.
+func (s *Store) mealPlanConflict(entry *models.MealPlanEntry) bool {
+	for _, existing := range s.mealPlanEntries {
+		if existing.ID != entry.ID && existing.RecipeID == entry.RecipeID && existing.Title == entry.Title &&
+			existing.Date.Equal(entry.Date) && existing.Slot == entry.Slot {
+			return true
+		}
+	}
+	return false
+}
+
+// alreadyPlannedError is the error for planning entry's recipe or title twice for a meal.
+func alreadyPlannedError(entry *models.MealPlanEntry) error {
+	if entry.RecipeID == "" {
+		return fmt.Errorf("%q is already planned for %s on %s", entry.Title, entry.Slot, entry.Date.Format("2006-01-02"))
+	}
+	return fmt.Errorf("recipe %s is already planned for %s on %s", entry.RecipeID, entry.Slot, entry.Date.Format("2006-01-02"))
+}
+
+// mealEntries returns the stored entries of a meal other than skipID, in order.
+func (s *Store) mealEntries(date time.Time, slot string, skipID string) []*models.MealPlanEntry {
+	var entries []*models.MealPlanEntry
+	for _, entry := range s.mealPlanEntries {
+		if entry.ID != skipID && entry.Date.Equal(date) && entry.Slot == slot {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].SortOrder != entries[j].SortOrder {
+			return entries[i].SortOrder < entries[j].SortOrder
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+// placeMealPlanEntry moves a stored entry to position stored.SortOrder among the other entries
+// of its meal, or last when that is negative or past the end, and numbers the meal from 0.
+func (s *Store) placeMealPlanEntry(stored *models.MealPlanEntry) {
+	others := s.mealEntries(stored.Date, stored.Slot, stored.ID)
+	position := stored.SortOrder
+	if position < 0 || position > len(others) {
+		position = len(others)
+	}
+	ordered := append(others[:position:position], append([]*models.MealPlanEntry{stored}, others[position:]...)...)
+	for i, entry := range ordered {
+		entry.SortOrder = i
+	}
+}
+
+// moveMealPlanEntry changes a stored entry's date, slot, servings and notes to entry's and
+// places it in its meal. When it leaves its meal, the entries staying there are numbered
+// from 0 again.
+func (s *Store) moveMealPlanEntry(stored *models.MealPlanEntry, entry *models.MealPlanEntry) error {
+	moved := *stored
+	moved.Date, moved.Slot, moved.Servings, moved.Notes, moved.SortOrder = entry.Date, entry.Slot, entry.Servings, entry.Notes, entry.SortOrder
+	if s.mealPlanConflict(&moved) {
+		return fmt.Errorf("failed to update meal plan entry ID %s: %w", stored.ID, alreadyPlannedError(&moved))
+	}
+	oldDate, oldSlot := stored.Date, stored.Slot
+	*stored = moved
+	s.placeMealPlanEntry(stored)
+	if !oldDate.Equal(stored.Date) || oldSlot != stored.Slot {
+		for i, entry := range s.mealEntries(oldDate, oldSlot, "") {
+			entry.SortOrder = i
+		}
+	}
+	return nil
+}
+
+// mealPlanEntry returns a copy of a stored entry, titled with its recipe's name when it plans one.
+func (s *Store) mealPlanEntry(stored *models.MealPlanEntry) models.MealPlanEntry {
+	entry := *stored
+	if recipe, ok := s.recipes[entry.RecipeID]; ok {
+		entry.Title = recipe.Name
+	}
+	return entry
+}
+
+// CreateMealPlanEntry plans, last in its meal, a recipe, which must exist, or a free-text title such as
+// "Leftovers" for a meal on a date. Entries without a slot are dinners. A recipe or title can
+// only be planned once per meal.
+func (s *Store) CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	entry.CreatedAt = time.Now().UTC()
+	entry.Date = dateOnly(entry.Date)
+	if entry.Slot == "" {
+		entry.Slot = models.MealSlotDinner
+	}
+
+	if entry.RecipeID != "" {
+		entry.Title = "" // The recipe's name is shown instead
+		if s.recipes[entry.RecipeID] == nil {
+			return nil, fmt.Errorf("failed to insert meal plan entry ID %s: recipe with ID %s not found", entry.ID, entry.RecipeID)
+		}
+	}
+
+	if s.plannedMealPlanEntry(entry) {
+		return nil, alreadyPlannedError(entry)
+	}
+	stored := *entry
+	stored.SortOrder = len(s.mealEntries(stored.Date, stored.Slot, stored.ID))
+	s.mealPlanEntries[entry.ID] = &stored
+	created := s.mealPlanEntry(&stored)
+	return &created, nil
+}
+
+// GetMealPlanEntry returns an entry by its ID.
+func (s *Store) GetMealPlanEntry(ctx context.Context, entryID string) (*models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.mealPlanEntries[entryID]
+	if !ok {
+		return nil, fmt.Errorf("meal plan entry with ID %s not found", entryID)
+	}
+	entry := s.mealPlanEntry(stored)
+	return &entry, nil
+}
+
+// UpdateMealPlanEntry changes an entry's date, slot, servings and notes, and moves it to
+// position entry.SortOrder within its meal, or last when that is negative.
+func (s *Store) UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.mealPlanEntries[entry.ID]
+	if !ok {
+		return nil, fmt.Errorf("meal plan entry with ID %s not found", entry.ID)
+	}
+	entry.Date = dateOnly(entry.Date)
+	if entry.Slot == "" {
+		entry.Slot = models.MealSlotDinner
+	}
+	if err := s.moveMealPlanEntry(stored, entry); err != nil {
+		return nil, err
+	}
+	updated := s.mealPlanEntry(stored)
+	return &updated, nil
+}
+
+// ApplyMealPlanEntryOperations moves and copies entries as one: if any operation fails, the
+// entries are restored as they were. A moved entry that changes meal, and every copy, goes
+// last in its new meal.
+func (s *Store) ApplyMealPlanEntryOperations(ctx context.Context, operations []models.MealPlanEntryOperation) ([]models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := make(map[string]models.MealPlanEntry, len(s.mealPlanEntries))
+	for id, entry := range s.mealPlanEntries {
+		saved[id] = *entry
+	}
+	ids, err := s.applyMealPlanEntryOperations(operations)
+	if err != nil {
+		s.mealPlanEntries = make(map[string]*models.MealPlanEntry, len(saved))
+		for id, entry := range saved {
+			entry := entry
+			s.mealPlanEntries[id] = &entry
+		}
+		return nil, err
+	}
+
+	entries := make([]models.MealPlanEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, s.mealPlanEntry(s.mealPlanEntries[id]))
+	}
+	return entries, nil
+}
+
+// applyMealPlanEntryOperations applies operations in order, stopping at the first that fails,
+// and returns the IDs of the moved entries and the copies.
+func (s *Store) applyMealPlanEntryOperations(operations []models.MealPlanEntryOperation) ([]string, error) {
+	ids := make([]string, 0, len(operations))
+	for _, op := range operations {
+		stored, ok := s.mealPlanEntries[op.EntryID]
+		if !ok {
+			return nil, fmt.Errorf("meal plan entry with ID %s not found", op.EntryID)
+		}
+		target := *stored
+		if !op.Date.IsZero() {
+			target.Date = dateOnly(op.Date)
+		}
+		if op.Slot != "" {
+			target.Slot = op.Slot
+		}
+		if !target.Date.Equal(stored.Date) || target.Slot != stored.Slot {
+			target.SortOrder = -1
+		}
+
+		switch op.Action {
+		case models.MealPlanMove:
+			if err := s.moveMealPlanEntry(stored, &target); err != nil {
+				return nil, err
+			}
+		case models.MealPlanCopy:
+			target.ID = uuid.NewString()
+			target.CreatedAt = time.Now().UTC()
+			if s.mealPlanConflict(&target) {
+				return nil, fmt.Errorf("failed to insert meal plan entry ID %s: %w", target.ID, alreadyPlannedError(&target))
+			}
+			target.SortOrder = len(s.mealEntries(target.Date, target.Slot, target.ID))
+			s.mealPlanEntries[target.ID] = &target
+		default:
+			return nil, fmt.Errorf("unknown meal plan entry operation %q", op.Action)
+		}
+		ids = append(ids, target.ID)
+	}
+	return ids, nil
+}
+
+// GetMealPlanEntriesByDateRange returns the entries between two dates, inclusive.
+func (s *Store) GetMealPlanEntriesByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, end := dateOnly(startDate), dateOnly(endDate)
+	var entries []models.MealPlanEntry
+	for _, entry := range s.mealPlanEntries {
+		if !entry.Date.Before(start) && !entry.Date.After(end) {
+			entries = append(entries, s.mealPlanEntry(entry))
+		}
+	}
+	sortMealPlanEntries(entries)
+	return entries, nil
+}
+
+// DeleteMealPlanEntry removes an entry. Missing entries are ignored.
+func (s *Store) DeleteMealPlanEntry(ctx context.Context, entryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID == "" {
+		return fmt.Errorf("meal plan entry ID cannot be empty for deletion")
+	}
+	delete(s.mealPlanEntries, entryID)
+	return nil
+}
+
+// GetAllMealPlanEntries returns every entry.
+func (s *Store) GetAllMealPlanEntries(ctx context.Context) ([]models.MealPlanEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []models.MealPlanEntry
+	for _, entry := range s.mealPlanEntries {
+		entries = append(entries, s.mealPlanEntry(entry))
+	}
+	sortMealPlanEntries(entries)
+	return entries, nil
+}
@@ -0,0 +1,148 @@
+// Package memory is an in-memory implementation of the database repositories, so the
+// HTTP layer can be exercised with httptest alone. It follows the PostgreSQL implementation
+// closely, including its error messages, which handlers inspect to pick status codes.
+// Full-text search and ingredient filters are approximated with word matching.
+package memory
+
+import (
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store holds every table in maps guarded by a single mutex. Use New to create one.
+type Store struct {
+	mu sync.Mutex
+
+	recipes                map[string]*models.Recipe            // Without Ingredients, Tags and Photos, which are derived
+	ingredients            map[string]*models.Ingredient        // By ID
+	ingredientAliases      map[string]*models.IngredientAlias   // By ID
+	recipeIngredients      map[string][]models.RecipeIngredient // By recipe ID, in sort order
+	tags                   map[string]*models.Tag               // By ID
+	recipeTags             map[string]map[string]bool           // Recipe ID to the set of its tag IDs
+	photos                 map[string][]models.RecipePhoto      // By recipe ID
+	revisions              map[string][]models.RecipeRevision   // By recipe ID, oldest first
+	comments               map[string]*models.Comment           // By ID
+	mealPlanEntries        map[string]*models.MealPlanEntry     // By ID, without recipe names
+	foods                  map[string]*models.Food              // By ID
+	ingredientFoods        map[string]*models.IngredientFood    // By ingredient ID, without Food
+	recipeNutrition        map[string]models.RecipeNutrition    // By recipe ID
+	ingredientDietaryFlags map[string]map[string]bool           // Ingredient ID to the set of flags it carries itself
+	recipeDietaryOverrides map[string]map[string]bool           // Recipe ID to its overridden flags
+	substitutions          map[string]*models.Substitution      // By ID, without ingredient names, which are derived
+	pantryItems            map[string]*models.PantryItem        // By ID, without ingredient names
+	pantryStaples          map[string]time.Time                 // Ingredient ID to when it became a staple
+	ingredientAisles       map[string]*models.IngredientAisle   // By ingredient ID, without ingredient names
+	shoppingLists          map[string]*models.ShoppingList      // By ID, without items and counts, which are derived
+	shoppingListItems      map[string][]models.ShoppingListItem // By list ID, in sort order
+}
+
+var (
+	_ database.RecipeRepository       = (*Store)(nil)
+	_ database.IngredientRepository   = (*Store)(nil)
+	_ database.CommentRepository      = (*Store)(nil)
+	_ database.MealPlanRepository     = (*Store)(nil)
+	_ database.NutritionRepository    = (*Store)(nil)
+	_ database.DietaryRepository      = (*Store)(nil)
+	_ database.SubstitutionRepository = (*Store)(nil)
+	_ database.PantryRepository       = (*Store)(nil)
+	_ database.ShoppingListRepository = (*Store)(nil)
+)
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{
+		recipes:                make(map[string]*models.Recipe),
+		ingredients:            make(map[string]*models.Ingredient),
+		ingredientAliases:      make(map[string]*models.IngredientAlias),
+		recipeIngredients:      make(map[string][]models.RecipeIngredient),
+		tags:                   make(map[string]*models.Tag),
+		recipeTags:             make(map[string]map[string]bool),
+		photos:                 make(map[string][]models.RecipePhoto),
+		revisions:              make(map[string][]models.RecipeRevision),
+		comments:               make(map[string]*models.Comment),
+		mealPlanEntries:        make(map[string]*models.MealPlanEntry),
+		foods:                  make(map[string]*models.Food),
+		ingredientFoods:        make(map[string]*models.IngredientFood),
+		recipeNutrition:        make(map[string]models.RecipeNutrition),
+		ingredientDietaryFlags: make(map[string]map[string]bool),
+		recipeDietaryOverrides: make(map[string]map[string]bool),
+		substitutions:          make(map[string]*models.Substitution),
+		pantryItems:            make(map[string]*models.PantryItem),
+		pantryStaples:          make(map[string]time.Time),
+		ingredientAisles:       make(map[string]*models.IngredientAisle),
+		shoppingLists:          make(map[string]*models.ShoppingList),
+		shoppingListItems:      make(map[string][]models.ShoppingListItem),
+	}
+}
+
+// NewRepositories returns every repository backed by one new, empty store.
+func NewRepositories() database.Repositories {
+	s := New()
+	return database.Repositories{Recipes: s, Ingredients: s, Comments: s, MealPlans: s, Nutrition: s, Dietary: s, Substitutions: s, Pantry: s, ShoppingLists: s}
+}
+
+// lowerWords splits text into lowercased words, ignoring punctuation.
+func lowerWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+}
+
+// stem strips a plural ending, a rough stand-in for PostgreSQL's English stemmer.
+func stem(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes") && len(word) > 4:
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 2:
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// matchesAllWords reports whether every word of query appears in text, like
+// to_tsvector(text) @@ plainto_tsquery(query).
+func matchesAllWords(text string, query string) bool {
+	words := make(map[string]bool)
+	for _, word := range lowerWords(text) {
+		words[stem(word)] = true
+	}
+	queryWords := lowerWords(query)
+	for _, word := range queryWords {
+		if !words[stem(word)] {
+			return false
+		}
+	}
+	return len(queryWords) > 0
+}
+
+// importedID returns the ID of a row from an import file if it is a valid UUID that taken
+// does not report as used, and a new UUID otherwise.
+func importedID(id string, taken func(string) bool) string {
+	if _, err := uuid.Parse(id); err != nil || taken(id) {
+		return uuid.NewString()
+	}
+	return id
+}
+
+// timeOrNow returns t, or the current time if t is zero (missing from an import file).
+func timeOrNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now().UTC()
+	}
+	return t
+}
+
+// sortByLowerName sorts names alphabetically, ignoring case.
+func sortByLowerName(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+}
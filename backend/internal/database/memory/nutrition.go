@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UpsertFoods adds foods of a food composition dataset, replacing foods already loaded, and
+// discards the nutrition computed for recipes.
+func (s *Store) UpsertFoods(ctx context.Context, foods []models.Food) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, food := range foods {
+		food.UpdatedAt = timeOrNow(food.UpdatedAt)
+		s.foods[food.ID] = &food
+	}
+	s.recipeNutrition = make(map[string]models.RecipeNutrition)
+	return len(foods), nil
+}
+
+// SearchFoods finds foods whose description contains every word of the query, shorter
+// descriptions first.
+func (s *Store) SearchFoods(ctx context.Context, query string, limit int) ([]models.Food, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	words := strings.Fields(strings.ToLower(query))
+	foods := []models.Food{}
+	if len(words) == 0 {
+		return foods, nil
+	}
+	for _, food := range s.foods {
+		description := strings.ToLower(food.Description)
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(description, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			foods = append(foods, *food)
+		}
+	}
+	sort.Slice(foods, func(i, j int) bool {
+		if len(foods[i].Description) != len(foods[j].Description) {
+			return len(foods[i].Description) < len(foods[j].Description)
+		}
+		return foods[i].Description < foods[j].Description
+	})
+	if len(foods) > limit {
+		foods = foods[:limit]
+	}
+	return foods, nil
+}
+
+// ingredientFood returns a copy of an ingredient's food mapping with its food.
+func (s *Store) ingredientFood(mapping *models.IngredientFood) models.IngredientFood {
+	result := *mapping
+	food := *s.foods[mapping.FoodID]
+	result.Food = &food
+	return result
+}
+
+// GetIngredientFoods fetches the food mappings of the given ingredients, with their food.
+func (s *Store) GetIngredientFoods(ctx context.Context, ingredientIDs []string) ([]models.IngredientFood, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mappings := []models.IngredientFood{}
+	for _, id := range ingredientIDs {
+		if mapping, ok := s.ingredientFoods[id]; ok {
+			mappings = append(mappings, s.ingredientFood(mapping))
+		}
+	}
+	return mappings, nil
+}
+
+// GetAllIngredientFoods fetches every food mapping with its food, for export.
+func (s *Store) GetAllIngredientFoods(ctx context.Context) ([]models.IngredientFood, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mappings := []models.IngredientFood{}
+	for _, mapping := range s.ingredientFoods {
+		mappings = append(mappings, s.ingredientFood(mapping))
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].IngredientID < mappings[j].IngredientID })
+	return mappings, nil
+}
+
+// SetIngredientFood maps an ingredient to a food, replacing its previous mapping, and
+// discards the cached nutrition of the recipes using the ingredient.
+func (s *Store) SetIngredientFood(ctx context.Context, mapping models.IngredientFood) (*models.IngredientFood, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ingredients[mapping.IngredientID] == nil {
+		return nil, fmt.Errorf("ingredient with ID %s not found", mapping.IngredientID)
+	}
+	if s.foods[mapping.FoodID] == nil {
+		return nil, fmt.Errorf("food with ID %s not found", mapping.FoodID)
+	}
+	mapping.Food = nil
+	mapping.UpdatedAt = time.Now().UTC()
+	s.ingredientFoods[mapping.IngredientID] = &mapping
+	s.clearIngredientNutrition(mapping.IngredientID)
+
+	saved := s.ingredientFood(&mapping)
+	return &saved, nil
+}
+
+// DeleteIngredientFood removes an ingredient's food mapping and discards the cached
+// nutrition of the recipes using the ingredient.
+func (s *Store) DeleteIngredientFood(ctx context.Context, ingredientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ingredientFoods[ingredientID]; !ok {
+		return fmt.Errorf("food of ingredient %s not found", ingredientID)
+	}
+	delete(s.ingredientFoods, ingredientID)
+	s.clearIngredientNutrition(ingredientID)
+	return nil
+}
+
+// clearIngredientNutrition discards the cached nutrition of the recipes using an ingredient.
+func (s *Store) clearIngredientNutrition(ingredientID string) {
+	for recipeID, links := range s.recipeIngredients {
+		for _, link := range links {
+			if link.IngredientID == ingredientID {
+				delete(s.recipeNutrition, recipeID)
+				break
+			}
+		}
+	}
+}
+
+// GetRecipeNutrition fetches the nutrition last computed for a recipe, or nil if there is none.
+func (s *Store) GetRecipeNutrition(ctx context.Context, recipeID string) (*models.RecipeNutrition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nutrition, ok := s.recipeNutrition[recipeID]
+	if !ok {
+		return nil, nil
+	}
+	return &nutrition, nil
+}
+
+// SaveRecipeNutrition caches the nutrition computed for a recipe, replacing any earlier result.
+func (s *Store) SaveRecipeNutrition(ctx context.Context, nutrition models.RecipeNutrition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recipes[nutrition.RecipeID] == nil {
+		return fmt.Errorf("failed to save nutrition of recipe %s: recipe not found", nutrition.RecipeID)
+	}
+	s.recipeNutrition[nutrition.RecipeID] = nutrition
+	return nil
+}
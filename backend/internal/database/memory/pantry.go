@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// datePtr truncates an optional time to its calendar date, like a DATE column.
+func datePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	day := dateOnly(*t)
+	return &day
+}
+
+// pantryItem returns a copy of a stored pantry item with its ingredient name filled in.
+func (s *Store) pantryItem(stored *models.PantryItem) models.PantryItem {
+	item := *stored
+	item.IngredientName = s.ingredients[item.IngredientID].Name
+	return item
+}
+
+// pantryItemByIngredient returns the pantry item of an ingredient, or nil.
+func (s *Store) pantryItemByIngredient(ingredientID string) *models.PantryItem {
+	for _, item := range s.pantryItems {
+		if item.IngredientID == ingredientID {
+			return item
+		}
+	}
+	return nil
+}
+
+// storePantryItem resolves the ingredient of item and stores it under its ID. Each
+// ingredient can be in the pantry once.
+func (s *Store) storePantryItem(item models.PantryItem) (*models.PantryItem, error) {
+	ingredientID, err := s.resolveIngredient(item.IngredientID, item.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	if existing := s.pantryItemByIngredient(ingredientID); existing != nil && existing.ID != item.ID {
+		return nil, fmt.Errorf("ingredient %s already exists in the pantry", ingredientID)
+	}
+	item.IngredientID = ingredientID
+	item.IngredientName = ""
+	item.ExpiresOn = datePtr(item.ExpiresOn)
+	if item.Quantity != nil {
+		quantity := *item.Quantity
+		item.Quantity = &quantity
+	}
+	s.pantryItems[item.ID] = &item
+
+	result := s.pantryItem(&item)
+	return &result, nil
+}
+
+// GetPantryItems returns everything in the pantry, ordered by ingredient name.
+func (s *Store) GetPantryItems(ctx context.Context) ([]models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.PantryItem{}
+	for _, stored := range s.pantryItems {
+		result = append(result, s.pantryItem(stored))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].IngredientName < result[j].IngredientName })
+	return result, nil
+}
+
+// CreatePantryItem adds an ingredient to the pantry. The ingredient is given by ID, or by
+// canonical name when the ID is empty, in which case a missing ingredient is created.
+func (s *Store) CreatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.ID = uuid.NewString()
+	item.CreatedAt = time.Now().UTC()
+	item.UpdatedAt = item.CreatedAt
+	return s.storePantryItem(item)
+}
+
+// UpdatePantryItem replaces an existing pantry item. The ingredient is resolved as in
+// CreatePantryItem.
+func (s *Store) UpdatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.pantryItems[item.ID]
+	if !ok {
+		return nil, fmt.Errorf("pantry item with ID %s not found", item.ID)
+	}
+	item.CreatedAt = existing.CreatedAt
+	item.UpdatedAt = time.Now().UTC()
+	return s.storePantryItem(item)
+}
+
+// DeletePantryItem removes an ingredient from the pantry.
+func (s *Store) DeletePantryItem(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pantryItems[id]; !ok {
+		return fmt.Errorf("pantry item with ID %s not found", id)
+	}
+	delete(s.pantryItems, id)
+	return nil
+}
+
+// pantryStapleList returns the staples ordered by ingredient name.
+func (s *Store) pantryStapleList() []models.PantryStaple {
+	staples := []models.PantryStaple{}
+	for ingredientID := range s.pantryStaples {
+		staples = append(staples, models.PantryStaple{IngredientID: ingredientID, IngredientName: s.ingredients[ingredientID].Name})
+	}
+	sort.Slice(staples, func(i, j int) bool { return staples[i].IngredientName < staples[j].IngredientName })
+	return staples
+}
+
+// GetPantryStaples returns the ingredients that always count as owned, ordered by name.
+func (s *Store) GetPantryStaples(ctx context.Context) ([]models.PantryStaple, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pantryStapleList(), nil
+}
+
+// SetPantryStaples replaces the staples with the ingredients of the given canonical names,
+// creating missing ones.
+func (s *Store) SetPantryStaples(ctx context.Context, names []string) ([]models.PantryStaple, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keep := make(map[string]bool)
+	for _, name := range names {
+		keep[s.getOrCreateIngredientByName(name)] = true
+	}
+	for ingredientID := range s.pantryStaples {
+		if !keep[ingredientID] {
+			delete(s.pantryStaples, ingredientID)
+		}
+	}
+	now := time.Now().UTC()
+	for ingredientID := range keep {
+		if _, ok := s.pantryStaples[ingredientID]; !ok {
+			s.pantryStaples[ingredientID] = now
+		}
+	}
+	return s.pantryStapleList(), nil
+}
+
+// ownedIngredients returns the ingredients in the pantry on day that are not used up or
+// expired, the staples, and every ingredient above those in the taxonomy.
+func (s *Store) ownedIngredients(day time.Time) map[string]bool {
+	owned := make(map[string]bool)
+	var own func(id string)
+	own = func(id string) {
+		for id != "" && !owned[id] {
+			owned[id] = true
+			ingredient, ok := s.ingredients[id]
+			if !ok {
+				return
+			}
+			id = ingredient.ParentID
+		}
+	}
+	for _, item := range s.pantryItems {
+		if (item.Quantity == nil || *item.Quantity > 0) && (item.ExpiresOn == nil || !item.ExpiresOn.Before(day)) {
+			own(item.IngredientID)
+		}
+	}
+	for ingredientID := range s.pantryStaples {
+		own(ingredientID)
+	}
+	return owned
+}
+
+// GetOwnedIngredientIDs returns the ingredients owned on today, as ownedIngredients finds them.
+func (s *Store) GetOwnedIngredientIDs(ctx context.Context, today time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ingredientIDs := []string{}
+	for ingredientID := range s.ownedIngredients(dateOnly(today)) {
+		ingredientIDs = append(ingredientIDs, ingredientID)
+	}
+	return ingredientIDs, nil
+}
+
+// GetCookableRecipes ranks live recipes by the fraction of their ingredients owned on today,
+// then by the number missing and by name. Recipes missing more than maxMissing ingredients
+// are skipped unless it is negative. Returns the requested page and the number of recipes ranked.
+func (s *Store) GetCookableRecipes(ctx context.Context, today time.Time, maxMissing int, page int, pageSize int) ([]models.CookableRecipe, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owned := s.ownedIngredients(dateOnly(today))
+	ranked := []models.CookableRecipe{}
+	for recipeID, links := range s.recipeIngredients {
+		recipe, ok := s.recipes[recipeID]
+		if !ok || recipe.DeletedAt != nil || len(links) == 0 {
+			continue
+		}
+		cookable := models.CookableRecipe{RecipeID: recipeID, Name: recipe.Name, Missing: []models.MissingIngredient{}}
+		counted := make(map[string]bool) // An ingredient named on several lines counts once
+		for _, link := range links {
+			if counted[link.IngredientID] {
+				continue
+			}
+			counted[link.IngredientID] = true
+			cookable.IngredientCount++
+			if owned[link.IngredientID] {
+				cookable.OwnedCount++
+				continue
+			}
+			cookable.Missing = append(cookable.Missing, models.MissingIngredient{
+				IngredientID:   link.IngredientID,
+				IngredientName: s.ingredients[link.IngredientID].Name,
+				OriginalText:   link.OriginalText,
+			})
+		}
+		if maxMissing >= 0 && len(cookable.Missing) > maxMissing {
+			continue
+		}
+		cookable.OwnedFraction = float64(cookable.OwnedCount) / float64(cookable.IngredientCount)
+		ranked = append(ranked, cookable)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.OwnedFraction != b.OwnedFraction {
+			return a.OwnedFraction > b.OwnedFraction
+		}
+		if len(a.Missing) != len(b.Missing) {
+			return len(a.Missing) < len(b.Missing)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.RecipeID < b.RecipeID
+	})
+
+	start := (page - 1) * pageSize
+	if start > len(ranked) {
+		start = len(ranked)
+	}
+	end := start + pageSize
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	return ranked[start:end], len(ranked), nil
+}
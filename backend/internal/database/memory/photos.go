@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sortedPhotos returns a copy of a recipe's photos in display order.
+func (s *Store) sortedPhotos(recipeID string) []models.RecipePhoto {
+	photos := append([]models.RecipePhoto(nil), s.photos[recipeID]...)
+	sort.SliceStable(photos, func(i, j int) bool {
+		if photos[i].SortOrder != photos[j].SortOrder {
+			return photos[i].SortOrder < photos[j].SortOrder
+		}
+		return photos[i].CreatedAt.Before(photos[j].CreatedAt)
+	})
+	return photos
+}
+
+// nextPhotoSortOrder returns the sort order that puts a new photo at the end of a recipe's gallery.
+func (s *Store) nextPhotoSortOrder(recipeID string) int {
+	next := 0
+	for _, photo := range s.photos[recipeID] {
+		if photo.SortOrder >= next {
+			next = photo.SortOrder + 1
+		}
+	}
+	return next
+}
+
+// photoIndex returns the index of a photo in the recipe's gallery, or -1.
+func (s *Store) photoIndex(recipeID string, match func(models.RecipePhoto) bool) int {
+	for i, photo := range s.photos[recipeID] {
+		if match(photo) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setCoverPhoto makes a photo the recipe's cover and mirrors its filename to the recipe.
+func (s *Store) setCoverPhoto(recipeID string, photoID string) {
+	filename := ""
+	for i := range s.photos[recipeID] {
+		photo := &s.photos[recipeID][i]
+		photo.IsCover = photo.ID == photoID
+		if photo.IsCover {
+			filename = photo.Filename
+		}
+	}
+	s.setRecipeCoverFilename(recipeID, filename)
+}
+
+// setRecipeCoverFilename stores a new cover filename on the recipe and records the change as a revision.
+func (s *Store) setRecipeCoverFilename(recipeID string, filename string) {
+	recipe := s.recipes[recipeID]
+	recipe.PhotoFilename = filename
+	recipe.UpdatedAt = time.Now().UTC()
+	s.insertRevision(recipeID, "")
+}
+
+// syncCoverPhoto keeps the gallery in step with a photo_filename written directly to the
+// recipe: the file is added to the gallery if needed and flagged as the cover.
+// The placeholder leaves the recipe without a cover.
+func (s *Store) syncCoverPhoto(recipeID string, filename string) {
+	if filename != "" && filename != models.PlaceholderPhotoFilename &&
+		s.photoIndex(recipeID, func(p models.RecipePhoto) bool { return p.Filename == filename }) < 0 {
+		s.photos[recipeID] = append(s.photos[recipeID], models.RecipePhoto{
+			ID:        uuid.NewString(),
+			RecipeID:  recipeID,
+			Filename:  filename,
+			SortOrder: s.nextPhotoSortOrder(recipeID),
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+	for i := range s.photos[recipeID] {
+		photo := &s.photos[recipeID][i]
+		photo.IsCover = photo.Filename == filename
+	}
+}
+
+// insertImportedPhoto adds an imported photo to a recipe unless it already has that file,
+// keeping the photo's ID where it is free.
+func (s *Store) insertImportedPhoto(photo models.RecipePhoto, recipeID string) {
+	if s.photoIndex(recipeID, func(p models.RecipePhoto) bool { return p.Filename == photo.Filename }) >= 0 {
+		return
+	}
+	photo.ID = importedID(photo.ID, func(id string) bool {
+		for _, photos := range s.photos {
+			for _, p := range photos {
+				if p.ID == id {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	photo.RecipeID = recipeID
+	photo.IsCover = false
+	photo.URLs = nil
+	photo.CreatedAt = timeOrNow(photo.CreatedAt)
+	s.photos[recipeID] = append(s.photos[recipeID], photo)
+}
+
+// GetRecipePhotos returns a recipe's photos in display order.
+func (s *Store) GetRecipePhotos(ctx context.Context, recipeID string) ([]models.RecipePhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedPhotos(recipeID), nil
+}
+
+// GetAllRecipePhotos returns every recipe photo, for export.
+func (s *Store) GetAllRecipePhotos(ctx context.Context) ([]models.RecipePhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recipeIDs []string
+	for recipeID := range s.photos {
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+	sort.Strings(recipeIDs)
+
+	var photos []models.RecipePhoto
+	for _, recipeID := range recipeIDs {
+		photos = append(photos, s.sortedPhotos(recipeID)...)
+	}
+	return photos, nil
+}
+
+// AddRecipePhoto appends a photo to the end of a recipe's gallery. It becomes the cover
+// if photo.IsCover is set or the recipe has no cover yet.
+func (s *Store) AddRecipePhoto(ctx context.Context, photo models.RecipePhoto) (*models.RecipePhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(photo.RecipeID); !ok {
+		return nil, fmt.Errorf("recipe with ID %s not found", photo.RecipeID)
+	}
+	if s.photoIndex(photo.RecipeID, func(p models.RecipePhoto) bool { return p.Filename == photo.Filename }) >= 0 {
+		return nil, fmt.Errorf("photo '%s' already exists for recipe ID %s", photo.Filename, photo.RecipeID)
+	}
+	hasCover := s.photoIndex(photo.RecipeID, func(p models.RecipePhoto) bool { return p.IsCover }) >= 0
+
+	if photo.ID == "" {
+		photo.ID = uuid.NewString()
+	}
+	photo.CreatedAt = time.Now().UTC()
+	photo.SortOrder = s.nextPhotoSortOrder(photo.RecipeID)
+	photo.IsCover = photo.IsCover || !hasCover
+	stored := photo
+	stored.IsCover = false
+	s.photos[photo.RecipeID] = append(s.photos[photo.RecipeID], stored)
+	if photo.IsCover {
+		s.setCoverPhoto(photo.RecipeID, photo.ID)
+	}
+	return &photo, nil
+}
+
+// UpdateRecipePhoto changes a photo's caption (when caption is non-nil) and, if makeCover
+// is set, makes it the recipe's cover.
+func (s *Store) UpdateRecipePhoto(ctx context.Context, recipeID string, photoID string, caption *string, makeCover bool) (*models.RecipePhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(recipeID); !ok {
+		return nil, fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+	i := s.photoIndex(recipeID, func(p models.RecipePhoto) bool { return p.ID == photoID })
+	if i < 0 {
+		return nil, fmt.Errorf("photo with ID %s not found for recipe ID %s", photoID, recipeID)
+	}
+
+	if caption != nil {
+		s.photos[recipeID][i].Caption = *caption
+	}
+	if makeCover && !s.photos[recipeID][i].IsCover {
+		s.setCoverPhoto(recipeID, photoID)
+	}
+	photo := s.photos[recipeID][i]
+	return &photo, nil
+}
+
+// ReorderRecipePhotos sets the display order of a recipe's photos. photoIDs must list
+// every photo of the recipe exactly once, first to last.
+func (s *Store) ReorderRecipePhotos(ctx context.Context, recipeID string, photoIDs []string) ([]models.RecipePhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(recipeID); !ok {
+		return nil, fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+	photos := s.photos[recipeID]
+	order := make(map[string]int, len(photoIDs))
+	for i, photoID := range photoIDs {
+		if _, duplicate := order[photoID]; duplicate {
+			break
+		}
+		order[photoID] = i
+	}
+	valid := len(photoIDs) == len(photos) && len(order) == len(photos)
+	for _, photo := range photos {
+		if _, ok := order[photo.ID]; !ok {
+			valid = false
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("photo order for recipe ID %s must list each of its %d photos exactly once", recipeID, len(photos))
+	}
+
+	for i := range photos {
+		photos[i].SortOrder = order[photos[i].ID]
+	}
+	return s.sortedPhotos(recipeID), nil
+}
+
+// DeleteRecipePhoto removes a photo from a recipe's gallery and returns its filename. If it was
+// the cover, the next photo in order becomes the cover, or the recipe falls back to the placeholder.
+func (s *Store) DeleteRecipePhoto(ctx context.Context, recipeID string, photoID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveRecipe(recipeID); !ok {
+		return "", fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+	i := s.photoIndex(recipeID, func(p models.RecipePhoto) bool { return p.ID == photoID })
+	if i < 0 {
+		return "", fmt.Errorf("photo with ID %s not found for recipe ID %s", photoID, recipeID)
+	}
+	removed := s.photos[recipeID][i]
+	s.photos[recipeID] = append(s.photos[recipeID][:i], s.photos[recipeID][i+1:]...)
+
+	if removed.IsCover {
+		if remaining := s.sortedPhotos(recipeID); len(remaining) > 0 {
+			s.setCoverPhoto(recipeID, remaining[0].ID)
+		} else {
+			s.setRecipeCoverFilename(recipeID, models.PlaceholderPhotoFilename)
+		}
+	}
+	return removed.Filename, nil
+}
@@ -0,0 +1,788 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// liveRecipe returns the stored recipe with the given ID unless it is missing or in the trash.
+func (s *Store) liveRecipe(id string) (*models.Recipe, bool) {
+	recipe, ok := s.recipes[id]
+	if !ok || recipe.DeletedAt != nil {
+		return nil, false
+	}
+	return recipe, true
+}
+
+// recipeIngredientLines returns a recipe's ingredient lines as entered and in structured form.
+func (s *Store) recipeIngredientLines(recipeID string) ([]string, []models.StructuredIngredient) {
+	var lines []string
+	var structured []models.StructuredIngredient
+	for _, ri := range s.recipeIngredients[recipeID] {
+		si := models.StructuredIngredient{
+			Original:     ri.OriginalText,
+			QuantityText: ri.QuantityText,
+			Quantity:     ri.Quantity,
+			QuantityMax:  ri.QuantityMax,
+			Unit:         ri.Unit,
+			Name:         s.ingredients[ri.IngredientID].Name,
+			Preparation:  ri.Preparation,
+			IngredientID: ri.IngredientID,
+		}
+		if si.Original == "" {
+			si.Original = strings.TrimSpace(si.QuantityText + " " + si.Name)
+		}
+		lines = append(lines, si.Original)
+		structured = append(structured, si)
+	}
+	return lines, structured
+}
+
+// recipeTagNames returns the names of the tags linked to a recipe, alphabetically.
+func (s *Store) recipeTagNames(recipeID string) []string {
+	var names []string
+	for tagID := range s.recipeTags[recipeID] {
+		names = append(names, s.tags[tagID].Name)
+	}
+	sortByLowerName(names)
+	return names
+}
+
+// listedRecipe returns a copy of a stored recipe with its ingredient lines and tags, as listings show it.
+func (s *Store) listedRecipe(recipe *models.Recipe) models.Recipe {
+	listed := *recipe
+	listed.Ingredients, _ = s.recipeIngredientLines(recipe.ID)
+	if listed.Ingredients == nil {
+		listed.Ingredients = []string{}
+	}
+	listed.Tags = s.recipeTagNames(recipe.ID)
+	if listed.Tags == nil {
+		listed.Tags = []string{}
+	}
+	return listed
+}
+
+// getOrCreateIngredientByName returns the ID of the ingredient with the given canonical name,
+// or with the name as an alias, creating it if it does not exist yet.
+func (s *Store) getOrCreateIngredientByName(name string) string {
+	for _, ingredient := range s.ingredients {
+		if ingredient.Name == name {
+			return ingredient.ID
+		}
+	}
+	if alias := s.aliasByNormalizedName(database.NormalizeIngredientName(name)); alias != nil {
+		return alias.IngredientID
+	}
+	now := time.Now().UTC()
+	ingredient := &models.Ingredient{
+		ID:             uuid.NewString(),
+		Name:           name,
+		NormalizedName: database.NormalizeIngredientName(name),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.ingredients[ingredient.ID] = ingredient
+	return ingredient.ID
+}
+
+// addRecipeIngredient adds an ingredient line to a recipe unless the recipe already has a
+// line at its position. It reports whether it was added.
+func (s *Store) addRecipeIngredient(ri models.RecipeIngredient) bool {
+	for _, existing := range s.recipeIngredients[ri.RecipeID] {
+		if existing.SortOrder == ri.SortOrder {
+			return false
+		}
+	}
+	links := append(s.recipeIngredients[ri.RecipeID], ri)
+	sort.SliceStable(links, func(i, j int) bool { return links[i].SortOrder < links[j].SortOrder })
+	s.recipeIngredients[ri.RecipeID] = links
+	return true
+}
+
+// linkRecipeIngredients parses each ingredient line of a recipe and links the result.
+// Every line is kept, including several naming the same ingredient.
+func (s *Store) linkRecipeIngredients(recipeID string, lines []string) {
+	for i, line := range lines {
+		parsed := parser.ParseIngredient(line)
+		if parsed.Name == "" {
+			continue
+		}
+		s.addRecipeIngredient(models.RecipeIngredient{
+			ID:           uuid.NewString(),
+			RecipeID:     recipeID,
+			IngredientID: s.getOrCreateIngredientByName(parsed.Name),
+			OriginalText: parsed.Original,
+			QuantityText: parsed.QuantityText,
+			Quantity:     parsed.Quantity,
+			QuantityMax:  parsed.QuantityMax,
+			Unit:         parsed.Unit,
+			Preparation:  parsed.Preparation,
+			SortOrder:    i,
+		})
+	}
+}
+
+// storeRecipeFields copies the stored columns of a recipe, leaving out derived fields.
+func storeRecipeFields(recipe *models.Recipe) *models.Recipe {
+	stored := *recipe
+	stored.Ingredients = nil
+	stored.StructuredIngredients = nil
+	stored.Tags = nil
+	stored.PhotoURLs = nil
+	stored.Photos = nil
+	stored.ComputeTotalTime()
+	return &stored
+}
+
+// RecipeExistsByID checks if a recipe with the given ID exists. Recipes in the trash do not count.
+func (s *Store) RecipeExistsByID(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.liveRecipe(id)
+	return ok, nil
+}
+
+// GetRecipeByID retrieves a single recipe with its ingredients, tags and photos.
+// Recipes in the trash are treated as not found.
+func (s *Store) GetRecipeByID(ctx context.Context, id string) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.liveRecipe(id)
+	if !ok {
+		return nil, nil
+	}
+	recipe := *stored
+	recipe.Ingredients, recipe.StructuredIngredients = s.recipeIngredientLines(id)
+	recipe.Tags = s.recipeTagNames(id)
+	recipe.Photos = s.sortedPhotos(id)
+	return &recipe, nil
+}
+
+// CreateRecipe adds a new recipe with its ingredients, tags and cover photo,
+// and records it as revision 1, attributed to editor (may be empty).
+func (s *Store) CreateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if recipe.ID == "" {
+		recipe.ID = uuid.NewString()
+	}
+	if _, exists := s.recipes[recipe.ID]; exists {
+		return nil, fmt.Errorf("failed to insert recipe ID %s: duplicate key", recipe.ID)
+	}
+	recipe.CreatedAt = time.Now().UTC()
+	recipe.UpdatedAt = recipe.CreatedAt
+	recipe.ComputeTotalTime()
+	s.recipes[recipe.ID] = storeRecipeFields(recipe)
+
+	s.linkRecipeIngredients(recipe.ID, recipe.Ingredients)
+	recipe.Tags = s.setRecipeTags(recipe.ID, recipe.Tags)
+	s.syncCoverPhoto(recipe.ID, recipe.PhotoFilename)
+	s.insertRevision(recipe.ID, editor)
+	return recipe, nil
+}
+
+// GetAllRecipes retrieves recipes with optional search, filtering, and pagination, ordered by name.
+func (s *Store) GetAllRecipes(ctx context.Context, filter database.RecipeFilter, page int, pageSize int) ([]models.Recipe, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	var matches []models.Recipe
+	for _, stored := range s.recipes {
+		if stored.DeletedAt == nil && s.matchesFilter(stored, filter) {
+			matches = append(matches, s.listedRecipe(stored))
+		}
+	}
+	if len(matches) == 0 {
+		return []models.Recipe{}, 0, nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Name != matches[j].Name {
+			return matches[i].Name < matches[j].Name
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	offset := (page - 1) * pageSize
+	if offset >= len(matches) {
+		return nil, len(matches), nil
+	}
+	end := offset + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[offset:end], len(matches), nil
+}
+
+// matchesFilter reports whether a live recipe meets every criterion of filter.
+func (s *Store) matchesFilter(recipe *models.Recipe, filter database.RecipeFilter) bool {
+	if filter.SearchTerm != "" && !matchesAllWords(recipe.Name+" "+recipe.Method, filter.SearchTerm) {
+		return false
+	}
+	for _, term := range filter.IngredientFilters {
+		matching := make(map[string]bool)
+		for _, ingredient := range s.ingredients {
+			if matchesAllWords(ingredient.NormalizedName, term) {
+				matching[ingredient.ID] = true
+			}
+		}
+		if filter.IncludeDescendants {
+			matching = s.descendantIngredients(matching)
+		}
+		found := false
+		for _, ri := range s.recipeIngredients[recipe.ID] {
+			if matching[ri.IngredientID] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.MaxTotalTime > 0 && (recipe.TotalTimeMinutes == 0 || recipe.TotalTimeMinutes > filter.MaxTotalTime) {
+		return false
+	}
+	if filter.Servings > 0 && recipe.Servings != filter.Servings {
+		return false
+	}
+
+	tags := make(map[string]bool)
+	for _, name := range s.recipeTagNames(recipe.ID) {
+		tags[strings.ToLower(name)] = true
+	}
+	for _, name := range filter.TagsAll {
+		if name = strings.ToLower(database.NormalizeTagName(name)); name != "" && !tags[name] {
+			return false
+		}
+	}
+	anyWanted, anyFound := false, false
+	for _, name := range filter.TagsAny {
+		if name = strings.ToLower(database.NormalizeTagName(name)); name != "" {
+			anyWanted = true
+			anyFound = anyFound || tags[name]
+		}
+	}
+	if anyWanted && !anyFound {
+		return false
+	}
+	for _, name := range filter.TagsNone {
+		if tags[strings.ToLower(database.NormalizeTagName(name))] {
+			return false
+		}
+	}
+	if len(filter.ExcludeFlags) > 0 {
+		flags := s.recipeDietaryFlags(recipe.ID)
+		for _, flag := range filter.ExcludeFlags {
+			for _, present := range flags {
+				if flag == present {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// UpdateRecipe replaces a recipe's fields, ingredients and tags and records the result
+// as the next revision, attributed to editor (may be empty).
+func (s *Store) UpdateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if recipe.ID == "" {
+		return nil, fmt.Errorf("recipe ID cannot be empty for update")
+	}
+	existing, ok := s.liveRecipe(recipe.ID)
+	if !ok {
+		return nil, fmt.Errorf("recipe with ID %s not found for update", recipe.ID)
+	}
+
+	recipe.UpdatedAt = time.Now().UTC()
+	recipe.ComputeTotalTime()
+	stored := storeRecipeFields(recipe)
+	stored.CreatedAt = existing.CreatedAt
+	stored.DeletedAt = nil
+	s.recipes[recipe.ID] = stored
+
+	delete(s.recipeIngredients, recipe.ID)
+	s.linkRecipeIngredients(recipe.ID, recipe.Ingredients)
+	recipe.Tags = s.setRecipeTags(recipe.ID, recipe.Tags)
+	s.syncCoverPhoto(recipe.ID, recipe.PhotoFilename)
+	s.insertRevision(recipe.ID, editor)
+	return recipe, nil
+}
+
+// DeleteRecipe moves a recipe to the trash. Missing or already deleted recipes are ignored.
+func (s *Store) DeleteRecipe(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == "" {
+		return fmt.Errorf("recipe ID cannot be empty for deletion")
+	}
+	if recipe, ok := s.liveRecipe(id); ok {
+		now := time.Now().UTC()
+		recipe.DeletedAt = &now
+	}
+	return nil
+}
+
+// GetAllRecipesForExport returns every recipe, including those in the trash, oldest first.
+// Ingredients, tags and photos are exported separately.
+func (s *Store) GetAllRecipesForExport(ctx context.Context) ([]models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recipes []models.Recipe
+	for _, recipe := range s.recipes {
+		recipes = append(recipes, *recipe)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].CreatedAt.Before(recipes[j].CreatedAt) })
+	return recipes, nil
+}
+
+// ImportRecipeDataBundle imports recipes, ingredients and their aliases, tags, photos,
+// revisions, comments, meal plan entries and their links, matching ingredients by normalized
+// name and recipes by ID like the PostgreSQL version. Rows created by the import keep the IDs and timestamps
+// from the file. Nothing is changed if any part of the import fails.
+func (s *Store) ImportRecipeDataBundle(ctx context.Context, data models.ExportedData) (importedRecipes int, importedIngredients int, importedLinks int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate every reference before touching the store, standing in for the transaction
+	recipeIDs := make(map[string]bool)
+	for _, recipe := range data.Recipes {
+		recipeIDs[recipe.ID] = true
+	}
+	ingredientIDs := make(map[string]bool)
+	for _, ingredient := range data.Ingredients {
+		ingredientIDs[ingredient.ID] = true
+	}
+	tagIDs := make(map[string]bool)
+	for _, tag := range data.Tags {
+		if database.NormalizeTagName(tag.Name) != "" {
+			tagIDs[tag.ID] = true
+		}
+	}
+	for _, ingredient := range data.Ingredients {
+		if ingredient.ParentID != "" && !ingredientIDs[ingredient.ParentID] {
+			return 0, 0, 0, fmt.Errorf("error processing parent of ingredient '%s': could not find DB ID for original parent ingredient ID '%s'", ingredient.Name, ingredient.ParentID)
+		}
+	}
+	for _, alias := range data.IngredientAliases {
+		if !ingredientIDs[alias.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing ingredient alias '%s': could not find DB ID for original ingredient ID '%s'", alias.Name, alias.IngredientID)
+		}
+	}
+	for _, mapping := range data.IngredientFoods {
+		if !ingredientIDs[mapping.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing food of ingredient '%s': could not find DB ID for original ingredient ID '%s'", mapping.IngredientID, mapping.IngredientID)
+		}
+	}
+	for _, flagged := range data.IngredientDietaryFlags {
+		if !ingredientIDs[flagged.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing dietary flags of ingredient '%s': could not find DB ID for original ingredient ID '%s'", flagged.IngredientID, flagged.IngredientID)
+		}
+	}
+	for _, sub := range data.Substitutions {
+		if !ingredientIDs[sub.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing substitution '%s' for ingredient '%s': could not find DB ID for original ingredient ID '%s'", sub.ID, sub.IngredientID, sub.IngredientID)
+		}
+		for _, r := range sub.Replacements {
+			if !ingredientIDs[r.IngredientID] {
+				return 0, 0, 0, fmt.Errorf("error processing substitution '%s' for ingredient '%s': could not find DB ID for original ingredient ID '%s'", sub.ID, sub.IngredientID, r.IngredientID)
+			}
+		}
+	}
+	for _, item := range data.PantryItems {
+		if !ingredientIDs[item.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing pantry item '%s' for ingredient '%s': could not find DB ID for original ingredient ID '%s'", item.ID, item.IngredientID, item.IngredientID)
+		}
+	}
+	for _, staple := range data.PantryStaples {
+		if !ingredientIDs[staple.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing pantry staple '%s': could not find DB ID for original ingredient ID '%s'", staple.IngredientID, staple.IngredientID)
+		}
+	}
+	for _, aisle := range data.IngredientAisles {
+		if !ingredientIDs[aisle.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing aisle of ingredient '%s': could not find DB ID for original ingredient ID '%s'", aisle.IngredientID, aisle.IngredientID)
+		}
+	}
+	for _, list := range data.ShoppingLists {
+		for _, item := range list.Items {
+			if item.IngredientID != "" && !ingredientIDs[item.IngredientID] {
+				return 0, 0, 0, fmt.Errorf("error processing shopping list '%s': could not find DB ID for original ingredient ID '%s'", list.Name, item.IngredientID)
+			}
+		}
+	}
+	for _, entry := range data.MealPlanEntries {
+		if _, err := uuid.Parse(entry.RecipeID); err == nil && !recipeIDs[entry.RecipeID] && s.recipes[entry.RecipeID] == nil {
+			return 0, 0, 0, fmt.Errorf("error processing meal plan entry '%s': recipe with ID %s not found", entry.ID, entry.RecipeID)
+		}
+	}
+	for _, ri := range data.RecipeIngredients {
+		if !recipeIDs[ri.RecipeID] {
+			return 0, 0, 0, fmt.Errorf("error processing recipe_ingredient link for recipe '%s' and ingredient '%s': could not find DB ID for original recipe ID '%s'", ri.RecipeID, ri.IngredientID, ri.RecipeID)
+		}
+		if !ingredientIDs[ri.IngredientID] {
+			return 0, 0, 0, fmt.Errorf("error processing recipe_ingredient link for recipe '%s' and ingredient '%s': could not find DB ID for original ingredient ID '%s'", ri.RecipeID, ri.IngredientID, ri.IngredientID)
+		}
+	}
+	for _, link := range data.RecipeTags {
+		if !recipeIDs[link.RecipeID] || !tagIDs[link.TagID] {
+			return 0, 0, 0, fmt.Errorf("error processing recipe_tag link for recipe '%s' and tag '%s': could not find DB IDs", link.RecipeID, link.TagID)
+		}
+	}
+	for _, photo := range data.RecipePhotos {
+		if !recipeIDs[photo.RecipeID] {
+			return 0, 0, 0, fmt.Errorf("error processing photo '%s' for recipe '%s': could not find DB ID for original recipe ID '%s'", photo.Filename, photo.RecipeID, photo.RecipeID)
+		}
+	}
+	for _, overrides := range data.RecipeDietaryOverrides {
+		if !recipeIDs[overrides.RecipeID] {
+			return 0, 0, 0, fmt.Errorf("error processing dietary overrides of recipe '%s': could not find DB ID for original recipe ID '%s'", overrides.RecipeID, overrides.RecipeID)
+		}
+	}
+	for _, comment := range data.Comments {
+		if !recipeIDs[comment.RecipeID] {
+			return 0, 0, 0, fmt.Errorf("error processing comment '%s' for recipe '%s': could not find DB ID for original recipe ID '%s'", comment.ID, comment.RecipeID, comment.RecipeID)
+		}
+	}
+	for _, rev := range data.RecipeRevisions {
+		if !recipeIDs[rev.RecipeID] {
+			return 0, 0, 0, fmt.Errorf("error processing revision %d of recipe '%s': could not find DB ID for original recipe ID '%s'", rev.Revision, rev.RecipeID, rev.RecipeID)
+		}
+	}
+
+	// 1. Ingredients, matched by normalized name
+	ingredientIDMap := make(map[string]string)
+	for _, ingFromFile := range data.Ingredients {
+		id := ""
+		for _, existing := range s.ingredients {
+			if existing.NormalizedName == ingFromFile.NormalizedName {
+				id = existing.ID
+				break
+			}
+		}
+		if id == "" {
+			for _, existing := range s.ingredients {
+				if existing.Name == ingFromFile.Name {
+					id = existing.ID
+					break
+				}
+			}
+		}
+		if id == "" {
+			ingredient := &models.Ingredient{
+				ID:             importedID(ingFromFile.ID, func(id string) bool { return s.ingredients[id] != nil }),
+				Name:           ingFromFile.Name,
+				NormalizedName: database.NormalizeIngredientName(ingFromFile.Name),
+				CreatedAt:      timeOrNow(ingFromFile.CreatedAt),
+				UpdatedAt:      timeOrNow(ingFromFile.UpdatedAt),
+			}
+			s.ingredients[ingredient.ID] = ingredient
+			id = ingredient.ID
+		}
+		ingredientIDMap[ingFromFile.ID] = id
+		importedIngredients++
+	}
+	for _, ingFromFile := range data.Ingredients {
+		if ingFromFile.ParentID == "" {
+			continue
+		}
+		ingredient := s.ingredients[ingredientIDMap[ingFromFile.ID]]
+		parentID := ingredientIDMap[ingFromFile.ParentID]
+		if ingredient.ParentID == "" && !s.isIngredientAncestor(ingredient.ID, parentID) {
+			ingredient.ParentID = parentID
+		}
+	}
+	for _, aliasFromFile := range data.IngredientAliases {
+		ingredientID := ingredientIDMap[aliasFromFile.IngredientID]
+		normalized := database.NormalizeIngredientName(aliasFromFile.Name)
+		if normalized == s.ingredients[ingredientID].NormalizedName || s.aliasByNormalizedName(normalized) != nil {
+			continue
+		}
+		alias := &models.IngredientAlias{
+			ID:             importedID(aliasFromFile.ID, func(id string) bool { return s.ingredientAliases[id] != nil }),
+			IngredientID:   ingredientID,
+			Name:           aliasFromFile.Name,
+			NormalizedName: normalized,
+			CreatedAt:      timeOrNow(aliasFromFile.CreatedAt),
+		}
+		s.ingredientAliases[alias.ID] = alias
+	}
+	// Foods already loaded keep their values and an ingredient that has a mapping keeps it
+	for _, mappingFromFile := range data.IngredientFoods {
+		if mappingFromFile.Food != nil && s.foods[mappingFromFile.FoodID] == nil {
+			food := *mappingFromFile.Food
+			food.ID = mappingFromFile.FoodID
+			food.UpdatedAt = timeOrNow(food.UpdatedAt)
+			s.foods[food.ID] = &food
+		}
+		ingredientID := ingredientIDMap[mappingFromFile.IngredientID]
+		if s.foods[mappingFromFile.FoodID] == nil || s.ingredientFoods[ingredientID] != nil {
+			continue
+		}
+		mapping := mappingFromFile
+		mapping.IngredientID = ingredientID
+		mapping.Food = nil
+		mapping.UpdatedAt = timeOrNow(mapping.UpdatedAt)
+		s.ingredientFoods[ingredientID] = &mapping
+	}
+	// Flags are added to those an ingredient already carries; unknown flags are skipped
+	for _, flaggedFromFile := range data.IngredientDietaryFlags {
+		s.addIngredientDietaryFlags(ingredientIDMap[flaggedFromFile.IngredientID], flaggedFromFile.Flags)
+	}
+	for _, subFromFile := range data.Substitutions {
+		sub := subFromFile
+		if _, err := uuid.Parse(sub.ID); err != nil {
+			sub.ID = uuid.NewString()
+		} else if s.substitutions[sub.ID] != nil {
+			continue
+		}
+		sub.IngredientID = ingredientIDMap[subFromFile.IngredientID]
+		sub.IngredientName = ""
+		sub.Replacements = make([]models.SubstitutionReplacement, len(subFromFile.Replacements))
+		for i, r := range subFromFile.Replacements {
+			sub.Replacements[i] = models.SubstitutionReplacement{IngredientID: ingredientIDMap[r.IngredientID], Quantity: r.Quantity, Unit: r.Unit}
+		}
+		sub.CreatedAt = timeOrNow(sub.CreatedAt)
+		sub.UpdatedAt = timeOrNow(sub.UpdatedAt)
+		s.substitutions[sub.ID] = &sub
+	}
+	for _, itemFromFile := range data.PantryItems {
+		item := itemFromFile
+		if _, err := uuid.Parse(item.ID); err != nil {
+			item.ID = uuid.NewString()
+		} else if s.pantryItems[item.ID] != nil {
+			continue
+		}
+		item.IngredientID = ingredientIDMap[itemFromFile.IngredientID]
+		if s.pantryItemByIngredient(item.IngredientID) != nil {
+			continue
+		}
+		item.IngredientName = ""
+		item.ExpiresOn = datePtr(item.ExpiresOn)
+		item.CreatedAt = timeOrNow(item.CreatedAt)
+		item.UpdatedAt = timeOrNow(item.UpdatedAt)
+		s.pantryItems[item.ID] = &item
+	}
+	for _, stapleFromFile := range data.PantryStaples {
+		ingredientID := ingredientIDMap[stapleFromFile.IngredientID]
+		if _, ok := s.pantryStaples[ingredientID]; !ok {
+			s.pantryStaples[ingredientID] = time.Now().UTC()
+		}
+	}
+	for _, aisleFromFile := range data.IngredientAisles {
+		ingredientID := ingredientIDMap[aisleFromFile.IngredientID]
+		if s.ingredientAisles[ingredientID] == nil {
+			s.ingredientAisles[ingredientID] = &models.IngredientAisle{IngredientID: ingredientID, Aisle: aisleFromFile.Aisle, UpdatedAt: timeOrNow(aisleFromFile.UpdatedAt)}
+		}
+	}
+	for _, listFromFile := range data.ShoppingLists {
+		if s.shoppingLists[listFromFile.ID] != nil {
+			continue
+		}
+		list := listFromFile
+		list.Recipes = append([]string{}, list.Recipes...)
+		list.StartDate, list.EndDate = datePtr(list.StartDate), datePtr(list.EndDate)
+		if list.Version < 1 {
+			list.Version = 1
+		}
+		list.CreatedAt = timeOrNow(list.CreatedAt)
+		list.UpdatedAt = timeOrNow(list.UpdatedAt)
+		items := make([]models.ShoppingListItem, 0, len(list.Items))
+		for i, item := range list.Items {
+			if item.IngredientID != "" {
+				item.IngredientID = ingredientIDMap[item.IngredientID]
+			}
+			if item.ID == "" {
+				item.ID = uuid.NewString()
+			}
+			item.Recipes = append([]string{}, item.Recipes...)
+			item.SortOrder = i
+			if item.Version < 1 {
+				item.Version = 1
+			}
+			items = append(items, item)
+		}
+		list.Items = nil
+		s.shoppingLists[list.ID] = &list
+		s.shoppingListItems[list.ID] = items
+	}
+
+	// 2. Recipes, matched by ID
+	recipeIDMap := make(map[string]string)
+	var createdRecipeIDs []string
+	createdRecipePhotos := make(map[string]string)
+	for _, recFromFile := range data.Recipes {
+		id := ""
+		if existing, ok := s.recipes[recFromFile.ID]; ok {
+			id = existing.ID
+		}
+		if id == "" {
+			recipe := storeRecipeFields(&recFromFile)
+			recipe.ID = importedID(recFromFile.ID, func(id string) bool { return s.recipes[id] != nil })
+			recipe.CreatedAt = timeOrNow(recFromFile.CreatedAt)
+			recipe.UpdatedAt = timeOrNow(recFromFile.UpdatedAt)
+			s.recipes[recipe.ID] = recipe
+			id = recipe.ID
+			createdRecipeIDs = append(createdRecipeIDs, id)
+			createdRecipePhotos[id] = recFromFile.PhotoFilename
+		}
+		recipeIDMap[recFromFile.ID] = id
+		importedRecipes++
+	}
+
+	// 3. Recipe-ingredient links
+	linkIDs := make(map[string]bool)
+	for _, links := range s.recipeIngredients {
+		for _, ri := range links {
+			linkIDs[ri.ID] = true
+		}
+	}
+	for _, riFromFile := range data.RecipeIngredients {
+		ri := riFromFile
+		ri.ID = importedID(riFromFile.ID, func(id string) bool { return linkIDs[id] })
+		linkIDs[ri.ID] = true
+		ri.RecipeID = recipeIDMap[riFromFile.RecipeID]
+		ri.IngredientID = ingredientIDMap[riFromFile.IngredientID]
+		s.addRecipeIngredient(ri)
+		importedLinks++
+	}
+
+	// 4. Tags and recipe-tag links
+	tagIDMap := make(map[string]string)
+	for _, tagFromFile := range data.Tags {
+		name := database.NormalizeTagName(tagFromFile.Name)
+		if name == "" {
+			continue
+		}
+		if existing, ok := s.tagByLowerName(name); ok {
+			tagIDMap[tagFromFile.ID] = existing.ID
+			continue
+		}
+		tag := &models.Tag{
+			ID:        importedID(tagFromFile.ID, func(id string) bool { return s.tags[id] != nil }),
+			Name:      name,
+			Category:  tagFromFile.Category,
+			CreatedAt: timeOrNow(tagFromFile.CreatedAt),
+			UpdatedAt: timeOrNow(tagFromFile.UpdatedAt),
+		}
+		s.tags[tag.ID] = tag
+		tagIDMap[tagFromFile.ID] = tag.ID
+	}
+	for _, link := range data.RecipeTags {
+		recipeID := recipeIDMap[link.RecipeID]
+		if s.recipeTags[recipeID] == nil {
+			s.recipeTags[recipeID] = make(map[string]bool)
+		}
+		s.recipeTags[recipeID][tagIDMap[link.TagID]] = true
+	}
+
+	// 5. Recipe photos and dietary overrides; a recipe keeps the overrides it already has
+	for _, photo := range data.RecipePhotos {
+		s.insertImportedPhoto(photo, recipeIDMap[photo.RecipeID])
+	}
+	for _, overridesFromFile := range data.RecipeDietaryOverrides {
+		recipeID := recipeIDMap[overridesFromFile.RecipeID]
+		for flag, present := range overridesFromFile.Overrides {
+			if !dietary.IsFlag(flag) {
+				continue
+			}
+			if s.recipeDietaryOverrides[recipeID] == nil {
+				s.recipeDietaryOverrides[recipeID] = make(map[string]bool)
+			}
+			if _, ok := s.recipeDietaryOverrides[recipeID][flag]; !ok {
+				s.recipeDietaryOverrides[recipeID][flag] = present
+			}
+		}
+	}
+
+	// 6. Comments and meal plan entries; entries for recipes not in the file keep their recipe_id
+	for _, commentFromFile := range data.Comments {
+		comment := commentFromFile
+		if _, err := uuid.Parse(comment.ID); err != nil {
+			comment.ID = uuid.NewString()
+		} else if s.comments[comment.ID] != nil {
+			continue
+		}
+		comment.RecipeID = recipeIDMap[commentFromFile.RecipeID]
+		comment.CreatedAt = timeOrNow(comment.CreatedAt)
+		comment.UpdatedAt = timeOrNow(comment.UpdatedAt)
+		s.comments[comment.ID] = &comment
+	}
+	for _, entryFromFile := range data.MealPlanEntries {
+		entry := entryFromFile
+		if _, err := uuid.Parse(entry.ID); err != nil {
+			entry.ID = uuid.NewString()
+		}
+		if _, err := uuid.Parse(entry.RecipeID); err != nil && entry.RecipeID != "" && entry.Title == "" {
+			entry.RecipeID, entry.Title = "", entry.RecipeID // Free text exported in recipe_id by older versions
+		}
+		if recipeID, ok := recipeIDMap[entry.RecipeID]; ok {
+			entry.RecipeID = recipeID
+		}
+		if entry.RecipeID != "" {
+			entry.Title = "" // Exported as the recipe's name
+		}
+		entry.Date = dateOnly(entry.Date)
+		if entry.Slot == "" {
+			entry.Slot = models.MealSlotDinner
+		}
+		entry.CreatedAt = timeOrNow(entry.CreatedAt)
+		if !s.plannedMealPlanEntry(&entry) {
+			s.mealPlanEntries[entry.ID] = &entry
+		}
+	}
+
+	// 7. Cover and history of newly created recipes; recipes exported without revisions get a first one
+	revisionsByRecipe := make(map[string][]models.RecipeRevision)
+	for _, rev := range data.RecipeRevisions {
+		recipeID := recipeIDMap[rev.RecipeID]
+		revisionsByRecipe[recipeID] = append(revisionsByRecipe[recipeID], rev)
+	}
+	for _, recipeID := range createdRecipeIDs {
+		s.syncCoverPhoto(recipeID, createdRecipePhotos[recipeID])
+		revisions := revisionsByRecipe[recipeID]
+		if len(revisions) == 0 {
+			s.insertRevision(recipeID, "import")
+			continue
+		}
+		sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+		for _, rev := range revisions {
+			if rev.Revision != len(s.revisions[recipeID])+1 {
+				continue // Revisions are numbered from 1 without gaps; skip duplicates
+			}
+			rev.ID = importedID(rev.ID, func(string) bool { return false })
+			rev.RecipeID = recipeID
+			rev.Ingredients = append([]string{}, rev.Ingredients...)
+			rev.Tags = append([]string{}, rev.Tags...)
+			rev.CreatedAt = timeOrNow(rev.CreatedAt)
+			s.revisions[recipeID] = append(s.revisions[recipeID], rev)
+		}
+	}
+	// Imported links and food mappings can change the nutrition of existing recipes
+	s.recipeNutrition = make(map[string]models.RecipeNutrition)
+	return importedRecipes, importedIngredients, importedLinks, nil
+}
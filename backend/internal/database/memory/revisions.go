@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// insertRevision records the recipe's stored state, including its ingredients and tags,
+// as its next revision.
+func (s *Store) insertRevision(recipeID string, editor string) {
+	recipe := s.recipes[recipeID]
+	ingredients, _ := s.recipeIngredientLines(recipeID)
+	if ingredients == nil {
+		ingredients = []string{}
+	}
+	tags := s.recipeTagNames(recipeID)
+	if tags == nil {
+		tags = []string{}
+	}
+	s.revisions[recipeID] = append(s.revisions[recipeID], models.RecipeRevision{
+		ID:              uuid.NewString(),
+		RecipeID:        recipeID,
+		Revision:        len(s.revisions[recipeID]) + 1,
+		Name:            recipe.Name,
+		Method:          recipe.Method,
+		Ingredients:     ingredients,
+		Servings:        recipe.Servings,
+		Yield:           recipe.Yield,
+		PrepTimeMinutes: recipe.PrepTimeMinutes,
+		CookTimeMinutes: recipe.CookTimeMinutes,
+		RestTimeMinutes: recipe.RestTimeMinutes,
+		Tags:            tags,
+		PhotoFilename:   recipe.PhotoFilename,
+		Editor:          editor,
+		CreatedAt:       time.Now().UTC(),
+	})
+}
+
+// GetRecipeRevisions returns every revision of a recipe, newest first.
+func (s *Store) GetRecipeRevisions(ctx context.Context, recipeID string) ([]models.RecipeRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.revisions[recipeID]
+	var revisions []models.RecipeRevision
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+	return revisions, nil
+}
+
+// GetAllRecipeRevisions returns every revision of every recipe, for export.
+func (s *Store) GetAllRecipeRevisions(ctx context.Context) ([]models.RecipeRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recipeIDs []string
+	for recipeID := range s.revisions {
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+	sort.Strings(recipeIDs)
+
+	var revisions []models.RecipeRevision
+	for _, recipeID := range recipeIDs {
+		revisions = append(revisions, s.revisions[recipeID]...)
+	}
+	return revisions, nil
+}
+
+// GetRecipeRevision returns a single revision of a recipe by its number.
+// A revision number of 0 returns the latest revision.
+func (s *Store) GetRecipeRevision(ctx context.Context, recipeID string, revision int) (*models.RecipeRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.revisions[recipeID]
+	if revision == 0 {
+		revision = len(stored)
+	}
+	if revision < 1 || revision > len(stored) {
+		return nil, fmt.Errorf("revision %d of recipe %s not found", revision, recipeID)
+	}
+	rev := stored[revision-1]
+	return &rev, nil
+}
@@ -0,0 +1,313 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetAllIngredientAisles returns every ingredient given an aisle, ordered by aisle and
+// ingredient name.
+func (s *Store) GetAllIngredientAisles(ctx context.Context) ([]models.IngredientAisle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aisles := []models.IngredientAisle{}
+	for _, stored := range s.ingredientAisles {
+		aisle := *stored
+		aisle.IngredientName = s.ingredients[aisle.IngredientID].Name
+		aisles = append(aisles, aisle)
+	}
+	sort.Slice(aisles, func(i, j int) bool {
+		if aisles[i].Aisle != aisles[j].Aisle {
+			return aisles[i].Aisle < aisles[j].Aisle
+		}
+		return aisles[i].IngredientName < aisles[j].IngredientName
+	})
+	return aisles, nil
+}
+
+// SetIngredientAisle puts an ingredient in a store aisle, replacing its previous aisle.
+func (s *Store) SetIngredientAisle(ctx context.Context, ingredientID string, aisle string) (*models.IngredientAisle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ingredient, ok := s.ingredients[ingredientID]
+	if !ok {
+		return nil, fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+	stored := &models.IngredientAisle{IngredientID: ingredientID, Aisle: aisle, UpdatedAt: time.Now().UTC()}
+	s.ingredientAisles[ingredientID] = stored
+
+	result := *stored
+	result.IngredientName = ingredient.Name
+	return &result, nil
+}
+
+// DeleteIngredientAisle removes an ingredient's own aisle; it then takes the aisle of the
+// ingredients above it in the taxonomy, if any.
+func (s *Store) DeleteIngredientAisle(ctx context.Context, ingredientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ingredientAisles[ingredientID]; !ok {
+		return fmt.Errorf("aisle of ingredient %s not found", ingredientID)
+	}
+	delete(s.ingredientAisles, ingredientID)
+	return nil
+}
+
+// shoppingList returns a copy of a stored shopping list with its counts filled in, and its
+// items when withItems is set.
+func (s *Store) shoppingList(stored *models.ShoppingList, withItems bool) models.ShoppingList {
+	list := *stored
+	list.Recipes = append([]string{}, stored.Recipes...)
+	items := s.shoppingListItems[list.ID]
+	list.ItemCount, list.CheckedCount = len(items), 0
+	for _, item := range items {
+		if item.Checked {
+			list.CheckedCount++
+		}
+	}
+	if withItems {
+		list.Items = make([]models.ShoppingListItem, len(items))
+		for i, item := range items {
+			item.Recipes = append([]string{}, item.Recipes...)
+			list.Items[i] = item
+		}
+	}
+	return list
+}
+
+// shoppingListAt returns the stored shopping list when it is at version, failing when another
+// change got there first. Callers bump the version once their change is made.
+func (s *Store) shoppingListAt(id string, version int) (*models.ShoppingList, error) {
+	stored, ok := s.shoppingLists[id]
+	if !ok {
+		return nil, fmt.Errorf("shopping list with ID %s not found", id)
+	}
+	if stored.Version != version {
+		return nil, fmt.Errorf("version conflict: shopping list %s is at version %d, not %d", id, stored.Version, version)
+	}
+	return stored, nil
+}
+
+// bumpShoppingListVersion records a change to a stored shopping list.
+func bumpShoppingListVersion(stored *models.ShoppingList) {
+	stored.Version++
+	stored.UpdatedAt = time.Now().UTC()
+}
+
+// GetShoppingLists returns every shopping list without its items, newest first.
+func (s *Store) GetShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists := []models.ShoppingList{}
+	for _, stored := range s.shoppingLists {
+		lists = append(lists, s.shoppingList(stored, false))
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].CreatedAt.After(lists[j].CreatedAt) })
+	return lists, nil
+}
+
+// GetAllShoppingLists returns every shopping list with its items, oldest first, for export.
+func (s *Store) GetAllShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists := []models.ShoppingList{}
+	for _, stored := range s.shoppingLists {
+		lists = append(lists, s.shoppingList(stored, true))
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].CreatedAt.Before(lists[j].CreatedAt) })
+	return lists, nil
+}
+
+// GetShoppingList returns a shopping list with its items in display order.
+func (s *Store) GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.shoppingLists[id]
+	if !ok {
+		return nil, fmt.Errorf("shopping list with ID %s not found", id)
+	}
+	list := s.shoppingList(stored, true)
+	return &list, nil
+}
+
+// CreateShoppingList stores a new shopping list at version 1, with its items in the order given.
+func (s *Store) CreateShoppingList(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := list
+	stored.ID = uuid.NewString()
+	stored.StartDate, stored.EndDate = datePtr(list.StartDate), datePtr(list.EndDate)
+	stored.Recipes = append([]string{}, list.Recipes...)
+	stored.Version = 1
+	stored.CreatedAt = time.Now().UTC()
+	stored.UpdatedAt = stored.CreatedAt
+	stored.Items = nil
+	items := make([]models.ShoppingListItem, 0, len(list.Items))
+	for i, item := range list.Items {
+		item.ID = uuid.NewString()
+		item.Recipes = append([]string{}, item.Recipes...)
+		item.SortOrder = i
+		item.Version = 1
+		items = append(items, item)
+	}
+	s.shoppingLists[stored.ID] = &stored
+	s.shoppingListItems[stored.ID] = items
+
+	created := s.shoppingList(&stored, true)
+	return &created, nil
+}
+
+// DeleteShoppingList removes a shopping list and its items.
+func (s *Store) DeleteShoppingList(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shoppingLists[id]; !ok {
+		return fmt.Errorf("shopping list with ID %s not found", id)
+	}
+	delete(s.shoppingLists, id)
+	delete(s.shoppingListItems, id)
+	return nil
+}
+
+// RenameShoppingList renames the shopping list as of version.
+func (s *Store) RenameShoppingList(ctx context.Context, id string, version int, name string) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.shoppingListAt(id, version)
+	if err != nil {
+		return nil, err
+	}
+	stored.Name = name
+	bumpShoppingListVersion(stored)
+
+	list := s.shoppingList(stored, true)
+	return &list, nil
+}
+
+// AddShoppingListItem adds an item to the end of the shopping list as of version.
+func (s *Store) AddShoppingListItem(ctx context.Context, listID string, version int, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.shoppingListAt(listID, version)
+	if err != nil {
+		return nil, err
+	}
+	items := s.shoppingListItems[listID]
+	item.ID = uuid.NewString()
+	item.Recipes = append([]string{}, item.Recipes...)
+	item.Version = 1
+	item.SortOrder = 0
+	if len(items) > 0 {
+		item.SortOrder = items[len(items)-1].SortOrder + 1
+	}
+	s.shoppingListItems[listID] = append(items, item)
+	bumpShoppingListVersion(stored)
+
+	list := s.shoppingList(stored, true)
+	return &list, nil
+}
+
+// UpdateShoppingListItem replaces the editable fields of an item of the shopping list as of
+// item.Version, the item's own version: its name, amount, aisle, check mark and buyer. The
+// list's version is left alone, so ticks on different items never conflict.
+func (s *Store) UpdateShoppingListItem(ctx context.Context, listID string, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.shoppingLists[listID]
+	if !ok {
+		return nil, fmt.Errorf("shopping list with ID %s not found", listID)
+	}
+	items := s.shoppingListItems[listID]
+	for i := range items {
+		if items[i].ID == item.ID {
+			if items[i].Version != item.Version {
+				return nil, fmt.Errorf("version conflict: shopping list item %s is at version %d, not %d", item.ID, items[i].Version, item.Version)
+			}
+			items[i].Name = item.Name
+			items[i].Quantity = item.Quantity
+			items[i].Unit = item.Unit
+			items[i].Aisle = item.Aisle
+			items[i].Checked = item.Checked
+			items[i].BoughtBy = item.BoughtBy
+			items[i].Version++
+			stored.UpdatedAt = time.Now().UTC()
+
+			list := s.shoppingList(stored, true)
+			return &list, nil
+		}
+	}
+	return nil, fmt.Errorf("shopping list item with ID %s not found", item.ID)
+}
+
+// DeleteShoppingListItem removes an item from the shopping list as of version.
+func (s *Store) DeleteShoppingListItem(ctx context.Context, listID string, version int, itemID string) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.shoppingListAt(listID, version)
+	if err != nil {
+		return nil, err
+	}
+	items := s.shoppingListItems[listID]
+	for i := range items {
+		if items[i].ID == itemID {
+			s.shoppingListItems[listID] = append(items[:i:i], items[i+1:]...)
+			bumpShoppingListVersion(stored)
+
+			list := s.shoppingList(stored, true)
+			return &list, nil
+		}
+	}
+	return nil, fmt.Errorf("shopping list item with ID %s not found", itemID)
+}
+
+// ReorderShoppingListItems sets the display order of the items of the shopping list as of
+// version. itemIDs must list every item of the list exactly once, first to last.
+func (s *Store) ReorderShoppingListItems(ctx context.Context, listID string, version int, itemIDs []string) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.shoppingListAt(listID, version)
+	if err != nil {
+		return nil, err
+	}
+	items := s.shoppingListItems[listID]
+	byID := make(map[string]models.ShoppingListItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	if len(itemIDs) != len(items) {
+		return nil, fmt.Errorf("item order for shopping list ID %s must list each of its %d items exactly once", listID, len(items))
+	}
+	reordered := make([]models.ShoppingListItem, 0, len(items))
+	for i, itemID := range itemIDs {
+		item, ok := byID[itemID]
+		if !ok {
+			return nil, fmt.Errorf("item order for shopping list ID %s must list each of its %d items exactly once", listID, len(items))
+		}
+		delete(byID, itemID)
+		item.SortOrder = i
+		reordered = append(reordered, item)
+	}
+	s.shoppingListItems[listID] = reordered
+	bumpShoppingListVersion(stored)
+
+	list := s.shoppingList(stored, true)
+	return &list, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// substitution returns a copy of a stored substitution with its ingredient names filled in.
+func (s *Store) substitution(stored *models.Substitution) models.Substitution {
+	sub := *stored
+	sub.IngredientName = s.ingredients[sub.IngredientID].Name
+	sub.Replacements = make([]models.SubstitutionReplacement, len(stored.Replacements))
+	for i, r := range stored.Replacements {
+		r.IngredientName = s.ingredients[r.IngredientID].Name
+		sub.Replacements[i] = r
+	}
+	return sub
+}
+
+// storeSubstitution resolves the ingredients of sub and stores it under its ID.
+func (s *Store) storeSubstitution(sub models.Substitution) (*models.Substitution, error) {
+	ingredientID, err := s.resolveIngredient(sub.IngredientID, sub.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	replacements := make([]models.SubstitutionReplacement, len(sub.Replacements))
+	for i, r := range sub.Replacements {
+		replacementID, err := s.resolveIngredient(r.IngredientID, r.IngredientName)
+		if err != nil {
+			return nil, err
+		}
+		replacements[i] = models.SubstitutionReplacement{IngredientID: replacementID, Quantity: r.Quantity, Unit: r.Unit}
+	}
+	sub.IngredientID = ingredientID
+	sub.IngredientName = ""
+	sub.Replacements = replacements
+	s.substitutions[sub.ID] = &sub
+
+	result := s.substitution(&sub)
+	return &result, nil
+}
+
+// GetAllSubstitutions returns the whole substitutions knowledge base, ordered by the name of
+// the ingredient replaced.
+func (s *Store) GetAllSubstitutions(ctx context.Context) ([]models.Substitution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.Substitution{}
+	for _, stored := range s.substitutions {
+		result = append(result, s.substitution(stored))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IngredientName != result[j].IngredientName {
+			return result[i].IngredientName < result[j].IngredientName
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetSubstitutionsForIngredients returns the substitutions replacing any of the given ingredients.
+func (s *Store) GetSubstitutionsForIngredients(ctx context.Context, ingredientIDs []string) ([]models.Substitution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(ingredientIDs))
+	for _, id := range ingredientIDs {
+		wanted[id] = true
+	}
+	result := []models.Substitution{}
+	for _, stored := range s.substitutions {
+		if wanted[stored.IngredientID] {
+			result = append(result, s.substitution(stored))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+// CreateSubstitution adds a substitution, resolving ingredients given by name only.
+func (s *Store) CreateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub.ID = uuid.NewString()
+	sub.CreatedAt = time.Now().UTC()
+	sub.UpdatedAt = sub.CreatedAt
+	return s.storeSubstitution(sub)
+}
+
+// UpdateSubstitution replaces an existing substitution, replacements included.
+func (s *Store) UpdateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.substitutions[sub.ID]
+	if existing == nil {
+		return nil, fmt.Errorf("substitution with ID %s not found", sub.ID)
+	}
+	sub.CreatedAt = existing.CreatedAt
+	sub.UpdatedAt = time.Now().UTC()
+	return s.storeSubstitution(sub)
+}
+
+// DeleteSubstitution removes a substitution.
+func (s *Store) DeleteSubstitution(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.substitutions[id] == nil {
+		return fmt.Errorf("substitution with ID %s not found", id)
+	}
+	delete(s.substitutions, id)
+	return nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tagByLowerName returns the tag with the given name, ignoring case.
+func (s *Store) tagByLowerName(name string) (*models.Tag, bool) {
+	for _, tag := range s.tags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag, true
+		}
+	}
+	return nil, false
+}
+
+// liveTagRecipeCount returns the number of recipes outside the trash carrying a tag.
+func (s *Store) liveTagRecipeCount(tagID string) int {
+	count := 0
+	for recipeID, tagIDs := range s.recipeTags {
+		if _, live := s.liveRecipe(recipeID); live && tagIDs[tagID] {
+			count++
+		}
+	}
+	return count
+}
+
+// getOrCreateTagByName returns the ID of the tag with the given name (case-insensitive),
+// creating it if it does not exist yet.
+func (s *Store) getOrCreateTagByName(name string, category string) string {
+	if tag, ok := s.tagByLowerName(name); ok {
+		return tag.ID
+	}
+	now := time.Now().UTC()
+	tag := &models.Tag{ID: uuid.NewString(), Name: name, Category: category, CreatedAt: now, UpdatedAt: now}
+	s.tags[tag.ID] = tag
+	return tag.ID
+}
+
+// setRecipeTags replaces the tags linked to a recipe, creating tags that do not exist yet.
+// It returns the normalized names.
+func (s *Store) setRecipeTags(recipeID string, names []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	tagIDs := make(map[string]bool)
+	for _, name := range names {
+		name = database.NormalizeTagName(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		normalized = append(normalized, name)
+		tagIDs[s.getOrCreateTagByName(name, "")] = true
+	}
+	s.recipeTags[recipeID] = tagIDs
+	return normalized
+}
+
+// GetAllTags returns every tag with the number of live recipes using it, ordered by category
+// and name. An empty category returns tags of all categories.
+func (s *Store) GetAllTags(ctx context.Context, category string) ([]models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tags []models.Tag
+	for _, stored := range s.tags {
+		if category != "" && !strings.EqualFold(stored.Category, category) {
+			continue
+		}
+		tag := *stored
+		tag.RecipeCount = s.liveTagRecipeCount(tag.ID)
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Category != tags[j].Category {
+			return tags[i].Category < tags[j].Category
+		}
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	return tags, nil
+}
+
+// GetTagByID returns a single tag and its recipe count.
+func (s *Store) GetTagByID(ctx context.Context, tagID string) (*models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.tags[tagID]
+	if !ok {
+		return nil, fmt.Errorf("tag with ID %s not found", tagID)
+	}
+	tag := *stored
+	tag.RecipeCount = s.liveTagRecipeCount(tagID)
+	return &tag, nil
+}
+
+// CreateTag stores a new tag. Names must be unique regardless of case.
+func (s *Store) CreateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tagByLowerName(tag.Name); exists {
+		return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+	}
+	if tag.ID == "" {
+		tag.ID = uuid.NewString()
+	}
+	tag.CreatedAt = time.Now().UTC()
+	tag.UpdatedAt = tag.CreatedAt
+	stored := tag
+	s.tags[tag.ID] = &stored
+	return &tag, nil
+}
+
+// UpdateTag renames or recategorizes an existing tag.
+func (s *Store) UpdateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.tags[tag.ID]
+	if !ok {
+		return nil, fmt.Errorf("tag with ID %s not found for update", tag.ID)
+	}
+	if other, exists := s.tagByLowerName(tag.Name); exists && other.ID != tag.ID {
+		return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+	}
+	stored.Name = tag.Name
+	stored.Category = tag.Category
+	stored.UpdatedAt = time.Now().UTC()
+	updated := *stored
+	return &updated, nil
+}
+
+// DeleteTag removes a tag and its links to recipes.
+func (s *Store) DeleteTag(ctx context.Context, tagID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tags[tagID]; !ok {
+		return fmt.Errorf("tag with ID %s not found for deletion", tagID)
+	}
+	delete(s.tags, tagID)
+	for _, tagIDs := range s.recipeTags {
+		delete(tagIDs, tagID)
+	}
+	return nil
+}
+
+// GetAllRecipeTags returns every recipe-tag link, for export.
+func (s *Store) GetAllRecipeTags(ctx context.Context) ([]models.RecipeTag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var links []models.RecipeTag
+	for recipeID, tagIDs := range s.recipeTags {
+		for tagID := range tagIDs {
+			links = append(links, models.RecipeTag{RecipeID: recipeID, TagID: tagID})
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].RecipeID != links[j].RecipeID {
+			return links[i].RecipeID < links[j].RecipeID
+		}
+		return links[i].TagID < links[j].TagID
+	})
+	return links, nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"sort"
+	"time"
+)
+
+// GetDeletedRecipes returns the recipes in the trash, most recently deleted first.
+// Ingredients are not loaded.
+func (s *Store) GetDeletedRecipes(ctx context.Context) ([]models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recipes []models.Recipe
+	for _, recipe := range s.recipes {
+		if recipe.DeletedAt != nil {
+			recipes = append(recipes, *recipe)
+		}
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].DeletedAt.After(*recipes[j].DeletedAt) })
+	return recipes, nil
+}
+
+// RestoreDeletedRecipe takes a recipe out of the trash.
+func (s *Store) RestoreDeletedRecipe(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipe, ok := s.recipes[id]
+	if !ok || recipe.DeletedAt == nil {
+		return fmt.Errorf("recipe with ID %s not found in trash", id)
+	}
+	recipe.DeletedAt = nil
+	return nil
+}
+
+// GetDeletedRecipeIDsBefore returns the IDs of recipes that were moved to the trash before cutoff.
+func (s *Store) GetDeletedRecipeIDsBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id, recipe := range s.recipes {
+		if recipe.DeletedAt != nil && recipe.DeletedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// PurgeRecipe permanently removes a recipe that is in the trash with everything linked to it.
+// It returns the photo filenames the recipe, its revisions and its gallery referenced.
+func (s *Store) PurgeRecipe(ctx context.Context, id string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipe, ok := s.recipes[id]
+	if !ok || recipe.DeletedAt == nil {
+		return nil, fmt.Errorf("recipe with ID %s not found in trash", id)
+	}
+
+	photoFilenames := []string{}
+	seen := make(map[string]bool)
+	addPhoto := func(filename string) {
+		if filename != "" && !seen[filename] {
+			seen[filename] = true
+			photoFilenames = append(photoFilenames, filename)
+		}
+	}
+	addPhoto(recipe.PhotoFilename)
+	for _, rev := range s.revisions[id] {
+		addPhoto(rev.PhotoFilename)
+	}
+	for _, photo := range s.photos[id] {
+		addPhoto(photo.Filename)
+	}
+
+	delete(s.recipes, id)
+	delete(s.recipeIngredients, id)
+	delete(s.recipeTags, id)
+	delete(s.revisions, id)
+	delete(s.photos, id)
+	delete(s.recipeNutrition, id)
+	delete(s.recipeDietaryOverrides, id)
+	for commentID, comment := range s.comments {
+		if comment.RecipeID == id {
+			delete(s.comments, commentID)
+		}
+	}
+	for entryID, entry := range s.mealPlanEntries {
+		if entry.RecipeID == id {
+			delete(s.mealPlanEntries, entryID)
+		}
+	}
+	return photoFilenames, nil
+}
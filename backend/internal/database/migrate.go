@@ -0,0 +1,416 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the SQL migrations, so the binary does not depend on the source tree at runtime.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the PostgreSQL advisory lock held while migrating,
+// so replicas starting at the same time apply each migration once.
+const migrationLockID int64 = 7_245_108_937
+
+// legacyMigrations are migrations that predate schema_migrations and cannot be run twice,
+// by the table they create. On a database migrated before schema_migrations existed,
+// they are recorded as applied when that table is already there.
+var legacyMigrations = map[int64]string{
+	1:              "recipes",
+	20250613162217: "comments",
+}
+
+// Migration is one versioned schema change, read from migrations/<version>_<name>.sql
+// and its optional migrations/<version>_<name>_down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // Empty when the migration cannot be rolled back
+}
+
+// MigrationStatus is a migration and, if it has been applied, when.
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Missing   bool       `json:"missing,omitempty"` // Applied, but its file is no longer embedded
+}
+
+// LoadMigrations reads the *.sql files at the root of fsys and pairs each with its
+// _down.sql file, ordered by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	downs := make(map[int64]string)
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || path.Ext(filename) != ".sql" {
+			continue
+		}
+		base := strings.TrimSuffix(filename, ".sql")
+		isDown := strings.HasSuffix(base, "_down")
+		base = strings.TrimSuffix(base, "_down")
+
+		versionStr, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if !ok || err != nil || name == "" {
+			return nil, fmt.Errorf("migration file '%s' is not named <version>_<name>.sql", filename)
+		}
+		content, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration file '%s': %w", filename, err)
+		}
+
+		if isDown {
+			downs[version] = string(content)
+			continue
+		}
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("migrations '%s' and '%s' share version %d", existing.Name, name, version)
+		}
+		byVersion[version] = &Migration{Version: version, Name: name, Up: string(content)}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, down := range downs {
+		if _, ok := byVersion[version]; !ok {
+			return nil, fmt.Errorf("down migration for version %d has no up migration", version)
+		}
+		byVersion[version].Down = down
+	}
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationDialect holds the statements a Migrator runs that differ between database engines.
+type MigrationDialect struct {
+	Lock        string // Takes a lock held for the duration of an operation; empty if none is needed
+	Unlock      string // Releases the lock taken by Lock
+	TableExists string // Query with a table name argument, returning whether the table exists
+	CreateTable string // Creates schema_migrations (version, name, applied_at)
+}
+
+// PostgresMigrationDialect serializes migrations across replicas with an advisory lock.
+var PostgresMigrationDialect = MigrationDialect{
+	Lock:        fmt.Sprintf("SELECT pg_advisory_lock(%d)", migrationLockID),
+	Unlock:      fmt.Sprintf("SELECT pg_advisory_unlock(%d)", migrationLockID),
+	TableExists: "SELECT to_regclass($1) IS NOT NULL",
+	CreateTable: `
+		CREATE TABLE schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+}
+
+// Migrator applies and rolls back migrations, recording applied versions in schema_migrations.
+// On PostgreSQL every operation holds an advisory lock for its duration.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	dialect    MigrationDialect
+}
+
+// NewMigrator returns a Migrator for the PostgreSQL migrations embedded in the binary.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error opening embedded migrations: %w", err)
+	}
+	return NewMigratorFS(db, sub, PostgresMigrationDialect)
+}
+
+// NewMigratorFS returns a Migrator for the migrations at the root of fsys, for a database
+// spoken to with dialect.
+func NewMigratorFS(db *sql.DB, fsys fs.FS, dialect MigrationDialect) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, dialect: dialect}, nil
+}
+
+// Up applies every pending migration in version order and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the n most recently applied migrations, newest first, and returns the ones it rolled back.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		rolledBack, err = m.rollBack(ctx, conn, n)
+		return err
+	})
+	return rolledBack, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		rolledBack, err := m.rollBack(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			return fmt.Errorf("no migration has been applied")
+		}
+		redone = &rolledBack[0]
+		return applyMigration(ctx, conn, *redone)
+	})
+	return redone, err
+}
+
+// Status lists every known migration with the time it was applied, if it was, followed by
+// applied versions whose files are no longer embedded.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if at, ok := appliedAt[migration.Version]; ok {
+				status.AppliedAt = &at
+				delete(appliedAt, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+
+		var missing []MigrationStatus
+		for version, at := range appliedAt {
+			missing = append(missing, MigrationStatus{Version: version, AppliedAt: &at, Missing: true})
+		}
+		sort.Slice(missing, func(i, j int) bool { return missing[i].Version < missing[j].Version })
+		statuses = append(statuses, missing...)
+		return nil
+	})
+	return statuses, err
+}
+
+// rollBack rolls back up to n applied migrations, newest first. It must be called with the lock held.
+func (m *Migrator) rollBack(ctx context.Context, conn *sql.Conn, n int) ([]Migration, error) {
+	appliedAt, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	versions := make([]int64, 0, len(appliedAt))
+	for version := range appliedAt {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var rolledBack []Migration
+	for _, version := range versions {
+		if len(rolledBack) == n {
+			break
+		}
+		migration, ok := known[version]
+		if !ok {
+			return rolledBack, fmt.Errorf("cannot roll back migration %d: its file is not embedded in this binary", version)
+		}
+		if migration.Down == "" {
+			return rolledBack, fmt.Errorf("cannot roll back migration %d_%s: it has no down migration", version, migration.Name)
+		}
+		if err := runMigrationStep(ctx, conn, migration, migration.Down, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+			return rolledBack, err
+		}
+		log.Printf("Rolled back migration %d_%s", version, migration.Name)
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, if the dialect
+// has one, after making sure schema_migrations exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	if m.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting connection for migrations: %w", err)
+	}
+	defer conn.Close()
+
+	// Session-level advisory locks belong to the connection, so everything below runs on conn.
+	if m.dialect.Lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.Lock); err != nil {
+			return fmt.Errorf("error acquiring migration lock: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), m.dialect.Unlock); err != nil {
+				log.Printf("Warning: Failed to release migration lock: %v", err)
+			}
+		}()
+	}
+
+	if err := m.ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureMigrationsTable creates schema_migrations. When it is created on a database set up
+// before it existed, the legacy migrations found already applied are recorded.
+func (m *Migrator) ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, m.dialect.TableExists, "schema_migrations").Scan(&exists); err != nil {
+		return fmt.Errorf("error checking for schema_migrations: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, m.dialect.CreateTable)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	legacy, err := legacyAppliedMigrations(m.migrations, func(table string) (bool, error) {
+		var tableExists bool
+		err := tx.QueryRowContext(ctx, m.dialect.TableExists, table).Scan(&tableExists)
+		return tableExists, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, migration := range legacy {
+		_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		if err != nil {
+			return fmt.Errorf("error recording migration %d_%s as applied: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Found table %s from before schema_migrations; recorded migration %d_%s as applied", legacyMigrations[migration.Version], migration.Version, migration.Name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// legacyAppliedMigrations returns the legacy migrations whose table tableExists reports,
+// which a database set up before schema_migrations has already applied.
+func legacyAppliedMigrations(migrations []Migration, tableExists func(table string) (bool, error)) ([]Migration, error) {
+	var applied []Migration
+	for _, migration := range migrations {
+		table, ok := legacyMigrations[migration.Version]
+		if !ok {
+			continue
+		}
+		exists, err := tableExists(table)
+		if err != nil {
+			return nil, fmt.Errorf("error checking for table %s: %w", table, err)
+		}
+		if exists {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// appliedMigrations returns when each applied version was applied.
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("error scanning schema_migrations row: %w", err)
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations rows: %w", err)
+	}
+	return appliedAt, nil
+}
+
+// applyMigration runs a migration's up SQL and records it as applied.
+func applyMigration(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	err := runMigrationStep(ctx, conn, migration, migration.Up,
+		"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+	if err != nil {
+		return err
+	}
+	log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// runMigrationStep runs migration SQL and the schema_migrations bookkeeping statement in one transaction.
+func runMigrationStep(ctx context.Context, conn *sql.Conn, migration Migration, script string, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction for migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	defer tx.Rollback()
+
+	// The pq driver runs a multi-statement script when it is sent without arguments;
+	// the sqlite3 driver always does.
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("error running migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("error recording migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
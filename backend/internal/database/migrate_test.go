@@ -0,0 +1,127 @@
+package database
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20261016090000_structured_recipe_ingredients.sql":      {Data: []byte("ALTER TABLE recipe_ingredients ADD COLUMN unit VARCHAR(50);")},
+		"20261016090000_structured_recipe_ingredients_down.sql": {Data: []byte("ALTER TABLE recipe_ingredients DROP COLUMN unit;")},
+		"001_initial_schema.sql":                                {Data: []byte("CREATE TABLE recipes (id UUID PRIMARY KEY);")},
+		"20250613162217_create_comments_table.sql":              {Data: []byte("CREATE TABLE comments (id UUID PRIMARY KEY);")},
+		"20250613162217_create_comments_table_down.sql":         {Data: []byte("DROP TABLE comments;")},
+
+		// Skipped
+		"README.md":           {Data: []byte("Not a migration")},
+		"archive/002_old.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "initial_schema", Up: "CREATE TABLE recipes (id UUID PRIMARY KEY);"},
+		{Version: 20250613162217, Name: "create_comments_table", Up: "CREATE TABLE comments (id UUID PRIMARY KEY);", Down: "DROP TABLE comments;"},
+		{Version: 20261016090000, Name: "structured_recipe_ingredients",
+			Up: "ALTER TABLE recipe_ingredients ADD COLUMN unit VARCHAR(50);", Down: "ALTER TABLE recipe_ingredients DROP COLUMN unit;"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("LoadMigrations =\n%+v\nwant\n%+v", migrations, want)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{"duplicate version", []string{"002_add_servings.sql", "002_add_times.sql"}, "share version 2"},
+		{"down without up", []string{"002_add_servings.sql", "003_add_times_down.sql"}, "version 3 has no up migration"},
+		{"no version", []string{"add_servings.sql"}, "is not named <version>_<name>.sql"},
+		{"no name", []string{"002.sql"}, "is not named <version>_<name>.sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range tt.files {
+				fsys[file] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			if _, err := LoadMigrations(fsys); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadMigrations error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("fs.Sub: %v", err)
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	for _, migration := range migrations {
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLegacyAppliedMigrations(t *testing.T) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("fs.Sub: %v", err)
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tables []string
+		want   []int64
+	}{
+		{"fresh database", nil, nil},
+		{"recipes only", []string{"recipes"}, []int64{1}},
+		{"recipes and comments", []string{"recipes", "comments", "ingredients"}, []int64{1, 20250613162217}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legacy, err := legacyAppliedMigrations(migrations, func(table string) (bool, error) {
+				for _, existing := range tt.tables {
+					if existing == table {
+						return true, nil
+					}
+				}
+				return false, nil
+			})
+			if err != nil {
+				t.Fatalf("legacyAppliedMigrations: %v", err)
+			}
+			var versions []int64
+			for _, migration := range legacy {
+				versions = append(versions, migration.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.want) {
+				t.Errorf("legacy migrations = %v, want %v", versions, tt.want)
+			}
+		})
+	}
+
+	failure := errors.New("connection reset")
+	_, err = legacyAppliedMigrations(migrations, func(string) (bool, error) { return false, failure })
+	if !errors.Is(err, failure) {
+		t.Errorf("legacyAppliedMigrations error = %v, want %v", err, failure)
+	}
+}
@@ -0,0 +1,36 @@
+-- Migration: 20261016090000_structured_recipe_ingredients
+-- Description: Store parsed quantity, unit and preparation for each recipe ingredient line
+
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS original_text TEXT;
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS quantity NUMERIC(12, 4);
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS quantity_max NUMERIC(12, 4);
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS unit VARCHAR(50);
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS preparation TEXT;
+
+-- Rows created before parsing stored the whole line as the ingredient name;
+-- keep that text as the original line so the legacy ingredient list is unchanged.
+UPDATE recipe_ingredients ri
+SET original_text = TRIM(COALESCE(ri.quantity_text, '') || ' ' || i.name)
+FROM ingredients i
+WHERE ri.ingredient_id = i.id AND ri.original_text IS NULL;
+
+COMMENT ON COLUMN recipe_ingredients.original_text IS 'Ingredient line exactly as entered by the user';
+COMMENT ON COLUMN recipe_ingredients.quantity IS 'Parsed numeric amount (lower bound for ranges such as "1-2")';
+COMMENT ON COLUMN recipe_ingredients.quantity_max IS 'Upper bound of a quantity range, NULL if not a range';
+COMMENT ON COLUMN recipe_ingredients.unit IS 'Canonical unit name (g, ml, cup, tbsp, clove...)';
+COMMENT ON COLUMN recipe_ingredients.preparation IS 'Preparation notes such as "finely chopped" or "to taste"';
+
+-- "2 tbsp butter" and "1 tbsp butter, for frying" are separate lines of one recipe, so a line
+-- is identified by its position in the recipe rather than by the ingredient it names.
+ALTER TABLE recipe_ingredients DROP CONSTRAINT IF EXISTS recipe_ingredients_recipe_id_ingredient_id_key;
+
+-- Number existing lines from 0 in their current order
+UPDATE recipe_ingredients SET sort_order = ordered.position
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY recipe_id ORDER BY sort_order, id) - 1 AS position
+        FROM recipe_ingredients
+    ) AS ordered
+    WHERE ordered.id = recipe_ingredients.id AND recipe_ingredients.sort_order <> ordered.position;
+
+DROP INDEX IF EXISTS idx_recipe_ingredients_sort_order;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_ingredients_line ON recipe_ingredients(recipe_id, sort_order);
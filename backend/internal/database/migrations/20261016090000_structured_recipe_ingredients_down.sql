@@ -0,0 +1,18 @@
+-- A recipe can only reference an ingredient once again: keep the first line of each
+DELETE FROM recipe_ingredients WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY recipe_id, ingredient_id ORDER BY sort_order, id) AS n
+        FROM recipe_ingredients
+    ) ranked
+    WHERE n > 1
+);
+
+DROP INDEX IF EXISTS idx_recipe_ingredients_line;
+CREATE INDEX IF NOT EXISTS idx_recipe_ingredients_sort_order ON recipe_ingredients(recipe_id, sort_order);
+ALTER TABLE recipe_ingredients ADD CONSTRAINT recipe_ingredients_recipe_id_ingredient_id_key UNIQUE (recipe_id, ingredient_id);
+
+ALTER TABLE recipe_ingredients DROP COLUMN IF EXISTS preparation;
+ALTER TABLE recipe_ingredients DROP COLUMN IF EXISTS unit;
+ALTER TABLE recipe_ingredients DROP COLUMN IF EXISTS quantity_max;
+ALTER TABLE recipe_ingredients DROP COLUMN IF EXISTS quantity;
+ALTER TABLE recipe_ingredients DROP COLUMN IF EXISTS original_text;
@@ -0,0 +1,8 @@
+-- Migration: 20261016100000_add_recipe_servings
+-- Description: Record how many servings (or what yield) a recipe makes, used for scaling
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS servings INTEGER CHECK (servings IS NULL OR servings > 0);
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS yield VARCHAR(100);
+
+COMMENT ON COLUMN recipes.servings IS 'Number of servings the recipe makes as written';
+COMMENT ON COLUMN recipes.yield IS 'Free-text yield for recipes not measured in servings, e.g. "24 cookies"';
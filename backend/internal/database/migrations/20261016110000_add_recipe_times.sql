@@ -0,0 +1,17 @@
+-- Migration: 20261016110000_add_recipe_times
+-- Description: Prep, cook and rest times for recipes, with a generated total for filtering
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS prep_time_minutes INTEGER CHECK (prep_time_minutes IS NULL OR prep_time_minutes >= 0);
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS cook_time_minutes INTEGER CHECK (cook_time_minutes IS NULL OR cook_time_minutes >= 0);
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS rest_time_minutes INTEGER CHECK (rest_time_minutes IS NULL OR rest_time_minutes >= 0);
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS total_time_minutes INTEGER GENERATED ALWAYS AS (
+    CASE
+        WHEN prep_time_minutes IS NULL AND cook_time_minutes IS NULL AND rest_time_minutes IS NULL THEN NULL
+        ELSE COALESCE(prep_time_minutes, 0) + COALESCE(cook_time_minutes, 0) + COALESCE(rest_time_minutes, 0)
+    END
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_recipes_total_time ON recipes(total_time_minutes);
+CREATE INDEX IF NOT EXISTS idx_recipes_servings ON recipes(servings);
+
+COMMENT ON COLUMN recipes.total_time_minutes IS 'Prep + cook + rest time, maintained by PostgreSQL';
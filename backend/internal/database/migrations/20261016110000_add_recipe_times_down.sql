@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_recipes_servings;
+DROP INDEX IF EXISTS idx_recipes_total_time;
+ALTER TABLE recipes DROP COLUMN IF EXISTS total_time_minutes;
+ALTER TABLE recipes DROP COLUMN IF EXISTS rest_time_minutes;
+ALTER TABLE recipes DROP COLUMN IF EXISTS cook_time_minutes;
+ALTER TABLE recipes DROP COLUMN IF EXISTS prep_time_minutes;
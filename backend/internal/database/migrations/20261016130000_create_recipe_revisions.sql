@@ -0,0 +1,42 @@
+-- Migration: 20261016130000_create_recipe_revisions
+-- Description: Snapshot of a recipe after every save, so edits can be reviewed, diffed and undone
+
+CREATE TABLE IF NOT EXISTS recipe_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL, -- 1 for the recipe as created, incremented on every update
+    name VARCHAR(255) NOT NULL,
+    method TEXT NOT NULL,
+    ingredients TEXT[] NOT NULL DEFAULT '{}', -- Ingredient lines as entered
+    servings INTEGER,
+    yield VARCHAR(100),
+    prep_time_minutes INTEGER,
+    cook_time_minutes INTEGER,
+    rest_time_minutes INTEGER,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    photo_filename VARCHAR(255),
+    editor VARCHAR(255), -- Who saved this revision, if known
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(recipe_id, revision)
+);
+
+-- Recipes saved before revision tracking start with their current state as revision 1
+INSERT INTO recipe_revisions (recipe_id, revision, name, method, ingredients, servings, yield,
+    prep_time_minutes, cook_time_minutes, rest_time_minutes, tags, photo_filename, created_at)
+SELECT r.id, 1, r.name, r.method,
+    COALESCE((
+        SELECT array_agg(COALESCE(NULLIF(ri.original_text, ''), TRIM(COALESCE(ri.quantity_text, '') || ' ' || i.name)) ORDER BY ri.sort_order ASC)
+        FROM recipe_ingredients ri
+        JOIN ingredients i ON ri.ingredient_id = i.id
+        WHERE ri.recipe_id = r.id
+    ), '{}'),
+    r.servings, r.yield, r.prep_time_minutes, r.cook_time_minutes, r.rest_time_minutes,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY LOWER(t.name) ASC)
+        FROM recipe_tags rt
+        JOIN tags t ON rt.tag_id = t.id
+        WHERE rt.recipe_id = r.id
+    ), '{}'),
+    r.photo_filename, r.updated_at
+FROM recipes r
+WHERE NOT EXISTS (SELECT 1 FROM recipe_revisions rr WHERE rr.recipe_id = r.id);
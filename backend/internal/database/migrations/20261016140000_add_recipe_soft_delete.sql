@@ -0,0 +1,10 @@
+-- Migration: 20261016140000_add_recipe_soft_delete
+-- Description: Deleted recipes move to a trash bin (deleted_at set) and are purged after a retention period
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Live recipes are what almost every query asks for
+CREATE INDEX IF NOT EXISTS idx_recipes_live_name ON recipes(name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_recipes_deleted_at ON recipes(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN recipes.deleted_at IS 'When the recipe was moved to the trash; NULL for live recipes';
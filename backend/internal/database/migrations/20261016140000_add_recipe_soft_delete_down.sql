@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_recipes_deleted_at;
+DROP INDEX IF EXISTS idx_recipes_live_name;
+ALTER TABLE recipes DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,24 @@
+-- Migration: 20261016150000_create_recipe_photos
+-- Description: Several photos per recipe with captions and ordering; recipes.photo_filename mirrors the cover
+
+CREATE TABLE IF NOT EXISTS recipe_photos (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    caption TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(recipe_id, filename)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_photos_recipe_order ON recipe_photos(recipe_id, sort_order);
+-- At most one cover per recipe
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_photos_one_cover ON recipe_photos(recipe_id) WHERE is_cover;
+
+-- Existing photos become the cover of their recipe's gallery
+INSERT INTO recipe_photos (recipe_id, filename, sort_order, is_cover, created_at)
+SELECT r.id, r.photo_filename, 0, TRUE, r.updated_at
+FROM recipes r
+WHERE r.photo_filename IS NOT NULL AND r.photo_filename <> '' AND r.photo_filename <> 'placeholder.jpg'
+    AND NOT EXISTS (SELECT 1 FROM recipe_photos p WHERE p.recipe_id = r.id);
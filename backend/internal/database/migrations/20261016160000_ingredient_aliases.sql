@@ -0,0 +1,32 @@
+-- Migration: 20261016160000_ingredient_aliases
+-- Description: Trigram indexes for fuzzy ingredient autocomplete, and aliases: other names an ingredient is found by
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS ingredient_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL, -- Set by trigger_set_normalized_alias_name
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- An alias stands for a single ingredient
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingredient_aliases_normalized_name ON ingredient_aliases(normalized_name);
+CREATE INDEX IF NOT EXISTS idx_ingredient_aliases_ingredient_id ON ingredient_aliases(ingredient_id);
+
+-- Serve both prefix (LIKE 'x%') and similarity (%) matches
+CREATE INDEX IF NOT EXISTS idx_ingredients_normalized_name_trgm ON ingredients USING GIN (normalized_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_ingredient_aliases_normalized_name_trgm ON ingredient_aliases USING GIN (normalized_name gin_trgm_ops);
+
+CREATE OR REPLACE FUNCTION set_normalized_alias_name()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.normalized_name = normalize_ingredient_name(NEW.name);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_set_normalized_alias_name ON ingredient_aliases;
+CREATE TRIGGER trigger_set_normalized_alias_name BEFORE INSERT OR UPDATE ON ingredient_aliases
+    FOR EACH ROW EXECUTE FUNCTION set_normalized_alias_name();
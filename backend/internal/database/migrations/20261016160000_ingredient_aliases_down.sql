@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ingredient_aliases;
+DROP FUNCTION IF EXISTS set_normalized_alias_name();
+DROP INDEX IF EXISTS idx_ingredients_normalized_name_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,8 @@
+-- Migration: 20261016170000_ingredient_taxonomy
+-- Description: Parent/child relationships between ingredients (cheddar -> cheese -> dairy)
+
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES ingredients(id) ON DELETE SET NULL;
+ALTER TABLE ingredients DROP CONSTRAINT IF EXISTS ingredients_parent_not_self;
+ALTER TABLE ingredients ADD CONSTRAINT ingredients_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_ingredients_parent_id ON ingredients(parent_id);
@@ -0,0 +1,37 @@
+-- Migration: 20261016180000_nutrition
+-- Description: Food composition data, the foods ingredients are mapped to, and cached recipe nutrition
+
+-- One row per food of the loaded dataset, e.g. USDA FoodData Central; amounts are per 100 g
+CREATE TABLE IF NOT EXISTS nutrients (
+    id VARCHAR(64) PRIMARY KEY, -- Identifier in the dataset, e.g. the fdc_id
+    description TEXT NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    calories NUMERIC(10, 3) NOT NULL DEFAULT 0, -- kcal
+    protein_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    fat_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    carbohydrate_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    fiber_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    sodium_mg NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_nutrients_description_trgm ON nutrients USING GIN (description gin_trgm_ops);
+
+-- The food whose composition an ingredient has
+CREATE TABLE IF NOT EXISTS ingredient_foods (
+    ingredient_id UUID PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    food_id VARCHAR(64) NOT NULL REFERENCES nutrients(id) ON DELETE CASCADE,
+    grams_per_ml NUMERIC(10, 4) CHECK (grams_per_ml > 0), -- Density, for amounts by volume
+    grams_per_piece NUMERIC(10, 3) CHECK (grams_per_piece > 0), -- Weight of one item, for counted amounts
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_foods_food_id ON ingredient_foods(food_id);
+
+-- Nutrition computed for a recipe, valid while the recipe's updated_at is recipe_updated_at
+CREATE TABLE IF NOT EXISTS recipe_nutrition (
+    recipe_id UUID PRIMARY KEY REFERENCES recipes(id) ON DELETE CASCADE,
+    recipe_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    nutrition JSONB NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS recipe_nutrition;
+DROP TABLE IF EXISTS ingredient_foods;
+DROP TABLE IF EXISTS nutrients;
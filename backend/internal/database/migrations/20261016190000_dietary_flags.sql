@@ -0,0 +1,102 @@
+-- Migration: 20261016190000_dietary_flags
+-- Description: Allergen and meat/fish flags of ingredients, seeded for common ones, manual overrides per recipe, and the flags of each recipe
+
+-- Flags an ingredient carries itself; ingredients below it in the taxonomy inherit them
+CREATE TABLE IF NOT EXISTS ingredient_dietary_flags (
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    flag VARCHAR(20) NOT NULL CHECK (flag IN ('gluten', 'dairy', 'egg', 'nuts', 'peanuts', 'soy', 'shellfish', 'sesame', 'meat', 'fish')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ingredient_id, flag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_dietary_flags_flag ON ingredient_dietary_flags(flag);
+
+-- Manual corrections: present = TRUE adds a flag to a recipe, FALSE removes a derived one
+CREATE TABLE IF NOT EXISTS recipe_dietary_overrides (
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    flag VARCHAR(20) NOT NULL CHECK (flag IN ('gluten', 'dairy', 'egg', 'nuts', 'peanuts', 'soy', 'shellfish', 'sesame', 'meat', 'fish')),
+    present BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (recipe_id, flag)
+);
+
+-- Flags of each recipe: those of its ingredients and their ancestors, after the overrides
+CREATE OR REPLACE VIEW recipe_dietary_flags AS
+WITH RECURSIVE ingredient_ancestors(ingredient_id, ancestor_id) AS (
+    SELECT id, id FROM ingredients
+    UNION
+    SELECT a.ingredient_id, i.parent_id
+    FROM ingredient_ancestors a
+    JOIN ingredients i ON i.id = a.ancestor_id
+    WHERE i.parent_id IS NOT NULL
+),
+derived AS (
+    SELECT DISTINCT ri.recipe_id, f.flag
+    FROM recipe_ingredients ri
+    JOIN ingredient_ancestors a ON a.ingredient_id = ri.ingredient_id
+    JOIN ingredient_dietary_flags f ON f.ingredient_id = a.ancestor_id
+)
+SELECT d.recipe_id, d.flag
+FROM derived d
+WHERE NOT EXISTS (
+    SELECT 1 FROM recipe_dietary_overrides o
+    WHERE o.recipe_id = d.recipe_id AND o.flag = d.flag AND NOT o.present
+)
+UNION
+SELECT recipe_id, flag FROM recipe_dietary_overrides WHERE present;
+
+-- Ingredients without flags count as free of every allergen, so seed the common culprits.
+-- Names are canonical, as the ingredient parser stores them.
+CREATE TEMPORARY TABLE dietary_flag_seed (
+    name VARCHAR(255) NOT NULL,
+    flag VARCHAR(20) NOT NULL
+);
+
+INSERT INTO dietary_flag_seed (name, flag) VALUES
+    -- Gluten
+    ('flour', 'gluten'), ('bread', 'gluten'), ('breadcrumb', 'gluten'), ('pasta', 'gluten'),
+    ('spaghetti', 'gluten'), ('couscous', 'gluten'), ('semolina', 'gluten'), ('barley', 'gluten'),
+    -- Dairy
+    ('milk', 'dairy'), ('buttermilk', 'dairy'), ('butter', 'dairy'), ('cream', 'dairy'),
+    ('heavy cream', 'dairy'), ('sour cream', 'dairy'), ('yogurt', 'dairy'), ('cheese', 'dairy'),
+    ('cheddar', 'dairy'), ('parmesan', 'dairy'), ('mozzarella', 'dairy'), ('cream cheese', 'dairy'),
+    -- Egg
+    ('egg', 'egg'), ('egg yolk', 'egg'), ('egg white', 'egg'), ('mayonnaise', 'egg'),
+    -- Tree nuts
+    ('almond', 'nuts'), ('walnut', 'nuts'), ('cashew', 'nuts'), ('hazelnut', 'nuts'),
+    ('pecan', 'nuts'), ('pistachio', 'nuts'),
+    -- Peanuts
+    ('peanut', 'peanuts'), ('peanut butter', 'peanuts'),
+    -- Soy
+    ('soy sauce', 'soy'), ('soy sauce', 'gluten'), ('tofu', 'soy'), ('miso', 'soy'),
+    -- Shellfish
+    ('shrimp', 'shellfish'), ('prawn', 'shellfish'), ('crab', 'shellfish'), ('lobster', 'shellfish'),
+    ('mussel', 'shellfish'), ('scallop', 'shellfish'),
+    -- Sesame
+    ('sesame seed', 'sesame'), ('sesame oil', 'sesame'), ('tahini', 'sesame'),
+    -- Meat
+    ('chicken', 'meat'), ('chicken breast', 'meat'), ('chicken stock', 'meat'), ('beef', 'meat'),
+    ('ground beef', 'meat'), ('beef stock', 'meat'), ('pork', 'meat'), ('bacon', 'meat'),
+    ('ham', 'meat'), ('sausage', 'meat'), ('lamb', 'meat'),
+    -- Fish
+    ('salmon', 'fish'), ('tuna', 'fish'), ('cod', 'fish'), ('anchovy', 'fish'), ('fish sauce', 'fish');
+
+-- Create the ingredients that neither exist nor are an alias of another yet, so that
+-- recipes naming them later link to the flagged ingredient
+INSERT INTO ingredients (name)
+SELECT DISTINCT s.name FROM dietary_flag_seed s
+WHERE NOT EXISTS (
+    SELECT 1 FROM ingredient_aliases a WHERE a.normalized_name = normalize_ingredient_name(s.name)
+)
+ON CONFLICT (name) DO NOTHING;
+
+-- Flag the ingredient each name resolves to, the ingredient itself before an alias
+INSERT INTO ingredient_dietary_flags (ingredient_id, flag)
+SELECT DISTINCT COALESCE(i.id, a.ingredient_id), s.flag
+FROM dietary_flag_seed s
+LEFT JOIN ingredients i ON i.name = s.name
+LEFT JOIN ingredient_aliases a ON a.normalized_name = normalize_ingredient_name(s.name)
+WHERE COALESCE(i.id, a.ingredient_id) IS NOT NULL
+ON CONFLICT (ingredient_id, flag) DO NOTHING;
+
+DROP TABLE dietary_flag_seed;
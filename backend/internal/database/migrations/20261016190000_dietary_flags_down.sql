@@ -0,0 +1,4 @@
+-- Ingredients created by the seed are kept, as recipes may link to them by now
+DROP VIEW IF EXISTS recipe_dietary_flags;
+DROP TABLE IF EXISTS recipe_dietary_overrides;
+DROP TABLE IF EXISTS ingredient_dietary_flags;
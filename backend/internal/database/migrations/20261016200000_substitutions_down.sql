@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS substitution_replacements;
+DROP TABLE IF EXISTS substitutions;
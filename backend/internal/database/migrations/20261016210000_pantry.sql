@@ -0,0 +1,25 @@
+-- Migration: 20261016210000_pantry
+-- Description: Pantry of owned ingredients, and staples that always count as owned
+
+CREATE TABLE IF NOT EXISTS pantry_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ingredient_id UUID NOT NULL UNIQUE REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 3) CHECK (quantity >= 0), -- Amount left; NULL when not tracked
+    unit VARCHAR(20), -- Canonical unit name; NULL for counted items
+    expires_on DATE, -- Last usable day
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pantry_items_expires_on ON pantry_items(expires_on);
+
+-- Ingredients that always count as owned, such as salt and water
+CREATE TABLE IF NOT EXISTS pantry_staples (
+    ingredient_id UUID PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO pantry_staples (ingredient_id)
+SELECT id FROM ingredients WHERE name IN ('salt', 'water')
+ON CONFLICT (ingredient_id) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Migration: 20261017000000_meal_plan_slots
+-- Description: Meal slots, planned servings and notes on meal plan entries
+
+-- slot is breakfast, lunch, dinner, snack or a custom name; existing entries become dinners.
+-- servings is how many the meal is planned for; NULL means the recipe's own servings.
+ALTER TABLE meal_plan_entries
+    ADD COLUMN IF NOT EXISTS slot VARCHAR(50) NOT NULL DEFAULT 'dinner' CHECK (slot <> ''),
+    ADD COLUMN IF NOT EXISTS servings INTEGER CHECK (servings > 0),
+    ADD COLUMN IF NOT EXISTS notes TEXT;
+
+-- A recipe can be planned once per meal, so the same soup can be lunch and dinner
+ALTER TABLE meal_plan_entries DROP CONSTRAINT IF EXISTS meal_plan_entries_recipe_id_date_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_recipe ON meal_plan_entries(date, slot, recipe_id);
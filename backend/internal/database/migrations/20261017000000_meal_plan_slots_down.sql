@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_meal_plan_entries_date_slot_recipe;
+
+-- Keep the first entry of a recipe on each date, so the old constraint can be restored
+DELETE FROM meal_plan_entries a
+    USING meal_plan_entries b
+    WHERE a.recipe_id = b.recipe_id AND a.date = b.date
+      AND (a.created_at, a.id) > (b.created_at, b.id);
+ALTER TABLE meal_plan_entries ADD CONSTRAINT meal_plan_entries_recipe_id_date_key UNIQUE (recipe_id, date);
+
+ALTER TABLE meal_plan_entries
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS servings,
+    DROP COLUMN IF EXISTS slot;
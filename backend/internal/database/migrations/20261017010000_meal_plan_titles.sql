@@ -0,0 +1,41 @@
+-- Migration: 20261017010000_meal_plan_titles
+-- Description: Free-text meal plan entries, such as "Leftovers", that don't reference a recipe
+
+ALTER TABLE meal_plan_entries ADD COLUMN IF NOT EXISTS title TEXT CHECK (title <> '');
+ALTER TABLE meal_plan_entries ALTER COLUMN recipe_id DROP NOT NULL;
+
+-- Databases set up from an older schema.sql keep recipe_id as TEXT without its foreign key,
+-- with the free text of custom entries in it. Move that text to title and restore the key.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'meal_plan_entries'
+        AND column_name = 'recipe_id'
+        AND data_type = 'text'
+    ) THEN
+        UPDATE meal_plan_entries SET title = recipe_id, recipe_id = NULL
+            WHERE recipe_id !~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$';
+        ALTER TABLE meal_plan_entries ALTER COLUMN recipe_id TYPE UUID USING recipe_id::uuid;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.table_constraints
+        WHERE constraint_name = 'meal_plan_entries_recipe_id_fkey'
+        AND table_name = 'meal_plan_entries'
+    ) THEN
+        -- Entries of recipes deleted while the key was missing have nothing left to show
+        DELETE FROM meal_plan_entries e
+            WHERE e.recipe_id IS NOT NULL
+            AND NOT EXISTS (SELECT 1 FROM recipes r WHERE r.id = e.recipe_id);
+        ALTER TABLE meal_plan_entries ADD CONSTRAINT meal_plan_entries_recipe_id_fkey
+            FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE;
+    END IF;
+END $$;
+
+-- An entry plans either a recipe or a free-text title
+ALTER TABLE meal_plan_entries ADD CONSTRAINT meal_plan_entries_recipe_or_title
+    CHECK ((recipe_id IS NULL) <> (title IS NULL));
+
+-- Like recipes, a title can be planned once per meal
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_title ON meal_plan_entries(date, slot, title) WHERE title IS NOT NULL;
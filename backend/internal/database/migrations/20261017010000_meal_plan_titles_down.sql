@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_meal_plan_entries_date_slot_title;
+ALTER TABLE meal_plan_entries DROP CONSTRAINT IF EXISTS meal_plan_entries_recipe_or_title;
+
+-- Free-text entries cannot be kept without a recipe
+DELETE FROM meal_plan_entries WHERE recipe_id IS NULL;
+ALTER TABLE meal_plan_entries ALTER COLUMN recipe_id SET NOT NULL;
+ALTER TABLE meal_plan_entries DROP COLUMN IF EXISTS title;
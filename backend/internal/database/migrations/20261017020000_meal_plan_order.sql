@@ -0,0 +1,12 @@
+-- Migration: 20261017020000_meal_plan_order
+-- Description: Order of meal plan entries within their meal, for moving them around the planner
+
+ALTER TABLE meal_plan_entries ADD COLUMN IF NOT EXISTS sort_order INTEGER NOT NULL DEFAULT 0;
+
+-- Existing entries keep the order they were planned in
+UPDATE meal_plan_entries SET sort_order = ordered.position
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY date, slot ORDER BY created_at, id) - 1 AS position
+        FROM meal_plan_entries
+    ) AS ordered
+    WHERE ordered.id = meal_plan_entries.id;
@@ -0,0 +1,338 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UpsertFoods adds foods of a food composition dataset, replacing the description and nutrients
+// of foods already loaded. Nutrition computed for recipes is discarded, as it may be based on
+// the old values. Returns the number of foods stored.
+func UpsertFoods(ctx context.Context, foods []models.Food) (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, upsertFoodQuery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare food upsert: %w", err)
+	}
+	defer stmt.Close()
+	for _, food := range foods {
+		if err := upsertFoodStmt(ctx, stmt, food); err != nil {
+			return 0, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM recipe_nutrition`); err != nil {
+		return 0, fmt.Errorf("failed to clear cached recipe nutrition: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction for foods: %w", err)
+	}
+	return len(foods), nil
+}
+
+const upsertFoodQuery = `INSERT INTO nutrients (id, description, source, calories, protein_g, fat_g, carbohydrate_g, fiber_g, sodium_mg, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (id) DO UPDATE SET description = EXCLUDED.description, source = EXCLUDED.source,
+		calories = EXCLUDED.calories, protein_g = EXCLUDED.protein_g, fat_g = EXCLUDED.fat_g,
+		carbohydrate_g = EXCLUDED.carbohydrate_g, fiber_g = EXCLUDED.fiber_g, sodium_mg = EXCLUDED.sodium_mg,
+		updated_at = EXCLUDED.updated_at`
+
+// upsertFoodStmt stores one food with a statement prepared from upsertFoodQuery.
+func upsertFoodStmt(ctx context.Context, stmt *sql.Stmt, food models.Food) error {
+	_, err := stmt.ExecContext(ctx, food.ID, food.Description, food.Source, food.Calories, food.ProteinG,
+		food.FatG, food.CarbohydrateG, food.FiberG, food.SodiumMg, timeOrNow(food.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to store food %s: %w", food.ID, err)
+	}
+	return nil
+}
+
+// foodColumns are the nutrients columns scanned by scanFood, for a table aliased as "n".
+const foodColumns = `n.id, n.description, n.source, n.calories, n.protein_g, n.fat_g, n.carbohydrate_g, n.fiber_g, n.sodium_mg, n.updated_at`
+
+// scanFood scans a row starting with foodColumns, followed by extra destinations.
+func scanFood(row interface{ Scan(...interface{}) error }, food *models.Food, extra ...interface{}) error {
+	dest := []interface{}{&food.ID, &food.Description, &food.Source, &food.Calories, &food.ProteinG,
+		&food.FatG, &food.CarbohydrateG, &food.FiberG, &food.SodiumMg, &food.UpdatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// SearchFoods finds foods whose description contains every word of the query, shorter
+// (more generic) descriptions first.
+func SearchFoods(ctx context.Context, query string, limit int) ([]models.Food, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	var patterns []string
+	for _, word := range strings.Fields(query) {
+		patterns = append(patterns, "%"+EscapeLike(word)+"%")
+	}
+	if len(patterns) == 0 {
+		return []models.Food{}, nil
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT `+foodColumns+` FROM nutrients n
+		WHERE n.description ILIKE ALL($1)
+		ORDER BY length(n.description), n.description
+		LIMIT $2`, pq.Array(patterns), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search foods: %w", err)
+	}
+	defer rows.Close()
+
+	foods := []models.Food{}
+	for rows.Next() {
+		var food models.Food
+		if err := scanFood(rows, &food); err != nil {
+			return nil, fmt.Errorf("failed to scan food: %w", err)
+		}
+		foods = append(foods, food)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating food rows: %w", err)
+	}
+	return foods, nil
+}
+
+// ingredientFoodSelect selects the columns scanned by scanIngredientFood.
+const ingredientFoodSelect = `SELECT ` + foodColumns + `, f.ingredient_id, f.grams_per_ml, f.grams_per_piece, f.updated_at
+	FROM ingredient_foods f
+	JOIN nutrients n ON n.id = f.food_id`
+
+// scanIngredientFood scans a row selected with ingredientFoodSelect.
+func scanIngredientFood(row interface{ Scan(...interface{}) error }) (models.IngredientFood, error) {
+	var mapping models.IngredientFood
+	var food models.Food
+	var gramsPerML, gramsPerPiece sql.NullFloat64
+	if err := scanFood(row, &food, &mapping.IngredientID, &gramsPerML, &gramsPerPiece, &mapping.UpdatedAt); err != nil {
+		return mapping, err
+	}
+	mapping.FoodID = food.ID
+	mapping.GramsPerML = nullFloatPtr(gramsPerML)
+	mapping.GramsPerPiece = nullFloatPtr(gramsPerPiece)
+	mapping.Food = &food
+	return mapping, nil
+}
+
+// queryIngredientFoods runs a query selecting ingredientFoodSelect rows.
+func queryIngredientFoods(ctx context.Context, query string, args ...interface{}) ([]models.IngredientFood, error) {
+	rows, err := DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredient foods: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []models.IngredientFood{}
+	for rows.Next() {
+		mapping, err := scanIngredientFood(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient food: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient food rows: %w", err)
+	}
+	return mappings, nil
+}
+
+// GetIngredientFoods fetches the food mappings of the given ingredients, with their food.
+// Ingredients without a mapping are left out.
+func GetIngredientFoods(ctx context.Context, ingredientIDs []string) ([]models.IngredientFood, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if len(ingredientIDs) == 0 {
+		return []models.IngredientFood{}, nil
+	}
+	return queryIngredientFoods(ctx, ingredientFoodSelect+` WHERE f.ingredient_id = ANY($1::uuid[])`, pq.Array(ingredientIDs))
+}
+
+// GetAllIngredientFoods fetches every food mapping with its food, for export.
+func GetAllIngredientFoods(ctx context.Context) ([]models.IngredientFood, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return queryIngredientFoods(ctx, ingredientFoodSelect+` ORDER BY f.ingredient_id`)
+}
+
+// SetIngredientFood maps an ingredient to a food, replacing its previous mapping, and
+// discards the cached nutrition of the recipes using the ingredient.
+func SetIngredientFood(ctx context.Context, mapping models.IngredientFood) (*models.IngredientFood, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = $1)`, mapping.IngredientID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", mapping.IngredientID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingredient with ID %s not found", mapping.IngredientID)
+	}
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM nutrients WHERE id = $1)`, mapping.FoodID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query food with ID %s: %w", mapping.FoodID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("food with ID %s not found", mapping.FoodID)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_foods (ingredient_id, food_id, grams_per_ml, grams_per_piece, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (ingredient_id) DO UPDATE SET food_id = EXCLUDED.food_id, grams_per_ml = EXCLUDED.grams_per_ml,
+			grams_per_piece = EXCLUDED.grams_per_piece, updated_at = EXCLUDED.updated_at`,
+		mapping.IngredientID, mapping.FoodID, mapping.GramsPerML, mapping.GramsPerPiece, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map ingredient %s to food %s: %w", mapping.IngredientID, mapping.FoodID, err)
+	}
+	if err = clearIngredientNutritionTx(ctx, tx, mapping.IngredientID); err != nil {
+		return nil, err
+	}
+
+	saved, err := scanIngredientFood(tx.QueryRowContext(ctx, ingredientFoodSelect+` WHERE f.ingredient_id = $1`, mapping.IngredientID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query food of ingredient %s: %w", mapping.IngredientID, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient food: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteIngredientFood removes an ingredient's food mapping and discards the cached
+// nutrition of the recipes using the ingredient.
+func DeleteIngredientFood(ctx context.Context, ingredientID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM ingredient_foods WHERE ingredient_id = $1`, ingredientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete food of ingredient %s: %w", ingredientID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("food of ingredient %s not found", ingredientID)
+	}
+	if err = clearIngredientNutritionTx(ctx, tx, ingredientID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for ingredient food: %w", err)
+	}
+	return nil
+}
+
+// clearIngredientNutritionTx discards the cached nutrition of the recipes using an ingredient.
+// Operates within a transaction.
+func clearIngredientNutritionTx(ctx context.Context, tx *sql.Tx, ingredientID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM recipe_nutrition
+		WHERE recipe_id IN (SELECT recipe_id FROM recipe_ingredients WHERE ingredient_id = $1)`, ingredientID)
+	if err != nil {
+		return fmt.Errorf("failed to clear cached nutrition of recipes using ingredient %s: %w", ingredientID, err)
+	}
+	return nil
+}
+
+// GetRecipeNutrition fetches the nutrition last computed for a recipe, or nil if there is none.
+// Callers compare its RecipeUpdatedAt with the recipe's to tell whether it is still current.
+func GetRecipeNutrition(ctx context.Context, recipeID string) (*models.RecipeNutrition, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var data []byte
+	var recipeUpdatedAt time.Time
+	err := DB.QueryRowContext(ctx, `SELECT nutrition, recipe_updated_at FROM recipe_nutrition WHERE recipe_id = $1`, recipeID).Scan(&data, &recipeUpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nutrition of recipe %s: %w", recipeID, err)
+	}
+	var nutrition models.RecipeNutrition
+	if err := json.Unmarshal(data, &nutrition); err != nil {
+		return nil, fmt.Errorf("failed to decode nutrition of recipe %s: %w", recipeID, err)
+	}
+	nutrition.RecipeUpdatedAt = recipeUpdatedAt
+	return &nutrition, nil
+}
+
+// SaveRecipeNutrition caches the nutrition computed for a recipe, replacing any earlier result.
+func SaveRecipeNutrition(ctx context.Context, nutrition models.RecipeNutrition) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	data, err := json.Marshal(nutrition)
+	if err != nil {
+		return fmt.Errorf("failed to encode nutrition of recipe %s: %w", nutrition.RecipeID, err)
+	}
+	_, err = DB.ExecContext(ctx, `INSERT INTO recipe_nutrition (recipe_id, recipe_updated_at, nutrition, computed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (recipe_id) DO UPDATE SET recipe_updated_at = EXCLUDED.recipe_updated_at,
+			nutrition = EXCLUDED.nutrition, computed_at = EXCLUDED.computed_at`,
+		nutrition.RecipeID, nutrition.RecipeUpdatedAt, string(data), timeOrNow(nutrition.ComputedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save nutrition of recipe %s: %w", nutrition.RecipeID, err)
+	}
+	return nil
+}
+
+// insertImportedIngredientFoodTx adds a food mapping from an import file to its imported
+// ingredient, together with the food it names. Foods already loaded keep their values and
+// an ingredient that already has a mapping keeps it. Operates within a transaction.
+func insertImportedIngredientFoodTx(ctx context.Context, tx *sql.Tx, mapping models.IngredientFood, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[mapping.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", mapping.IngredientID)
+	}
+	if mapping.Food != nil {
+		food := *mapping.Food
+		_, err := tx.ExecContext(ctx, `INSERT INTO nutrients (id, description, source, calories, protein_g, fat_g, carbohydrate_g, fiber_g, sodium_mg, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (id) DO NOTHING`,
+			mapping.FoodID, food.Description, food.Source, food.Calories, food.ProteinG,
+			food.FatG, food.CarbohydrateG, food.FiberG, food.SodiumMg, timeOrNow(food.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("failed to insert food %s: %w", mapping.FoodID, err)
+		}
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO ingredient_foods (ingredient_id, food_id, grams_per_ml, grams_per_piece, updated_at)
+		SELECT $1::uuid, $2::varchar, $3::numeric, $4::numeric, $5::timestamptz
+		WHERE EXISTS (SELECT 1 FROM nutrients WHERE id = $2)
+		ON CONFLICT (ingredient_id) DO NOTHING`,
+		dbIngredientID, mapping.FoodID, mapping.GramsPerML, mapping.GramsPerPiece, timeOrNow(mapping.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to map ingredient DB ID %s to food %s: %w", dbIngredientID, mapping.FoodID, err)
+	}
+	return nil
+}
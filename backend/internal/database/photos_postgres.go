@@ -0,0 +1,348 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const photoSelectColumns = `id, recipe_id, filename, COALESCE(caption, ''), sort_order, is_cover, created_at`
+
+// scanPhoto scans a row selected with photoSelectColumns.
+func scanPhoto(row interface{ Scan(...interface{}) error }) (models.RecipePhoto, error) {
+	var photo models.RecipePhoto
+	err := row.Scan(&photo.ID, &photo.RecipeID, &photo.Filename, &photo.Caption, &photo.SortOrder, &photo.IsCover, &photo.CreatedAt)
+	return photo, err
+}
+
+// queryPhotos runs a query selecting photoSelectColumns and collects the rows.
+func queryPhotos(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]models.RecipePhoto, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipe photos: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []models.RecipePhoto
+	for rows.Next() {
+		photo, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recipe photo row: %w", err)
+		}
+		photos = append(photos, photo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe photo rows: %w", err)
+	}
+	return photos, nil
+}
+
+// lockLiveRecipeTx locks a recipe that is not in the trash, so concurrent photo changes
+// to the same recipe are serialized. Operates within a transaction.
+func lockLiveRecipeTx(ctx context.Context, tx *sql.Tx, recipeID string) error {
+	var id string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM recipes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, recipeID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("recipe with ID %s not found", recipeID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock recipe ID %s: %w", recipeID, err)
+	}
+	return nil
+}
+
+// GetRecipePhotos retrieves a recipe's photos in display order.
+func GetRecipePhotos(ctx context.Context, recipeID string) ([]models.RecipePhoto, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return queryPhotos(ctx, DB, `SELECT `+photoSelectColumns+` FROM recipe_photos
+		WHERE recipe_id = $1
+		ORDER BY sort_order ASC, created_at ASC`, recipeID)
+}
+
+// GetAllRecipePhotos fetches every recipe photo, for export.
+func GetAllRecipePhotos(ctx context.Context) ([]models.RecipePhoto, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return queryPhotos(ctx, DB, `SELECT `+photoSelectColumns+` FROM recipe_photos
+		ORDER BY recipe_id ASC, sort_order ASC, created_at ASC`)
+}
+
+// AddRecipePhoto appends a photo to the end of a recipe's gallery. It becomes the cover
+// if photo.IsCover is set or the recipe has no cover yet.
+func AddRecipePhoto(ctx context.Context, photo models.RecipePhoto) (*models.RecipePhoto, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockLiveRecipeTx(ctx, tx, photo.RecipeID); err != nil {
+		return nil, err
+	}
+
+	var hasCover bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM recipe_photos WHERE recipe_id = $1 AND is_cover)`, photo.RecipeID).Scan(&hasCover); err != nil {
+		return nil, fmt.Errorf("failed to check cover photo for recipe ID %s: %w", photo.RecipeID, err)
+	}
+
+	if photo.ID == "" {
+		photo.ID = uuid.NewString()
+	}
+	photo.CreatedAt = time.Now().UTC()
+	query := `INSERT INTO recipe_photos (id, recipe_id, filename, caption, sort_order, is_cover, created_at)
+		VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(sort_order), -1) + 1 FROM recipe_photos WHERE recipe_id = $2), FALSE, $5)
+		RETURNING sort_order`
+	err = tx.QueryRowContext(ctx, query, photo.ID, photo.RecipeID, photo.Filename, nullIfEmpty(photo.Caption), photo.CreatedAt).Scan(&photo.SortOrder)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("photo '%s' already exists for recipe ID %s", photo.Filename, photo.RecipeID)
+		}
+		return nil, fmt.Errorf("failed to insert photo for recipe ID %s: %w", photo.RecipeID, err)
+	}
+
+	photo.IsCover = photo.IsCover || !hasCover
+	if photo.IsCover {
+		if err := setCoverPhotoTx(ctx, tx, photo.RecipeID, photo.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for photo upload: %w", err)
+	}
+	return &photo, nil
+}
+
+// UpdateRecipePhoto changes a photo's caption (when caption is non-nil) and, if makeCover
+// is set, makes it the recipe's cover. Covers can only be replaced, not unset, here.
+func UpdateRecipePhoto(ctx context.Context, recipeID string, photoID string, caption *string, makeCover bool) (*models.RecipePhoto, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockLiveRecipeTx(ctx, tx, recipeID); err != nil {
+		return nil, err
+	}
+
+	photo, err := scanPhoto(tx.QueryRowContext(ctx, `SELECT `+photoSelectColumns+` FROM recipe_photos WHERE id = $1 AND recipe_id = $2`, photoID, recipeID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("photo with ID %s not found for recipe ID %s", photoID, recipeID)
+		}
+		return nil, fmt.Errorf("failed to query photo with ID %s: %w", photoID, err)
+	}
+
+	if caption != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET caption = $1 WHERE id = $2`, nullIfEmpty(*caption), photoID); err != nil {
+			return nil, fmt.Errorf("failed to update caption of photo ID %s: %w", photoID, err)
+		}
+		photo.Caption = *caption
+	}
+	if makeCover && !photo.IsCover {
+		if err := setCoverPhotoTx(ctx, tx, recipeID, photoID); err != nil {
+			return nil, err
+		}
+		photo.IsCover = true
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for photo update: %w", err)
+	}
+	return &photo, nil
+}
+
+// ReorderRecipePhotos sets the display order of a recipe's photos. photoIDs must list
+// every photo of the recipe exactly once, first to last.
+func ReorderRecipePhotos(ctx context.Context, recipeID string, photoIDs []string) ([]models.RecipePhoto, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockLiveRecipeTx(ctx, tx, recipeID); err != nil {
+		return nil, err
+	}
+
+	current, err := queryPhotos(ctx, tx, `SELECT `+photoSelectColumns+` FROM recipe_photos WHERE recipe_id = $1`, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[string]bool, len(current))
+	for _, photo := range current {
+		remaining[photo.ID] = true
+	}
+	if len(photoIDs) != len(current) {
+		return nil, fmt.Errorf("photo order for recipe ID %s must list each of its %d photos exactly once", recipeID, len(current))
+	}
+	for i, photoID := range photoIDs {
+		if !remaining[photoID] {
+			return nil, fmt.Errorf("photo order for recipe ID %s must list each of its %d photos exactly once", recipeID, len(current))
+		}
+		delete(remaining, photoID)
+		if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET sort_order = $1 WHERE id = $2`, i, photoID); err != nil {
+			return nil, fmt.Errorf("failed to reorder photo ID %s: %w", photoID, err)
+		}
+	}
+
+	photos, err := queryPhotos(ctx, tx, `SELECT `+photoSelectColumns+` FROM recipe_photos
+		WHERE recipe_id = $1
+		ORDER BY sort_order ASC, created_at ASC`, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for photo reorder: %w", err)
+	}
+	return photos, nil
+}
+
+// DeleteRecipePhoto removes a photo from a recipe's gallery and returns its filename, so the
+// caller can remove the file. If it was the cover, the next photo in order becomes the cover,
+// or the recipe falls back to the placeholder when none is left.
+func DeleteRecipePhoto(ctx context.Context, recipeID string, photoID string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockLiveRecipeTx(ctx, tx, recipeID); err != nil {
+		return "", err
+	}
+
+	var filename string
+	var wasCover bool
+	err = tx.QueryRowContext(ctx, `DELETE FROM recipe_photos WHERE id = $1 AND recipe_id = $2 RETURNING filename, is_cover`, photoID, recipeID).Scan(&filename, &wasCover)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("photo with ID %s not found for recipe ID %s", photoID, recipeID)
+		}
+		return "", fmt.Errorf("failed to delete photo with ID %s: %w", photoID, err)
+	}
+
+	if wasCover {
+		var nextID string
+		err = tx.QueryRowContext(ctx, `SELECT id FROM recipe_photos WHERE recipe_id = $1 ORDER BY sort_order ASC, created_at ASC LIMIT 1`, recipeID).Scan(&nextID)
+		switch {
+		case err == sql.ErrNoRows:
+			if err := setRecipeCoverFilenameTx(ctx, tx, recipeID, models.PlaceholderPhotoFilename); err != nil {
+				return "", err
+			}
+		case err != nil:
+			return "", fmt.Errorf("failed to find next cover photo for recipe ID %s: %w", recipeID, err)
+		default:
+			if err := setCoverPhotoTx(ctx, tx, recipeID, nextID); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction for photo deletion: %w", err)
+	}
+	return filename, nil
+}
+
+// setCoverPhotoTx makes a photo the recipe's cover and mirrors its filename to
+// recipes.photo_filename. Operates within a transaction.
+func setCoverPhotoTx(ctx context.Context, tx *sql.Tx, recipeID string, photoID string) error {
+	// Clear the old cover first; the one-cover index is checked row by row.
+	if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET is_cover = FALSE WHERE recipe_id = $1 AND is_cover AND id <> $2`, recipeID, photoID); err != nil {
+		return fmt.Errorf("failed to clear cover photo for recipe ID %s: %w", recipeID, err)
+	}
+	var filename string
+	err := tx.QueryRowContext(ctx, `UPDATE recipe_photos SET is_cover = TRUE WHERE id = $1 AND recipe_id = $2 RETURNING filename`, photoID, recipeID).Scan(&filename)
+	if err != nil {
+		return fmt.Errorf("failed to set cover photo ID %s for recipe ID %s: %w", photoID, recipeID, err)
+	}
+	return setRecipeCoverFilenameTx(ctx, tx, recipeID, filename)
+}
+
+// setRecipeCoverFilenameTx stores a new cover filename on the recipe and records the change
+// as a revision, since the photo is part of the recipe's history. Operates within a transaction.
+func setRecipeCoverFilenameTx(ctx context.Context, tx *sql.Tx, recipeID string, filename string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE recipes SET photo_filename = $1, updated_at = $2 WHERE id = $3`, filename, time.Now().UTC(), recipeID)
+	if err != nil {
+		return fmt.Errorf("failed to update cover photo of recipe ID %s: %w", recipeID, err)
+	}
+	return insertRecipeRevisionTx(ctx, tx, recipeID, "")
+}
+
+// syncCoverPhotoTx keeps the gallery in step with a photo_filename written directly to the
+// recipe (create, update, restore, import): the file is added to the gallery if needed and
+// flagged as the cover. The placeholder leaves the recipe without a cover. Operates within a transaction.
+func syncCoverPhotoTx(ctx context.Context, tx *sql.Tx, recipeID string, filename string) error {
+	if filename == "" || filename == models.PlaceholderPhotoFilename {
+		if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET is_cover = FALSE WHERE recipe_id = $1 AND is_cover`, recipeID); err != nil {
+			return fmt.Errorf("failed to clear cover photo for recipe ID %s: %w", recipeID, err)
+		}
+		return nil
+	}
+
+	insertQuery := `INSERT INTO recipe_photos (id, recipe_id, filename, sort_order, is_cover, created_at)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(sort_order), -1) + 1 FROM recipe_photos WHERE recipe_id = $2), FALSE, $4)
+		ON CONFLICT (recipe_id, filename) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, insertQuery, uuid.NewString(), recipeID, filename, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to add photo '%s' to recipe ID %s: %w", filename, recipeID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET is_cover = FALSE WHERE recipe_id = $1 AND is_cover AND filename <> $2`, recipeID, filename); err != nil {
+		return fmt.Errorf("failed to clear cover photo for recipe ID %s: %w", recipeID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE recipe_photos SET is_cover = TRUE WHERE recipe_id = $1 AND filename = $2`, recipeID, filename); err != nil {
+		return fmt.Errorf("failed to set cover photo '%s' for recipe ID %s: %w", filename, recipeID, err)
+	}
+	return nil
+}
+
+// insertRecipePhotoTx adds an imported photo to an imported recipe, resolving the recipe ID
+// from the import file to its database ID and keeping the photo's own ID where it is free.
+// Photos the recipe already has are left untouched;
+// the cover is set afterwards from the recipe's photo_filename.
+func insertRecipePhotoTx(ctx context.Context, tx *sql.Tx, photo models.RecipePhoto, recipeOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, ok := recipeOriginalIDToDbIDMap[photo.RecipeID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", photo.RecipeID)
+	}
+	photoID, err := importedIDTx(ctx, tx, "recipe_photos", photo.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO recipe_photos (id, recipe_id, filename, caption, sort_order, is_cover, created_at)
+		VALUES ($1, $2, $3, $4, $5, FALSE, $6)
+		ON CONFLICT (recipe_id, filename) DO NOTHING`,
+		photoID, dbRecipeID, photo.Filename, nullIfEmpty(photo.Caption), photo.SortOrder, timeOrNow(photo.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert photo '%s' for recipe DB ID %s: %w", photo.Filename, dbRecipeID, err)
+	}
+	return nil
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"io/ioutil" // For ReadFile, or use os.ReadFile in Go 1.16+
 	"log"
-	"os" // Required for os.ReadFile if using Go 1.16+ and os.Getenv
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
@@ -47,16 +45,17 @@ func InitPostgreSQLDB(connectionString string) error {
 
 	log.Println("PostgreSQL database connected successfully.")
 
-	// Run migrations
-	migrationsPath := "./internal/database/migrations/"
-	if err := executeSQLFile(DB, migrationsPath+"001_initial_schema.sql", "initial schema"); err != nil {
-		log.Printf("Could not apply initial schema migration: %v", err)
-		// Depending on the desired behavior, you might want to return this error
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		DB.Close()
+		return err
 	}
-	if err := executeSQLFile(DB, migrationsPath+"20250613162217_create_comments_table.sql", "comments table migration"); err != nil {
-		log.Printf("Could not apply comments table migration: %v", err)
-		// Depending on the desired behavior, you might want to return this error
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		DB.Close()
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
+	log.Printf("Database schema is up to date (%d migrations applied now).", len(applied))
 
 	return nil
 }
@@ -72,44 +71,3 @@ func ClosePostgreSQLDB() {
 		}
 	}
 }
-
-// executeSQLFile reads and executes SQL statements from a given file path.
-func executeSQLFile(db *sql.DB, filePath string, stepName string) error {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Printf("%s file (%s) not found. Skipping this step.", stepName, filePath)
-		return nil // Not an error if file doesn't exist, just skip
-	}
-
-	log.Printf("Executing %s from %s...", stepName, filePath)
-
-	content, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		log.Printf("Warning: Failed to read %s file %s: %v. Continuing.", stepName, filePath, err)
-		return err // Return error to stop further processing if critical, or nil to allow continuation
-	}
-
-	// Begin a transaction for this file execution
-	tx, err := db.Begin()
-	if err != nil {
-		log.Printf("Warning: Failed to begin transaction for %s: %v. Continuing.", stepName, err)
-		return err
-	}
-
-	// Execute the entire file content as a single block of SQL.
-	// The pq driver supports multi-statement execution.
-	if _, err := tx.Exec(string(content)); err != nil {
-		tx.Rollback() // Rollback transaction on error
-		log.Printf("Warning: Failed to execute %s: error executing SQL block: %v.", stepName, err)
-		// log.Printf("Failed SQL block during %s: %s", stepName, string(content)) // Optional: log the failing SQL block
-		return err
-	}
-
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		log.Printf("Warning: Failed to commit %s transaction: %v.", stepName, err)
-		return err
-	}
-
-	log.Printf("%s executed successfully from %s.", stepName, filePath)
-	return nil
-}
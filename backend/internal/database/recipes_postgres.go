@@ -7,6 +7,7 @@ import (
 	// "errors" // For errors.As - No longer needed after ON CONFLICT DO NOTHING
 	"fmt"
 	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
 	"log"
 	"strings"
 	"time"
@@ -15,53 +16,106 @@ import (
 	"github.com/lib/pq" // For pq.Array
 )
 
-// extractIngredientNameParts is a placeholder for a utility function
-// that will parse an ingredient string (e.g., "1 cup flour") into its quantity ("1 cup")
-// and normalized name ("flour"). This will be properly implemented later.
-func extractIngredientNameParts(fullIngredient string) (quantity string, name string, err error) {
-	// More robustly separate quantity from the name.
-	// This is a simplified approach. A more advanced solution could use regex
-	// to identify numeric quantities, units, etc.
-	// For now, we'll assume the first word might be a quantity if it's numeric,
-	// otherwise the whole string is the name.
-
-	trimmedIngredient := strings.TrimSpace(fullIngredient)
-	parts := strings.SplitN(trimmedIngredient, " ", 2)
-
-	if len(parts) == 1 {
-		// If there's only one part, it's the ingredient name.
-		return "", strings.ToLower(trimmedIngredient), nil
-	}
-
-	// Check if the first part is likely a quantity (e.g., numeric, fraction, or common measure word)
-	// This is a basic check. A library or more complex regex would be better.
-	// For this fix, we'll just treat the whole string as the name part and quantity as empty,
-	// as the primary goal is to stop "tomato sauce" from becoming "sauce".
-	// The UI sends the full string, and we want to store it as such.
-	// The `quantity_text` was intended for things like "1 cup", where "1 cup" is the quantity
-	// and "flour" is the name. The current implementation is too naive for that.
-
-	// A better simple logic: if the first part contains a number, it's a quantity.
-	// For now, let's just treat the whole thing as the name and not split.
-	// This means quantity will be empty, but the ingredient name will be correct.
-	return "", strings.ToLower(trimmedIngredient), nil
+// nullIfEmpty converts an empty string into a SQL NULL.
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
-// normalizeIngredientName is a placeholder for a utility function
-// to normalize an ingredient name for consistent storage and searching.
-func normalizeIngredientName(name string) string {
-	return strings.ToLower(strings.TrimSpace(name))
+// nullIfZero converts a zero integer (meaning "unknown") into a SQL NULL.
+func nullIfZero(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullFloatPtr converts a scanned nullable numeric column into a *float64.
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
+
+// getOrCreateIngredientByNameTx returns the ID of the ingredient with the given
+// canonical name, or with an alias matching it, creating it if neither exists yet.
+// Operates within a transaction.
+func getOrCreateIngredientByNameTx(ctx context.Context, tx *sql.Tx, name string) (string, error) {
+	var ingredientID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM (
+			SELECT id, 0 AS rank FROM ingredients WHERE name = $1
+			UNION ALL
+			SELECT ingredient_id, 1 FROM ingredient_aliases WHERE normalized_name = normalize_ingredient_name($1)
+		) matches
+		ORDER BY rank
+		LIMIT 1`, name).Scan(&ingredientID)
+	if err == sql.ErrNoRows {
+		ingredientID = uuid.NewString()
+		now := time.Now().UTC()
+		insertIngredientQuery := `INSERT INTO ingredients (id, name, created_at, updated_at)
+			VALUES ($1, $2, $3, $4)`
+		if _, err = tx.ExecContext(ctx, insertIngredientQuery, ingredientID, name, now, now); err != nil {
+			return "", fmt.Errorf("failed to insert new ingredient '%s': %w", name, err)
+		}
+		return ingredientID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query ingredient '%s': %w", name, err)
+	}
+	return ingredientID, nil
+}
+
+// linkRecipeIngredientsTx parses each ingredient line of a recipe and stores the
+// structured result in recipe_ingredients, creating missing ingredients on the way.
+// Every line is kept, so a recipe can name an ingredient more than once
+// (e.g. "2 tbsp butter" and "1 tbsp butter, for frying").
+func linkRecipeIngredientsTx(ctx context.Context, tx *sql.Tx, recipeID string, lines []string) error {
+	for i, line := range lines {
+		parsed := parser.ParseIngredient(line)
+		if parsed.Name == "" {
+			continue
+		}
+
+		ingredientID, err := getOrCreateIngredientByNameTx(ctx, tx, parsed.Name)
+		if err != nil {
+			return err
+		}
+
+		insertRecipeIngredientQuery := `INSERT INTO recipe_ingredients
+			(id, recipe_id, ingredient_id, original_text, quantity_text, quantity, quantity_max, unit, preparation, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+		_, err = tx.ExecContext(ctx, insertRecipeIngredientQuery,
+			uuid.NewString(), recipeID, ingredientID, parsed.Original, parsed.QuantityText,
+			parsed.Quantity, parsed.QuantityMax, nullIfEmpty(parsed.Unit), nullIfEmpty(parsed.Preparation), i)
+		if err != nil {
+			return fmt.Errorf("failed to insert recipe_ingredient link for recipe ID %s and ingredient ID %s: %w", recipeID, ingredientID, err)
+		}
+	}
+	return nil
+}
+
+// recipeSelectColumns lists the recipes columns read into models.Recipe, in the
+// order expected by recipeScanDest. Queries must alias the recipes table as "r".
+const recipeSelectColumns = `r.id, r.name, r.method, COALESCE(r.servings, 0), COALESCE(r.yield, ''),
+	COALESCE(r.prep_time_minutes, 0), COALESCE(r.cook_time_minutes, 0), COALESCE(r.rest_time_minutes, 0),
+	COALESCE(r.total_time_minutes, 0), COALESCE(r.photo_filename, ''), r.created_at, r.updated_at, r.deleted_at`
+
+// recipeScanDest returns the scan destinations matching recipeSelectColumns.
+func recipeScanDest(r *models.Recipe) []interface{} {
+	return []interface{}{
+		&r.ID, &r.Name, &r.Method, &r.Servings, &r.Yield,
+		&r.PrepTimeMinutes, &r.CookTimeMinutes, &r.RestTimeMinutes,
+		&r.TotalTimeMinutes, &r.PhotoFilename, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt,
+	}
 }
 
 // RecipeExistsByID checks if a recipe with the given ID exists in the PostgreSQL database.
-func RecipeExistsByID(id string) (bool, error) {
+// Recipes in the trash do not count.
+func RecipeExistsByID(ctx context.Context, id string) (bool, error) {
 	if DB == nil {
 		return false, fmt.Errorf("database not initialized")
 	}
 
 	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM recipes WHERE id = $1)"
-	err := DB.QueryRow(query, id).Scan(&exists)
+	query := "SELECT EXISTS(SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)"
+	err := DB.QueryRowContext(ctx, query, id).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("error checking recipe existence for ID %s: %w", id, err)
 	}
@@ -69,21 +123,19 @@ func RecipeExistsByID(id string) (bool, error) {
 }
 
 // GetRecipeByID retrieves a single recipe by its ID from PostgreSQL,
-// including its ingredients.
-func GetRecipeByID(id string) (*models.Recipe, error) {
+// including its ingredients. Recipes in the trash are treated as not found.
+func GetRecipeByID(ctx context.Context, id string) (*models.Recipe, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
 	var recipe models.Recipe
 	recipeQuery := `
-		SELECT r.id, r.name, r.method, r.photo_filename, r.created_at, r.updated_at
+		SELECT ` + recipeSelectColumns + `
 		FROM recipes r
-		WHERE r.id = $1`
+		WHERE r.id = $1 AND r.deleted_at IS NULL`
 
-	err := DB.QueryRow(recipeQuery, id).Scan(
-		&recipe.ID, &recipe.Name, &recipe.Method, &recipe.PhotoFilename, &recipe.CreatedAt, &recipe.UpdatedAt,
-	)
+	err := DB.QueryRowContext(ctx, recipeQuery, id).Scan(recipeScanDest(&recipe)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Or a specific "not found" error
@@ -93,47 +145,66 @@ func GetRecipeByID(id string) (*models.Recipe, error) {
 
 	// Fetch ingredients for the recipe
 	ingredientsQuery := `
-		SELECT ri.quantity_text, i.name
+		SELECT ri.ingredient_id, COALESCE(ri.original_text, ''), COALESCE(ri.quantity_text, ''),
+			ri.quantity, ri.quantity_max, COALESCE(ri.unit, ''), COALESCE(ri.preparation, ''), i.name
 		FROM recipe_ingredients ri
 		JOIN ingredients i ON ri.ingredient_id = i.id
 		WHERE ri.recipe_id = $1
 		ORDER BY ri.sort_order ASC`
 
-	rows, err := DB.Query(ingredientsQuery, id)
+	rows, err := DB.QueryContext(ctx, ingredientsQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching ingredients for recipe ID %s: %w", id, err)
 	}
 	defer rows.Close()
 
 	var ingredients []string
+	var structured []models.StructuredIngredient
 	for rows.Next() {
-		var quantityText, ingredientName string
-		if err := rows.Scan(&quantityText, &ingredientName); err != nil {
+		var si models.StructuredIngredient
+		var quantity, quantityMax sql.NullFloat64
+		if err := rows.Scan(&si.IngredientID, &si.Original, &si.QuantityText, &quantity, &quantityMax,
+			&si.Unit, &si.Preparation, &si.Name); err != nil {
 			return nil, fmt.Errorf("error scanning ingredient for recipe ID %s: %w", id, err)
 		}
-		if quantityText != "" {
-			ingredients = append(ingredients, fmt.Sprintf("%s %s", quantityText, ingredientName))
-		} else {
-			ingredients = append(ingredients, ingredientName) // Handle cases where quantity might be empty
+		si.Quantity = nullFloatPtr(quantity)
+		si.QuantityMax = nullFloatPtr(quantityMax)
+		if si.Original == "" {
+			// Rows linked before the original line was stored
+			si.Original = strings.TrimSpace(si.QuantityText + " " + si.Name)
 		}
+		ingredients = append(ingredients, si.Original)
+		structured = append(structured, si)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating ingredients for recipe ID %s: %w", id, err)
 	}
 
 	recipe.Ingredients = ingredients
+	recipe.StructuredIngredients = structured
+
+	recipe.Tags, err = getRecipeTagNames(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe.Photos, err = GetRecipePhotos(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
 	return &recipe, nil
 }
 
 // CreateRecipe adds a new recipe to the PostgreSQL database.
-// It handles creating the recipe, ingredients, and their associations.
-func CreateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
+// It handles creating the recipe, ingredients, and their associations,
+// and records the result as revision 1, attributed to editor (may be empty).
+func CreateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -147,47 +218,35 @@ func CreateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 	recipe.UpdatedAt = recipe.CreatedAt
 
 	// Insert into recipes table
-	recipeQuery := `INSERT INTO recipes (id, name, method, photo_filename, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err = tx.Exec(recipeQuery, recipe.ID, recipe.Name, recipe.Method, recipe.PhotoFilename, recipe.CreatedAt, recipe.UpdatedAt)
+	recipe.ComputeTotalTime()
+	recipeQuery := `INSERT INTO recipes (id, name, method, servings, yield, prep_time_minutes, cook_time_minutes, rest_time_minutes,
+			photo_filename, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err = tx.ExecContext(ctx, recipeQuery, recipe.ID, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+		nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+		recipe.PhotoFilename, recipe.CreatedAt, recipe.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert recipe ID %s: %w", recipe.ID, err)
 	}
 
-	// Process and insert ingredients
-	for i, fullIngredientStr := range recipe.Ingredients {
-		quantityText, ingredientNamePart, err := extractIngredientNameParts(fullIngredientStr)
-		if err != nil {
-			log.Printf("Error parsing ingredient string '%s': %v. Skipping.", fullIngredientStr, err)
-			// Depending on desired behavior, you might want to return an error here
-			continue
-		}
-		normalizedIngredientName := normalizeIngredientName(ingredientNamePart)
+	// Parse and link ingredients
+	if err := linkRecipeIngredientsTx(ctx, tx, recipe.ID, recipe.Ingredients); err != nil {
+		return nil, err
+	}
 
-		var ingredientID string
-		// Check if ingredient exists, otherwise create it
-		ingredientQuery := `SELECT id FROM ingredients WHERE name = $1`
-		err = tx.QueryRow(ingredientQuery, normalizedIngredientName).Scan(&ingredientID)
-		if err == sql.ErrNoRows {
-			ingredientID = uuid.NewString()
-			insertIngredientQuery := `INSERT INTO ingredients (id, name, created_at, updated_at)
-				VALUES ($1, $2, $3, $4)`
-			_, err = tx.Exec(insertIngredientQuery, ingredientID, normalizedIngredientName, time.Now().UTC(), time.Now().UTC())
-			if err != nil {
-				return nil, fmt.Errorf("failed to insert new ingredient '%s': %w", normalizedIngredientName, err)
-			}
-		} else if err != nil {
-			return nil, fmt.Errorf("failed to query ingredient '%s': %w", normalizedIngredientName, err)
-		}
+	// Attach tags, creating any that don't exist yet
+	recipe.Tags = normalizeTagNames(recipe.Tags)
+	if err := setRecipeTagsTx(ctx, tx, recipe.ID, recipe.Tags); err != nil {
+		return nil, err
+	}
 
-		// Insert into recipe_ingredients junction table
-		recipeIngredientID := uuid.NewString()
-		insertRecipeIngredientQuery := `INSERT INTO recipe_ingredients (id, recipe_id, ingredient_id, quantity_text, sort_order)
-			VALUES ($1, $2, $3, $4, $5)`
-		_, err = tx.Exec(insertRecipeIngredientQuery, recipeIngredientID, recipe.ID, ingredientID, quantityText, i)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert recipe_ingredient link for recipe ID %s and ingredient ID %s: %w", recipe.ID, ingredientID, err)
-		}
+	// The uploaded photo starts the gallery as its cover
+	if err := syncCoverPhotoTx(ctx, tx, recipe.ID, recipe.PhotoFilename); err != nil {
+		return nil, err
+	}
+
+	if err := insertRecipeRevisionTx(ctx, tx, recipe.ID, editor); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -197,8 +256,30 @@ func CreateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 	return recipe, nil
 }
 
-// GetAllRecipes retrieves recipes with optional search, ingredient filtering, and pagination.
-func GetAllRecipes(searchTerm string, ingredientFilters []string, page int, pageSize int) ([]models.Recipe, int, error) {
+// RecipeFilter holds the optional criteria accepted by GetAllRecipes.
+// Zero values mean "no filter".
+type RecipeFilter struct {
+	SearchTerm         string   // Full-text search on recipe name and method
+	IngredientFilters  []string // Every term must match one of the recipe's ingredients
+	IncludeDescendants bool     // Ingredient filters also match ingredients below a matching one: "cheese" finds cheddar
+	MaxTotalTime       int      // Only recipes whose total time is known and at most this many minutes
+	Servings           int      // Only recipes that serve exactly this many people
+	TagsAll            []string // Recipe must carry every one of these tags (AND)
+	TagsAny            []string // Recipe must carry at least one of these tags (OR)
+	TagsNone           []string // Recipe must carry none of these tags (NOT)
+	ExcludeFlags       []string // Recipe must have none of these dietary flags, see package dietary
+}
+
+// recipeTagExistsSQL is an EXISTS subquery matching recipes that carry any of the
+// lowercased tag names bound to the given placeholder.
+const recipeTagExistsSQL = `EXISTS (
+			SELECT 1 FROM recipe_tags rt_f
+			JOIN tags t_f ON rt_f.tag_id = t_f.id
+			WHERE rt_f.recipe_id = r.id AND LOWER(t_f.name) = ANY($%d)
+		)`
+
+// GetAllRecipes retrieves recipes with optional search, filtering, and pagination.
+func GetAllRecipes(ctx context.Context, filter RecipeFilter, page int, pageSize int) ([]models.Recipe, int, error) {
 	if DB == nil {
 		return nil, 0, fmt.Errorf("database not initialized")
 	}
@@ -217,67 +298,99 @@ func GetAllRecipes(searchTerm string, ingredientFilters []string, page int, page
 	// plainto_tsquery will handle further normalization for tsvector matching.
 
 	// Base query for fetching recipes
-	selectSQL := `SELECT r.id, r.name, r.method, r.photo_filename, r.created_at, r.updated_at,
+	selectSQL := `SELECT ` + recipeSelectColumns + `,
 		(
-			SELECT COALESCE(array_agg(ri_s.quantity_text || ' ' || i_s.name ORDER BY ri_s.sort_order ASC), '{}'::TEXT[])
+			SELECT COALESCE(array_agg(COALESCE(NULLIF(ri_s.original_text, ''), TRIM(COALESCE(ri_s.quantity_text, '') || ' ' || i_s.name)) ORDER BY ri_s.sort_order ASC), '{}'::TEXT[])
 			FROM recipe_ingredients ri_s
 			JOIN ingredients i_s ON ri_s.ingredient_id = i_s.id
 			WHERE ri_s.recipe_id = r.id
-		) AS ingredients_list
+		) AS ingredients_list,
+		(
+			SELECT COALESCE(array_agg(t_s.name ORDER BY LOWER(t_s.name) ASC), '{}'::TEXT[])
+			FROM recipe_tags rt_s
+			JOIN tags t_s ON rt_s.tag_id = t_s.id
+			WHERE rt_s.recipe_id = r.id
+		) AS tags_list
 		FROM recipes r`
 
 	// Base query for counting total matching recipes
 	countSQL := `SELECT COUNT(DISTINCT r.id) FROM recipes r`
 
-	// WHERE clauses and JOINs for filtering
-	var conditions []string
-	var joinClauses string
+	// WHERE clauses and JOINs for filtering; recipes in the trash are never listed
+	conditions := []string{"r.deleted_at IS NULL"}
 
-	if searchTerm != "" {
+	if filter.SearchTerm != "" {
 		conditions = append(conditions, fmt.Sprintf("r.search_vector @@ plainto_tsquery('english', $%d)", argCount))
-		args = append(args, searchTerm)
+		args = append(args, filter.SearchTerm)
+		argCount++
+	}
+
+	for _, filterTerm := range filter.IngredientFilters {
+		// Each filterTerm must match an ingredient in the recipe, or with IncludeDescendants
+		// an ingredient below a matching one in the taxonomy.
+		matchingSQL := fmt.Sprintf(`SELECT id FROM ingredients WHERE normalized_name_tsvector @@ plainto_tsquery('english', $%d)`, argCount)
+		if filter.IncludeDescendants {
+			matchingSQL = fmt.Sprintf(`WITH RECURSIVE matched(id) AS (
+					%s
+					UNION
+					SELECT c.id FROM ingredients c JOIN matched m ON c.parent_id = m.id
+				)
+				SELECT id FROM matched`, matchingSQL)
+		}
+		conditions = append(conditions, fmt.Sprintf(`r.id IN (
+			SELECT ri_f.recipe_id FROM recipe_ingredients ri_f
+			WHERE ri_f.ingredient_id IN (%s)
+		)`, matchingSQL))
+		args = append(args, filterTerm)
 		argCount++
 	}
 
-	if len(ingredientFilters) > 0 {
-		for i, filterTerm := range ingredientFilters {
-			// Each filterTerm must match an ingredient in the recipe.
-			// We add a set of JOINs for each filterTerm to ensure AND logic.
-			ingredientAlias := fmt.Sprintf("i_f%d", i)
-			recipeIngredientAlias := fmt.Sprintf("ri_f%d", i)
+	if filter.MaxTotalTime > 0 {
+		conditions = append(conditions, fmt.Sprintf("r.total_time_minutes <= $%d", argCount))
+		args = append(args, filter.MaxTotalTime)
+		argCount++
+	}
 
-			joinSQLPart := fmt.Sprintf(`
-				JOIN recipe_ingredients %s ON r.id = %s.recipe_id
-				JOIN ingredients %s ON %s.ingredient_id = %s.id AND %s.normalized_name_tsvector @@ plainto_tsquery('english', $%d)`,
-				recipeIngredientAlias, recipeIngredientAlias,
-				ingredientAlias, recipeIngredientAlias, ingredientAlias,
-				ingredientAlias, argCount)
+	if filter.Servings > 0 {
+		conditions = append(conditions, fmt.Sprintf("r.servings = $%d", argCount))
+		args = append(args, filter.Servings)
+		argCount++
+	}
 
-			joinClauses += joinSQLPart
-			args = append(args, filterTerm)
-			argCount++
-		}
+	// Tag filters: one EXISTS per required tag, a single EXISTS for "any of", NOT EXISTS for exclusions.
+	for _, tag := range normalizeTagNames(filter.TagsAll) {
+		conditions = append(conditions, fmt.Sprintf(recipeTagExistsSQL, argCount))
+		args = append(args, pq.Array([]string{strings.ToLower(tag)}))
+		argCount++
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	if tagsAny := normalizeTagNames(filter.TagsAny); len(tagsAny) > 0 {
+		conditions = append(conditions, fmt.Sprintf(recipeTagExistsSQL, argCount))
+		args = append(args, pq.Array(lowerAll(tagsAny)))
+		argCount++
 	}
 
-	// Construct final count query
-	finalCountQuery := countSQL + joinClauses + whereClause
-	var totalCount int
-	// Re-evaluate arg slicing for count query
-	currentArgsForCount := []interface{}{}
-	if searchTerm != "" {
-		currentArgsForCount = append(currentArgsForCount, searchTerm)
+	if tagsNone := normalizeTagNames(filter.TagsNone); len(tagsNone) > 0 {
+		conditions = append(conditions, "NOT "+fmt.Sprintf(recipeTagExistsSQL, argCount))
+		args = append(args, pq.Array(lowerAll(tagsNone)))
+		argCount++
 	}
-	if len(ingredientFilters) > 0 {
-		for _, filterTerm := range ingredientFilters {
-			currentArgsForCount = append(currentArgsForCount, filterTerm)
-		}
+
+	if len(filter.ExcludeFlags) > 0 {
+		conditions = append(conditions, fmt.Sprintf(`NOT EXISTS (
+			SELECT 1 FROM recipe_dietary_flags df_f
+			WHERE df_f.recipe_id = r.id AND df_f.flag = ANY($%d)
+		)`, argCount))
+		args = append(args, pq.Array(filter.ExcludeFlags))
+		argCount++
 	}
-	err := DB.QueryRow(finalCountQuery, currentArgsForCount...).Scan(&totalCount)
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	// Construct final count query; it uses every argument except pagination.
+	finalCountQuery := countSQL + whereClause
+	var totalCount int
+	err := DB.QueryRowContext(ctx, finalCountQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting recipes: %w", err)
 	}
@@ -290,10 +403,10 @@ func GetAllRecipes(searchTerm string, ingredientFilters []string, page int, page
 	orderByClause := " ORDER BY r.name ASC"
 	offset := (page - 1) * pageSize
 	paginationClause := fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	finalSelectQuery := selectSQL + joinClauses + whereClause + orderByClause + paginationClause
+	finalSelectQuery := selectSQL + whereClause + orderByClause + paginationClause
 	args = append(args, pageSize, offset)
 
-	rows, err := DB.Query(finalSelectQuery, args...)
+	rows, err := DB.QueryContext(ctx, finalSelectQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error fetching recipes: %w", err)
 	}
@@ -302,14 +415,12 @@ func GetAllRecipes(searchTerm string, ingredientFilters []string, page int, page
 	var recipes []models.Recipe
 	for rows.Next() {
 		var recipe models.Recipe
-		var ingredientsList pq.StringArray
-		if err := rows.Scan(
-			&recipe.ID, &recipe.Name, &recipe.Method, &recipe.PhotoFilename,
-			&recipe.CreatedAt, &recipe.UpdatedAt, &ingredientsList,
-		); err != nil {
+		var ingredientsList, tagsList pq.StringArray
+		if err := rows.Scan(append(recipeScanDest(&recipe), &ingredientsList, &tagsList)...); err != nil {
 			return nil, 0, fmt.Errorf("error scanning recipe row: %w", err)
 		}
 		recipe.Ingredients = []string(ingredientsList)
+		recipe.Tags = []string(tagsList)
 		recipes = append(recipes, recipe)
 	}
 
@@ -321,7 +432,9 @@ func GetAllRecipes(searchTerm string, ingredientFilters []string, page int, page
 }
 
 // UpdateRecipe updates an existing recipe in the PostgreSQL database.
-func UpdateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
+// The previous state is kept in recipe_revisions and the new state is recorded
+// as the next revision, attributed to editor (may be empty).
+func UpdateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -329,7 +442,7 @@ func UpdateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 		return nil, fmt.Errorf("recipe ID cannot be empty for update")
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -337,9 +450,13 @@ func UpdateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 
 	// Update recipe's main fields
 	recipe.UpdatedAt = time.Now().UTC()
-	updateRecipeQuery := `UPDATE recipes SET name = $1, method = $2, photo_filename = $3, updated_at = $4
-		WHERE id = $5`
-	res, err := tx.Exec(updateRecipeQuery, recipe.Name, recipe.Method, recipe.PhotoFilename, recipe.UpdatedAt, recipe.ID)
+	recipe.ComputeTotalTime()
+	updateRecipeQuery := `UPDATE recipes SET name = $1, method = $2, servings = $3, yield = $4,
+			prep_time_minutes = $5, cook_time_minutes = $6, rest_time_minutes = $7, photo_filename = $8, updated_at = $9
+		WHERE id = $10 AND deleted_at IS NULL`
+	res, err := tx.ExecContext(ctx, updateRecipeQuery, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+		nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+		recipe.PhotoFilename, recipe.UpdatedAt, recipe.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update recipe ID %s: %w", recipe.ID, err)
 	}
@@ -353,42 +470,29 @@ func UpdateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 
 	// Delete existing ingredients for this recipe
 	deleteIngredientsQuery := `DELETE FROM recipe_ingredients WHERE recipe_id = $1`
-	_, err = tx.Exec(deleteIngredientsQuery, recipe.ID)
+	_, err = tx.ExecContext(ctx, deleteIngredientsQuery, recipe.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete old ingredients for recipe ID %s: %w", recipe.ID, err)
 	}
 
-	// Process and insert new ingredients (similar to CreateRecipe)
-	for i, fullIngredientStr := range recipe.Ingredients {
-		quantityText, ingredientNamePart, err := extractIngredientNameParts(fullIngredientStr)
-		if err != nil {
-			log.Printf("Error parsing ingredient string '%s' during update: %v. Skipping.", fullIngredientStr, err)
-			continue
-		}
-		normalizedIngredientName := normalizeIngredientName(ingredientNamePart)
+	// Parse and link the new ingredients (same as CreateRecipe)
+	if err := linkRecipeIngredientsTx(ctx, tx, recipe.ID, recipe.Ingredients); err != nil {
+		return nil, fmt.Errorf("failed to link ingredients during update: %w", err)
+	}
 
-		var ingredientID string
-		ingredientQuery := `SELECT id FROM ingredients WHERE name = $1`
-		err = tx.QueryRow(ingredientQuery, normalizedIngredientName).Scan(&ingredientID)
-		if err == sql.ErrNoRows {
-			ingredientID = uuid.NewString()
-			insertIngredientQuery := `INSERT INTO ingredients (id, name, created_at, updated_at)
-				VALUES ($1, $2, $3, $4)`
-			_, err = tx.Exec(insertIngredientQuery, ingredientID, normalizedIngredientName, time.Now().UTC(), time.Now().UTC())
-			if err != nil {
-				return nil, fmt.Errorf("failed to insert new ingredient '%s' during update: %w", normalizedIngredientName, err)
-			}
-		} else if err != nil {
-			return nil, fmt.Errorf("failed to query ingredient '%s' during update: %w", normalizedIngredientName, err)
-		}
+	// Replace the recipe's tags
+	recipe.Tags = normalizeTagNames(recipe.Tags)
+	if err := setRecipeTagsTx(ctx, tx, recipe.ID, recipe.Tags); err != nil {
+		return nil, fmt.Errorf("failed to set tags during update: %w", err)
+	}
 
-		recipeIngredientID := uuid.NewString()
-		insertRecipeIngredientQuery := `INSERT INTO recipe_ingredients (id, recipe_id, ingredient_id, quantity_text, sort_order)
-			VALUES ($1, $2, $3, $4, $5)`
-		_, err = tx.Exec(insertRecipeIngredientQuery, recipeIngredientID, recipe.ID, ingredientID, quantityText, i)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert recipe_ingredient link for recipe ID %s and ingredient ID %s during update: %w", recipe.ID, ingredientID, err)
-		}
+	// A new or restored photo joins the gallery as its cover
+	if err := syncCoverPhotoTx(ctx, tx, recipe.ID, recipe.PhotoFilename); err != nil {
+		return nil, err
+	}
+
+	if err := insertRecipeRevisionTx(ctx, tx, recipe.ID, editor); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -399,8 +503,9 @@ func UpdateRecipe(recipe *models.Recipe) (*models.Recipe, error) {
 }
 
 // GetAllRecipesForExport fetches all recipes from the database without pagination or filtering, for export purposes.
-func GetAllRecipesForExport() ([]models.Recipe, error) {
-	rows, err := DB.QueryContext(context.Background(), `SELECT id, name, method, photo_filename, created_at, updated_at FROM recipes ORDER BY created_at ASC`)
+// Recipes in the trash are included with their deleted_at, so an import restores them to the trash.
+func GetAllRecipesForExport(ctx context.Context) ([]models.Recipe, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT `+recipeSelectColumns+` FROM recipes r ORDER BY r.created_at ASC`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying all recipes for export: %w", err)
 	}
@@ -409,15 +514,10 @@ func GetAllRecipesForExport() ([]models.Recipe, error) {
 	var recipes []models.Recipe
 	for rows.Next() {
 		var r models.Recipe
-		var photoFilename sql.NullString // Handle potentially NULL photo_filename
-		if err := rows.Scan(&r.ID, &r.Name, &r.Method, &photoFilename, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		// NULL photo_filename, servings and times are mapped to zero values by recipeSelectColumns.
+		if err := rows.Scan(recipeScanDest(&r)...); err != nil {
 			return nil, fmt.Errorf("error scanning recipe for export: %w", err)
 		}
-		if photoFilename.Valid {
-			r.PhotoFilename = photoFilename.String
-		} else {
-			r.PhotoFilename = "" // Or your desired default for NULL photo_filename
-		}
 		// The Recipe struct's Ingredients field ([]string) is not populated here as it's a denormalized representation.
 		// For export, we fetch recipe_ingredients separately.
 		recipes = append(recipes, r)
@@ -429,8 +529,10 @@ func GetAllRecipesForExport() ([]models.Recipe, error) {
 }
 
 // GetAllRecipeIngredients fetches all recipe_ingredients records from the database.
-func GetAllRecipeIngredients() ([]models.RecipeIngredient, error) {
-	rows, err := DB.QueryContext(context.Background(), `SELECT id, recipe_id, ingredient_id, quantity_text, sort_order FROM recipe_ingredients ORDER BY recipe_id ASC, sort_order ASC`)
+func GetAllRecipeIngredients(ctx context.Context) ([]models.RecipeIngredient, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT id, recipe_id, ingredient_id, original_text, quantity_text,
+		quantity, quantity_max, unit, preparation, sort_order
+		FROM recipe_ingredients ORDER BY recipe_id ASC, sort_order ASC`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying recipe_ingredients: %w", err)
 	}
@@ -439,15 +541,18 @@ func GetAllRecipeIngredients() ([]models.RecipeIngredient, error) {
 	var recipeIngredients []models.RecipeIngredient
 	for rows.Next() {
 		var ri models.RecipeIngredient
-		var quantityText sql.NullString // Handle potentially NULL quantity_text
-		if err := rows.Scan(&ri.ID, &ri.RecipeID, &ri.IngredientID, &quantityText, &ri.SortOrder); err != nil {
+		var originalText, quantityText, unit, preparation sql.NullString // Handle potentially NULL text columns
+		var quantity, quantityMax sql.NullFloat64
+		if err := rows.Scan(&ri.ID, &ri.RecipeID, &ri.IngredientID, &originalText, &quantityText,
+			&quantity, &quantityMax, &unit, &preparation, &ri.SortOrder); err != nil {
 			return nil, fmt.Errorf("error scanning recipe_ingredient: %w", err)
 		}
-		if quantityText.Valid {
-			ri.QuantityText = quantityText.String
-		} else {
-			ri.QuantityText = ""
-		}
+		ri.OriginalText = originalText.String
+		ri.QuantityText = quantityText.String
+		ri.Quantity = nullFloatPtr(quantity)
+		ri.QuantityMax = nullFloatPtr(quantityMax)
+		ri.Unit = unit.String
+		ri.Preparation = preparation.String
 		recipeIngredients = append(recipeIngredients, ri)
 	}
 	if err = rows.Err(); err != nil {
@@ -457,8 +562,8 @@ func GetAllRecipeIngredients() ([]models.RecipeIngredient, error) {
 }
 
 // GetAllIngredients fetches all ingredients from the database.
-func GetAllIngredients() ([]models.Ingredient, error) {
-	rows, err := DB.QueryContext(context.Background(), `SELECT id, name, normalized_name, created_at, updated_at FROM ingredients ORDER BY name ASC`)
+func GetAllIngredients(ctx context.Context) ([]models.Ingredient, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT id, name, normalized_name, parent_id, created_at, updated_at FROM ingredients ORDER BY name ASC`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying ingredients: %w", err)
 	}
@@ -467,9 +572,11 @@ func GetAllIngredients() ([]models.Ingredient, error) {
 	var ingredients []models.Ingredient
 	for rows.Next() {
 		var i models.Ingredient
-		if err := rows.Scan(&i.ID, &i.Name, &i.NormalizedName, &i.CreatedAt, &i.UpdatedAt); err != nil {
+		var parentID sql.NullString
+		if err := rows.Scan(&i.ID, &i.Name, &i.NormalizedName, &parentID, &i.CreatedAt, &i.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("error scanning ingredient: %w", err)
 		}
+		i.ParentID = parentID.String
 		ingredients = append(ingredients, i)
 	}
 	if err = rows.Err(); err != nil {
@@ -478,8 +585,10 @@ func GetAllIngredients() ([]models.Ingredient, error) {
 	return ingredients, nil
 }
 
-// DeleteRecipe removes a recipe from the PostgreSQL database.
-func DeleteRecipe(id string) error {
+// DeleteRecipe moves a recipe to the trash by setting its deleted_at timestamp.
+// The recipe keeps its ingredients, tags, revisions, comments and meal plan entries
+// until it is purged with PurgeRecipe.
+func DeleteRecipe(ctx context.Context, id string) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
@@ -487,62 +596,35 @@ func DeleteRecipe(id string) error {
 		return fmt.Errorf("recipe ID cannot be empty for deletion")
 	}
 
-	tx, err := DB.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// First, delete from recipe_ingredients (junction table)
-	deleteIngredientsQuery := `DELETE FROM recipe_ingredients WHERE recipe_id = $1`
-	_, err = tx.Exec(deleteIngredientsQuery, id)
-	if err != nil {
-		// It's okay if there were no ingredients, but other errors should be reported
-		log.Printf("Warning: could not delete recipe_ingredients for recipe ID %s (may not have had any): %v", id, err)
-		// Depending on strictness, you might choose to return error here or proceed.
-		// For now, we proceed to delete the main recipe entry.
-	}
-
-	// Then, delete from recipes table
-	deleteRecipeQuery := `DELETE FROM recipes WHERE id = $1`
-	res, err := tx.Exec(deleteRecipeQuery, id)
+	res, err := DB.ExecContext(ctx, `UPDATE recipes SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, time.Now().UTC(), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete recipe ID %s: %w", id, err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		// This error is less critical if the delete query itself didn't fail,
-		// but good to log for diagnostics.
 		log.Printf("Warning: could not get rows affected after deleting recipe ID %s: %v", id, err)
 	}
 	if rowsAffected == 0 {
-		// If no rows were affected, the recipe didn't exist.
-		// This might not be an error condition depending on desired idempotency.
-		// For now, we'll consider it a success if no error occurred during exec.
+		// Either the recipe never existed or it is already in the trash.
 		log.Printf("Recipe with ID %s not found for deletion, or already deleted.", id)
 	}
 
-	// Note: Orphaned ingredients in the 'ingredients' table are not cleaned up here.
-	// This could be a separate maintenance task if desired.
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction for recipe deletion: %w", err)
-	}
-
-	log.Printf("Recipe deleted successfully (or did not exist): ID=%s", id)
+	log.Printf("Recipe moved to trash (or did not exist): ID=%s", id)
 	return nil
 }
 
-// ImportRecipeDataBundle handles the import of recipes, ingredients, and their links
-// within a single database transaction.
+// ImportRecipeDataBundle handles the import of recipes, ingredients and their aliases, and their links,
+// along with tags, photos, revisions, comments and meal plan entries, within a single
+// database transaction. Ingredients are matched by normalized name and recipes by ID;
+// rows created by the import keep the IDs and timestamps from the file.
 // It returns counts of successfully imported items or an error if the process fails.
-func ImportRecipeDataBundle(data models.ExportedData) (importedRecipes int, importedIngredients int, importedLinks int, err error) {
+func ImportRecipeDataBundle(ctx context.Context, data models.ExportedData) (importedRecipes int, importedIngredients int, importedLinks int, err error) {
 	if DB == nil {
 		return 0, 0, 0, fmt.Errorf("database not initialized")
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -560,10 +642,12 @@ func ImportRecipeDataBundle(data models.ExportedData) (importedRecipes int, impo
 	// Maps to store original ID (from JSON) to new/existing DB ID (UUID string)
 	ingredientOriginalIDToDbIDMap := make(map[string]string)
 	recipeOriginalIDToDbIDMap := make(map[string]string)
+	var createdRecipeIDs []string                  // Recipes new to this database, which get a first revision
+	createdRecipePhotos := make(map[string]string) // DB ID of a created recipe to its cover photo
 
 	// 1. Import Ingredients
 	for _, ingFromFile := range data.Ingredients {
-		dbIngredientID, createErr := getOrCreateIngredientTx(tx, ingFromFile)
+		dbIngredientID, createErr := getOrCreateIngredientTx(ctx, tx, ingFromFile)
 		if createErr != nil {
 			err = fmt.Errorf("error processing ingredient '%s': %w", ingFromFile.Name, createErr)
 			return
@@ -572,22 +656,90 @@ func ImportRecipeDataBundle(data models.ExportedData) (importedRecipes int, impo
 		importedIngredients++
 	}
 	log.Printf("Processed %d ingredients. Map size: %d", len(data.Ingredients), len(ingredientOriginalIDToDbIDMap))
+	for _, aliasFromFile := range data.IngredientAliases {
+		if createErr := insertImportedAliasTx(ctx, tx, aliasFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing ingredient alias '%s': %w", aliasFromFile.Name, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d ingredient aliases.", len(data.IngredientAliases))
+	for _, ingFromFile := range data.Ingredients {
+		if ingFromFile.ParentID == "" {
+			continue
+		}
+		if createErr := setImportedIngredientParentTx(ctx, tx, ingFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing parent of ingredient '%s': %w", ingFromFile.Name, createErr)
+			return
+		}
+	}
+	for _, mappingFromFile := range data.IngredientFoods {
+		if createErr := insertImportedIngredientFoodTx(ctx, tx, mappingFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing food of ingredient '%s': %w", mappingFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d ingredient food mappings.", len(data.IngredientFoods))
+	for _, flaggedFromFile := range data.IngredientDietaryFlags {
+		if createErr := insertImportedIngredientDietaryFlagsTx(ctx, tx, flaggedFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing dietary flags of ingredient '%s': %w", flaggedFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed dietary flags of %d ingredients.", len(data.IngredientDietaryFlags))
+	for _, subFromFile := range data.Substitutions {
+		if createErr := insertImportedSubstitutionTx(ctx, tx, subFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing substitution '%s' for ingredient '%s': %w", subFromFile.ID, subFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d substitutions.", len(data.Substitutions))
+	for _, itemFromFile := range data.PantryItems {
+		if createErr := insertImportedPantryItemTx(ctx, tx, itemFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing pantry item '%s' for ingredient '%s': %w", itemFromFile.ID, itemFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	for _, stapleFromFile := range data.PantryStaples {
+		if createErr := insertImportedPantryStapleTx(ctx, tx, stapleFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing pantry staple '%s': %w", stapleFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d pantry items and %d staples.", len(data.PantryItems), len(data.PantryStaples))
+	for _, aisleFromFile := range data.IngredientAisles {
+		if createErr := insertImportedIngredientAisleTx(ctx, tx, aisleFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing aisle of ingredient '%s': %w", aisleFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed aisles of %d ingredients.", len(data.IngredientAisles))
+	for _, listFromFile := range data.ShoppingLists {
+		if createErr := insertImportedShoppingListTx(ctx, tx, listFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing shopping list '%s': %w", listFromFile.Name, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d shopping lists.", len(data.ShoppingLists))
 
 	// 2. Import Recipes
 	for _, recFromFile := range data.Recipes {
-		dbRecipeID, createErr := getOrCreateRecipeTx(tx, recFromFile)
+		dbRecipeID, created, createErr := getOrCreateRecipeTx(ctx, tx, recFromFile)
 		if createErr != nil {
 			err = fmt.Errorf("error processing recipe '%s': %w", recFromFile.Name, createErr)
 			return
 		}
 		recipeOriginalIDToDbIDMap[recFromFile.ID] = dbRecipeID
+		if created {
+			createdRecipeIDs = append(createdRecipeIDs, dbRecipeID)
+			createdRecipePhotos[dbRecipeID] = recFromFile.PhotoFilename
+		}
 		importedRecipes++
 	}
 	log.Printf("Processed %d recipes. Map size: %d", len(data.Recipes), len(recipeOriginalIDToDbIDMap))
 
 	// 3. Import Recipe-Ingredient Links
 	for _, riFromFile := range data.RecipeIngredients {
-		createErr := insertRecipeIngredientLinkTx(tx, riFromFile, recipeOriginalIDToDbIDMap, ingredientOriginalIDToDbIDMap)
+		createErr := insertRecipeIngredientLinkTx(ctx, tx, riFromFile, recipeOriginalIDToDbIDMap, ingredientOriginalIDToDbIDMap)
 		if createErr != nil {
 			// Any error from insertRecipeIngredientLinkTx is now considered fatal
 			// as ON CONFLICT DO NOTHING should handle duplicates silently.
@@ -598,13 +750,141 @@ func ImportRecipeDataBundle(data models.ExportedData) (importedRecipes int, impo
 	}
 	log.Printf("Processed %d recipe_ingredient links.", len(data.RecipeIngredients))
 
+	// 4. Import Tags and Recipe-Tag Links (absent from exports made before tags existed)
+	tagOriginalIDToDbIDMap := make(map[string]string)
+	for _, tagFromFile := range data.Tags {
+		name := NormalizeTagName(tagFromFile.Name)
+		if name == "" {
+			continue
+		}
+		tagFromFile.Name = name
+		dbTagID, createErr := getOrCreateImportedTagTx(ctx, tx, tagFromFile)
+		if createErr != nil {
+			err = fmt.Errorf("error processing tag '%s': %w", tagFromFile.Name, createErr)
+			return
+		}
+		tagOriginalIDToDbIDMap[tagFromFile.ID] = dbTagID
+	}
+	for _, linkFromFile := range data.RecipeTags {
+		createErr := insertRecipeTagLinkTx(ctx, tx, linkFromFile, recipeOriginalIDToDbIDMap, tagOriginalIDToDbIDMap)
+		if createErr != nil {
+			err = fmt.Errorf("error processing recipe_tag link for recipe '%s' and tag '%s': %w", linkFromFile.RecipeID, linkFromFile.TagID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d tags and %d recipe_tag links.", len(data.Tags), len(data.RecipeTags))
+
+	// 5. Import Recipe Photos and dietary overrides (absent from older exports)
+	for _, photoFromFile := range data.RecipePhotos {
+		if createErr := insertRecipePhotoTx(ctx, tx, photoFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing photo '%s' for recipe '%s': %w", photoFromFile.Filename, photoFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d recipe photos.", len(data.RecipePhotos))
+	for _, overridesFromFile := range data.RecipeDietaryOverrides {
+		if createErr := insertImportedRecipeDietaryOverridesTx(ctx, tx, overridesFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing dietary overrides of recipe '%s': %w", overridesFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed dietary overrides of %d recipes.", len(data.RecipeDietaryOverrides))
+
+	// 6. Import Comments and Meal Plan Entries (absent from older exports). Entries planning
+	// a recipe that is not in the file keep their recipe_id, as it may be a custom name.
+	for _, commentFromFile := range data.Comments {
+		if createErr := insertImportedCommentTx(ctx, tx, commentFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing comment '%s' for recipe '%s': %w", commentFromFile.ID, commentFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	for _, entryFromFile := range data.MealPlanEntries {
+		if dbRecipeID, ok := recipeOriginalIDToDbIDMap[entryFromFile.RecipeID]; ok {
+			entryFromFile.RecipeID = dbRecipeID
+		}
+		if createErr := insertImportedMealPlanEntryTx(ctx, tx, entryFromFile); createErr != nil {
+			err = fmt.Errorf("error processing meal plan entry '%s': %w", entryFromFile.ID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d comments and %d meal plan entries.", len(data.Comments), len(data.MealPlanEntries))
+
+	// 7. Set the cover of newly created recipes, now that their ingredients, tags and photos are
+	// linked, and bring over their history. Recipes exported without revisions get a first one.
+	revisionsByRecipe := make(map[string][]models.RecipeRevision)
+	for _, revFromFile := range data.RecipeRevisions {
+		dbRecipeID, ok := recipeOriginalIDToDbIDMap[revFromFile.RecipeID]
+		if !ok {
+			err = fmt.Errorf("error processing revision %d of recipe '%s': could not find DB ID for original recipe ID '%s'", revFromFile.Revision, revFromFile.RecipeID, revFromFile.RecipeID)
+			return
+		}
+		revisionsByRecipe[dbRecipeID] = append(revisionsByRecipe[dbRecipeID], revFromFile)
+	}
+	for _, recipeID := range createdRecipeIDs {
+		if coverErr := syncCoverPhotoTx(ctx, tx, recipeID, createdRecipePhotos[recipeID]); coverErr != nil {
+			err = coverErr
+			return
+		}
+		if len(revisionsByRecipe[recipeID]) == 0 {
+			if revErr := insertRecipeRevisionTx(ctx, tx, recipeID, "import"); revErr != nil {
+				err = revErr
+				return
+			}
+		}
+		for _, rev := range revisionsByRecipe[recipeID] {
+			if revErr := insertImportedRevisionTx(ctx, tx, rev, recipeID); revErr != nil {
+				err = revErr
+				return
+			}
+		}
+	}
+
+	// 8. Imported links and food mappings can change the nutrition of existing recipes
+	if _, execErr := tx.ExecContext(ctx, `DELETE FROM recipe_nutrition`); execErr != nil {
+		err = fmt.Errorf("failed to clear cached recipe nutrition: %w", execErr)
+		return
+	}
+
 	return // err will be nil if commit succeeds, or set by defer if commit fails or rollback occurs
 }
 
+// importedID returns an ID from an import file if it is a valid UUID, and a new UUID otherwise.
+func importedID(id string) string {
+	if _, err := uuid.Parse(id); err != nil {
+		return uuid.NewString()
+	}
+	return id
+}
+
+// importedIDTx returns the ID an imported row is stored under in table: its ID from the import
+// file if that is a valid UUID no other row uses yet, so IDs survive a move between backends,
+// and a new UUID otherwise. Operates within a transaction.
+func importedIDTx(ctx context.Context, tx *sql.Tx, table string, id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return uuid.NewString(), nil
+	}
+	var taken bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, id).Scan(&taken); err != nil {
+		return "", fmt.Errorf("failed to check for existing %s ID %s: %w", table, id, err)
+	}
+	if taken {
+		return uuid.NewString(), nil
+	}
+	return id, nil
+}
+
+// timeOrNow returns t, or the current time if t is zero (missing from an import file).
+func timeOrNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now().UTC()
+	}
+	return t
+}
+
 // getOrCreateIngredientTx finds an ingredient by its normalized name or creates it if not found.
 // Operates within a transaction. Returns the database ID of the ingredient.
 // The input ingredient's NormalizedName should be pre-populated if known, otherwise it relies on the DB trigger.
-func getOrCreateIngredientTx(tx *sql.Tx, ingredient models.Ingredient) (string, error) {
+func getOrCreateIngredientTx(ctx context.Context, tx *sql.Tx, ingredient models.Ingredient) (string, error) {
 	var dbIngredientID string
 	var existingNormalizedName string // To store what the DB generates/has
 
@@ -615,16 +895,18 @@ func getOrCreateIngredientTx(tx *sql.Tx, ingredient models.Ingredient) (string,
 	// If not, we might need to query by name and then compare normalized versions, or just insert and let unique constraints handle it.
 
 	query := `SELECT id, normalized_name FROM ingredients WHERE normalized_name = $1`
-	err := tx.QueryRow(query, ingredient.NormalizedName).Scan(&dbIngredientID, &existingNormalizedName)
+	err := tx.QueryRowContext(ctx, query, ingredient.NormalizedName).Scan(&dbIngredientID, &existingNormalizedName)
 
 	if err == sql.ErrNoRows { // Ingredient does not exist, create it
-		newID := uuid.NewString()
+		newID, idErr := importedIDTx(ctx, tx, "ingredients", ingredient.ID)
+		if idErr != nil {
+			return "", idErr
+		}
 		insertQuery := `INSERT INTO ingredients (id, name, created_at, updated_at)
 						VALUES ($1, $2, $3, $4) RETURNING id, normalized_name`
 		// Note: normalized_name is set by a trigger using the 'name' field.
 		// We pass ingredient.Name and expect the trigger to work.
-		now := time.Now().UTC()
-		err = tx.QueryRow(insertQuery, newID, ingredient.Name, now, now).Scan(&dbIngredientID, &existingNormalizedName)
+		err = tx.QueryRowContext(ctx, insertQuery, newID, ingredient.Name, timeOrNow(ingredient.CreatedAt), timeOrNow(ingredient.UpdatedAt)).Scan(&dbIngredientID, &existingNormalizedName)
 		if err != nil {
 			return "", fmt.Errorf("failed to insert new ingredient '%s': %w", ingredient.Name, err)
 		}
@@ -639,40 +921,48 @@ func getOrCreateIngredientTx(tx *sql.Tx, ingredient models.Ingredient) (string,
 	return dbIngredientID, nil
 }
 
-// getOrCreateRecipeTx finds a recipe by its name or creates it if not found.
-// Operates within a transaction. Returns the database ID of the recipe.
-func getOrCreateRecipeTx(tx *sql.Tx, recipe models.Recipe) (string, error) {
+// getOrCreateRecipeTx finds a recipe by the ID it was exported with or creates it if not found.
+// Recipes are not matched by name, which two of them can share. Operates within a transaction.
+// Returns the database ID of the recipe and whether it was created.
+func getOrCreateRecipeTx(ctx context.Context, tx *sql.Tx, recipe models.Recipe) (string, bool, error) {
 	var dbRecipeID string
-	query := `SELECT id FROM recipes WHERE name = $1`
-	err := tx.QueryRow(query, recipe.Name).Scan(&dbRecipeID)
+	err := sql.ErrNoRows
+	if _, parseErr := uuid.Parse(recipe.ID); parseErr == nil {
+		err = tx.QueryRowContext(ctx, `SELECT id FROM recipes WHERE id = $1`, recipe.ID).Scan(&dbRecipeID)
+	}
 
 	if err == sql.ErrNoRows { // Recipe does not exist, create it
-		newID := uuid.NewString()
-		insertQuery := `INSERT INTO recipes (id, name, method, photo_filename, created_at, updated_at)
-						VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
-		now := time.Now().UTC()
+		newID, idErr := importedIDTx(ctx, tx, "recipes", recipe.ID)
+		if idErr != nil {
+			return "", false, idErr
+		}
+		insertQuery := `INSERT INTO recipes (id, name, method, servings, yield, prep_time_minutes, cook_time_minutes, rest_time_minutes,
+							photo_filename, created_at, updated_at, deleted_at)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
 		// Handle empty photo_filename from import gracefully
 		var photoFilename sql.NullString
 		if recipe.PhotoFilename != "" {
 			photoFilename = sql.NullString{String: recipe.PhotoFilename, Valid: true}
 		}
 
-		err = tx.QueryRow(insertQuery, newID, recipe.Name, recipe.Method, photoFilename, now, now).Scan(&dbRecipeID)
+		err = tx.QueryRowContext(ctx, insertQuery, newID, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+			nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+			photoFilename, timeOrNow(recipe.CreatedAt), timeOrNow(recipe.UpdatedAt), recipe.DeletedAt).Scan(&dbRecipeID)
 		if err != nil {
-			return "", fmt.Errorf("failed to insert new recipe '%s': %w", recipe.Name, err)
+			return "", false, fmt.Errorf("failed to insert new recipe '%s': %w", recipe.Name, err)
 		}
 		log.Printf("Created new recipe: Name='%s', DB_ID='%s'", recipe.Name, dbRecipeID)
-		return dbRecipeID, nil
+		return dbRecipeID, true, nil
 	} else if err != nil { // Other query error
-		return "", fmt.Errorf("failed to query for existing recipe '%s': %w", recipe.Name, err)
+		return "", false, fmt.Errorf("failed to query for existing recipe '%s': %w", recipe.Name, err)
 	}
 	log.Printf("Found existing recipe: Name='%s', DB_ID='%s'", recipe.Name, dbRecipeID)
-	return dbRecipeID, nil
+	return dbRecipeID, false, nil
 }
 
 // insertRecipeIngredientLinkTx inserts a link between a recipe and an ingredient.
 // Operates within a transaction. Uses maps to resolve original JSON IDs to current DB IDs.
-func insertRecipeIngredientLinkTx(tx *sql.Tx, ri models.RecipeIngredient, recipeOriginalIDToDbIDMap map[string]string, ingredientOriginalIDToDbIDMap map[string]string) error {
+func insertRecipeIngredientLinkTx(ctx context.Context, tx *sql.Tx, ri models.RecipeIngredient, recipeOriginalIDToDbIDMap map[string]string, ingredientOriginalIDToDbIDMap map[string]string) error {
 	dbRecipeID, okRecipe := recipeOriginalIDToDbIDMap[ri.RecipeID]
 	if !okRecipe {
 		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", ri.RecipeID)
@@ -683,16 +973,17 @@ func insertRecipeIngredientLinkTx(tx *sql.Tx, ri models.RecipeIngredient, recipe
 		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", ri.IngredientID)
 	}
 
-	newLinkID := uuid.NewString()
-	// Handle empty quantity_text from import gracefully
-	var quantityText sql.NullString
-	if ri.QuantityText != "" {
-		quantityText = sql.NullString{String: ri.QuantityText, Valid: true}
-	}
-
-	insertQuery := `INSERT INTO recipe_ingredients (id, recipe_id, ingredient_id, quantity_text, sort_order)
-					VALUES ($1, $2, $3, $4, $5) ON CONFLICT (recipe_id, ingredient_id) DO NOTHING`
-	_, err := tx.Exec(insertQuery, newLinkID, dbRecipeID, dbIngredientID, quantityText, ri.SortOrder)
+	newLinkID, err := importedIDTx(ctx, tx, "recipe_ingredients", ri.ID)
+	if err != nil {
+		return err
+	}
+	// Handle empty text columns from import gracefully
+	insertQuery := `INSERT INTO recipe_ingredients
+					(id, recipe_id, ingredient_id, original_text, quantity_text, quantity, quantity_max, unit, preparation, sort_order)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (recipe_id, sort_order) DO NOTHING`
+	_, err = tx.ExecContext(ctx, insertQuery, newLinkID, dbRecipeID, dbIngredientID,
+		nullIfEmpty(ri.OriginalText), nullIfEmpty(ri.QuantityText), ri.Quantity, ri.QuantityMax,
+		nullIfEmpty(ri.Unit), nullIfEmpty(ri.Preparation), ri.SortOrder)
 	if err != nil {
 		// The caller will check for unique_violation (pq.ErrorCode("23505"))
 		return fmt.Errorf("failed to insert recipe_ingredient link (RecipeDB_ID: %s, IngredientDB_ID: %s): %w", dbRecipeID, dbIngredientID, err)
@@ -701,8 +992,28 @@ func insertRecipeIngredientLinkTx(tx *sql.Tx, ri models.RecipeIngredient, recipe
 	return nil
 }
 
+// insertImportedCommentTx adds a comment from an import file to its imported recipe, keeping
+// its ID and timestamps. A comment that is already present is left untouched.
+// Operates within a transaction.
+func insertImportedCommentTx(ctx context.Context, tx *sql.Tx, comment models.Comment, recipeOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, ok := recipeOriginalIDToDbIDMap[comment.RecipeID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", comment.RecipeID)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO comments (id, recipe_id, author, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING`,
+		importedID(comment.ID), dbRecipeID, comment.Author, comment.Content,
+		timeOrNow(comment.CreatedAt), timeOrNow(comment.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert comment for recipe DB ID %s: %w", dbRecipeID, err)
+	}
+	return nil
+}
+
 // CreateComment inserts a new comment into the database.
-func CreateComment(comment models.Comment) (*models.Comment, error) {
+func CreateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -715,7 +1026,7 @@ func CreateComment(comment models.Comment) (*models.Comment, error) {
 			  VALUES ($1, $2, $3, $4, $5, $6)
 			  RETURNING id, recipe_id, author, content, created_at, updated_at`
 
-	err := DB.QueryRow(query,
+	err := DB.QueryRowContext(ctx, query,
 		comment.ID, comment.RecipeID, comment.Author, comment.Content,
 		comment.CreatedAt, comment.UpdatedAt).
 		Scan(&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
@@ -729,7 +1040,7 @@ func CreateComment(comment models.Comment) (*models.Comment, error) {
 }
 
 // GetCommentsByRecipeID retrieves all comments for a given recipe ID.
-func GetCommentsByRecipeID(recipeID string) ([]models.Comment, error) {
+func GetCommentsByRecipeID(ctx context.Context, recipeID string) ([]models.Comment, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -739,7 +1050,7 @@ func GetCommentsByRecipeID(recipeID string) ([]models.Comment, error) {
 			  WHERE recipe_id = $1
 			  ORDER BY created_at ASC`
 
-	rows, err := DB.Query(query, recipeID)
+	rows, err := DB.QueryContext(ctx, query, recipeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query comments for recipe ID %s: %w", recipeID, err)
 	}
@@ -764,8 +1075,37 @@ func GetCommentsByRecipeID(recipeID string) ([]models.Comment, error) {
 	return comments, nil
 }
 
+// GetAllComments fetches every comment, for export.
+func GetAllComments(ctx context.Context) ([]models.Comment, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT id, recipe_id, author, content, created_at, updated_at
+		FROM comments
+		ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
+			&comment.CreatedAt, &comment.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comment rows: %w", err)
+	}
+	return comments, nil
+}
+
 // GetCommentByID retrieves a single comment by its ID.
-func GetCommentByID(commentID string) (*models.Comment, error) {
+func GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -775,7 +1115,7 @@ func GetCommentByID(commentID string) (*models.Comment, error) {
 			  FROM comments
 			  WHERE id = $1`
 
-	err := DB.QueryRow(query, commentID).Scan(
+	err := DB.QueryRowContext(ctx, query, commentID).Scan(
 		&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
 		&comment.CreatedAt, &comment.UpdatedAt,
 	)
@@ -790,7 +1130,7 @@ func GetCommentByID(commentID string) (*models.Comment, error) {
 }
 
 // UpdateComment updates an existing comment in the database.
-func UpdateComment(comment models.Comment) (*models.Comment, error) {
+func UpdateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -802,7 +1142,7 @@ func UpdateComment(comment models.Comment) (*models.Comment, error) {
 			  WHERE id = $3
 			  RETURNING id, recipe_id, author, content, created_at, updated_at`
 
-	err := DB.QueryRow(query,
+	err := DB.QueryRowContext(ctx, query,
 		comment.Content, comment.UpdatedAt, comment.ID).
 		Scan(&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
 			&comment.CreatedAt, &comment.UpdatedAt)
@@ -818,14 +1158,14 @@ func UpdateComment(comment models.Comment) (*models.Comment, error) {
 }
 
 // DeleteComment deletes a comment from the database by its ID.
-func DeleteComment(commentID string) error {
+func DeleteComment(ctx context.Context, commentID string) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
 	query := `DELETE FROM comments WHERE id = $1`
 
-	res, err := DB.Exec(query, commentID)
+	res, err := DB.ExecContext(ctx, query, commentID)
 	if err != nil {
 		return fmt.Errorf("failed to delete comment with ID %s: %w", commentID, err)
 	}
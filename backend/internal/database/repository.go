@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"strings"
+	"time"
+)
+
+// RecipeRepository stores recipes together with everything that belongs to them:
+// tags, photos, revisions and their trash state.
+type RecipeRepository interface {
+	RecipeExistsByID(ctx context.Context, id string) (bool, error)
+	GetRecipeByID(ctx context.Context, id string) (*models.Recipe, error) // (nil, nil) when missing or in the trash
+	GetAllRecipes(ctx context.Context, filter RecipeFilter, page int, pageSize int) ([]models.Recipe, int, error)
+	CreateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error)
+	UpdateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error)
+	DeleteRecipe(ctx context.Context, id string) error
+
+	GetRecipeRevisions(ctx context.Context, recipeID string) ([]models.RecipeRevision, error)
+	GetRecipeRevision(ctx context.Context, recipeID string, revision int) (*models.RecipeRevision, error)
+
+	GetDeletedRecipes(ctx context.Context) ([]models.Recipe, error)
+	RestoreDeletedRecipe(ctx context.Context, id string) error
+	GetDeletedRecipeIDsBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+	PurgeRecipe(ctx context.Context, id string) ([]string, error)
+
+	GetRecipePhotos(ctx context.Context, recipeID string) ([]models.RecipePhoto, error)
+	AddRecipePhoto(ctx context.Context, photo models.RecipePhoto) (*models.RecipePhoto, error)
+	UpdateRecipePhoto(ctx context.Context, recipeID string, photoID string, caption *string, makeCover bool) (*models.RecipePhoto, error)
+	ReorderRecipePhotos(ctx context.Context, recipeID string, photoIDs []string) ([]models.RecipePhoto, error)
+	DeleteRecipePhoto(ctx context.Context, recipeID string, photoID string) (string, error)
+
+	GetAllTags(ctx context.Context, category string) ([]models.Tag, error)
+	GetTagByID(ctx context.Context, tagID string) (*models.Tag, error)
+	CreateTag(ctx context.Context, tag models.Tag) (*models.Tag, error)
+	UpdateTag(ctx context.Context, tag models.Tag) (*models.Tag, error)
+	DeleteTag(ctx context.Context, tagID string) error
+
+	GetAllRecipesForExport(ctx context.Context) ([]models.Recipe, error)
+	GetAllRecipeTags(ctx context.Context) ([]models.RecipeTag, error)
+	GetAllRecipePhotos(ctx context.Context) ([]models.RecipePhoto, error)
+	GetAllRecipeRevisions(ctx context.Context) ([]models.RecipeRevision, error)
+	ImportRecipeDataBundle(ctx context.Context, data models.ExportedData) (importedRecipes int, importedIngredients int, importedLinks int, err error)
+}
+
+// IngredientRepository stores the canonical ingredients recipes are linked to.
+type IngredientRepository interface {
+	SearchIngredients(ctx context.Context, query string, limit int) ([]models.IngredientSuggestion, error)
+	GetIngredientSummaries(ctx context.Context) ([]models.IngredientSummary, error)
+	RenameIngredient(ctx context.Context, id string, name string) (*models.IngredientSummary, error)
+	MergeIngredients(ctx context.Context, targetID string, sourceIDs []string) (*models.IngredientMergeResult, error)
+	SetIngredientParent(ctx context.Context, id string, parentID string) (*models.IngredientSummary, error)
+
+	GetAllIngredients(ctx context.Context) ([]models.Ingredient, error)
+	GetAllRecipeIngredients(ctx context.Context) ([]models.RecipeIngredient, error)
+	GetAllIngredientAliases(ctx context.Context) ([]models.IngredientAlias, error)
+}
+
+// CommentRepository stores comments on recipes.
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment models.Comment) (*models.Comment, error)
+	GetCommentsByRecipeID(ctx context.Context, recipeID string) ([]models.Comment, error)
+	GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error)
+	UpdateComment(ctx context.Context, comment models.Comment) (*models.Comment, error)
+	DeleteComment(ctx context.Context, commentID string) error
+	GetAllComments(ctx context.Context) ([]models.Comment, error)
+}
+
+// MealPlanRepository stores meal plan entries.
+type MealPlanRepository interface {
+	CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error)
+	GetMealPlanEntriesByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.MealPlanEntry, error)
+	GetMealPlanEntry(ctx context.Context, entryID string) (*models.MealPlanEntry, error)
+	// UpdateMealPlanEntry changes an entry's date, slot, servings and notes, and moves it to
+	// position SortOrder within its meal; a negative SortOrder puts it last.
+	UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error)
+	// ApplyMealPlanEntryOperations moves and copies entries in one transaction, returning
+	// the moved entries and the copies in operation order.
+	ApplyMealPlanEntryOperations(ctx context.Context, operations []models.MealPlanEntryOperation) ([]models.MealPlanEntry, error)
+	DeleteMealPlanEntry(ctx context.Context, entryID string) error
+	GetAllMealPlanEntries(ctx context.Context) ([]models.MealPlanEntry, error)
+}
+
+// NutritionRepository stores the food composition dataset, the food each ingredient is mapped
+// to, and the nutrition computed for recipes.
+type NutritionRepository interface {
+	UpsertFoods(ctx context.Context, foods []models.Food) (int, error)
+	SearchFoods(ctx context.Context, query string, limit int) ([]models.Food, error)
+	GetIngredientFoods(ctx context.Context, ingredientIDs []string) ([]models.IngredientFood, error)
+	SetIngredientFood(ctx context.Context, mapping models.IngredientFood) (*models.IngredientFood, error)
+	DeleteIngredientFood(ctx context.Context, ingredientID string) error
+
+	GetRecipeNutrition(ctx context.Context, recipeID string) (*models.RecipeNutrition, error) // nil when not computed yet
+	SaveRecipeNutrition(ctx context.Context, nutrition models.RecipeNutrition) error
+
+	GetAllIngredientFoods(ctx context.Context) ([]models.IngredientFood, error)
+}
+
+// DietaryRepository stores the allergen and meat/fish flags of ingredients and the manual
+// overrides of recipes, from which recipes' allergens and diets are derived.
+type DietaryRepository interface {
+	SetIngredientDietaryFlags(ctx context.Context, ingredientID string, flags []string) (*models.IngredientDietaryFlags, error)
+	GetRecipeDietary(ctx context.Context, recipeID string) (*models.RecipeDietary, error) // nil when missing or in the trash; without Allergens and Diets
+	SetRecipeDietaryOverrides(ctx context.Context, recipeID string, overrides map[string]bool) (*models.RecipeDietary, error)
+
+	GetAllIngredientDietaryFlags(ctx context.Context) ([]models.IngredientDietaryFlags, error)
+	GetAllRecipeDietaryOverrides(ctx context.Context) ([]models.RecipeDietaryOverrides, error)
+}
+
+// SubstitutionRepository stores the substitutions knowledge base: which ingredients can stand
+// in for an ingredient, and in what amounts.
+type SubstitutionRepository interface {
+	GetAllSubstitutions(ctx context.Context) ([]models.Substitution, error)
+	GetSubstitutionsForIngredients(ctx context.Context, ingredientIDs []string) ([]models.Substitution, error)
+	CreateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error)
+	UpdateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error)
+	DeleteSubstitution(ctx context.Context, id string) error
+}
+
+// PantryRepository stores the ingredients we have at home and the staples that always count
+// as owned, and ranks recipes by how much of them the pantry covers.
+type PantryRepository interface {
+	GetPantryItems(ctx context.Context) ([]models.PantryItem, error)
+	CreatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error)
+	UpdatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error)
+	DeletePantryItem(ctx context.Context, id string) error
+
+	GetPantryStaples(ctx context.Context) ([]models.PantryStaple, error)
+	SetPantryStaples(ctx context.Context, names []string) ([]models.PantryStaple, error)
+
+	// GetCookableRecipes ranks live recipes by the fraction of their ingredients owned on
+	// today, skipping those missing more than maxMissing ingredients unless it is negative.
+	GetCookableRecipes(ctx context.Context, today time.Time, maxMissing int, page int, pageSize int) ([]models.CookableRecipe, int, error)
+	// GetOwnedIngredientIDs returns the ingredients owned on today, as GetCookableRecipes counts them.
+	GetOwnedIngredientIDs(ctx context.Context, today time.Time) ([]string, error)
+}
+
+// ShoppingListRepository stores shopping lists and the store aisles ingredients are shopped
+// in, by which shopping lists are grouped.
+type ShoppingListRepository interface {
+	GetAllIngredientAisles(ctx context.Context) ([]models.IngredientAisle, error)
+	SetIngredientAisle(ctx context.Context, ingredientID string, aisle string) (*models.IngredientAisle, error)
+	DeleteIngredientAisle(ctx context.Context, ingredientID string) error
+
+	// GetShoppingLists fetches every list without its items, newest first.
+	GetShoppingLists(ctx context.Context) ([]models.ShoppingList, error)
+	// GetAllShoppingLists fetches every list with its items, for export.
+	GetAllShoppingLists(ctx context.Context) ([]models.ShoppingList, error)
+	GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error)
+	CreateShoppingList(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error)
+	DeleteShoppingList(ctx context.Context, id string) error
+
+	// The changes below are made to a list as of version, and fail with a version conflict
+	// when the list has changed since. They return the changed list.
+	RenameShoppingList(ctx context.Context, id string, version int, name string) (*models.ShoppingList, error)
+	AddShoppingListItem(ctx context.Context, listID string, version int, item models.ShoppingListItem) (*models.ShoppingList, error)
+	DeleteShoppingListItem(ctx context.Context, listID string, version int, itemID string) (*models.ShoppingList, error)
+	ReorderShoppingListItems(ctx context.Context, listID string, version int, itemIDs []string) (*models.ShoppingList, error)
+	// UpdateShoppingListItem changes an item as of item.Version, the item's own version, and
+	// fails with a version conflict when that item has changed since. Changes to other items
+	// and to the list do not conflict with it. It returns the changed list.
+	UpdateShoppingListItem(ctx context.Context, listID string, item models.ShoppingListItem) (*models.ShoppingList, error)
+}
+
+// Repositories bundles one implementation of each repository, as handed to router.SetupRouter.
+type Repositories struct {
+	Recipes       RecipeRepository
+	Ingredients   IngredientRepository
+	Comments      CommentRepository
+	MealPlans     MealPlanRepository
+	Nutrition     NutritionRepository
+	Dietary       DietaryRepository
+	Substitutions SubstitutionRepository
+	Pantry        PantryRepository
+	ShoppingLists ShoppingListRepository
+}
+
+// NormalizeIngredientName mirrors the normalize_ingredient_name SQL function that fills
+// ingredients.normalized_name, for stores without the trigger. The SQL function's
+// descriptor and quantity patterns use \b, which PostgreSQL reads as a backspace rather
+// than a word boundary, so in effect it only lowercases and collapses whitespace.
+func NormalizeIngredientName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// MergeSourceIDs validates the ingredients to merge into targetID, dropping blanks and
+// duplicates. At least one is required, and the target cannot be one of them.
+func MergeSourceIDs(targetID string, sourceIDs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range sourceIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		if id == targetID {
+			return nil, fmt.Errorf("cannot merge ingredient %s into itself", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("cannot merge: no source ingredients given")
+	}
+	return ids, nil
+}
@@ -0,0 +1,568 @@
+package database
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"time"
+)
+
+// Postgres implements every repository on top of the package-level DB connection pool
+// opened by InitPostgreSQLDB. Each call is limited to QueryTimeout.
+type Postgres struct{}
+
+var (
+	_ RecipeRepository       = Postgres{}
+	_ IngredientRepository   = Postgres{}
+	_ CommentRepository      = Postgres{}
+	_ MealPlanRepository     = Postgres{}
+	_ NutritionRepository    = Postgres{}
+	_ DietaryRepository      = Postgres{}
+	_ SubstitutionRepository = Postgres{}
+	_ PantryRepository       = Postgres{}
+	_ ShoppingListRepository = Postgres{}
+)
+
+// PostgresRepositories returns the PostgreSQL implementation of every repository.
+func PostgresRepositories() Repositories {
+	return Repositories{Recipes: Postgres{}, Ingredients: Postgres{}, Comments: Postgres{}, MealPlans: Postgres{}, Nutrition: Postgres{}, Dietary: Postgres{}, Substitutions: Postgres{}, Pantry: Postgres{}, ShoppingLists: Postgres{}}
+}
+
+// RecipeRepository
+
+func (Postgres) RecipeExistsByID(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return RecipeExistsByID(ctx, id)
+}
+
+func (Postgres) GetRecipeByID(ctx context.Context, id string) (*models.Recipe, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipeByID(ctx, id)
+}
+
+func (Postgres) GetAllRecipes(ctx context.Context, filter RecipeFilter, page int, pageSize int) ([]models.Recipe, int, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipes(ctx, filter, page, pageSize)
+}
+
+func (Postgres) CreateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateRecipe(ctx, recipe, editor)
+}
+
+func (Postgres) UpdateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateRecipe(ctx, recipe, editor)
+}
+
+func (Postgres) DeleteRecipe(ctx context.Context, id string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteRecipe(ctx, id)
+}
+
+func (Postgres) GetRecipeRevisions(ctx context.Context, recipeID string) ([]models.RecipeRevision, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipeRevisions(ctx, recipeID)
+}
+
+func (Postgres) GetRecipeRevision(ctx context.Context, recipeID string, revision int) (*models.RecipeRevision, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipeRevision(ctx, recipeID, revision)
+}
+
+func (Postgres) GetDeletedRecipes(ctx context.Context) ([]models.Recipe, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetDeletedRecipes(ctx)
+}
+
+func (Postgres) RestoreDeletedRecipe(ctx context.Context, id string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return RestoreDeletedRecipe(ctx, id)
+}
+
+func (Postgres) GetDeletedRecipeIDsBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetDeletedRecipeIDsBefore(ctx, cutoff)
+}
+
+func (Postgres) PurgeRecipe(ctx context.Context, id string) ([]string, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return PurgeRecipe(ctx, id)
+}
+
+func (Postgres) GetRecipePhotos(ctx context.Context, recipeID string) ([]models.RecipePhoto, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipePhotos(ctx, recipeID)
+}
+
+func (Postgres) AddRecipePhoto(ctx context.Context, photo models.RecipePhoto) (*models.RecipePhoto, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return AddRecipePhoto(ctx, photo)
+}
+
+func (Postgres) UpdateRecipePhoto(ctx context.Context, recipeID string, photoID string, caption *string, makeCover bool) (*models.RecipePhoto, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateRecipePhoto(ctx, recipeID, photoID, caption, makeCover)
+}
+
+func (Postgres) ReorderRecipePhotos(ctx context.Context, recipeID string, photoIDs []string) ([]models.RecipePhoto, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return ReorderRecipePhotos(ctx, recipeID, photoIDs)
+}
+
+func (Postgres) DeleteRecipePhoto(ctx context.Context, recipeID string, photoID string) (string, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteRecipePhoto(ctx, recipeID, photoID)
+}
+
+func (Postgres) GetAllTags(ctx context.Context, category string) ([]models.Tag, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllTags(ctx, category)
+}
+
+func (Postgres) GetTagByID(ctx context.Context, tagID string) (*models.Tag, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetTagByID(ctx, tagID)
+}
+
+func (Postgres) CreateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateTag(ctx, tag)
+}
+
+func (Postgres) UpdateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateTag(ctx, tag)
+}
+
+func (Postgres) DeleteTag(ctx context.Context, tagID string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteTag(ctx, tagID)
+}
+
+func (Postgres) GetAllRecipesForExport(ctx context.Context) ([]models.Recipe, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipesForExport(ctx)
+}
+
+func (Postgres) GetAllRecipeTags(ctx context.Context) ([]models.RecipeTag, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipeTags(ctx)
+}
+
+func (Postgres) GetAllRecipePhotos(ctx context.Context) ([]models.RecipePhoto, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipePhotos(ctx)
+}
+
+func (Postgres) GetAllRecipeRevisions(ctx context.Context) ([]models.RecipeRevision, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipeRevisions(ctx)
+}
+
+func (Postgres) ImportRecipeDataBundle(ctx context.Context, data models.ExportedData) (int, int, int, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return ImportRecipeDataBundle(ctx, data)
+}
+
+// IngredientRepository
+
+func (Postgres) SearchIngredients(ctx context.Context, query string, limit int) ([]models.IngredientSuggestion, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SearchIngredients(ctx, query, limit)
+}
+
+func (Postgres) GetIngredientSummaries(ctx context.Context) ([]models.IngredientSummary, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetIngredientSummaries(ctx)
+}
+
+func (Postgres) RenameIngredient(ctx context.Context, id string, name string) (*models.IngredientSummary, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return RenameIngredient(ctx, id, name)
+}
+
+func (Postgres) MergeIngredients(ctx context.Context, targetID string, sourceIDs []string) (*models.IngredientMergeResult, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return MergeIngredients(ctx, targetID, sourceIDs)
+}
+
+func (Postgres) SetIngredientParent(ctx context.Context, id string, parentID string) (*models.IngredientSummary, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetIngredientParent(ctx, id, parentID)
+}
+
+func (Postgres) GetAllIngredients(ctx context.Context) ([]models.Ingredient, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllIngredients(ctx)
+}
+
+func (Postgres) GetAllRecipeIngredients(ctx context.Context) ([]models.RecipeIngredient, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipeIngredients(ctx)
+}
+
+func (Postgres) GetAllIngredientAliases(ctx context.Context) ([]models.IngredientAlias, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllIngredientAliases(ctx)
+}
+
+// CommentRepository
+
+func (Postgres) CreateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateComment(ctx, comment)
+}
+
+func (Postgres) GetCommentsByRecipeID(ctx context.Context, recipeID string) ([]models.Comment, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetCommentsByRecipeID(ctx, recipeID)
+}
+
+func (Postgres) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetCommentByID(ctx, commentID)
+}
+
+func (Postgres) UpdateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateComment(ctx, comment)
+}
+
+func (Postgres) DeleteComment(ctx context.Context, commentID string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteComment(ctx, commentID)
+}
+
+func (Postgres) GetAllComments(ctx context.Context) ([]models.Comment, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllComments(ctx)
+}
+
+// MealPlanRepository
+
+func (Postgres) CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateMealPlanEntry(ctx, entry)
+}
+
+func (Postgres) GetMealPlanEntriesByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetMealPlanEntriesByDateRange(ctx, startDate, endDate)
+}
+
+func (Postgres) GetMealPlanEntry(ctx context.Context, entryID string) (*models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetMealPlanEntry(ctx, entryID)
+}
+
+func (Postgres) UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateMealPlanEntry(ctx, entry)
+}
+
+func (Postgres) ApplyMealPlanEntryOperations(ctx context.Context, operations []models.MealPlanEntryOperation) ([]models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return ApplyMealPlanEntryOperations(ctx, operations)
+}
+
+func (Postgres) DeleteMealPlanEntry(ctx context.Context, entryID string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteMealPlanEntry(ctx, entryID)
+}
+
+func (Postgres) GetAllMealPlanEntries(ctx context.Context) ([]models.MealPlanEntry, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllMealPlanEntries(ctx)
+}
+
+// NutritionRepository
+
+func (Postgres) UpsertFoods(ctx context.Context, foods []models.Food) (int, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpsertFoods(ctx, foods)
+}
+
+func (Postgres) SearchFoods(ctx context.Context, query string, limit int) ([]models.Food, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SearchFoods(ctx, query, limit)
+}
+
+func (Postgres) GetIngredientFoods(ctx context.Context, ingredientIDs []string) ([]models.IngredientFood, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetIngredientFoods(ctx, ingredientIDs)
+}
+
+func (Postgres) SetIngredientFood(ctx context.Context, mapping models.IngredientFood) (*models.IngredientFood, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetIngredientFood(ctx, mapping)
+}
+
+func (Postgres) DeleteIngredientFood(ctx context.Context, ingredientID string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteIngredientFood(ctx, ingredientID)
+}
+
+func (Postgres) GetRecipeNutrition(ctx context.Context, recipeID string) (*models.RecipeNutrition, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipeNutrition(ctx, recipeID)
+}
+
+func (Postgres) SaveRecipeNutrition(ctx context.Context, nutrition models.RecipeNutrition) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SaveRecipeNutrition(ctx, nutrition)
+}
+
+func (Postgres) GetAllIngredientFoods(ctx context.Context) ([]models.IngredientFood, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllIngredientFoods(ctx)
+}
+
+// DietaryRepository
+
+func (Postgres) SetIngredientDietaryFlags(ctx context.Context, ingredientID string, flags []string) (*models.IngredientDietaryFlags, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetIngredientDietaryFlags(ctx, ingredientID, flags)
+}
+
+func (Postgres) GetRecipeDietary(ctx context.Context, recipeID string) (*models.RecipeDietary, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetRecipeDietary(ctx, recipeID)
+}
+
+func (Postgres) SetRecipeDietaryOverrides(ctx context.Context, recipeID string, overrides map[string]bool) (*models.RecipeDietary, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetRecipeDietaryOverrides(ctx, recipeID, overrides)
+}
+
+func (Postgres) GetAllIngredientDietaryFlags(ctx context.Context) ([]models.IngredientDietaryFlags, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllIngredientDietaryFlags(ctx)
+}
+
+func (Postgres) GetAllRecipeDietaryOverrides(ctx context.Context) ([]models.RecipeDietaryOverrides, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllRecipeDietaryOverrides(ctx)
+}
+
+// SubstitutionRepository
+
+func (Postgres) GetAllSubstitutions(ctx context.Context) ([]models.Substitution, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllSubstitutions(ctx)
+}
+
+func (Postgres) GetSubstitutionsForIngredients(ctx context.Context, ingredientIDs []string) ([]models.Substitution, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetSubstitutionsForIngredients(ctx, ingredientIDs)
+}
+
+func (Postgres) CreateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateSubstitution(ctx, sub)
+}
+
+func (Postgres) UpdateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateSubstitution(ctx, sub)
+}
+
+func (Postgres) DeleteSubstitution(ctx context.Context, id string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteSubstitution(ctx, id)
+}
+
+// PantryRepository
+
+func (Postgres) GetPantryItems(ctx context.Context) ([]models.PantryItem, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetPantryItems(ctx)
+}
+
+func (Postgres) CreatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreatePantryItem(ctx, item)
+}
+
+func (Postgres) UpdatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdatePantryItem(ctx, item)
+}
+
+func (Postgres) DeletePantryItem(ctx context.Context, id string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeletePantryItem(ctx, id)
+}
+
+func (Postgres) GetPantryStaples(ctx context.Context) ([]models.PantryStaple, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetPantryStaples(ctx)
+}
+
+func (Postgres) SetPantryStaples(ctx context.Context, names []string) ([]models.PantryStaple, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetPantryStaples(ctx, names)
+}
+
+func (Postgres) GetCookableRecipes(ctx context.Context, today time.Time, maxMissing int, page int, pageSize int) ([]models.CookableRecipe, int, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetCookableRecipes(ctx, today, maxMissing, page, pageSize)
+}
+
+func (Postgres) GetOwnedIngredientIDs(ctx context.Context, today time.Time) ([]string, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetOwnedIngredientIDs(ctx, today)
+}
+
+// ShoppingListRepository
+
+func (Postgres) GetAllIngredientAisles(ctx context.Context) ([]models.IngredientAisle, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllIngredientAisles(ctx)
+}
+
+func (Postgres) SetIngredientAisle(ctx context.Context, ingredientID string, aisle string) (*models.IngredientAisle, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return SetIngredientAisle(ctx, ingredientID, aisle)
+}
+
+func (Postgres) DeleteIngredientAisle(ctx context.Context, ingredientID string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteIngredientAisle(ctx, ingredientID)
+}
+
+func (Postgres) GetShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetShoppingLists(ctx)
+}
+
+func (Postgres) GetAllShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetAllShoppingLists(ctx)
+}
+
+func (Postgres) GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return GetShoppingList(ctx, id)
+}
+
+func (Postgres) CreateShoppingList(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return CreateShoppingList(ctx, list)
+}
+
+func (Postgres) DeleteShoppingList(ctx context.Context, id string) error {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteShoppingList(ctx, id)
+}
+
+func (Postgres) RenameShoppingList(ctx context.Context, id string, version int, name string) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return RenameShoppingList(ctx, id, version, name)
+}
+
+func (Postgres) AddShoppingListItem(ctx context.Context, listID string, version int, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return AddShoppingListItem(ctx, listID, version, item)
+}
+
+func (Postgres) UpdateShoppingListItem(ctx context.Context, listID string, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return UpdateShoppingListItem(ctx, listID, item)
+}
+
+func (Postgres) DeleteShoppingListItem(ctx context.Context, listID string, version int, itemID string) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return DeleteShoppingListItem(ctx, listID, version, itemID)
+}
+
+func (Postgres) ReorderShoppingListItems(ctx context.Context, listID string, version int, itemIDs []string) (*models.ShoppingList, error) {
+	ctx, cancel := WithQueryTimeout(ctx)
+	defer cancel()
+	return ReorderShoppingListItems(ctx, listID, version, itemIDs)
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const revisionSelectColumns = `id, recipe_id, revision, name, method, ingredients, COALESCE(servings, 0), COALESCE(yield, ''),
+	COALESCE(prep_time_minutes, 0), COALESCE(cook_time_minutes, 0), COALESCE(rest_time_minutes, 0),
+	tags, COALESCE(photo_filename, ''), COALESCE(editor, ''), created_at`
+
+// scanRevision scans a row selected with revisionSelectColumns.
+func scanRevision(row interface{ Scan(...interface{}) error }) (models.RecipeRevision, error) {
+	var rev models.RecipeRevision
+	var ingredients, tags pq.StringArray
+	err := row.Scan(&rev.ID, &rev.RecipeID, &rev.Revision, &rev.Name, &rev.Method, &ingredients,
+		&rev.Servings, &rev.Yield, &rev.PrepTimeMinutes, &rev.CookTimeMinutes, &rev.RestTimeMinutes,
+		&tags, &rev.PhotoFilename, &rev.Editor, &rev.CreatedAt)
+	rev.Ingredients = []string(ingredients)
+	rev.Tags = []string(tags)
+	return rev, err
+}
+
+// insertRecipeRevisionTx records the recipe's stored state, including its linked
+// ingredients and tags, as its next revision. Callers write the recipes row first,
+// which locks it and keeps revision numbers sequential.
+func insertRecipeRevisionTx(ctx context.Context, tx *sql.Tx, recipeID string, editor string) error {
+	query := `INSERT INTO recipe_revisions (id, recipe_id, revision, name, method, ingredients, servings, yield,
+			prep_time_minutes, cook_time_minutes, rest_time_minutes, tags, photo_filename, editor, created_at)
+		SELECT $1, r.id,
+			(SELECT COALESCE(MAX(rr.revision), 0) + 1 FROM recipe_revisions rr WHERE rr.recipe_id = r.id),
+			r.name, r.method,
+			COALESCE((
+				SELECT array_agg(COALESCE(NULLIF(ri.original_text, ''), TRIM(COALESCE(ri.quantity_text, '') || ' ' || i.name)) ORDER BY ri.sort_order ASC)
+				FROM recipe_ingredients ri
+				JOIN ingredients i ON ri.ingredient_id = i.id
+				WHERE ri.recipe_id = r.id
+			), '{}'),
+			r.servings, r.yield, r.prep_time_minutes, r.cook_time_minutes, r.rest_time_minutes,
+			COALESCE((
+				SELECT array_agg(t.name ORDER BY LOWER(t.name) ASC)
+				FROM recipe_tags rt
+				JOIN tags t ON rt.tag_id = t.id
+				WHERE rt.recipe_id = r.id
+			), '{}'),
+			r.photo_filename, $3, $4
+		FROM recipes r
+		WHERE r.id = $2`
+	_, err := tx.ExecContext(ctx, query, uuid.NewString(), recipeID, nullIfEmpty(editor), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record revision for recipe ID %s: %w", recipeID, err)
+	}
+	return nil
+}
+
+// GetRecipeRevisions retrieves every revision of a recipe, newest first.
+func GetRecipeRevisions(ctx context.Context, recipeID string) ([]models.RecipeRevision, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT `+revisionSelectColumns+` FROM recipe_revisions
+		WHERE recipe_id = $1
+		ORDER BY revision DESC`, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions for recipe ID %s: %w", recipeID, err)
+	}
+	defer rows.Close()
+
+	var revisions []models.RecipeRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revision rows: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetAllRecipeRevisions fetches every revision of every recipe, for export.
+func GetAllRecipeRevisions(ctx context.Context) ([]models.RecipeRevision, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT `+revisionSelectColumns+` FROM recipe_revisions
+		ORDER BY recipe_id ASC, revision ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying recipe_revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.RecipeRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning recipe_revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe_revision rows: %w", err)
+	}
+	return revisions, nil
+}
+
+// insertImportedRevisionTx adds a revision from an import file to a recipe created by the
+// import, keeping its number, ID and timestamp. Operates within a transaction.
+func insertImportedRevisionTx(ctx context.Context, tx *sql.Tx, rev models.RecipeRevision, dbRecipeID string) error {
+	createdAt := rev.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	query := `INSERT INTO recipe_revisions (id, recipe_id, revision, name, method, ingredients, servings, yield,
+			prep_time_minutes, cook_time_minutes, rest_time_minutes, tags, photo_filename, editor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT DO NOTHING`
+	_, err := tx.ExecContext(ctx, query, importedID(rev.ID), dbRecipeID, rev.Revision, rev.Name, rev.Method,
+		pq.Array(append([]string{}, rev.Ingredients...)), nullIfZero(rev.Servings), nullIfEmpty(rev.Yield),
+		nullIfZero(rev.PrepTimeMinutes), nullIfZero(rev.CookTimeMinutes), nullIfZero(rev.RestTimeMinutes),
+		pq.Array(append([]string{}, rev.Tags...)), nullIfEmpty(rev.PhotoFilename), nullIfEmpty(rev.Editor), createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert revision %d for recipe DB ID %s: %w", rev.Revision, dbRecipeID, err)
+	}
+	return nil
+}
+
+// GetRecipeRevision retrieves a single revision of a recipe by its number.
+// A revision number of 0 returns the latest revision.
+func GetRecipeRevision(ctx context.Context, recipeID string, revision int) (*models.RecipeRevision, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT ` + revisionSelectColumns + ` FROM recipe_revisions
+		WHERE recipe_id = $1 AND ($2 = 0 OR revision = $2)
+		ORDER BY revision DESC
+		LIMIT 1`
+	rev, err := scanRevision(DB.QueryRowContext(ctx, query, recipeID, revision))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("revision %d of recipe %s not found", revision, recipeID)
+		}
+		return nil, fmt.Errorf("failed to query revision %d of recipe %s: %w", revision, recipeID, err)
+	}
+	return &rev, nil
+}
@@ -3,15 +3,28 @@
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Trigram matching for ingredient autocomplete
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 -- Create recipes table
 CREATE TABLE IF NOT EXISTS recipes (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     name VARCHAR(255) NOT NULL,
     method TEXT NOT NULL,
+    servings INTEGER CHECK (servings IS NULL OR servings > 0), -- Servings as written, used for scaling
+    yield VARCHAR(100), -- Free-text yield, e.g. "24 cookies"
+    prep_time_minutes INTEGER CHECK (prep_time_minutes IS NULL OR prep_time_minutes >= 0),
+    cook_time_minutes INTEGER CHECK (cook_time_minutes IS NULL OR cook_time_minutes >= 0),
+    rest_time_minutes INTEGER CHECK (rest_time_minutes IS NULL OR rest_time_minutes >= 0),
+    total_time_minutes INTEGER GENERATED ALWAYS AS (
+        CASE WHEN prep_time_minutes IS NULL AND cook_time_minutes IS NULL AND rest_time_minutes IS NULL THEN NULL
+             ELSE COALESCE(prep_time_minutes, 0) + COALESCE(cook_time_minutes, 0) + COALESCE(rest_time_minutes, 0)
+        END
+    ) STORED, -- Sum of the known times, NULL if none are set
     photo_filename VARCHAR(255),
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE -- Set while the recipe is in the trash
 );
 
 -- Create ingredients table
@@ -19,8 +32,10 @@ CREATE TABLE IF NOT EXISTS ingredients (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     name VARCHAR(255) NOT NULL UNIQUE,
     normalized_name VARCHAR(255) NOT NULL,
+    parent_id UUID REFERENCES ingredients(id) ON DELETE SET NULL, -- Broader ingredient: cheddar -> cheese -> dairy
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ingredients_parent_not_self CHECK (parent_id <> id)
 );
 
 -- Ensure 'updated_at' column exists in 'ingredients' table for existing tables
@@ -39,24 +54,41 @@ BEGIN
     END IF;
 END $$;
 
+-- Create ingredient_aliases table (other names an ingredient is found by)
+CREATE TABLE IF NOT EXISTS ingredient_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL, -- Set by trigger_set_normalized_alias_name
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 -- Create recipe_ingredients junction table
 CREATE TABLE IF NOT EXISTS recipe_ingredients (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
     ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    original_text TEXT, -- Ingredient line exactly as entered, e.g. "2 cups chopped tomatoes"
     quantity_text VARCHAR(500), -- Store original quantity string like "2 cups", "1 large"
-    sort_order INTEGER NOT NULL DEFAULT 0,
-    UNIQUE(recipe_id, ingredient_id) -- Prevent duplicate ingredient assignments
+    quantity NUMERIC(12, 4), -- Parsed amount (lower bound for ranges)
+    quantity_max NUMERIC(12, 4), -- Upper bound for ranges like "1-2"
+    unit VARCHAR(50), -- Canonical unit name (g, ml, cup, tbsp...)
+    preparation TEXT, -- Preparation notes such as "finely chopped"
+    sort_order INTEGER NOT NULL DEFAULT 0 -- Position of the line; a recipe can name an ingredient on several lines
 );
 
 -- Create meal_plan_entries table
 CREATE TABLE IF NOT EXISTS meal_plan_entries (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    recipe_id TEXT NOT NULL, -- Changed from UUID to TEXT to allow custom recipe names
+    recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE, -- NULL for free-text entries
+    title TEXT CHECK (title <> ''), -- Free text such as "Leftovers"; NULL when planning a recipe
     date DATE NOT NULL,
-    notes TEXT NULL, -- Added notes column
+    slot VARCHAR(50) NOT NULL DEFAULT 'dinner' CHECK (slot <> ''), -- breakfast, lunch, dinner, snack or a custom name
+    servings INTEGER CHECK (servings > 0), -- NULL means the recipe's own servings
+    notes TEXT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0, -- Order within its meal on the day
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    UNIQUE(recipe_id, date) -- Prevent duplicate recipe assignments for the same date
+    CONSTRAINT meal_plan_entries_recipe_or_title CHECK ((recipe_id IS NULL) <> (title IS NULL))
 );
 
 -- Create comments table
@@ -69,31 +101,178 @@ CREATE TABLE IF NOT EXISTS comments (
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
--- Remove the foreign key constraint if it exists to allow custom recipe names
--- This allows meal_plan_entries.recipe_id to be either a UUID (for real recipes) or a custom string
-DO $$
-BEGIN
-    -- Drop foreign key constraint if it exists
-    IF EXISTS (
-        SELECT 1 FROM information_schema.table_constraints 
-        WHERE constraint_name = 'meal_plan_entries_recipe_id_fkey' 
-        AND table_name = 'meal_plan_entries'
-    ) THEN
-        ALTER TABLE meal_plan_entries DROP CONSTRAINT meal_plan_entries_recipe_id_fkey;
-        RAISE NOTICE 'Foreign key constraint meal_plan_entries_recipe_id_fkey dropped to allow custom recipe names.';
-    END IF;
-    
-    -- Change column type from UUID to TEXT if it's currently UUID
-    IF EXISTS (
-        SELECT 1 FROM information_schema.columns 
-        WHERE table_name = 'meal_plan_entries' 
-        AND column_name = 'recipe_id' 
-        AND data_type = 'uuid'
-    ) THEN
-        ALTER TABLE meal_plan_entries ALTER COLUMN recipe_id TYPE TEXT;
-        RAISE NOTICE 'Column recipe_id in meal_plan_entries changed from UUID to TEXT to support custom recipe names.';
-    END IF;
-END $$;
+-- Create tags table (user-defined labels such as "vegetarian" or "weeknight")
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(50), -- Optional grouping, e.g. "diet", "cuisine", "course"
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create recipe_tags junction table
+CREATE TABLE IF NOT EXISTS recipe_tags (
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (recipe_id, tag_id)
+);
+
+-- Create recipe_revisions table (a snapshot of the recipe after every save)
+CREATE TABLE IF NOT EXISTS recipe_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL, -- 1 for the recipe as created, incremented on every update
+    name VARCHAR(255) NOT NULL,
+    method TEXT NOT NULL,
+    ingredients TEXT[] NOT NULL DEFAULT '{}', -- Ingredient lines as entered
+    servings INTEGER,
+    yield VARCHAR(100),
+    prep_time_minutes INTEGER,
+    cook_time_minutes INTEGER,
+    rest_time_minutes INTEGER,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    photo_filename VARCHAR(255),
+    editor VARCHAR(255), -- Who saved this revision, if known
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(recipe_id, revision)
+);
+
+-- Create recipe_photos table (recipes.photo_filename mirrors the cover photo)
+CREATE TABLE IF NOT EXISTS recipe_photos (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    caption TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(recipe_id, filename)
+);
+
+-- Create nutrients table (food composition data, e.g. USDA FoodData Central; amounts per 100 g)
+CREATE TABLE IF NOT EXISTS nutrients (
+    id VARCHAR(64) PRIMARY KEY, -- Identifier in the dataset, e.g. the fdc_id
+    description TEXT NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    calories NUMERIC(10, 3) NOT NULL DEFAULT 0, -- kcal
+    protein_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    fat_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    carbohydrate_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    fiber_g NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    sodium_mg NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create ingredient_foods table (the food whose composition an ingredient has)
+CREATE TABLE IF NOT EXISTS ingredient_foods (
+    ingredient_id UUID PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    food_id VARCHAR(64) NOT NULL REFERENCES nutrients(id) ON DELETE CASCADE,
+    grams_per_ml NUMERIC(10, 4) CHECK (grams_per_ml > 0), -- Density, for amounts by volume
+    grams_per_piece NUMERIC(10, 3) CHECK (grams_per_piece > 0), -- Weight of one item, for counted amounts
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create recipe_nutrition table (computed nutrition, valid while recipes.updated_at = recipe_updated_at)
+CREATE TABLE IF NOT EXISTS recipe_nutrition (
+    recipe_id UUID PRIMARY KEY REFERENCES recipes(id) ON DELETE CASCADE,
+    recipe_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    nutrition JSONB NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create ingredient_dietary_flags table (allergens, meat and fish an ingredient carries; inherited down the taxonomy)
+CREATE TABLE IF NOT EXISTS ingredient_dietary_flags (
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    flag VARCHAR(20) NOT NULL CHECK (flag IN ('gluten', 'dairy', 'egg', 'nuts', 'peanuts', 'soy', 'shellfish', 'sesame', 'meat', 'fish')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ingredient_id, flag)
+);
+
+-- Create recipe_dietary_overrides table (present = TRUE adds a flag to a recipe, FALSE removes a derived one)
+CREATE TABLE IF NOT EXISTS recipe_dietary_overrides (
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    flag VARCHAR(20) NOT NULL CHECK (flag IN ('gluten', 'dairy', 'egg', 'nuts', 'peanuts', 'soy', 'shellfish', 'sesame', 'meat', 'fish')),
+    present BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (recipe_id, flag)
+);
+
+-- Create substitutions table (an amount of an ingredient that the replacements below can stand in for)
+CREATE TABLE IF NOT EXISTS substitutions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 3) NOT NULL CHECK (quantity > 0),
+    unit VARCHAR(20), -- Canonical unit name; NULL for counted items
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create substitution_replacements table (the ingredients a substitution uses instead, in order)
+CREATE TABLE IF NOT EXISTS substitution_replacements (
+    substitution_id UUID NOT NULL REFERENCES substitutions(id) ON DELETE CASCADE,
+    sort_order INTEGER NOT NULL,
+    ingredient_id UUID NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 3) NOT NULL CHECK (quantity > 0),
+    unit VARCHAR(20),
+    PRIMARY KEY (substitution_id, sort_order)
+);
+
+-- Create pantry_items table (ingredients we have at home, one item per ingredient)
+CREATE TABLE IF NOT EXISTS pantry_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ingredient_id UUID NOT NULL UNIQUE REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 3) CHECK (quantity >= 0), -- Amount left; NULL when not tracked
+    unit VARCHAR(20), -- Canonical unit name; NULL for counted items
+    expires_on DATE, -- Last usable day
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create pantry_staples table (ingredients that always count as owned, such as salt and water)
+CREATE TABLE IF NOT EXISTS pantry_staples (
+    ingredient_id UUID PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create ingredient_aisles table (store aisle of an ingredient and, through the taxonomy, the ingredients below it)
+CREATE TABLE IF NOT EXISTS ingredient_aisles (
+    ingredient_id UUID PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    aisle VARCHAR(100) NOT NULL CHECK (aisle <> ''),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create shopping_lists table (stored shopping lists; version is bumped on every change to the list or its set
+-- and order of items, for optimistic concurrency)
+CREATE TABLE IF NOT EXISTS shopping_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    start_date DATE, -- Meal plan range the list was generated from; NULL for lists started empty
+    end_date DATE,
+    recipes TEXT[] NOT NULL DEFAULT '{}', -- Names of the planned recipes
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create shopping_list_items table (things to buy, generated or added by hand, in display order)
+CREATE TABLE IF NOT EXISTS shopping_list_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    shopping_list_id UUID NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    ingredient_id UUID REFERENCES ingredients(id) ON DELETE SET NULL, -- NULL for items added by hand
+    name VARCHAR(255) NOT NULL,
+    quantity NUMERIC(10, 3) CHECK (quantity >= 0),
+    unit VARCHAR(20), -- Canonical unit name; NULL for counted items
+    aisle VARCHAR(100) NOT NULL,
+    in_pantry BOOLEAN NOT NULL DEFAULT FALSE,
+    recipes TEXT[] NOT NULL DEFAULT '{}', -- Names of the recipes calling for the item
+    manual BOOLEAN NOT NULL DEFAULT FALSE,
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    bought_by VARCHAR(100),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1 -- Bumped when the item is checked off or edited
+);
 
 -- Create indexes for performance
 
@@ -101,22 +280,61 @@ END $$;
 CREATE INDEX IF NOT EXISTS idx_recipes_name ON recipes USING GIN (to_tsvector('english', name));
 CREATE INDEX IF NOT EXISTS idx_recipes_created_at ON recipes(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_recipes_updated_at ON recipes(updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recipes_total_time ON recipes(total_time_minutes);
+CREATE INDEX IF NOT EXISTS idx_recipes_servings ON recipes(servings);
+CREATE INDEX IF NOT EXISTS idx_recipes_live_name ON recipes(name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_recipes_deleted_at ON recipes(deleted_at) WHERE deleted_at IS NOT NULL;
 
 -- Ingredients indexes
 CREATE INDEX IF NOT EXISTS idx_ingredients_name ON ingredients(name);
 CREATE INDEX IF NOT EXISTS idx_ingredients_normalized_name ON ingredients(normalized_name);
 CREATE INDEX IF NOT EXISTS idx_ingredients_name_gin ON ingredients USING GIN (to_tsvector('english', name));
 CREATE INDEX IF NOT EXISTS idx_ingredients_normalized_name_gin ON ingredients USING GIN (to_tsvector('english', normalized_name));
+CREATE INDEX IF NOT EXISTS idx_ingredients_normalized_name_trgm ON ingredients USING GIN (normalized_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_ingredients_parent_id ON ingredients(parent_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingredient_aliases_normalized_name ON ingredient_aliases(normalized_name);
+CREATE INDEX IF NOT EXISTS idx_ingredient_aliases_ingredient_id ON ingredient_aliases(ingredient_id);
+CREATE INDEX IF NOT EXISTS idx_ingredient_aliases_normalized_name_trgm ON ingredient_aliases USING GIN (normalized_name gin_trgm_ops);
 
 -- Recipe ingredients indexes
 CREATE INDEX IF NOT EXISTS idx_recipe_ingredients_recipe_id ON recipe_ingredients(recipe_id);
 CREATE INDEX IF NOT EXISTS idx_recipe_ingredients_ingredient_id ON recipe_ingredients(ingredient_id);
-CREATE INDEX IF NOT EXISTS idx_recipe_ingredients_sort_order ON recipe_ingredients(recipe_id, sort_order);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_ingredients_line ON recipe_ingredients(recipe_id, sort_order);
+
+-- Tags indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name_lower ON tags(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_tags_category ON tags(category);
+CREATE INDEX IF NOT EXISTS idx_recipe_tags_tag_id ON recipe_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_recipe_photos_recipe_order ON recipe_photos(recipe_id, sort_order);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_photos_one_cover ON recipe_photos(recipe_id) WHERE is_cover;
+
+-- Nutrition indexes
+CREATE INDEX IF NOT EXISTS idx_nutrients_description_trgm ON nutrients USING GIN (description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_ingredient_foods_food_id ON ingredient_foods(food_id);
+
+-- Dietary flag indexes
+CREATE INDEX IF NOT EXISTS idx_ingredient_dietary_flags_flag ON ingredient_dietary_flags(flag);
+
+-- Substitutions indexes
+CREATE INDEX IF NOT EXISTS idx_substitutions_ingredient_id ON substitutions(ingredient_id);
+CREATE INDEX IF NOT EXISTS idx_substitution_replacements_ingredient_id ON substitution_replacements(ingredient_id);
+
+-- Pantry indexes
+CREATE INDEX IF NOT EXISTS idx_pantry_items_expires_on ON pantry_items(expires_on);
+
+-- Ingredient aisles indexes
+CREATE INDEX IF NOT EXISTS idx_ingredient_aisles_aisle ON ingredient_aisles(aisle);
+
+-- Shopping list items indexes
+CREATE INDEX IF NOT EXISTS idx_shopping_list_items_list ON shopping_list_items(shopping_list_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_shopping_list_items_ingredient_id ON shopping_list_items(ingredient_id);
 
 -- Meal plan entries indexes
 CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date ON meal_plan_entries(date DESC);
 CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_recipe_id ON meal_plan_entries(recipe_id);
 CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_recipe ON meal_plan_entries(date, slot, recipe_id); -- A recipe once per meal
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_title ON meal_plan_entries(date, slot, title) WHERE title IS NOT NULL; -- A title once per meal
 
 -- Create a function to automatically update the updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -142,6 +360,11 @@ DROP TRIGGER IF EXISTS update_comments_updated_at ON comments;
 CREATE TRIGGER update_comments_updated_at BEFORE UPDATE ON comments
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+-- Create trigger to automatically update updated_at for tags
+DROP TRIGGER IF EXISTS update_tags_updated_at ON tags;
+CREATE TRIGGER update_tags_updated_at BEFORE UPDATE ON tags
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 -- Create a view for recipes with ingredient count (useful for API responses)
 CREATE OR REPLACE VIEW recipes_with_stats AS
 SELECT 
@@ -169,6 +392,32 @@ LEFT JOIN recipe_ingredients ri ON i.id = ri.ingredient_id
 GROUP BY i.id, i.name, i.normalized_name, i.created_at
 ORDER BY usage_count DESC, i.name;
 
+-- Create a view for the dietary flags of each recipe: those of its ingredients and their
+-- ancestors in the taxonomy, after the recipe's overrides
+CREATE OR REPLACE VIEW recipe_dietary_flags AS
+WITH RECURSIVE ingredient_ancestors(ingredient_id, ancestor_id) AS (
+    SELECT id, id FROM ingredients
+    UNION
+    SELECT a.ingredient_id, i.parent_id
+    FROM ingredient_ancestors a
+    JOIN ingredients i ON i.id = a.ancestor_id
+    WHERE i.parent_id IS NOT NULL
+),
+derived AS (
+    SELECT DISTINCT ri.recipe_id, f.flag
+    FROM recipe_ingredients ri
+    JOIN ingredient_ancestors a ON a.ingredient_id = ri.ingredient_id
+    JOIN ingredient_dietary_flags f ON f.ingredient_id = a.ancestor_id
+)
+SELECT d.recipe_id, d.flag
+FROM derived d
+WHERE NOT EXISTS (
+    SELECT 1 FROM recipe_dietary_overrides o
+    WHERE o.recipe_id = d.recipe_id AND o.flag = d.flag AND NOT o.present
+)
+UNION
+SELECT recipe_id, flag FROM recipe_dietary_overrides WHERE present;
+
 -- Function to normalize ingredient names for consistent searching
 CREATE OR REPLACE FUNCTION normalize_ingredient_name(input_name TEXT)
 RETURNS TEXT AS $$
@@ -201,6 +450,20 @@ CREATE TRIGGER trigger_set_normalized_ingredient_name
     FOR EACH ROW
     EXECUTE FUNCTION set_normalized_ingredient_name();
 
+CREATE OR REPLACE FUNCTION set_normalized_alias_name()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.normalized_name = normalize_ingredient_name(NEW.name);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_set_normalized_alias_name ON ingredient_aliases;
+CREATE TRIGGER trigger_set_normalized_alias_name
+    BEFORE INSERT OR UPDATE ON ingredient_aliases
+    FOR EACH ROW
+    EXECUTE FUNCTION set_normalized_alias_name();
+
 -- Add tsvector column for full-text search on normalized ingredient names
 -- Ensure this is idempotent for repeated script execution
 DO $$
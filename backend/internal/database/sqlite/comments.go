@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+)
+
+const commentSelectColumns = `id, recipe_id, author, content, created_at, updated_at`
+
+// queryComments runs a query selecting commentSelectColumns and collects the rows.
+func (s *Store) queryComments(ctx context.Context, query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
+			&comment.CreatedAt, &comment.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment row: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comment rows: %w", err)
+	}
+	return comments, nil
+}
+
+// CreateComment inserts a new comment into the database.
+func (s *Store) CreateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	comment.CreatedAt = now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	query := `INSERT INTO comments (id, recipe_id, author, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, comment.ID, comment.RecipeID, comment.Author, comment.Content,
+		comment.CreatedAt, comment.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+	return &comment, nil
+}
+
+// GetCommentsByRecipeID retrieves all comments for a given recipe ID.
+func (s *Store) GetCommentsByRecipeID(ctx context.Context, recipeID string) ([]models.Comment, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryComments(ctx, `SELECT `+commentSelectColumns+` FROM comments
+		WHERE recipe_id = ?
+		ORDER BY created_at ASC`, recipeID)
+}
+
+// GetAllComments fetches every comment, for export.
+func (s *Store) GetAllComments(ctx context.Context) ([]models.Comment, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryComments(ctx, `SELECT `+commentSelectColumns+` FROM comments
+		ORDER BY created_at ASC`)
+}
+
+// GetCommentByID retrieves a single comment by its ID.
+func (s *Store) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var comment models.Comment
+	err := s.db.QueryRowContext(ctx, `SELECT `+commentSelectColumns+` FROM comments WHERE id = ?`, commentID).Scan(
+		&comment.ID, &comment.RecipeID, &comment.Author, &comment.Content,
+		&comment.CreatedAt, &comment.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment with ID %s not found", commentID)
+		}
+		return nil, fmt.Errorf("failed to query comment with ID %s: %w", commentID, err)
+	}
+	return &comment, nil
+}
+
+// UpdateComment updates an existing comment's content.
+func (s *Store) UpdateComment(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `UPDATE comments SET content = ?, updated_at = ? WHERE id = ?`,
+		comment.Content, now(), comment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment with ID %s: %w", comment.ID, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected for comment ID %s: %w", comment.ID, err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("comment with ID %s not found for update", comment.ID)
+	}
+	return s.GetCommentByID(ctx, comment.ID)
+}
+
+// DeleteComment deletes a comment from the database by its ID.
+func (s *Store) DeleteComment(ctx context.Context, commentID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment with ID %s: %w", commentID, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for comment ID %s: %w", commentID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment with ID %s not found for deletion", commentID)
+	}
+	return nil
+}
+
+// insertImportedCommentTx adds a comment from an import file to its imported recipe, keeping
+// its ID and timestamps. A comment that is already present is left untouched.
+// Operates within a transaction.
+func insertImportedCommentTx(ctx context.Context, tx *sql.Tx, comment models.Comment, recipeOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, ok := recipeOriginalIDToDbIDMap[comment.RecipeID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", comment.RecipeID)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO comments (id, recipe_id, author, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		importedID(comment.ID), dbRecipeID, comment.Author, comment.Content,
+		timeOrNow(comment.CreatedAt), timeOrNow(comment.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert comment for recipe DB ID %s: %w", dbRecipeID, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestSeededDietaryFlags(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"Pancakes", []string{"200 g flour", "2 eggs", "300 ml milk", "1 tbsp butter, for frying"}, []string{"gluten", "dairy", "egg"}},
+		{"Stir fry", []string{"2 chicken breasts, sliced", "2 tbsp soy sauce", "1 tsp sesame oil"}, []string{"gluten", "soy", "sesame", "meat"}},
+		{"Tomato salad", []string{"4 tomatoes", "2 tbsp olive oil", "salt, to taste"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := store.CreateRecipe(ctx, &models.Recipe{Name: tt.name, Method: "Cook.", Ingredients: tt.lines}, "")
+			if err != nil {
+				t.Fatalf("CreateRecipe: %v", err)
+			}
+			got, err := store.GetRecipeDietary(ctx, recipe.ID)
+			if err != nil || got == nil {
+				t.Fatalf("GetRecipeDietary = %v, %v", got, err)
+			}
+			dietary.SortFlags(tt.want)
+			if !reflect.DeepEqual(got.Flags, tt.want) {
+				t.Errorf("Flags = %v, want %v", got.Flags, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestMergeIngredientsKeepsRecipeLines(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	lines := []string{"2 tbsp butter", "1 cup flour", "1 tbsp unsalted butter, for frying"}
+	recipe, err := store.CreateRecipe(ctx, &models.Recipe{Name: "Pancakes", Method: "Mix, then fry.", Ingredients: lines}, "")
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	other, err := store.CreateRecipe(ctx, &models.Recipe{Name: "Bread", Method: "Knead.", Ingredients: []string{"500 g flour"}}, "")
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	before, err := store.GetRecipeByID(ctx, recipe.ID)
+	if err != nil || before == nil {
+		t.Fatalf("GetRecipeByID = %v, %v", before, err)
+	}
+	butterID := before.StructuredIngredients[0].IngredientID
+	unsaltedID := before.StructuredIngredients[2].IngredientID
+
+	merged, err := store.MergeIngredients(ctx, butterID, []string{unsaltedID})
+	if err != nil {
+		t.Fatalf("MergeIngredients: %v", err)
+	}
+	if want := []string{recipe.ID}; !reflect.DeepEqual(merged.AffectedRecipeIDs, want) {
+		t.Errorf("AffectedRecipeIDs = %v, want %v (not %s)", merged.AffectedRecipeIDs, want, other.ID)
+	}
+	if !reflect.DeepEqual(merged.Aliases, []string{"unsalted butter"}) {
+		t.Errorf("Aliases = %v, want [unsalted butter]", merged.Aliases)
+	}
+
+	got, err := store.GetRecipeByID(ctx, recipe.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetRecipeByID = %v, %v", got, err)
+	}
+	if !reflect.DeepEqual(got.Ingredients, lines) {
+		t.Errorf("Ingredients = %q, want %q", got.Ingredients, lines)
+	}
+	if id := got.StructuredIngredients[2].IngredientID; id != butterID {
+		t.Errorf("merged line links ingredient %s, want %s", id, butterID)
+	}
+}
@@ -0,0 +1,391 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dateOnly truncates t to midnight UTC of its calendar day, the form DATE columns are stored in.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// mealPlanEntrySelect selects meal plan entries as queryMealPlanEntries reads them. The title
+// of an entry planning a recipe is the recipe's name.
+const mealPlanEntrySelect = `SELECT e.id, e.recipe_id, COALESCE(e.title, r.name, ''), e.date, e.slot, e.servings, e.notes, e.sort_order, e.created_at
+	FROM meal_plan_entries e
+	LEFT JOIN recipes r ON r.id = e.recipe_id`
+
+// mealPlanEntryOrder lists meal plan entries by date, then by meal slot: the standard slots
+// in the order they are eaten, then custom slots by name. See models.MealSlotRank.
+const mealPlanEntryOrder = ` ORDER BY e.date ASC,
+		CASE e.slot WHEN 'breakfast' THEN 0 WHEN 'lunch' THEN 1 WHEN 'dinner' THEN 2 WHEN 'snack' THEN 3 ELSE 4 END,
+		e.slot ASC, e.sort_order ASC, e.created_at ASC`
+
+// queryMealPlanEntries runs a query selecting mealPlanEntrySelect's columns.
+func queryMealPlanEntries(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]models.MealPlanEntry, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying meal plan entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.MealPlanEntry
+	for rows.Next() {
+		var entry models.MealPlanEntry
+		var recipeID sql.NullString // NULL for free-text entries
+		var servings sql.NullInt64
+		var notes sql.NullString
+		if err := rows.Scan(&entry.ID, &recipeID, &entry.Title, &entry.Date, &entry.Slot, &servings, &notes, &entry.SortOrder, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning meal plan entry: %w", err)
+		}
+		entry.RecipeID = recipeID.String
+		entry.Servings = int(servings.Int64)
+		entry.Notes = notes.String
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating meal plan entries: %w", err)
+	}
+	return entries, nil
+}
+
+// getMealPlanEntry fetches a meal plan entry by its ID.
+func getMealPlanEntry(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, id string) (*models.MealPlanEntry, error) {
+	entries, err := queryMealPlanEntries(ctx, q, mealPlanEntrySelect+` WHERE e.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("meal plan entry with ID %s not found", id)
+	}
+	return &entries[0], nil
+}
+
+// mealPlanEntryError turns a constraint violation on meal_plan_entries into the error
+// handlers tell apart: a recipe or title planned twice for a meal, or a missing recipe.
+func mealPlanEntryError(entry *models.MealPlanEntry, err error) error {
+	if isUniqueViolation(err) {
+		if entry.RecipeID == "" {
+			return fmt.Errorf("%q is already planned for %s on %s", entry.Title, entry.Slot, entry.Date.Format("2006-01-02"))
+		}
+		return fmt.Errorf("recipe %s is already planned for %s on %s", entry.RecipeID, entry.Slot, entry.Date.Format("2006-01-02"))
+	}
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("recipe with ID %s not found", entry.RecipeID)
+	}
+	return err
+}
+
+// insertMealPlanEntry adds a meal plan entry last in its meal.
+func insertMealPlanEntry(ctx context.Context, q interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}, entry *models.MealPlanEntry) error {
+	if entry.RecipeID != "" {
+		entry.Title = "" // The recipe's name is shown instead
+	}
+
+	_, err := q.ExecContext(ctx, `INSERT INTO meal_plan_entries (id, recipe_id, title, date, slot, servings, notes, sort_order, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7,
+			(SELECT COALESCE(MAX(sort_order) + 1, 0) FROM meal_plan_entries WHERE date = ?4 AND slot = ?5), ?8)`,
+		entry.ID, nullIfEmpty(entry.RecipeID), nullIfEmpty(entry.Title), entry.Date, entry.Slot,
+		nullIfZero(entry.Servings), nullIfEmpty(entry.Notes), entry.CreatedAt)
+	if err = mealPlanEntryError(entry, err); err != nil {
+		return fmt.Errorf("failed to insert meal plan entry ID %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// CreateMealPlanEntry adds a new meal plan entry, last in its meal. An entry plans either a recipe, which must
+// exist, or a free-text title such as "Leftovers". Entries without a slot are dinners.
+// Planning a recipe or title twice for the same meal is an "already planned" error.
+func (s *Store) CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	entry.CreatedAt = now()
+	entry.Date = dateOnly(entry.Date)
+	if entry.Slot == "" {
+		entry.Slot = models.MealSlotDinner
+	}
+
+	if err := insertMealPlanEntry(ctx, s.db, entry); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Meal plan entry created successfully: ID=%s, RecipeID=%s, Title=%q, Date=%s, Slot=%s", entry.ID, entry.RecipeID, entry.Title, entry.Date.Format("2006-01-02"), entry.Slot)
+	return getMealPlanEntry(ctx, s.db, entry.ID)
+}
+
+// GetMealPlanEntry fetches a meal plan entry by its ID.
+func (s *Store) GetMealPlanEntry(ctx context.Context, entryID string) (*models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return getMealPlanEntry(ctx, s.db, entryID)
+}
+
+// mealEntryIDsTx returns the IDs of the entries planned for a meal, other than skipID, in
+// their order. Operates within a transaction.
+func mealEntryIDsTx(ctx context.Context, tx *sql.Tx, date time.Time, slot string, skipID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM meal_plan_entries
+		WHERE date = ? AND slot = ? AND id <> ?
+		ORDER BY sort_order ASC, created_at ASC`, date, slot, skipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entries planned for %s on %s: %w", slot, date.Format("2006-01-02"), err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan meal plan entry ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating meal plan entry IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// numberMealEntriesTx sets the sort_order of each entry to its position in ids. Operates
+// within a transaction.
+func numberMealEntriesTx(ctx context.Context, tx *sql.Tx, ids []string) error {
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE meal_plan_entries SET sort_order = ?1 WHERE id = ?2 AND sort_order <> ?1`, i, id); err != nil {
+			return fmt.Errorf("failed to order meal plan entry ID %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// placeMealPlanEntryTx moves an entry to position entry.SortOrder among the other entries of
+// its meal, or last when that is negative or past the end, and numbers the meal's entries
+// from 0. Operates within a transaction.
+func placeMealPlanEntryTx(ctx context.Context, tx *sql.Tx, entry *models.MealPlanEntry) error {
+	ids, err := mealEntryIDsTx(ctx, tx, entry.Date, entry.Slot, entry.ID)
+	if err != nil {
+		return err
+	}
+	position := entry.SortOrder
+	if position < 0 || position > len(ids) {
+		position = len(ids)
+	}
+	ids = append(ids[:position], append([]string{entry.ID}, ids[position:]...)...)
+	if err := numberMealEntriesTx(ctx, tx, ids); err != nil {
+		return err
+	}
+	entry.SortOrder = position
+	return nil
+}
+
+// updateMealPlanEntryTx changes an entry's date, slot, servings and notes and places it in
+// its meal. When the entry leaves its meal, the entries staying there are numbered from 0
+// again. Operates within a transaction.
+func updateMealPlanEntryTx(ctx context.Context, tx *sql.Tx, entry *models.MealPlanEntry) error {
+	var oldDate time.Time
+	var oldSlot string
+	err := tx.QueryRowContext(ctx, `SELECT date, slot FROM meal_plan_entries WHERE id = ?`, entry.ID).Scan(&oldDate, &oldSlot)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("meal plan entry with ID %s not found", entry.ID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get meal plan entry ID %s: %w", entry.ID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE meal_plan_entries SET date = ?, slot = ?, servings = ?, notes = ? WHERE id = ?`,
+		entry.Date, entry.Slot, nullIfZero(entry.Servings), nullIfEmpty(entry.Notes), entry.ID)
+	if err = mealPlanEntryError(entry, err); err != nil {
+		return fmt.Errorf("failed to update meal plan entry ID %s: %w", entry.ID, err)
+	}
+	if err := placeMealPlanEntryTx(ctx, tx, entry); err != nil {
+		return err
+	}
+	if oldDate.Equal(entry.Date) && oldSlot == entry.Slot {
+		return nil
+	}
+	ids, err := mealEntryIDsTx(ctx, tx, oldDate, oldSlot, "")
+	if err != nil {
+		return err
+	}
+	return numberMealEntriesTx(ctx, tx, ids)
+}
+
+// UpdateMealPlanEntry changes an entry's date, slot, servings and notes, and moves it to
+// position entry.SortOrder within its meal, or last when that is negative. Its recipe or
+// title stays. Planning its recipe or title twice for the same meal is an "already planned"
+// error.
+func (s *Store) UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	entry.Date = dateOnly(entry.Date)
+	if entry.Slot == "" {
+		entry.Slot = models.MealSlotDinner
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateMealPlanEntryTx(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit meal plan entry update: %w", err)
+	}
+
+	log.Printf("Meal plan entry updated successfully: ID=%s, Date=%s, Slot=%s, Position=%d", entry.ID, entry.Date.Format("2006-01-02"), entry.Slot, entry.SortOrder)
+	return getMealPlanEntry(ctx, s.db, entry.ID)
+}
+
+// ApplyMealPlanEntryOperations moves and copies meal plan entries in one transaction: if any
+// operation fails, none is applied. A moved entry that changes meal, and every copy, goes
+// last in its new meal. It returns the moved entries and the copies in operation order.
+func (s *Store) ApplyMealPlanEntryOperations(ctx context.Context, operations []models.MealPlanEntryOperation) ([]models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, 0, len(operations))
+	for _, op := range operations {
+		entry, err := getMealPlanEntry(ctx, tx, op.EntryID)
+		if err != nil {
+			return nil, err
+		}
+		moved := false
+		if !op.Date.IsZero() {
+			date := dateOnly(op.Date)
+			moved = moved || !date.Equal(entry.Date)
+			entry.Date = date
+		}
+		if op.Slot != "" {
+			moved = moved || op.Slot != entry.Slot
+			entry.Slot = op.Slot
+		}
+
+		switch op.Action {
+		case models.MealPlanMove:
+			if moved {
+				entry.SortOrder = -1
+			}
+			err = updateMealPlanEntryTx(ctx, tx, entry)
+		case models.MealPlanCopy:
+			entry.ID = uuid.NewString()
+			entry.CreatedAt = now()
+			err = insertMealPlanEntry(ctx, tx, entry)
+		default:
+			err = fmt.Errorf("unknown meal plan entry operation %q", op.Action)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit meal plan entry operations: %w", err)
+	}
+	log.Printf("Applied %d meal plan entry operations.", len(operations))
+
+	entries := make([]models.MealPlanEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := getMealPlanEntry(ctx, s.db, id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// GetMealPlanEntriesByDateRange retrieves all meal plan entries within a given date range (inclusive).
+func (s *Store) GetMealPlanEntriesByDateRange(ctx context.Context, startDate, endDate time.Time) ([]models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	entries, err := queryMealPlanEntries(ctx, s.db, mealPlanEntrySelect+`
+		WHERE e.date >= ? AND e.date <= ?`+mealPlanEntryOrder, dateOnly(startDate), dateOnly(endDate))
+	if err != nil {
+		return nil, fmt.Errorf("error querying meal plan entries by date range: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteMealPlanEntry removes a meal plan entry by its ID. Deleting an entry that does not
+// exist is not an error.
+func (s *Store) DeleteMealPlanEntry(ctx context.Context, entryID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if entryID == "" {
+		return fmt.Errorf("meal plan entry ID cannot be empty for deletion")
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM meal_plan_entries WHERE id = ?`, entryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete meal plan entry ID %s: %w", entryID, err)
+	}
+	if rowsAffected, err := res.RowsAffected(); err == nil && rowsAffected == 0 {
+		log.Printf("Meal plan entry with ID %s not found for deletion, or already deleted.", entryID)
+	}
+
+	log.Printf("Meal plan entry deleted successfully (or did not exist): ID=%s", entryID)
+	return nil
+}
+
+// GetAllMealPlanEntries fetches all meal_plan_entries, for export.
+func (s *Store) GetAllMealPlanEntries(ctx context.Context) ([]models.MealPlanEntry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return queryMealPlanEntries(ctx, s.db, mealPlanEntrySelect+mealPlanEntryOrder)
+}
+
+// insertImportedMealPlanEntryTx adds a meal plan entry from an import file, keeping its ID,
+// slot, servings, notes, order and creation time. Entries exported without a slot are dinners, and
+// free text exported in recipe_id, as older versions did, becomes the title.
+// Entries that are already planned are left untouched. Operates within a transaction.
+func insertImportedMealPlanEntryTx(ctx context.Context, tx *sql.Tx, entry models.MealPlanEntry) error {
+	date := dateOnly(entry.Date)
+	if entry.Slot == "" {
+		entry.Slot = models.MealSlotDinner
+	}
+	if _, err := uuid.Parse(entry.RecipeID); err != nil && entry.RecipeID != "" && entry.Title == "" {
+		entry.RecipeID, entry.Title = "", entry.RecipeID
+	}
+	if entry.RecipeID != "" {
+		entry.Title = "" // Exported as the recipe's name
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO meal_plan_entries (id, recipe_id, title, date, slot, servings, notes, sort_order, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		importedID(entry.ID), nullIfEmpty(entry.RecipeID), nullIfEmpty(entry.Title), date, entry.Slot,
+		nullIfZero(entry.Servings), nullIfEmpty(entry.Notes), entry.SortOrder, timeOrNow(entry.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert meal plan entry for recipe '%s' on %s: %w", entry.RecipeID, date.Format("2006-01-02"), err)
+	}
+	return nil
+}
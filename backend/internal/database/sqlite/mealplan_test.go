@@ -0,0 +1,51 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMovingAMealPlanEntryRenumbersItsMeal(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	var soup *models.MealPlanEntry
+	for _, title := range []string{"Soup", "Pasta", "Curry"} {
+		entry, err := store.CreateMealPlanEntry(ctx, &models.MealPlanEntry{Date: monday, Slot: models.MealSlotDinner, Title: title})
+		if err != nil {
+			t.Fatalf("CreateMealPlanEntry(%s): %v", title, err)
+		}
+		if soup == nil {
+			soup = entry
+		}
+	}
+
+	soup.Slot, soup.SortOrder = models.MealSlotLunch, -1
+	if _, err := store.UpdateMealPlanEntry(ctx, soup); err != nil {
+		t.Fatalf("UpdateMealPlanEntry: %v", err)
+	}
+
+	entries, err := store.GetMealPlanEntriesByDateRange(ctx, monday, monday)
+	if err != nil {
+		t.Fatalf("GetMealPlanEntriesByDateRange: %v", err)
+	}
+	type placed struct {
+		slot, title string
+		sortOrder   int
+	}
+	var got []placed
+	for _, entry := range entries {
+		got = append(got, placed{entry.Slot, entry.Title, entry.SortOrder})
+	}
+	// The dinners left behind are numbered from 0 again
+	want := []placed{{"lunch", "Soup", 0}, {"dinner", "Pasta", 0}, {"dinner", "Curry", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
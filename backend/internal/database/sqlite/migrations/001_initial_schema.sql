@@ -0,0 +1,197 @@
+-- Migration: 001_initial_schema
+-- Description: SQLite equivalent of the PostgreSQL schema as of 20261016150000_create_recipe_photos.
+-- Timestamps are written by the application as UTC text, which sorts chronologically.
+-- normalize_ingredient_name() is registered by the application on every connection.
+
+CREATE TABLE IF NOT EXISTS recipes (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    method TEXT NOT NULL,
+    servings INTEGER CHECK (servings IS NULL OR servings > 0),
+    yield TEXT,
+    prep_time_minutes INTEGER CHECK (prep_time_minutes IS NULL OR prep_time_minutes >= 0),
+    cook_time_minutes INTEGER CHECK (cook_time_minutes IS NULL OR cook_time_minutes >= 0),
+    rest_time_minutes INTEGER CHECK (rest_time_minutes IS NULL OR rest_time_minutes >= 0),
+    total_time_minutes INTEGER GENERATED ALWAYS AS (
+        CASE WHEN prep_time_minutes IS NULL AND cook_time_minutes IS NULL AND rest_time_minutes IS NULL THEN NULL
+             ELSE COALESCE(prep_time_minutes, 0) + COALESCE(cook_time_minutes, 0) + COALESCE(rest_time_minutes, 0)
+        END
+    ) STORED,
+    photo_filename TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ingredients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    normalized_name TEXT NOT NULL DEFAULT '', -- Set by trigger_set_normalized_ingredient_name
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    ingredient_id TEXT NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    original_text TEXT,
+    quantity_text TEXT,
+    quantity REAL,
+    quantity_max REAL,
+    unit TEXT,
+    preparation TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0 -- Position of the line; a recipe can name an ingredient on several lines
+);
+
+CREATE TABLE IF NOT EXISTS meal_plan_entries (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE(recipe_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    category TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipe_tags (
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (recipe_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_revisions (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    method TEXT NOT NULL,
+    ingredients TEXT NOT NULL DEFAULT '[]', -- JSON array of ingredient lines as entered
+    servings INTEGER,
+    yield TEXT,
+    prep_time_minutes INTEGER,
+    cook_time_minutes INTEGER,
+    rest_time_minutes INTEGER,
+    tags TEXT NOT NULL DEFAULT '[]', -- JSON array of tag names
+    photo_filename TEXT,
+    editor TEXT,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE(recipe_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_photos (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    caption TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE(recipe_id, filename)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipes_created_at ON recipes(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recipes_total_time ON recipes(total_time_minutes);
+CREATE INDEX IF NOT EXISTS idx_recipes_servings ON recipes(servings);
+CREATE INDEX IF NOT EXISTS idx_recipes_live_name ON recipes(name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_recipes_deleted_at ON recipes(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ingredients_normalized_name ON ingredients(normalized_name);
+CREATE INDEX IF NOT EXISTS idx_recipe_ingredients_ingredient_id ON recipe_ingredients(ingredient_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_ingredients_line ON recipe_ingredients(recipe_id, sort_order);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name_lower ON tags(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_tags_category ON tags(category);
+CREATE INDEX IF NOT EXISTS idx_recipe_tags_tag_id ON recipe_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_comments_recipe_id ON comments(recipe_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_recipe_photos_recipe_order ON recipe_photos(recipe_id, sort_order);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_photos_one_cover ON recipe_photos(recipe_id) WHERE is_cover;
+CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
+
+-- Keep updated_at current on updates that don't set it, like the PostgreSQL update_*_updated_at triggers.
+-- On ingredients only renames count, so filling in normalized_name below leaves it alone.
+CREATE TRIGGER IF NOT EXISTS update_recipes_updated_at AFTER UPDATE ON recipes
+    FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE recipes SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_ingredients_updated_at AFTER UPDATE OF name ON ingredients
+    FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE ingredients SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_comments_updated_at AFTER UPDATE ON comments
+    FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE comments SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_tags_updated_at AFTER UPDATE ON tags
+    FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE tags SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+-- Equivalent of trigger_set_normalized_ingredient_name
+CREATE TRIGGER IF NOT EXISTS trigger_set_normalized_ingredient_name AFTER INSERT ON ingredients
+BEGIN
+    UPDATE ingredients SET normalized_name = normalize_ingredient_name(NEW.name) WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trigger_update_normalized_ingredient_name AFTER UPDATE OF name ON ingredients
+BEGIN
+    UPDATE ingredients SET normalized_name = normalize_ingredient_name(NEW.name) WHERE id = NEW.id;
+END;
+
+-- Full-text search, standing in for the PostgreSQL tsvector indexes. The porter tokenizer
+-- stems English words like to_tsvector('english', ...).
+CREATE VIRTUAL TABLE IF NOT EXISTS recipes_fts USING fts5(
+    recipe_id UNINDEXED, name, method, tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS recipes_fts_insert AFTER INSERT ON recipes
+BEGIN
+    INSERT INTO recipes_fts (recipe_id, name, method) VALUES (NEW.id, NEW.name, NEW.method);
+END;
+
+CREATE TRIGGER IF NOT EXISTS recipes_fts_update AFTER UPDATE OF name, method ON recipes
+BEGIN
+    DELETE FROM recipes_fts WHERE recipe_id = OLD.id;
+    INSERT INTO recipes_fts (recipe_id, name, method) VALUES (NEW.id, NEW.name, NEW.method);
+END;
+
+CREATE TRIGGER IF NOT EXISTS recipes_fts_delete AFTER DELETE ON recipes
+BEGIN
+    DELETE FROM recipes_fts WHERE recipe_id = OLD.id;
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS ingredients_fts USING fts5(
+    ingredient_id UNINDEXED, normalized_name, tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS ingredients_fts_update AFTER UPDATE OF normalized_name ON ingredients
+BEGIN
+    DELETE FROM ingredients_fts WHERE ingredient_id = OLD.id;
+    INSERT INTO ingredients_fts (ingredient_id, normalized_name) VALUES (NEW.id, NEW.normalized_name);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ingredients_fts_delete AFTER DELETE ON ingredients
+BEGIN
+    DELETE FROM ingredients_fts WHERE ingredient_id = OLD.id;
+END;
@@ -0,0 +1,14 @@
+-- Migration: 001_initial_schema (down)
+-- Description: Drops every table created by 001_initial_schema; their triggers go with them.
+
+DROP TABLE IF EXISTS ingredients_fts;
+DROP TABLE IF EXISTS recipes_fts;
+DROP TABLE IF EXISTS recipe_photos;
+DROP TABLE IF EXISTS recipe_revisions;
+DROP TABLE IF EXISTS recipe_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS meal_plan_entries;
+DROP TABLE IF EXISTS recipe_ingredients;
+DROP TABLE IF EXISTS ingredients;
+DROP TABLE IF EXISTS recipes;
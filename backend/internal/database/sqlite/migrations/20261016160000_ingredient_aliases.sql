@@ -0,0 +1,24 @@
+-- Migration: 20261016160000_ingredient_aliases
+-- Description: Aliases: other names an ingredient is found by. Fuzzy matching uses the
+-- similarity() function the application registers, so there are no trigram indexes.
+
+CREATE TABLE IF NOT EXISTS ingredient_aliases (
+    id TEXT PRIMARY KEY,
+    ingredient_id TEXT NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    normalized_name TEXT NOT NULL DEFAULT '', -- Set by trigger_set_normalized_alias_name
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingredient_aliases_normalized_name ON ingredient_aliases(normalized_name);
+CREATE INDEX IF NOT EXISTS idx_ingredient_aliases_ingredient_id ON ingredient_aliases(ingredient_id);
+
+CREATE TRIGGER IF NOT EXISTS trigger_set_normalized_alias_name AFTER INSERT ON ingredient_aliases
+BEGIN
+    UPDATE ingredient_aliases SET normalized_name = normalize_ingredient_name(NEW.name) WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trigger_update_normalized_alias_name AFTER UPDATE OF name ON ingredient_aliases
+BEGIN
+    UPDATE ingredient_aliases SET normalized_name = normalize_ingredient_name(NEW.name) WHERE id = NEW.id;
+END;
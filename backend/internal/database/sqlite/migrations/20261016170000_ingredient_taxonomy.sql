@@ -0,0 +1,6 @@
+-- Migration: 20261016170000_ingredient_taxonomy
+-- Description: Parent/child relationships between ingredients (cheddar -> cheese -> dairy)
+
+ALTER TABLE ingredients ADD COLUMN parent_id TEXT REFERENCES ingredients(id) ON DELETE SET NULL CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_ingredients_parent_id ON ingredients(parent_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ingredients_parent_id;
+ALTER TABLE ingredients DROP COLUMN parent_id;
@@ -0,0 +1,35 @@
+-- Migration: 20261016180000_nutrition
+-- Description: Food composition data, the foods ingredients are mapped to, and cached recipe nutrition
+
+-- One row per food of the loaded dataset, e.g. USDA FoodData Central; amounts are per 100 g
+CREATE TABLE IF NOT EXISTS nutrients (
+    id TEXT PRIMARY KEY, -- Identifier in the dataset, e.g. the fdc_id
+    description TEXT NOT NULL,
+    source TEXT NOT NULL,
+    calories REAL NOT NULL DEFAULT 0, -- kcal
+    protein_g REAL NOT NULL DEFAULT 0,
+    fat_g REAL NOT NULL DEFAULT 0,
+    carbohydrate_g REAL NOT NULL DEFAULT 0,
+    fiber_g REAL NOT NULL DEFAULT 0,
+    sodium_mg REAL NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- The food whose composition an ingredient has
+CREATE TABLE IF NOT EXISTS ingredient_foods (
+    ingredient_id TEXT PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    food_id TEXT NOT NULL REFERENCES nutrients(id) ON DELETE CASCADE,
+    grams_per_ml REAL CHECK (grams_per_ml > 0), -- Density, for amounts by volume
+    grams_per_piece REAL CHECK (grams_per_piece > 0), -- Weight of one item, for counted amounts
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_foods_food_id ON ingredient_foods(food_id);
+
+-- Nutrition computed for a recipe, valid while the recipe's updated_at is recipe_updated_at
+CREATE TABLE IF NOT EXISTS recipe_nutrition (
+    recipe_id TEXT PRIMARY KEY REFERENCES recipes(id) ON DELETE CASCADE,
+    recipe_updated_at TIMESTAMP NOT NULL,
+    nutrition TEXT NOT NULL, -- JSON
+    computed_at TIMESTAMP NOT NULL
+);
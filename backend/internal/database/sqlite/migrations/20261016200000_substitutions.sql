@@ -0,0 +1,26 @@
+-- Migration: 20261016200000_substitutions
+-- Description: Substitutions knowledge base: an amount of an ingredient and the ingredients that can replace it
+
+CREATE TABLE IF NOT EXISTS substitutions (
+    id TEXT PRIMARY KEY,
+    ingredient_id TEXT NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity REAL NOT NULL CHECK (quantity > 0), -- Amount of the ingredient the replacements stand for
+    unit TEXT, -- Canonical unit name; NULL for counted items
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_substitutions_ingredient_id ON substitutions(ingredient_id);
+
+-- The ingredients a substitution uses instead, with their amounts per the substitution's quantity
+CREATE TABLE IF NOT EXISTS substitution_replacements (
+    substitution_id TEXT NOT NULL REFERENCES substitutions(id) ON DELETE CASCADE,
+    sort_order INTEGER NOT NULL,
+    ingredient_id TEXT NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    unit TEXT,
+    PRIMARY KEY (substitution_id, sort_order)
+);
+
+CREATE INDEX IF NOT EXISTS idx_substitution_replacements_ingredient_id ON substitution_replacements(ingredient_id);
@@ -0,0 +1,25 @@
+-- Migration: 20261016210000_pantry
+-- Description: Pantry of owned ingredients, and staples that always count as owned
+
+CREATE TABLE IF NOT EXISTS pantry_items (
+    id TEXT PRIMARY KEY,
+    ingredient_id TEXT NOT NULL UNIQUE REFERENCES ingredients(id) ON DELETE CASCADE,
+    quantity REAL CHECK (quantity >= 0), -- Amount left; NULL when not tracked
+    unit TEXT, -- Canonical unit name; NULL for counted items
+    expires_on DATE, -- Last usable day
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pantry_items_expires_on ON pantry_items(expires_on);
+
+-- Ingredients that always count as owned, such as salt and water
+CREATE TABLE IF NOT EXISTS pantry_staples (
+    ingredient_id TEXT PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO pantry_staples (ingredient_id, created_at)
+SELECT id, CURRENT_TIMESTAMP FROM ingredients WHERE name IN ('salt', 'water')
+ON CONFLICT (ingredient_id) DO NOTHING;
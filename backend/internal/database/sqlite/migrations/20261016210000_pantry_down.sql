@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pantry_staples;
+DROP TABLE IF EXISTS pantry_items;
@@ -0,0 +1,11 @@
+-- Migration: 20261016220000_ingredient_aisles
+-- Description: Store aisle each ingredient is shopped in, for grouping shopping lists
+
+-- Ingredients below one in the taxonomy are shopped in its aisle unless they have their own
+CREATE TABLE IF NOT EXISTS ingredient_aisles (
+    ingredient_id TEXT PRIMARY KEY REFERENCES ingredients(id) ON DELETE CASCADE,
+    aisle TEXT NOT NULL CHECK (aisle <> ''),
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_aisles_aisle ON ingredient_aisles(aisle);
@@ -0,0 +1,35 @@
+-- Migration: 20261016230000_shopping_lists
+-- Description: Stored shopping lists with items to check off
+
+-- A list's version is bumped when it is renamed or items are added, removed or reordered;
+-- an item's own version when it is checked off or edited. Both guard against lost updates.
+CREATE TABLE IF NOT EXISTS shopping_lists (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    start_date DATE, -- Meal plan range the list was generated from; NULL for lists started empty
+    end_date DATE,
+    recipes TEXT NOT NULL DEFAULT '[]', -- JSON array of the names of the planned recipes
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS shopping_list_items (
+    id TEXT PRIMARY KEY,
+    shopping_list_id TEXT NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    ingredient_id TEXT REFERENCES ingredients(id) ON DELETE SET NULL, -- NULL for items added by hand
+    name TEXT NOT NULL,
+    quantity REAL CHECK (quantity >= 0),
+    unit TEXT, -- Canonical unit name; NULL for counted items
+    aisle TEXT NOT NULL,
+    in_pantry BOOLEAN NOT NULL DEFAULT FALSE,
+    recipes TEXT NOT NULL DEFAULT '[]', -- JSON array of the names of the recipes calling for the item
+    manual BOOLEAN NOT NULL DEFAULT FALSE,
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    bought_by TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1 -- Bumped when the item is checked off or edited
+);
+
+CREATE INDEX IF NOT EXISTS idx_shopping_list_items_list ON shopping_list_items(shopping_list_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_shopping_list_items_ingredient_id ON shopping_list_items(ingredient_id);
@@ -0,0 +1,24 @@
+-- Migration: 20261017000000_meal_plan_slots
+-- Description: Meal slots, planned servings and notes on meal plan entries
+
+-- SQLite cannot drop the UNIQUE(recipe_id, date) constraint, so the table is rebuilt.
+-- slot is breakfast, lunch, dinner, snack or a custom name; existing entries become dinners.
+-- servings is how many the meal is planned for; NULL means the recipe's own servings.
+CREATE TABLE meal_plan_entries_new (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    slot TEXT NOT NULL DEFAULT 'dinner' CHECK (slot <> '' AND length(slot) <= 50),
+    servings INTEGER CHECK (servings > 0),
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO meal_plan_entries_new (id, recipe_id, date, notes, created_at)
+    SELECT id, recipe_id, date, notes, created_at FROM meal_plan_entries;
+DROP TABLE meal_plan_entries;
+ALTER TABLE meal_plan_entries_new RENAME TO meal_plan_entries;
+
+CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
+-- A recipe can be planned once per meal, so the same soup can be lunch and dinner
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_recipe ON meal_plan_entries(date, slot, recipe_id);
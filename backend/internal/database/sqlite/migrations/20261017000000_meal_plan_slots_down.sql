@@ -0,0 +1,16 @@
+CREATE TABLE meal_plan_entries_old (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE(recipe_id, date)
+);
+
+-- Keep the first entry of a recipe on each date
+INSERT OR IGNORE INTO meal_plan_entries_old (id, recipe_id, date, notes, created_at)
+    SELECT id, recipe_id, date, notes, created_at FROM meal_plan_entries ORDER BY created_at, id;
+DROP TABLE meal_plan_entries;
+ALTER TABLE meal_plan_entries_old RENAME TO meal_plan_entries;
+
+CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
@@ -0,0 +1,26 @@
+-- Migration: 20261017010000_meal_plan_titles
+-- Description: Free-text meal plan entries, such as "Leftovers", that don't reference a recipe
+
+-- SQLite cannot make recipe_id nullable in place, so the table is rebuilt.
+-- An entry plans either a recipe or a free-text title.
+CREATE TABLE meal_plan_entries_new (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT REFERENCES recipes(id) ON DELETE CASCADE,
+    title TEXT CHECK (title <> ''),
+    date DATE NOT NULL,
+    slot TEXT NOT NULL DEFAULT 'dinner' CHECK (slot <> '' AND length(slot) <= 50),
+    servings INTEGER CHECK (servings > 0),
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    CHECK ((recipe_id IS NULL) <> (title IS NULL))
+);
+
+INSERT INTO meal_plan_entries_new (id, recipe_id, date, slot, servings, notes, created_at)
+    SELECT id, recipe_id, date, slot, servings, notes, created_at FROM meal_plan_entries;
+DROP TABLE meal_plan_entries;
+ALTER TABLE meal_plan_entries_new RENAME TO meal_plan_entries;
+
+CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_recipe ON meal_plan_entries(date, slot, recipe_id);
+-- Like recipes, a title can be planned once per meal
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_title ON meal_plan_entries(date, slot, title) WHERE title IS NOT NULL;
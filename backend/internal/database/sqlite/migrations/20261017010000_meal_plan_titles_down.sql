@@ -0,0 +1,18 @@
+-- Free-text entries cannot be kept without a recipe
+CREATE TABLE meal_plan_entries_old (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    slot TEXT NOT NULL DEFAULT 'dinner' CHECK (slot <> '' AND length(slot) <= 50),
+    servings INTEGER CHECK (servings > 0),
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO meal_plan_entries_old (id, recipe_id, date, slot, servings, notes, created_at)
+    SELECT id, recipe_id, date, slot, servings, notes, created_at FROM meal_plan_entries WHERE recipe_id IS NOT NULL;
+DROP TABLE meal_plan_entries;
+ALTER TABLE meal_plan_entries_old RENAME TO meal_plan_entries;
+
+CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_date_range ON meal_plan_entries(date, recipe_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_entries_date_slot_recipe ON meal_plan_entries(date, slot, recipe_id);
@@ -0,0 +1 @@
+ALTER TABLE meal_plan_entries DROP COLUMN sort_order;
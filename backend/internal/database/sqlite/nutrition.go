@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"strings"
+)
+
+// UpsertFoods adds foods of a food composition dataset, replacing the description and nutrients
+// of foods already loaded. Nutrition computed for recipes is discarded, as it may be based on
+// the old values. Returns the number of foods stored.
+func (s *Store) UpsertFoods(ctx context.Context, foods []models.Food) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO nutrients (id, description, source, calories, protein_g, fat_g, carbohydrate_g, fiber_g, sodium_mg, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET description = excluded.description, source = excluded.source,
+			calories = excluded.calories, protein_g = excluded.protein_g, fat_g = excluded.fat_g,
+			carbohydrate_g = excluded.carbohydrate_g, fiber_g = excluded.fiber_g, sodium_mg = excluded.sodium_mg,
+			updated_at = excluded.updated_at`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare food upsert: %w", err)
+	}
+	defer stmt.Close()
+	for _, food := range foods {
+		_, err := stmt.ExecContext(ctx, food.ID, food.Description, food.Source, food.Calories, food.ProteinG,
+			food.FatG, food.CarbohydrateG, food.FiberG, food.SodiumMg, timeOrNow(food.UpdatedAt))
+		if err != nil {
+			return 0, fmt.Errorf("failed to store food %s: %w", food.ID, err)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM recipe_nutrition`); err != nil {
+		return 0, fmt.Errorf("failed to clear cached recipe nutrition: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction for foods: %w", err)
+	}
+	return len(foods), nil
+}
+
+// foodColumns are the nutrients columns scanned by scanFood, for a table aliased as "n".
+const foodColumns = `n.id, n.description, n.source, n.calories, n.protein_g, n.fat_g, n.carbohydrate_g, n.fiber_g, n.sodium_mg, n.updated_at`
+
+// scanFood scans a row starting with foodColumns, followed by extra destinations.
+func scanFood(row interface{ Scan(...interface{}) error }, food *models.Food, extra ...interface{}) error {
+	dest := []interface{}{&food.ID, &food.Description, &food.Source, &food.Calories, &food.ProteinG,
+		&food.FatG, &food.CarbohydrateG, &food.FiberG, &food.SodiumMg, &food.UpdatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// SearchFoods finds foods whose description contains every word of the query, shorter
+// (more generic) descriptions first.
+func (s *Store) SearchFoods(ctx context.Context, query string, limit int) ([]models.Food, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return []models.Food{}, nil
+	}
+	// LIKE is case-insensitive for ASCII in SQLite.
+	var conditions []string
+	var args []interface{}
+	for _, word := range words {
+		conditions = append(conditions, `n.description LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+database.EscapeLike(word)+"%")
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+foodColumns+` FROM nutrients n
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY length(n.description), n.description
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search foods: %w", err)
+	}
+	defer rows.Close()
+
+	foods := []models.Food{}
+	for rows.Next() {
+		var food models.Food
+		if err := scanFood(rows, &food); err != nil {
+			return nil, fmt.Errorf("failed to scan food: %w", err)
+		}
+		foods = append(foods, food)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating food rows: %w", err)
+	}
+	return foods, nil
+}
+
+// ingredientFoodSelect selects the columns scanned by scanIngredientFood.
+const ingredientFoodSelect = `SELECT ` + foodColumns + `, f.ingredient_id, f.grams_per_ml, f.grams_per_piece, f.updated_at
+	FROM ingredient_foods f
+	JOIN nutrients n ON n.id = f.food_id`
+
+// scanIngredientFood scans a row selected with ingredientFoodSelect.
+func scanIngredientFood(row interface{ Scan(...interface{}) error }) (models.IngredientFood, error) {
+	var mapping models.IngredientFood
+	var food models.Food
+	var gramsPerML, gramsPerPiece sql.NullFloat64
+	if err := scanFood(row, &food, &mapping.IngredientID, &gramsPerML, &gramsPerPiece, &mapping.UpdatedAt); err != nil {
+		return mapping, err
+	}
+	mapping.FoodID = food.ID
+	mapping.GramsPerML = nullFloatPtr(gramsPerML)
+	mapping.GramsPerPiece = nullFloatPtr(gramsPerPiece)
+	mapping.Food = &food
+	return mapping, nil
+}
+
+// queryIngredientFoods runs a query selecting ingredientFoodSelect rows.
+func (s *Store) queryIngredientFoods(ctx context.Context, query string, args ...interface{}) ([]models.IngredientFood, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredient foods: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []models.IngredientFood{}
+	for rows.Next() {
+		mapping, err := scanIngredientFood(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient food: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient food rows: %w", err)
+	}
+	return mappings, nil
+}
+
+// GetIngredientFoods fetches the food mappings of the given ingredients, with their food.
+// Ingredients without a mapping are left out.
+func (s *Store) GetIngredientFoods(ctx context.Context, ingredientIDs []string) ([]models.IngredientFood, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if len(ingredientIDs) == 0 {
+		return []models.IngredientFood{}, nil
+	}
+	return s.queryIngredientFoods(ctx, ingredientFoodSelect+` WHERE f.ingredient_id IN (SELECT value FROM json_each(?))`, jsonArray(ingredientIDs))
+}
+
+// GetAllIngredientFoods fetches every food mapping with its food, for export.
+func (s *Store) GetAllIngredientFoods(ctx context.Context) ([]models.IngredientFood, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryIngredientFoods(ctx, ingredientFoodSelect+` ORDER BY f.ingredient_id`)
+}
+
+// SetIngredientFood maps an ingredient to a food, replacing its previous mapping, and
+// discards the cached nutrition of the recipes using the ingredient.
+func (s *Store) SetIngredientFood(ctx context.Context, mapping models.IngredientFood) (*models.IngredientFood, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = ?)`, mapping.IngredientID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", mapping.IngredientID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingredient with ID %s not found", mapping.IngredientID)
+	}
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM nutrients WHERE id = ?)`, mapping.FoodID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query food with ID %s: %w", mapping.FoodID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("food with ID %s not found", mapping.FoodID)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_foods (ingredient_id, food_id, grams_per_ml, grams_per_piece, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (ingredient_id) DO UPDATE SET food_id = excluded.food_id, grams_per_ml = excluded.grams_per_ml,
+			grams_per_piece = excluded.grams_per_piece, updated_at = excluded.updated_at`,
+		mapping.IngredientID, mapping.FoodID, mapping.GramsPerML, mapping.GramsPerPiece, now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map ingredient %s to food %s: %w", mapping.IngredientID, mapping.FoodID, err)
+	}
+	if err = clearIngredientNutritionTx(ctx, tx, mapping.IngredientID); err != nil {
+		return nil, err
+	}
+
+	saved, err := scanIngredientFood(tx.QueryRowContext(ctx, ingredientFoodSelect+` WHERE f.ingredient_id = ?`, mapping.IngredientID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query food of ingredient %s: %w", mapping.IngredientID, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient food: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteIngredientFood removes an ingredient's food mapping and discards the cached
+// nutrition of the recipes using the ingredient.
+func (s *Store) DeleteIngredientFood(ctx context.Context, ingredientID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM ingredient_foods WHERE ingredient_id = ?`, ingredientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete food of ingredient %s: %w", ingredientID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("food of ingredient %s not found", ingredientID)
+	}
+	if err = clearIngredientNutritionTx(ctx, tx, ingredientID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for ingredient food: %w", err)
+	}
+	return nil
+}
+
+// clearIngredientNutritionTx discards the cached nutrition of the recipes using an ingredient.
+// Operates within a transaction.
+func clearIngredientNutritionTx(ctx context.Context, tx *sql.Tx, ingredientID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM recipe_nutrition
+		WHERE recipe_id IN (SELECT recipe_id FROM recipe_ingredients WHERE ingredient_id = ?)`, ingredientID)
+	if err != nil {
+		return fmt.Errorf("failed to clear cached nutrition of recipes using ingredient %s: %w", ingredientID, err)
+	}
+	return nil
+}
+
+// GetRecipeNutrition fetches the nutrition last computed for a recipe, or nil if there is none.
+// Callers compare its RecipeUpdatedAt with the recipe's to tell whether it is still current.
+func (s *Store) GetRecipeNutrition(ctx context.Context, recipeID string) (*models.RecipeNutrition, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var data string
+	var nutrition models.RecipeNutrition
+	err := s.db.QueryRowContext(ctx, `SELECT nutrition, recipe_updated_at FROM recipe_nutrition WHERE recipe_id = ?`, recipeID).
+		Scan(&data, &nutrition.RecipeUpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nutrition of recipe %s: %w", recipeID, err)
+	}
+	if err := json.Unmarshal([]byte(data), &nutrition); err != nil {
+		return nil, fmt.Errorf("failed to decode nutrition of recipe %s: %w", recipeID, err)
+	}
+	return &nutrition, nil
+}
+
+// SaveRecipeNutrition caches the nutrition computed for a recipe, replacing any earlier result.
+func (s *Store) SaveRecipeNutrition(ctx context.Context, nutrition models.RecipeNutrition) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	data, err := json.Marshal(nutrition)
+	if err != nil {
+		return fmt.Errorf("failed to encode nutrition of recipe %s: %w", nutrition.RecipeID, err)
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO recipe_nutrition (recipe_id, recipe_updated_at, nutrition, computed_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (recipe_id) DO UPDATE SET recipe_updated_at = excluded.recipe_updated_at,
+			nutrition = excluded.nutrition, computed_at = excluded.computed_at`,
+		nutrition.RecipeID, nutrition.RecipeUpdatedAt.UTC(), string(data), timeOrNow(nutrition.ComputedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save nutrition of recipe %s: %w", nutrition.RecipeID, err)
+	}
+	return nil
+}
+
+// insertImportedIngredientFoodTx adds a food mapping from an import file to its imported
+// ingredient, together with the food it names. Foods already loaded keep their values and
+// an ingredient that already has a mapping keeps it. Operates within a transaction.
+func insertImportedIngredientFoodTx(ctx context.Context, tx *sql.Tx, mapping models.IngredientFood, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[mapping.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", mapping.IngredientID)
+	}
+	if mapping.Food != nil {
+		food := *mapping.Food
+		_, err := tx.ExecContext(ctx, `INSERT INTO nutrients (id, description, source, calories, protein_g, fat_g, carbohydrate_g, fiber_g, sodium_mg, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO NOTHING`,
+			mapping.FoodID, food.Description, food.Source, food.Calories, food.ProteinG,
+			food.FatG, food.CarbohydrateG, food.FiberG, food.SodiumMg, timeOrNow(food.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("failed to insert food %s: %w", mapping.FoodID, err)
+		}
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO ingredient_foods (ingredient_id, food_id, grams_per_ml, grams_per_piece, updated_at)
+		SELECT ?1, ?2, ?3, ?4, ?5
+		WHERE EXISTS (SELECT 1 FROM nutrients WHERE id = ?2)
+		ON CONFLICT (ingredient_id) DO NOTHING`,
+		dbIngredientID, mapping.FoodID, mapping.GramsPerML, mapping.GramsPerPiece, timeOrNow(mapping.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to map ingredient DB ID %s to food %s: %w", dbIngredientID, mapping.FoodID, err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const pantryItemSelect = `SELECT p.id, p.ingredient_id, i.name, p.quantity, COALESCE(p.unit, ''), p.expires_on, COALESCE(p.notes, ''), p.created_at, p.updated_at
+	FROM pantry_items p
+	JOIN ingredients i ON i.id = p.ingredient_id`
+
+// pantryOwnedCTE defines "owned": the ingredients in the pantry on day ?1 that are not used up
+// or expired, the staples, and every ingredient above those in the taxonomy, since a recipe
+// calling for cheese can be made with the cheddar we have.
+const pantryOwnedCTE = `WITH RECURSIVE owned(ingredient_id) AS (
+		SELECT ingredient_id FROM (
+			SELECT ingredient_id FROM pantry_items
+			WHERE (quantity IS NULL OR quantity > 0) AND (expires_on IS NULL OR expires_on >= ?1)
+			UNION
+			SELECT ingredient_id FROM pantry_staples
+		)
+		UNION
+		SELECT i.parent_id
+		FROM owned o
+		JOIN ingredients i ON i.id = o.ingredient_id
+		WHERE i.parent_id IS NOT NULL
+	)`
+
+// pantryCoverageCTE extends pantryOwnedCTE with "coverage": the number of ingredients of each
+// live recipe and how many of them are owned. An ingredient named on several lines counts once.
+// Recipes without linked ingredients are left out.
+const pantryCoverageCTE = pantryOwnedCTE + `,
+	coverage AS (
+		SELECT r.id, r.name, COUNT(DISTINCT ri.ingredient_id) AS total, COUNT(DISTINCT o.ingredient_id) AS owned
+		FROM recipes r
+		JOIN recipe_ingredients ri ON ri.recipe_id = r.id
+		LEFT JOIN owned o ON o.ingredient_id = ri.ingredient_id
+		WHERE r.deleted_at IS NULL
+		GROUP BY r.id, r.name
+	)`
+
+// nullDate converts an optional day into the value stored in a DATE column.
+func nullDate(day *time.Time) sql.NullTime {
+	if day == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: dateOnly(*day), Valid: true}
+}
+
+// queryPantryItems runs a query selecting pantryItemSelect's columns.
+func (s *Store) queryPantryItems(ctx context.Context, query string, args ...interface{}) ([]models.PantryItem, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pantry items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.PantryItem{}
+	for rows.Next() {
+		var item models.PantryItem
+		var quantity sql.NullFloat64
+		var expiresOn sql.NullTime
+		if err := rows.Scan(&item.ID, &item.IngredientID, &item.IngredientName, &quantity, &item.Unit, &expiresOn, &item.Notes, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pantry item row: %w", err)
+		}
+		if quantity.Valid {
+			item.Quantity = &quantity.Float64
+		}
+		if expiresOn.Valid {
+			day := dateOnly(expiresOn.Time)
+			item.ExpiresOn = &day
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pantry item rows: %w", err)
+	}
+	return items, nil
+}
+
+// GetPantryItems fetches everything in the pantry, ordered by ingredient name.
+func (s *Store) GetPantryItems(ctx context.Context) ([]models.PantryItem, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryPantryItems(ctx, pantryItemSelect+` ORDER BY i.name ASC`)
+}
+
+// getPantryItemByID fetches a single pantry item.
+func (s *Store) getPantryItemByID(ctx context.Context, id string) (*models.PantryItem, error) {
+	items, err := s.queryPantryItems(ctx, pantryItemSelect+` WHERE p.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("pantry item with ID %s not found", id)
+	}
+	return &items[0], nil
+}
+
+// CreatePantryItem adds an ingredient to the pantry. The ingredient is given by ID, or by
+// canonical name when the ID is empty, in which case a missing ingredient is created. Each
+// ingredient can be in the pantry once.
+func (s *Store) CreatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ingredientID, err := resolveIngredientTx(ctx, tx, item.IngredientID, item.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	item.ID = uuid.NewString()
+	createdAt := now()
+	_, err = tx.ExecContext(ctx, `INSERT INTO pantry_items (id, ingredient_id, quantity, unit, expires_on, notes, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?7)`, item.ID, ingredientID, item.Quantity, nullIfEmpty(item.Unit), nullDate(item.ExpiresOn), nullIfEmpty(item.Notes), createdAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("ingredient %s already exists in the pantry", ingredientID)
+		}
+		return nil, fmt.Errorf("failed to insert pantry item: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for pantry item: %w", err)
+	}
+	return s.getPantryItemByID(ctx, item.ID)
+}
+
+// UpdatePantryItem replaces an existing pantry item. The ingredient is resolved as in
+// CreatePantryItem.
+func (s *Store) UpdatePantryItem(ctx context.Context, item models.PantryItem) (*models.PantryItem, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pantry_items WHERE id = ?)`, item.ID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query pantry item with ID %s: %w", item.ID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("pantry item with ID %s not found", item.ID)
+	}
+	ingredientID, err := resolveIngredientTx(ctx, tx, item.IngredientID, item.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE pantry_items SET ingredient_id = ?, quantity = ?, unit = ?, expires_on = ?, notes = ?, updated_at = ?
+		WHERE id = ?`, ingredientID, item.Quantity, nullIfEmpty(item.Unit), nullDate(item.ExpiresOn), nullIfEmpty(item.Notes), now(), item.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("ingredient %s already exists in the pantry", ingredientID)
+		}
+		return nil, fmt.Errorf("failed to update pantry item %s: %w", item.ID, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for pantry item: %w", err)
+	}
+	return s.getPantryItemByID(ctx, item.ID)
+}
+
+// DeletePantryItem removes an ingredient from the pantry.
+func (s *Store) DeletePantryItem(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM pantry_items WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pantry item %s: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for pantry item deletion: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("pantry item with ID %s not found", id)
+	}
+	return nil
+}
+
+// GetPantryStaples fetches the ingredients that always count as owned, ordered by name.
+func (s *Store) GetPantryStaples(ctx context.Context) ([]models.PantryStaple, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT s.ingredient_id, i.name
+		FROM pantry_staples s
+		JOIN ingredients i ON i.id = s.ingredient_id
+		ORDER BY i.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pantry staples: %w", err)
+	}
+	defer rows.Close()
+
+	staples := []models.PantryStaple{}
+	for rows.Next() {
+		var staple models.PantryStaple
+		if err := rows.Scan(&staple.IngredientID, &staple.IngredientName); err != nil {
+			return nil, fmt.Errorf("failed to scan pantry staple row: %w", err)
+		}
+		staples = append(staples, staple)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pantry staple rows: %w", err)
+	}
+	return staples, nil
+}
+
+// SetPantryStaples replaces the staples with the ingredients of the given canonical names,
+// creating missing ones.
+func (s *Store) SetPantryStaples(ctx context.Context, names []string) ([]models.PantryStaple, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ingredientIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		ingredientID, err := getOrCreateIngredientByNameTx(ctx, tx, name)
+		if err != nil {
+			return nil, err
+		}
+		ingredientIDs = append(ingredientIDs, ingredientID)
+	}
+	idsJSON := jsonArray(ingredientIDs)
+	if _, err = tx.ExecContext(ctx, `DELETE FROM pantry_staples WHERE ingredient_id NOT IN (SELECT value FROM json_each(?))`, idsJSON); err != nil {
+		return nil, fmt.Errorf("failed to clear pantry staples: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO pantry_staples (ingredient_id, created_at)
+		SELECT DISTINCT value, ?2 FROM json_each(?1) WHERE true
+		ON CONFLICT (ingredient_id) DO NOTHING`, idsJSON, now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set pantry staples: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for pantry staples: %w", err)
+	}
+	return s.GetPantryStaples(ctx)
+}
+
+// GetCookableRecipes ranks live recipes by the fraction of their ingredients owned on today,
+// then by the number missing and by name. Recipes missing more than maxMissing ingredients
+// are skipped unless it is negative. Returns the requested page and the number of recipes ranked.
+func (s *Store) GetCookableRecipes(ctx context.Context, today time.Time, maxMissing int, page int, pageSize int) ([]models.CookableRecipe, int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	day := dateOnly(today)
+	var totalCount int
+	err := s.db.QueryRowContext(ctx, pantryCoverageCTE+`
+		SELECT COUNT(*) FROM coverage WHERE ?2 < 0 OR total - owned <= ?2`, day, maxMissing).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count cookable recipes: %w", err)
+	}
+	if totalCount == 0 {
+		return []models.CookableRecipe{}, 0, nil
+	}
+
+	recipes, err := s.queryCookableRecipes(ctx, day, maxMissing, page, pageSize)
+	if err != nil || len(recipes) == 0 {
+		return recipes, totalCount, err
+	}
+	if err = s.fillMissingIngredients(ctx, day, recipes); err != nil {
+		return nil, 0, err
+	}
+	return recipes, totalCount, nil
+}
+
+// queryCookableRecipes fetches a page of the ranking of GetCookableRecipes, without the
+// missing ingredients.
+func (s *Store) queryCookableRecipes(ctx context.Context, day time.Time, maxMissing int, page int, pageSize int) ([]models.CookableRecipe, error) {
+	rows, err := s.db.QueryContext(ctx, pantryCoverageCTE+`
+		SELECT id, name, total, owned
+		FROM coverage
+		WHERE ?2 < 0 OR total - owned <= ?2
+		ORDER BY CAST(owned AS REAL) / total DESC, total - owned ASC, name ASC, id ASC
+		LIMIT ?3 OFFSET ?4`, day, maxMissing, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cookable recipes: %w", err)
+	}
+	defer rows.Close()
+
+	recipes := []models.CookableRecipe{}
+	for rows.Next() {
+		var recipe models.CookableRecipe
+		if err := rows.Scan(&recipe.RecipeID, &recipe.Name, &recipe.IngredientCount, &recipe.OwnedCount); err != nil {
+			return nil, fmt.Errorf("failed to scan cookable recipe row: %w", err)
+		}
+		recipe.OwnedFraction = float64(recipe.OwnedCount) / float64(recipe.IngredientCount)
+		recipe.Missing = []models.MissingIngredient{}
+		recipes = append(recipes, recipe)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cookable recipe rows: %w", err)
+	}
+	return recipes, nil
+}
+
+// fillMissingIngredients lists the ingredients of each recipe that are not owned on day.
+func (s *Store) fillMissingIngredients(ctx context.Context, day time.Time, recipes []models.CookableRecipe) error {
+	positions := make(map[string]int, len(recipes))
+	recipeIDs := make([]string, len(recipes))
+	for i, recipe := range recipes {
+		positions[recipe.RecipeID] = i
+		recipeIDs[i] = recipe.RecipeID
+	}
+	rows, err := s.db.QueryContext(ctx, pantryOwnedCTE+`
+		SELECT ri.recipe_id, ri.ingredient_id, i.name, COALESCE(ri.original_text, '')
+		FROM recipe_ingredients ri
+		JOIN ingredients i ON i.id = ri.ingredient_id
+		WHERE ri.recipe_id IN (SELECT value FROM json_each(?2)) AND ri.ingredient_id NOT IN (SELECT ingredient_id FROM owned)
+			AND NOT EXISTS (
+				SELECT 1 FROM recipe_ingredients ri_e
+				WHERE ri_e.recipe_id = ri.recipe_id AND ri_e.ingredient_id = ri.ingredient_id AND ri_e.sort_order < ri.sort_order
+			)
+		ORDER BY ri.recipe_id, ri.sort_order`, day, jsonArray(recipeIDs))
+	if err != nil {
+		return fmt.Errorf("failed to query missing ingredients: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var recipeID string
+		var ingredient models.MissingIngredient
+		if err := rows.Scan(&recipeID, &ingredient.IngredientID, &ingredient.IngredientName, &ingredient.OriginalText); err != nil {
+			return fmt.Errorf("failed to scan missing ingredient row: %w", err)
+		}
+		recipe := &recipes[positions[recipeID]]
+		recipe.Missing = append(recipe.Missing, ingredient)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating missing ingredient rows: %w", err)
+	}
+	return nil
+}
+
+// GetOwnedIngredientIDs fetches the ingredients owned on today: those in the pantry that are not
+// used up or expired, the staples, and the ingredients above those in the taxonomy.
+func (s *Store) GetOwnedIngredientIDs(ctx context.Context, today time.Time) ([]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, pantryOwnedCTE+`
+		SELECT ingredient_id FROM owned`, dateOnly(today))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query owned ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	ingredientIDs := []string{}
+	for rows.Next() {
+		var ingredientID string
+		if err := rows.Scan(&ingredientID); err != nil {
+			return nil, fmt.Errorf("failed to scan owned ingredient row: %w", err)
+		}
+		ingredientIDs = append(ingredientIDs, ingredientID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating owned ingredient rows: %w", err)
+	}
+	return ingredientIDs, nil
+}
+
+// insertImportedPantryItemTx adds a pantry item from an import file, keeping its ID and
+// timestamps. An item that is already present, or whose ingredient already is in the pantry,
+// is left untouched. Operates within a transaction.
+func insertImportedPantryItemTx(ctx context.Context, tx *sql.Tx, item models.PantryItem, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[item.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", item.IngredientID)
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO pantry_items (id, ingredient_id, quantity, unit, expires_on, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`, importedID(item.ID), dbIngredientID, item.Quantity, nullIfEmpty(item.Unit), nullDate(item.ExpiresOn),
+		nullIfEmpty(item.Notes), timeOrNow(item.CreatedAt), timeOrNow(item.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert pantry item '%s': %w", item.ID, err)
+	}
+	return nil
+}
+
+// insertImportedPantryStapleTx makes the ingredient of a staple from an import file a staple.
+// Operates within a transaction.
+func insertImportedPantryStapleTx(ctx context.Context, tx *sql.Tx, staple models.PantryStaple, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[staple.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", staple.IngredientID)
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO pantry_staples (ingredient_id, created_at)
+		VALUES (?, ?)
+		ON CONFLICT (ingredient_id) DO NOTHING`, dbIngredientID, now())
+	if err != nil {
+		return fmt.Errorf("failed to insert pantry staple for ingredient DB ID %s: %w", dbIngredientID, err)
+	}
+	return nil
+}
@@ -0,0 +1,517 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// getOrCreateIngredientByNameTx returns the ID of the ingredient with the given
+// canonical name, or with an alias matching it, creating it if neither exists yet.
+// Operates within a transaction.
+func getOrCreateIngredientByNameTx(ctx context.Context, tx *sql.Tx, name string) (string, error) {
+	var ingredientID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM (
+			SELECT id, 0 AS rank FROM ingredients WHERE name = ?1
+			UNION ALL
+			SELECT ingredient_id, 1 FROM ingredient_aliases WHERE normalized_name = normalize_ingredient_name(?1)
+		)
+		ORDER BY rank
+		LIMIT 1`, name).Scan(&ingredientID)
+	if err == sql.ErrNoRows {
+		ingredientID = uuid.NewString()
+		createdAt := now()
+		insertIngredientQuery := `INSERT INTO ingredients (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`
+		if _, err = tx.ExecContext(ctx, insertIngredientQuery, ingredientID, name, createdAt, createdAt); err != nil {
+			return "", fmt.Errorf("failed to insert new ingredient '%s': %w", name, err)
+		}
+		return ingredientID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query ingredient '%s': %w", name, err)
+	}
+	return ingredientID, nil
+}
+
+// linkRecipeIngredientsTx parses each ingredient line of a recipe and stores the
+// structured result in recipe_ingredients, creating missing ingredients on the way.
+// Every line is kept, including several naming the same ingredient.
+func linkRecipeIngredientsTx(ctx context.Context, tx *sql.Tx, recipeID string, lines []string) error {
+	for i, line := range lines {
+		parsed := parser.ParseIngredient(line)
+		if parsed.Name == "" {
+			continue
+		}
+
+		ingredientID, err := getOrCreateIngredientByNameTx(ctx, tx, parsed.Name)
+		if err != nil {
+			return err
+		}
+
+		insertRecipeIngredientQuery := `INSERT INTO recipe_ingredients
+			(id, recipe_id, ingredient_id, original_text, quantity_text, quantity, quantity_max, unit, preparation, sort_order)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.ExecContext(ctx, insertRecipeIngredientQuery,
+			uuid.NewString(), recipeID, ingredientID, parsed.Original, parsed.QuantityText,
+			parsed.Quantity, parsed.QuantityMax, nullIfEmpty(parsed.Unit), nullIfEmpty(parsed.Preparation), i)
+		if err != nil {
+			return fmt.Errorf("failed to insert recipe_ingredient link for recipe ID %s and ingredient ID %s: %w", recipeID, ingredientID, err)
+		}
+	}
+	return nil
+}
+
+// recipeSelectColumns lists the recipes columns read into models.Recipe, in the
+// order expected by recipeScanDest. Queries must alias the recipes table as "r".
+const recipeSelectColumns = `r.id, r.name, r.method, COALESCE(r.servings, 0), COALESCE(r.yield, ''),
+	COALESCE(r.prep_time_minutes, 0), COALESCE(r.cook_time_minutes, 0), COALESCE(r.rest_time_minutes, 0),
+	COALESCE(r.total_time_minutes, 0), COALESCE(r.photo_filename, ''), r.created_at, r.updated_at, r.deleted_at`
+
+// recipeScanDest returns the scan destinations matching recipeSelectColumns.
+func recipeScanDest(r *models.Recipe) []interface{} {
+	return []interface{}{
+		&r.ID, &r.Name, &r.Method, &r.Servings, &r.Yield,
+		&r.PrepTimeMinutes, &r.CookTimeMinutes, &r.RestTimeMinutes,
+		&r.TotalTimeMinutes, &r.PhotoFilename, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt,
+	}
+}
+
+// ingredientLinesSQL aggregates a recipe's ingredient lines, in order, into a JSON array.
+// %[1]s is the alias of the outer recipes table.
+const ingredientLinesSQL = `(
+			SELECT json_group_array(COALESCE(NULLIF(ri_s.original_text, ''), TRIM(COALESCE(ri_s.quantity_text, '') || ' ' || i_s.name)) ORDER BY ri_s.sort_order ASC)
+			FROM recipe_ingredients ri_s
+			JOIN ingredients i_s ON ri_s.ingredient_id = i_s.id
+			WHERE ri_s.recipe_id = %[1]s.id
+		)`
+
+// tagNamesSQL aggregates the names of a recipe's tags, alphabetically, into a JSON array.
+// %[1]s is the alias of the outer recipes table.
+const tagNamesSQL = `(
+			SELECT json_group_array(t_s.name ORDER BY LOWER(t_s.name) ASC)
+			FROM recipe_tags rt_s
+			JOIN tags t_s ON rt_s.tag_id = t_s.id
+			WHERE rt_s.recipe_id = %[1]s.id
+		)`
+
+// jsonStrings decodes a JSON array of strings produced by json_group_array.
+func jsonStrings(text string) ([]string, error) {
+	list := []string{}
+	if text == "" {
+		return list, nil
+	}
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		return nil, fmt.Errorf("failed to decode list %q: %w", text, err)
+	}
+	return list, nil
+}
+
+// jsonArray encodes a list of strings as a JSON array, for json_each and TEXT list columns.
+func jsonArray(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	encoded, _ := json.Marshal(list)
+	return string(encoded)
+}
+
+// ftsQuery turns free text into an FTS5 query matching rows that contain every word,
+// like plainto_tsquery. Words are quoted, so FTS5 operators in the text are taken literally.
+// It returns "" when the text has no words.
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// RecipeExistsByID checks if a recipe with the given ID exists. Recipes in the trash do not count.
+func (s *Store) RecipeExistsByID(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM recipes WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking recipe existence for ID %s: %w", id, err)
+	}
+	return exists, nil
+}
+
+// GetRecipeByID retrieves a single recipe by its ID, including its ingredients, tags and
+// photos. Recipes in the trash are treated as not found.
+func (s *Store) GetRecipeByID(ctx context.Context, id string) (*models.Recipe, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var recipe models.Recipe
+	recipeQuery := `SELECT ` + recipeSelectColumns + ` FROM recipes r WHERE r.id = ? AND r.deleted_at IS NULL`
+	err := s.db.QueryRowContext(ctx, recipeQuery, id).Scan(recipeScanDest(&recipe)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching recipe with ID %s: %w", id, err)
+	}
+
+	ingredientsQuery := `
+		SELECT ri.ingredient_id, COALESCE(ri.original_text, ''), COALESCE(ri.quantity_text, ''),
+			ri.quantity, ri.quantity_max, COALESCE(ri.unit, ''), COALESCE(ri.preparation, ''), i.name
+		FROM recipe_ingredients ri
+		JOIN ingredients i ON ri.ingredient_id = i.id
+		WHERE ri.recipe_id = ?
+		ORDER BY ri.sort_order ASC`
+	rows, err := s.db.QueryContext(ctx, ingredientsQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ingredients for recipe ID %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	var ingredients []string
+	var structured []models.StructuredIngredient
+	for rows.Next() {
+		var si models.StructuredIngredient
+		var quantity, quantityMax sql.NullFloat64
+		if err := rows.Scan(&si.IngredientID, &si.Original, &si.QuantityText, &quantity, &quantityMax,
+			&si.Unit, &si.Preparation, &si.Name); err != nil {
+			return nil, fmt.Errorf("error scanning ingredient for recipe ID %s: %w", id, err)
+		}
+		si.Quantity = nullFloatPtr(quantity)
+		si.QuantityMax = nullFloatPtr(quantityMax)
+		if si.Original == "" {
+			si.Original = strings.TrimSpace(si.QuantityText + " " + si.Name)
+		}
+		ingredients = append(ingredients, si.Original)
+		structured = append(structured, si)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredients for recipe ID %s: %w", id, err)
+	}
+	rows.Close() // Free the connection for the queries below
+
+	recipe.Ingredients = ingredients
+	recipe.StructuredIngredients = structured
+
+	recipe.Tags, err = s.recipeTagNames(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe.Photos, err = s.GetRecipePhotos(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+// CreateRecipe adds a new recipe with its ingredients, tags and cover photo,
+// and records the result as revision 1, attributed to editor (may be empty).
+func (s *Store) CreateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if recipe.ID == "" {
+		recipe.ID = uuid.NewString()
+	}
+	recipe.CreatedAt = now()
+	recipe.UpdatedAt = recipe.CreatedAt
+
+	recipe.ComputeTotalTime()
+	recipeQuery := `INSERT INTO recipes (id, name, method, servings, yield, prep_time_minutes, cook_time_minutes, rest_time_minutes,
+			photo_filename, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, recipeQuery, recipe.ID, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+		nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+		recipe.PhotoFilename, recipe.CreatedAt, recipe.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert recipe ID %s: %w", recipe.ID, err)
+	}
+
+	if err := linkRecipeIngredientsTx(ctx, tx, recipe.ID, recipe.Ingredients); err != nil {
+		return nil, err
+	}
+
+	recipe.Tags = normalizeTagNames(recipe.Tags)
+	if err := setRecipeTagsTx(ctx, tx, recipe.ID, recipe.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := syncCoverPhotoTx(ctx, tx, recipe.ID, recipe.PhotoFilename); err != nil {
+		return nil, err
+	}
+
+	if err := insertRecipeRevisionTx(ctx, tx, recipe.ID, editor); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return recipe, nil
+}
+
+// recipeTagExistsSQL is an EXISTS subquery matching recipes that carry any of the
+// lowercased tag names in the JSON array bound to its placeholder.
+const recipeTagExistsSQL = `EXISTS (
+			SELECT 1 FROM recipe_tags rt_f
+			JOIN tags t_f ON rt_f.tag_id = t_f.id
+			WHERE rt_f.recipe_id = r.id AND LOWER(t_f.name) IN (SELECT value FROM json_each(?))
+		)`
+
+// GetAllRecipes retrieves recipes with optional search, filtering, and pagination.
+// The search term and ingredient filters are matched with FTS5.
+func (s *Store) GetAllRecipes(ctx context.Context, filter database.RecipeFilter, page int, pageSize int) ([]models.Recipe, int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	var args []interface{}
+	selectSQL := `SELECT ` + recipeSelectColumns + `, ` + fmt.Sprintf(ingredientLinesSQL, "r") + ` AS ingredients_list, ` +
+		fmt.Sprintf(tagNamesSQL, "r") + ` AS tags_list
+		FROM recipes r`
+	countSQL := `SELECT COUNT(*) FROM recipes r`
+
+	// Recipes in the trash are never listed
+	conditions := []string{"r.deleted_at IS NULL"}
+
+	if filter.SearchTerm != "" {
+		if query := ftsQuery(filter.SearchTerm); query != "" {
+			conditions = append(conditions, "r.id IN (SELECT recipe_id FROM recipes_fts WHERE recipes_fts MATCH ?)")
+			args = append(args, query)
+		} else {
+			conditions = append(conditions, "FALSE") // Like an empty tsquery, nothing matches
+		}
+	}
+
+	// Each term must match one of the recipe's ingredients, or with IncludeDescendants an
+	// ingredient below a matching one in the taxonomy
+	for _, filterTerm := range filter.IngredientFilters {
+		query := ftsQuery(filterTerm)
+		if query == "" {
+			conditions = append(conditions, "FALSE")
+			continue
+		}
+		matchingSQL := `SELECT ingredient_id FROM ingredients_fts WHERE ingredients_fts MATCH ?`
+		if filter.IncludeDescendants {
+			matchingSQL = `WITH RECURSIVE matched(id) AS (
+					` + matchingSQL + `
+					UNION
+					SELECT c.id FROM ingredients c JOIN matched m ON c.parent_id = m.id
+				)
+				SELECT id FROM matched`
+		}
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM recipe_ingredients ri_f
+			WHERE ri_f.recipe_id = r.id
+				AND ri_f.ingredient_id IN (`+matchingSQL+`)
+		)`)
+		args = append(args, query)
+	}
+
+	if filter.MaxTotalTime > 0 {
+		conditions = append(conditions, "r.total_time_minutes <= ?")
+		args = append(args, filter.MaxTotalTime)
+	}
+
+	if filter.Servings > 0 {
+		conditions = append(conditions, "r.servings = ?")
+		args = append(args, filter.Servings)
+	}
+
+	// Tag filters: one EXISTS per required tag, a single EXISTS for "any of", NOT EXISTS for exclusions.
+	for _, tag := range normalizeTagNames(filter.TagsAll) {
+		conditions = append(conditions, recipeTagExistsSQL)
+		args = append(args, jsonArray([]string{strings.ToLower(tag)}))
+	}
+
+	if tagsAny := normalizeTagNames(filter.TagsAny); len(tagsAny) > 0 {
+		conditions = append(conditions, recipeTagExistsSQL)
+		args = append(args, jsonArray(lowerAll(tagsAny)))
+	}
+
+	if tagsNone := normalizeTagNames(filter.TagsNone); len(tagsNone) > 0 {
+		conditions = append(conditions, "NOT "+recipeTagExistsSQL)
+		args = append(args, jsonArray(lowerAll(tagsNone)))
+	}
+
+	if len(filter.ExcludeFlags) > 0 {
+		conditions = append(conditions, `NOT EXISTS (
+			SELECT 1 FROM recipe_dietary_flags df_f
+			WHERE df_f.recipe_id = r.id AND df_f.flag IN (SELECT value FROM json_each(?))
+		)`)
+		args = append(args, jsonArray(filter.ExcludeFlags))
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var totalCount int
+	if err := s.db.QueryRowContext(ctx, countSQL+whereClause, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("error counting recipes: %w", err)
+	}
+	if totalCount == 0 {
+		return []models.Recipe{}, 0, nil
+	}
+
+	finalSelectQuery := selectSQL + whereClause + " ORDER BY r.name ASC LIMIT ? OFFSET ?"
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := s.db.QueryContext(ctx, finalSelectQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.Recipe
+	for rows.Next() {
+		var recipe models.Recipe
+		var ingredientsList, tagsList string
+		if err := rows.Scan(append(recipeScanDest(&recipe), &ingredientsList, &tagsList)...); err != nil {
+			return nil, 0, fmt.Errorf("error scanning recipe row: %w", err)
+		}
+		if recipe.Ingredients, err = jsonStrings(ingredientsList); err != nil {
+			return nil, 0, fmt.Errorf("error scanning recipe row: %w", err)
+		}
+		if recipe.Tags, err = jsonStrings(tagsList); err != nil {
+			return nil, 0, fmt.Errorf("error scanning recipe row: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating recipe rows: %w", err)
+	}
+
+	return recipes, totalCount, nil
+}
+
+// UpdateRecipe updates an existing recipe. The previous state is kept in recipe_revisions and
+// the new state is recorded as the next revision, attributed to editor (may be empty).
+func (s *Store) UpdateRecipe(ctx context.Context, recipe *models.Recipe, editor string) (*models.Recipe, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if recipe.ID == "" {
+		return nil, fmt.Errorf("recipe ID cannot be empty for update")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	recipe.UpdatedAt = now()
+	recipe.ComputeTotalTime()
+	updateRecipeQuery := `UPDATE recipes SET name = ?, method = ?, servings = ?, yield = ?,
+			prep_time_minutes = ?, cook_time_minutes = ?, rest_time_minutes = ?, photo_filename = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL`
+	res, err := tx.ExecContext(ctx, updateRecipeQuery, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+		nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+		recipe.PhotoFilename, recipe.UpdatedAt, recipe.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update recipe ID %s: %w", recipe.ID, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected for recipe ID %s: %w", recipe.ID, err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("recipe with ID %s not found for update", recipe.ID)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM recipe_ingredients WHERE recipe_id = ?`, recipe.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete old ingredients for recipe ID %s: %w", recipe.ID, err)
+	}
+	if err := linkRecipeIngredientsTx(ctx, tx, recipe.ID, recipe.Ingredients); err != nil {
+		return nil, fmt.Errorf("failed to link ingredients during update: %w", err)
+	}
+
+	recipe.Tags = normalizeTagNames(recipe.Tags)
+	if err := setRecipeTagsTx(ctx, tx, recipe.ID, recipe.Tags); err != nil {
+		return nil, fmt.Errorf("failed to set tags during update: %w", err)
+	}
+
+	if err := syncCoverPhotoTx(ctx, tx, recipe.ID, recipe.PhotoFilename); err != nil {
+		return nil, err
+	}
+
+	if err := insertRecipeRevisionTx(ctx, tx, recipe.ID, editor); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for recipe update: %w", err)
+	}
+	return recipe, nil
+}
+
+// DeleteRecipe moves a recipe to the trash by setting its deleted_at timestamp.
+// Missing or already deleted recipes are ignored.
+func (s *Store) DeleteRecipe(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if id == "" {
+		return fmt.Errorf("recipe ID cannot be empty for deletion")
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE recipes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recipe ID %s: %w", id, err)
+	}
+	if rowsAffected, err := res.RowsAffected(); err == nil && rowsAffected == 0 {
+		log.Printf("Recipe with ID %s not found for deletion, or already deleted.", id)
+	}
+
+	log.Printf("Recipe moved to trash (or did not exist): ID=%s", id)
+	return nil
+}
+
+// GetAllRecipesForExport fetches every recipe, including those in the trash, oldest first.
+// Ingredients, tags and photos are exported separately.
+func (s *Store) GetAllRecipesForExport(ctx context.Context) ([]models.Recipe, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+recipeSelectColumns+` FROM recipes r ORDER BY r.created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying all recipes for export: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.Recipe
+	for rows.Next() {
+		var r models.Recipe
+		if err := rows.Scan(recipeScanDest(&r)...); err != nil {
+			return nil, fmt.Errorf("error scanning recipe for export: %w", err)
+		}
+		recipes = append(recipes, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe rows for export: %w", err)
+	}
+	return recipes, nil
+}
@@ -0,0 +1,76 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecipeKeepsDuplicateIngredientLines(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	lines := []string{"2 tbsp butter", "1 cup flour", "1 tbsp butter, for frying", "salt", "salt, for the water"}
+	created, err := store.CreateRecipe(ctx, &models.Recipe{Name: "Pancakes", Method: "Mix, then fry.", Ingredients: lines}, "")
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	recipe, err := store.GetRecipeByID(ctx, created.ID)
+	if err != nil || recipe == nil {
+		t.Fatalf("GetRecipeByID = %v, %v", recipe, err)
+	}
+	if !reflect.DeepEqual(recipe.Ingredients, lines) {
+		t.Errorf("Ingredients = %q, want %q", recipe.Ingredients, lines)
+	}
+	structured := recipe.StructuredIngredients
+	if len(structured) != len(lines) {
+		t.Fatalf("got %d structured ingredients, want %d", len(structured), len(lines))
+	}
+	if structured[0].IngredientID != structured[2].IngredientID || structured[3].IngredientID != structured[4].IngredientID {
+		t.Errorf("duplicate lines link different ingredients: %+v", structured)
+	}
+	if q := structured[2].Quantity; q == nil || *q != 1 || structured[2].Unit != "tbsp" || structured[2].Preparation != "for frying" {
+		t.Errorf("second butter line = %+v, want 1 tbsp, for frying", structured[2])
+	}
+
+	// Updating relinks every line, in the new order
+	recipe.Ingredients = []string{"salt, for the water", "2 tbsp butter", "salt", "1 tbsp butter, for frying"}
+	if _, err := store.UpdateRecipe(ctx, recipe, ""); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	updated, err := store.GetRecipeByID(ctx, recipe.ID)
+	if err != nil || updated == nil {
+		t.Fatalf("GetRecipeByID after update = %v, %v", updated, err)
+	}
+	if !reflect.DeepEqual(updated.Ingredients, recipe.Ingredients) {
+		t.Errorf("Ingredients after update = %q, want %q", updated.Ingredients, recipe.Ingredients)
+	}
+
+	summaries, err := store.GetIngredientSummaries(ctx)
+	if err != nil {
+		t.Fatalf("GetIngredientSummaries: %v", err)
+	}
+	usage := make(map[string]int)
+	for _, summary := range summaries {
+		usage[summary.Name] = summary.UsageCount
+	}
+	if usage["butter"] != 1 || usage["salt"] != 1 {
+		t.Errorf("usage counts = %v, want one recipe each for butter and salt", usage)
+	}
+}
+
+// openTestStore opens a migrated database in a temporary directory, closed when the test ends.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open("sqlite://" + filepath.Join(t.TempDir(), "gorecipes.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const revisionSelectColumns = `id, recipe_id, revision, name, method, ingredients, COALESCE(servings, 0), COALESCE(yield, ''),
+	COALESCE(prep_time_minutes, 0), COALESCE(cook_time_minutes, 0), COALESCE(rest_time_minutes, 0),
+	tags, COALESCE(photo_filename, ''), COALESCE(editor, ''), created_at`
+
+// scanRevision scans a row selected with revisionSelectColumns.
+func scanRevision(row interface{ Scan(...interface{}) error }) (models.RecipeRevision, error) {
+	var rev models.RecipeRevision
+	var ingredients, tags string
+	err := row.Scan(&rev.ID, &rev.RecipeID, &rev.Revision, &rev.Name, &rev.Method, &ingredients,
+		&rev.Servings, &rev.Yield, &rev.PrepTimeMinutes, &rev.CookTimeMinutes, &rev.RestTimeMinutes,
+		&tags, &rev.PhotoFilename, &rev.Editor, &rev.CreatedAt)
+	if err != nil {
+		return rev, err
+	}
+	if rev.Ingredients, err = jsonStrings(ingredients); err != nil {
+		return rev, err
+	}
+	rev.Tags, err = jsonStrings(tags)
+	return rev, err
+}
+
+// queryRevisions runs a query selecting revisionSelectColumns and collects the rows.
+func (s *Store) queryRevisions(ctx context.Context, query string, args ...interface{}) ([]models.RecipeRevision, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.RecipeRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revision rows: %w", err)
+	}
+	return revisions, nil
+}
+
+// insertRecipeRevisionTx records the recipe's stored state, including its linked
+// ingredients and tags, as its next revision. Operates within a transaction.
+func insertRecipeRevisionTx(ctx context.Context, tx *sql.Tx, recipeID string, editor string) error {
+	query := `INSERT INTO recipe_revisions (id, recipe_id, revision, name, method, ingredients, servings, yield,
+			prep_time_minutes, cook_time_minutes, rest_time_minutes, tags, photo_filename, editor, created_at)
+		SELECT ?1, r.id,
+			(SELECT COALESCE(MAX(rr.revision), 0) + 1 FROM recipe_revisions rr WHERE rr.recipe_id = r.id),
+			r.name, r.method, ` + fmt.Sprintf(ingredientLinesSQL, "r") + `,
+			r.servings, r.yield, r.prep_time_minutes, r.cook_time_minutes, r.rest_time_minutes,
+			` + fmt.Sprintf(tagNamesSQL, "r") + `,
+			r.photo_filename, ?3, ?4
+		FROM recipes r
+		WHERE r.id = ?2`
+	_, err := tx.ExecContext(ctx, query, uuid.NewString(), recipeID, nullIfEmpty(editor), now())
+	if err != nil {
+		return fmt.Errorf("failed to record revision for recipe ID %s: %w", recipeID, err)
+	}
+	return nil
+}
+
+// insertImportedRevisionTx adds a revision from an import file to a recipe created by the
+// import, keeping its number, ID and timestamp. Operates within a transaction.
+func insertImportedRevisionTx(ctx context.Context, tx *sql.Tx, rev models.RecipeRevision, dbRecipeID string) error {
+	query := `INSERT INTO recipe_revisions (id, recipe_id, revision, name, method, ingredients, servings, yield,
+			prep_time_minutes, cook_time_minutes, rest_time_minutes, tags, photo_filename, editor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`
+	_, err := tx.ExecContext(ctx, query, importedID(rev.ID), dbRecipeID, rev.Revision, rev.Name, rev.Method,
+		jsonArray(rev.Ingredients), nullIfZero(rev.Servings), nullIfEmpty(rev.Yield),
+		nullIfZero(rev.PrepTimeMinutes), nullIfZero(rev.CookTimeMinutes), nullIfZero(rev.RestTimeMinutes),
+		jsonArray(rev.Tags), nullIfEmpty(rev.PhotoFilename), nullIfEmpty(rev.Editor), timeOrNow(rev.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert revision %d for recipe DB ID %s: %w", rev.Revision, dbRecipeID, err)
+	}
+	return nil
+}
+
+// GetRecipeRevisions retrieves every revision of a recipe, newest first.
+func (s *Store) GetRecipeRevisions(ctx context.Context, recipeID string) ([]models.RecipeRevision, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryRevisions(ctx, `SELECT `+revisionSelectColumns+` FROM recipe_revisions
+		WHERE recipe_id = ?
+		ORDER BY revision DESC`, recipeID)
+}
+
+// GetAllRecipeRevisions fetches every revision of every recipe, for export.
+func (s *Store) GetAllRecipeRevisions(ctx context.Context) ([]models.RecipeRevision, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryRevisions(ctx, `SELECT `+revisionSelectColumns+` FROM recipe_revisions
+		ORDER BY recipe_id ASC, revision ASC`)
+}
+
+// GetRecipeRevision retrieves a single revision of a recipe by its number.
+// A revision number of 0 returns the latest revision.
+func (s *Store) GetRecipeRevision(ctx context.Context, recipeID string, revision int) (*models.RecipeRevision, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + revisionSelectColumns + ` FROM recipe_revisions
+		WHERE recipe_id = ?1 AND (?2 = 0 OR revision = ?2)
+		ORDER BY revision DESC
+		LIMIT 1`
+	rev, err := scanRevision(s.db.QueryRowContext(ctx, query, recipeID, revision))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("revision %d of recipe %s not found", revision, recipeID)
+		}
+		return nil, fmt.Errorf("failed to query revision %d of recipe %s: %w", revision, recipeID, err)
+	}
+	return &rev, nil
+}
@@ -0,0 +1,558 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const ingredientAisleSelect = `SELECT a.ingredient_id, i.name, a.aisle, a.updated_at
+	FROM ingredient_aisles a
+	JOIN ingredients i ON i.id = a.ingredient_id`
+
+// scanIngredientAisle scans a row selected by ingredientAisleSelect.
+func scanIngredientAisle(row interface{ Scan(...interface{}) error }) (models.IngredientAisle, error) {
+	var aisle models.IngredientAisle
+	err := row.Scan(&aisle.IngredientID, &aisle.IngredientName, &aisle.Aisle, &aisle.UpdatedAt)
+	return aisle, err
+}
+
+// GetAllIngredientAisles fetches every ingredient given an aisle, ordered by aisle and
+// ingredient name.
+func (s *Store) GetAllIngredientAisles(ctx context.Context) ([]models.IngredientAisle, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, ingredientAisleSelect+` ORDER BY a.aisle ASC, i.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredient aisles: %w", err)
+	}
+	defer rows.Close()
+
+	aisles := []models.IngredientAisle{}
+	for rows.Next() {
+		aisle, err := scanIngredientAisle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient aisle row: %w", err)
+		}
+		aisles = append(aisles, aisle)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingredient aisle rows: %w", err)
+	}
+	return aisles, nil
+}
+
+// SetIngredientAisle puts an ingredient in a store aisle, replacing its previous aisle.
+func (s *Store) SetIngredientAisle(ctx context.Context, ingredientID string, aisle string) (*models.IngredientAisle, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ingredients WHERE id = ?)`, ingredientID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query ingredient with ID %s: %w", ingredientID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingredient with ID %s not found", ingredientID)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aisles (ingredient_id, aisle, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (ingredient_id) DO UPDATE SET aisle = excluded.aisle, updated_at = excluded.updated_at`,
+		ingredientID, aisle, now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set aisle of ingredient %s: %w", ingredientID, err)
+	}
+
+	saved, err := scanIngredientAisle(tx.QueryRowContext(ctx, ingredientAisleSelect+` WHERE a.ingredient_id = ?`, ingredientID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aisle of ingredient %s: %w", ingredientID, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for ingredient aisle: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteIngredientAisle removes an ingredient's own aisle; it then takes the aisle of the
+// ingredients above it in the taxonomy, if any.
+func (s *Store) DeleteIngredientAisle(ctx context.Context, ingredientID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM ingredient_aisles WHERE ingredient_id = ?`, ingredientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete aisle of ingredient %s: %w", ingredientID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("aisle of ingredient %s not found", ingredientID)
+	}
+	return nil
+}
+
+// insertImportedIngredientAisleTx puts an ingredient from an import file in its aisle.
+// An ingredient that already has an aisle keeps it. Operates within a transaction.
+func insertImportedIngredientAisleTx(ctx context.Context, tx *sql.Tx, aisle models.IngredientAisle, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[aisle.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", aisle.IngredientID)
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO ingredient_aisles (ingredient_id, aisle, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (ingredient_id) DO NOTHING`, dbIngredientID, aisle.Aisle, timeOrNow(aisle.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert aisle for ingredient DB ID %s: %w", dbIngredientID, err)
+	}
+	return nil
+}
+
+const shoppingListSelect = `SELECT l.id, l.name, l.start_date, l.end_date, l.recipes, l.version,
+		(SELECT COUNT(*) FROM shopping_list_items s WHERE s.shopping_list_id = l.id),
+		(SELECT COUNT(*) FROM shopping_list_items s WHERE s.shopping_list_id = l.id AND s.checked),
+		l.created_at, l.updated_at
+	FROM shopping_lists l`
+
+const shoppingListItemSelect = `SELECT id, shopping_list_id, COALESCE(ingredient_id, ''), name, quantity, COALESCE(unit, ''), aisle, in_pantry,
+		recipes, manual, checked, COALESCE(bought_by, ''), sort_order, version
+	FROM shopping_list_items`
+
+// queryShoppingLists runs a query selecting shoppingListSelect's columns.
+func queryShoppingLists(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]models.ShoppingList, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shopping lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []models.ShoppingList{}
+	for rows.Next() {
+		var list models.ShoppingList
+		var startDate, endDate sql.NullTime
+		var recipes string
+		err := rows.Scan(&list.ID, &list.Name, &startDate, &endDate, &recipes, &list.Version,
+			&list.ItemCount, &list.CheckedCount, &list.CreatedAt, &list.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list row: %w", err)
+		}
+		if startDate.Valid && endDate.Valid {
+			start, end := dateOnly(startDate.Time), dateOnly(endDate.Time)
+			list.StartDate, list.EndDate = &start, &end
+		}
+		if list.Recipes, err = jsonStrings(recipes); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shopping list rows: %w", err)
+	}
+	return lists, nil
+}
+
+// queryShoppingListItems runs a query selecting shoppingListItemSelect's columns and returns
+// the items by list ID.
+func queryShoppingListItems(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) (map[string][]models.ShoppingListItem, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shopping list items: %w", err)
+	}
+	defer rows.Close()
+
+	items := map[string][]models.ShoppingListItem{}
+	for rows.Next() {
+		var item models.ShoppingListItem
+		var listID, recipes string
+		var quantity sql.NullFloat64
+		err := rows.Scan(&item.ID, &listID, &item.IngredientID, &item.Name, &quantity, &item.Unit, &item.Aisle, &item.InPantry,
+			&recipes, &item.Manual, &item.Checked, &item.BoughtBy, &item.SortOrder, &item.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list item row: %w", err)
+		}
+		item.Quantity = nullFloatPtr(quantity)
+		if item.Recipes, err = jsonStrings(recipes); err != nil {
+			return nil, err
+		}
+		items[listID] = append(items[listID], item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shopping list item rows: %w", err)
+	}
+	return items, nil
+}
+
+// getShoppingList fetches a shopping list with its items in display order.
+func getShoppingList(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, id string) (*models.ShoppingList, error) {
+	lists, err := queryShoppingLists(ctx, q, shoppingListSelect+` WHERE l.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("shopping list with ID %s not found", id)
+	}
+	items, err := queryShoppingListItems(ctx, q, shoppingListItemSelect+` WHERE shopping_list_id = ? ORDER BY sort_order ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	list := lists[0]
+	list.Items = append([]models.ShoppingListItem{}, items[id]...)
+	return &list, nil
+}
+
+// bumpShoppingListVersionTx moves a shopping list on from version to the next, failing when
+// another change got there first. Operates within a transaction.
+func bumpShoppingListVersionTx(ctx context.Context, tx *sql.Tx, id string, version int) error {
+	res, err := tx.ExecContext(ctx, `UPDATE shopping_lists SET version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?`, now(), id, version)
+	if err != nil {
+		return fmt.Errorf("failed to update version of shopping list %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	var current int
+	if err := tx.QueryRowContext(ctx, `SELECT version FROM shopping_lists WHERE id = ?`, id).Scan(&current); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("shopping list with ID %s not found", id)
+		}
+		return fmt.Errorf("failed to query version of shopping list %s: %w", id, err)
+	}
+	return fmt.Errorf("version conflict: shopping list %s is at version %d, not %d", id, current, version)
+}
+
+// insertShoppingListItemTx adds an item to a shopping list, at version 1 unless it comes with
+// one. Operates within a transaction.
+func insertShoppingListItemTx(ctx context.Context, tx *sql.Tx, listID string, item models.ShoppingListItem) error {
+	if item.Version < 1 {
+		item.Version = 1
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO shopping_list_items
+		(id, shopping_list_id, ingredient_id, name, quantity, unit, aisle, in_pantry, recipes, manual, checked, bought_by, sort_order, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ID, listID, nullIfEmpty(item.IngredientID), item.Name, item.Quantity, nullIfEmpty(item.Unit), item.Aisle, item.InPantry,
+		jsonArray(item.Recipes), item.Manual, item.Checked, nullIfEmpty(item.BoughtBy), item.SortOrder, item.Version)
+	if err != nil {
+		return fmt.Errorf("failed to insert shopping list item %s: %w", item.Name, err)
+	}
+	return nil
+}
+
+// GetShoppingLists fetches every shopping list without its items, newest first.
+func (s *Store) GetShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return queryShoppingLists(ctx, s.db, shoppingListSelect+` ORDER BY l.created_at DESC`)
+}
+
+// GetAllShoppingLists fetches every shopping list with its items, oldest first, for export.
+func (s *Store) GetAllShoppingLists(ctx context.Context) ([]models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	lists, err := queryShoppingLists(ctx, s.db, shoppingListSelect+` ORDER BY l.created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	items, err := queryShoppingListItems(ctx, s.db, shoppingListItemSelect+` ORDER BY shopping_list_id, sort_order ASC`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		lists[i].Items = items[lists[i].ID]
+	}
+	return lists, nil
+}
+
+// GetShoppingList fetches a shopping list with its items in display order.
+func (s *Store) GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return getShoppingList(ctx, s.db, id)
+}
+
+// CreateShoppingList stores a new shopping list at version 1, with its items in the order given.
+func (s *Store) CreateShoppingList(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	list.ID = uuid.NewString()
+	createdAt := now()
+	_, err = tx.ExecContext(ctx, `INSERT INTO shopping_lists (id, name, start_date, end_date, recipes, version, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, 1, ?6, ?6)`,
+		list.ID, list.Name, nullDate(list.StartDate), nullDate(list.EndDate), jsonArray(list.Recipes), createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert shopping list: %w", err)
+	}
+	for i, item := range list.Items {
+		item.ID = uuid.NewString()
+		item.SortOrder = i
+		if err := insertShoppingListItemTx(ctx, tx, list.ID, item); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := getShoppingList(ctx, tx, list.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list: %w", err)
+	}
+	return created, nil
+}
+
+// RenameShoppingList renames the shopping list as of version.
+func (s *Store) RenameShoppingList(ctx context.Context, id string, version int, name string) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpShoppingListVersionTx(ctx, tx, id, version); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE shopping_lists SET name = ? WHERE id = ?`, name, id); err != nil {
+		return nil, fmt.Errorf("failed to rename shopping list %s: %w", id, err)
+	}
+
+	list, err := getShoppingList(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list: %w", err)
+	}
+	return list, nil
+}
+
+// DeleteShoppingList removes a shopping list and its items.
+func (s *Store) DeleteShoppingList(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM shopping_lists WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete shopping list %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("shopping list with ID %s not found", id)
+	}
+	return nil
+}
+
+// AddShoppingListItem adds an item to the end of the shopping list as of version.
+func (s *Store) AddShoppingListItem(ctx context.Context, listID string, version int, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpShoppingListVersionTx(ctx, tx, listID, version); err != nil {
+		return nil, err
+	}
+	if err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(sort_order) + 1, 0) FROM shopping_list_items WHERE shopping_list_id = ?`, listID).Scan(&item.SortOrder); err != nil {
+		return nil, fmt.Errorf("failed to query item order of shopping list %s: %w", listID, err)
+	}
+	item.ID = uuid.NewString()
+	if err := insertShoppingListItemTx(ctx, tx, listID, item); err != nil {
+		return nil, err
+	}
+
+	list, err := getShoppingList(ctx, tx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list item: %w", err)
+	}
+	return list, nil
+}
+
+// UpdateShoppingListItem replaces the editable fields of an item of the shopping list as of
+// item.Version, the item's own version: its name, amount, aisle, check mark and buyer. The
+// list's version is left alone, so ticks on different items never conflict.
+func (s *Store) UpdateShoppingListItem(ctx context.Context, listID string, item models.ShoppingListItem) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE shopping_list_items
+		SET name = ?, quantity = ?, unit = ?, aisle = ?, checked = ?, bought_by = ?, version = version + 1
+		WHERE id = ? AND shopping_list_id = ? AND version = ?`,
+		item.Name, item.Quantity, nullIfEmpty(item.Unit), item.Aisle, item.Checked, nullIfEmpty(item.BoughtBy), item.ID, listID, item.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shopping list item %s: %w", item.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		var current int
+		err := tx.QueryRowContext(ctx, `SELECT version FROM shopping_list_items WHERE id = ? AND shopping_list_id = ?`, item.ID, listID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shopping list item with ID %s not found", item.ID)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to query version of shopping list item %s: %w", item.ID, err)
+		}
+		return nil, fmt.Errorf("version conflict: shopping list item %s is at version %d, not %d", item.ID, current, item.Version)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE shopping_lists SET updated_at = ? WHERE id = ?`, now(), listID); err != nil {
+		return nil, fmt.Errorf("failed to update shopping list %s: %w", listID, err)
+	}
+
+	list, err := getShoppingList(ctx, tx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list item: %w", err)
+	}
+	return list, nil
+}
+
+// DeleteShoppingListItem removes an item from the shopping list as of version.
+func (s *Store) DeleteShoppingListItem(ctx context.Context, listID string, version int, itemID string) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpShoppingListVersionTx(ctx, tx, listID, version); err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM shopping_list_items WHERE id = ? AND shopping_list_id = ?`, itemID, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete shopping list item %s: %w", itemID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, fmt.Errorf("shopping list item with ID %s not found", itemID)
+	}
+
+	list, err := getShoppingList(ctx, tx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list item: %w", err)
+	}
+	return list, nil
+}
+
+// ReorderShoppingListItems sets the display order of the items of the shopping list as of
+// version. itemIDs must list every item of the list exactly once, first to last.
+func (s *Store) ReorderShoppingListItems(ctx context.Context, listID string, version int, itemIDs []string) (*models.ShoppingList, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpShoppingListVersionTx(ctx, tx, listID, version); err != nil {
+		return nil, err
+	}
+	current, err := queryShoppingListItems(ctx, tx, shoppingListItemSelect+` WHERE shopping_list_id = ?`, listID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[string]bool, len(current[listID]))
+	for _, item := range current[listID] {
+		remaining[item.ID] = true
+	}
+	if len(itemIDs) != len(remaining) {
+		return nil, fmt.Errorf("item order for shopping list ID %s must list each of its %d items exactly once", listID, len(current[listID]))
+	}
+	for i, itemID := range itemIDs {
+		if !remaining[itemID] {
+			return nil, fmt.Errorf("item order for shopping list ID %s must list each of its %d items exactly once", listID, len(current[listID]))
+		}
+		delete(remaining, itemID)
+		if _, err := tx.ExecContext(ctx, `UPDATE shopping_list_items SET sort_order = ? WHERE id = ?`, i, itemID); err != nil {
+			return nil, fmt.Errorf("failed to reorder shopping list item ID %s: %w", itemID, err)
+		}
+	}
+
+	list, err := getShoppingList(ctx, tx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for shopping list reorder: %w", err)
+	}
+	return list, nil
+}
+
+// insertImportedShoppingListTx adds a shopping list from an import file with its items,
+// keeping its ID. A list that already exists is left as it is. Operates within a transaction.
+func insertImportedShoppingListTx(ctx context.Context, tx *sql.Tx, list models.ShoppingList, ingredientOriginalIDToDbIDMap map[string]string) error {
+	version := list.Version
+	if version < 1 {
+		version = 1
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO shopping_lists (id, name, start_date, end_date, recipes, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		list.ID, list.Name, nullDate(list.StartDate), nullDate(list.EndDate), jsonArray(list.Recipes), version,
+		timeOrNow(list.CreatedAt), timeOrNow(list.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert shopping list %s: %w", list.ID, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	for i, item := range list.Items {
+		if item.IngredientID != "" {
+			dbIngredientID, ok := ingredientOriginalIDToDbIDMap[item.IngredientID]
+			if !ok {
+				return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", item.IngredientID)
+			}
+			item.IngredientID = dbIngredientID
+		}
+		if item.ID == "" {
+			item.ID = uuid.NewString()
+		}
+		item.SortOrder = i
+		if err := insertShoppingListItemTx(ctx, tx, list.ID, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
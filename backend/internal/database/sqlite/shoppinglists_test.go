@@ -0,0 +1,48 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestUpdateShoppingListItemChecksItemVersion(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	list, err := store.CreateShoppingList(ctx, models.ShoppingList{Name: "Saturday", Items: []models.ShoppingListItem{
+		{Name: "milk", Aisle: "Dairy"},
+		{Name: "bread", Aisle: "Bakery"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	milk, bread := list.Items[0], list.Items[1]
+	if milk.Version != 1 || bread.Version != 1 {
+		t.Fatalf("new item versions = %d, %d, want 1", milk.Version, bread.Version)
+	}
+
+	milk.Checked = true
+	if list, err = store.UpdateShoppingListItem(ctx, list.ID, milk); err != nil {
+		t.Fatalf("UpdateShoppingListItem(milk): %v", err)
+	}
+	bread.Checked = true
+	if list, err = store.UpdateShoppingListItem(ctx, list.ID, bread); err != nil {
+		t.Fatalf("UpdateShoppingListItem(bread): %v", err)
+	}
+	if list.Version != 1 || list.CheckedCount != 2 || list.Items[0].Version != 2 {
+		t.Errorf("list = %+v, want list version 1, both items checked and milk at version 2", list)
+	}
+
+	milk.Checked = false
+	if _, err := store.UpdateShoppingListItem(ctx, list.ID, milk); err == nil || !strings.Contains(err.Error(), "version conflict") {
+		t.Errorf("UpdateShoppingListItem from an outdated version = %v, want a version conflict", err)
+	}
+	milk.ID = "missing"
+	if _, err := store.UpdateShoppingListItem(ctx, list.ID, milk); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("UpdateShoppingListItem of a missing item = %v, want not found", err)
+	}
+}
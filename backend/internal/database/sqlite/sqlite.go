@@ -0,0 +1,221 @@
+// Package sqlite is a SQLite implementation of the database repositories, for single-user and
+// offline deployments that don't want to run PostgreSQL. It is selected with a sqlite:
+// DATABASE_URL and follows the PostgreSQL implementation closely, including its error
+// messages, which handlers inspect to pick status codes.
+//
+// Full-text search uses FTS5 tables in place of tsvector columns, and the ingredient name
+// normalization trigger calls database.NormalizeIngredientName, registered as a SQL function
+// on every connection. The driver needs cgo, and binaries must be built with -tags sqlite_fts5.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"io/fs"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the sqlite3 driver with normalize_ingredient_name and pg_trgm's similarity
+// registered on each connection.
+const driverName = "sqlite3_gorecipes"
+
+// connectionParams are appended to the database file name: enforce foreign keys (off by
+// default in SQLite), wait for locks held by other processes such as the migrate command,
+// and take the write lock when a transaction begins rather than halfway through it.
+const connectionParams = "_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("normalize_ingredient_name", database.NormalizeIngredientName, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("similarity", database.TrigramSimilarity, true)
+		},
+	})
+}
+
+// migrationFiles holds the SQLite migrations. They mirror the PostgreSQL ones; a schema
+// change made there needs a migration here with the same version.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationDialect needs no lock: each migration runs in an immediate transaction, which
+// SQLite serializes across processes.
+var migrationDialect = database.MigrationDialect{
+	TableExists: "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1)",
+	CreateTable: `
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+}
+
+// Store implements every repository on top of one SQLite database. Use Open to create one.
+type Store struct {
+	db *sql.DB
+}
+
+var (
+	_ database.RecipeRepository       = (*Store)(nil)
+	_ database.IngredientRepository   = (*Store)(nil)
+	_ database.CommentRepository      = (*Store)(nil)
+	_ database.MealPlanRepository     = (*Store)(nil)
+	_ database.NutritionRepository    = (*Store)(nil)
+	_ database.DietaryRepository      = (*Store)(nil)
+	_ database.SubstitutionRepository = (*Store)(nil)
+	_ database.PantryRepository       = (*Store)(nil)
+	_ database.ShoppingListRepository = (*Store)(nil)
+)
+
+// IsURL reports whether a DATABASE_URL selects SQLite, i.e. has the sqlite: scheme.
+func IsURL(databaseURL string) bool {
+	return strings.HasPrefix(strings.ToLower(databaseURL), "sqlite:")
+}
+
+// fileName returns the database file a sqlite: URL points at, followed by the connection
+// parameters: sqlite:///abs/path.db and sqlite:/abs/path.db are absolute, sqlite://rel.db and
+// sqlite:rel.db relative to the working directory. Driver parameters in the URL are kept.
+func fileName(databaseURL string) (string, error) {
+	if !IsURL(databaseURL) {
+		return "", fmt.Errorf("database URL must start with sqlite:")
+	}
+	name := databaseURL[len("sqlite:"):]
+	if strings.HasPrefix(name, "//") {
+		name = name[len("//"):]
+	}
+	name, query, _ := strings.Cut(name, "?")
+	if name == "" {
+		return "", fmt.Errorf("database URL %q does not name a database file", databaseURL)
+	}
+	if query != "" {
+		return name + "?" + query + "&" + connectionParams, nil
+	}
+	return name + "?" + connectionParams, nil
+}
+
+// OpenDB opens the SQLite database a sqlite: DATABASE_URL points at, without migrating it.
+func OpenDB(databaseURL string) (*sql.DB, error) {
+	name, err := fileName(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driverName, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection queues requests in the
+	// pool instead of failing them with "database is locked". It also keeps ":memory:"
+	// databases, which are private to their connection, intact.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var hasFTS5 bool
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&hasFTS5); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if !hasFTS5 {
+		db.Close()
+		return nil, fmt.Errorf("SQLite was built without FTS5; build the backend with -tags sqlite_fts5")
+	}
+	return db, nil
+}
+
+// NewMigrator returns a Migrator for the SQLite migrations embedded in the binary.
+func NewMigrator(db *sql.DB) (*database.Migrator, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error opening embedded migrations: %w", err)
+	}
+	return database.NewMigratorFS(db, sub, migrationDialect)
+}
+
+// Open opens the SQLite database a sqlite: DATABASE_URL points at, creating the file if
+// needed, and brings its schema up to date.
+func Open(databaseURL string) (*Store, error) {
+	log.Println("Initializing SQLite database...")
+	db, err := OpenDB(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	log.Printf("SQLite database schema is up to date (%d migrations applied now).", len(applied))
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Repositories returns every repository backed by the store.
+func (s *Store) Repositories() database.Repositories {
+	return database.Repositories{Recipes: s, Ingredients: s, Comments: s, MealPlans: s, Nutrition: s, Dietary: s, Substitutions: s, Pantry: s, ShoppingLists: s}
+}
+
+// isUniqueViolation reports whether err is a SQLite unique constraint violation. The message
+// is matched rather than the error code, so the package still builds without cgo.
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// isForeignKeyViolation reports whether err is a SQLite foreign key violation.
+func isForeignKeyViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
+
+// nullIfEmpty converts an empty string into a SQL NULL.
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullIfZero converts a zero integer (meaning "unknown") into a SQL NULL.
+func nullIfZero(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullFloatPtr converts a scanned nullable numeric column into a *float64.
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
+
+// now returns the current time in UTC. Times are stored as text, so they must all be UTC
+// to compare and sort correctly.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// utcPtr converts an optional time to UTC.
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
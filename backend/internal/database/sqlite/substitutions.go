@@ -0,0 +1,251 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const substitutionSelect = `SELECT s.id, s.ingredient_id, i.name, s.quantity, COALESCE(s.unit, ''), COALESCE(s.notes, ''), s.created_at, s.updated_at
+	FROM substitutions s
+	JOIN ingredients i ON i.id = s.ingredient_id`
+
+// querySubstitutions runs a query selecting substitutionSelect's columns and fills in the
+// replacements of the substitutions found.
+func (s *Store) querySubstitutions(ctx context.Context, query string, args ...interface{}) ([]models.Substitution, error) {
+	substitutions, err := s.scanSubstitutions(ctx, query, args...)
+	if err != nil || len(substitutions) == 0 {
+		return substitutions, err
+	}
+
+	positions := make(map[string]int, len(substitutions))
+	ids := make([]string, len(substitutions))
+	for i, sub := range substitutions {
+		positions[sub.ID] = i
+		ids[i] = sub.ID
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT sr.substitution_id, sr.ingredient_id, i.name, sr.quantity, COALESCE(sr.unit, '')
+		FROM substitution_replacements sr
+		JOIN ingredients i ON i.id = sr.ingredient_id
+		WHERE sr.substitution_id IN (SELECT value FROM json_each(?))
+		ORDER BY sr.substitution_id, sr.sort_order`, jsonArray(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query substitution replacements: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var substitutionID string
+		var r models.SubstitutionReplacement
+		if err := rows.Scan(&substitutionID, &r.IngredientID, &r.IngredientName, &r.Quantity, &r.Unit); err != nil {
+			return nil, fmt.Errorf("failed to scan substitution replacement row: %w", err)
+		}
+		sub := &substitutions[positions[substitutionID]]
+		sub.Replacements = append(sub.Replacements, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating substitution replacement rows: %w", err)
+	}
+	return substitutions, nil
+}
+
+// scanSubstitutions runs a query selecting substitutionSelect's columns, without replacements.
+func (s *Store) scanSubstitutions(ctx context.Context, query string, args ...interface{}) ([]models.Substitution, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query substitutions: %w", err)
+	}
+	defer rows.Close()
+
+	substitutions := []models.Substitution{}
+	for rows.Next() {
+		var sub models.Substitution
+		if err := rows.Scan(&sub.ID, &sub.IngredientID, &sub.IngredientName, &sub.Quantity, &sub.Unit, &sub.Notes, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan substitution row: %w", err)
+		}
+		sub.Replacements = []models.SubstitutionReplacement{}
+		substitutions = append(substitutions, sub)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating substitution rows: %w", err)
+	}
+	return substitutions, nil
+}
+
+// GetAllSubstitutions fetches the whole substitutions knowledge base, ordered by the name of
+// the ingredient replaced.
+func (s *Store) GetAllSubstitutions(ctx context.Context) ([]models.Substitution, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return s.querySubstitutions(ctx, substitutionSelect+` ORDER BY i.name ASC, s.created_at ASC`)
+}
+
+// GetSubstitutionsForIngredients fetches the substitutions replacing any of the given ingredients.
+func (s *Store) GetSubstitutionsForIngredients(ctx context.Context, ingredientIDs []string) ([]models.Substitution, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if len(ingredientIDs) == 0 {
+		return []models.Substitution{}, nil
+	}
+	return s.querySubstitutions(ctx, substitutionSelect+` WHERE s.ingredient_id IN (SELECT value FROM json_each(?)) ORDER BY s.created_at ASC`, jsonArray(ingredientIDs))
+}
+
+// getSubstitutionByID fetches a single substitution.
+func (s *Store) getSubstitutionByID(ctx context.Context, id string) (*models.Substitution, error) {
+	substitutions, err := s.querySubstitutions(ctx, substitutionSelect+` WHERE s.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(substitutions) == 0 {
+		return nil, fmt.Errorf("substitution with ID %s not found", id)
+	}
+	return &substitutions[0], nil
+}
+
+// insertSubstitutionReplacementsTx stores the replacements of a substitution in order,
+// resolving their ingredients. Operates within a transaction.
+func insertSubstitutionReplacementsTx(ctx context.Context, tx *sql.Tx, substitutionID string, replacements []models.SubstitutionReplacement) error {
+	for i, r := range replacements {
+		ingredientID, err := resolveIngredientTx(ctx, tx, r.IngredientID, r.IngredientName)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO substitution_replacements (substitution_id, sort_order, ingredient_id, quantity, unit)
+			VALUES (?, ?, ?, ?, ?)`, substitutionID, i, ingredientID, r.Quantity, nullIfEmpty(r.Unit))
+		if err != nil {
+			return fmt.Errorf("failed to insert replacement %d of substitution %s: %w", i, substitutionID, err)
+		}
+	}
+	return nil
+}
+
+// CreateSubstitution adds a substitution to the knowledge base. The ingredient and the
+// replacements are given by ID, or by canonical name when the ID is empty, in which case
+// missing ingredients are created.
+func (s *Store) CreateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ingredientID, err := resolveIngredientTx(ctx, tx, sub.IngredientID, sub.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	sub.ID = uuid.NewString()
+	createdAt := now()
+	_, err = tx.ExecContext(ctx, `INSERT INTO substitutions (id, ingredient_id, quantity, unit, notes, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?6)`, sub.ID, ingredientID, sub.Quantity, nullIfEmpty(sub.Unit), nullIfEmpty(sub.Notes), createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert substitution: %w", err)
+	}
+	if err = insertSubstitutionReplacementsTx(ctx, tx, sub.ID, sub.Replacements); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for substitution: %w", err)
+	}
+	return s.getSubstitutionByID(ctx, sub.ID)
+}
+
+// UpdateSubstitution replaces an existing substitution, replacements included. Ingredients
+// are resolved as in CreateSubstitution.
+func (s *Store) UpdateSubstitution(ctx context.Context, sub models.Substitution) (*models.Substitution, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM substitutions WHERE id = ?)`, sub.ID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query substitution with ID %s: %w", sub.ID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("substitution with ID %s not found", sub.ID)
+	}
+	ingredientID, err := resolveIngredientTx(ctx, tx, sub.IngredientID, sub.IngredientName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE substitutions SET ingredient_id = ?, quantity = ?, unit = ?, notes = ?, updated_at = ?
+		WHERE id = ?`, ingredientID, sub.Quantity, nullIfEmpty(sub.Unit), nullIfEmpty(sub.Notes), now(), sub.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update substitution %s: %w", sub.ID, err)
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM substitution_replacements WHERE substitution_id = ?`, sub.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear replacements of substitution %s: %w", sub.ID, err)
+	}
+	if err = insertSubstitutionReplacementsTx(ctx, tx, sub.ID, sub.Replacements); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for substitution: %w", err)
+	}
+	return s.getSubstitutionByID(ctx, sub.ID)
+}
+
+// DeleteSubstitution removes a substitution from the knowledge base.
+func (s *Store) DeleteSubstitution(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM substitutions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete substitution %s: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for substitution deletion: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("substitution with ID %s not found", id)
+	}
+	return nil
+}
+
+// insertImportedSubstitutionTx adds a substitution from an import file, keeping its ID and
+// timestamps. A substitution that is already present is left untouched.
+// Operates within a transaction.
+func insertImportedSubstitutionTx(ctx context.Context, tx *sql.Tx, sub models.Substitution, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbIngredientID, ok := ingredientOriginalIDToDbIDMap[sub.IngredientID]
+	if !ok {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", sub.IngredientID)
+	}
+	replacements := make([]models.SubstitutionReplacement, len(sub.Replacements))
+	for i, r := range sub.Replacements {
+		dbReplacementID, ok := ingredientOriginalIDToDbIDMap[r.IngredientID]
+		if !ok {
+			return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", r.IngredientID)
+		}
+		r.IngredientID = dbReplacementID
+		replacements[i] = r
+	}
+
+	id := importedID(sub.ID)
+	result, err := tx.ExecContext(ctx, `INSERT INTO substitutions (id, ingredient_id, quantity, unit, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`, id, dbIngredientID, sub.Quantity, nullIfEmpty(sub.Unit), nullIfEmpty(sub.Notes),
+		timeOrNow(sub.CreatedAt), timeOrNow(sub.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert substitution '%s': %w", sub.ID, err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		return err
+	}
+	return insertSubstitutionReplacementsTx(ctx, tx, id, replacements)
+}
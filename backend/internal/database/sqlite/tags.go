@@ -0,0 +1,245 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// normalizeTagNames normalizes a list of tag names, dropping blanks and
+// case-insensitive duplicates while keeping the first spelling.
+func normalizeTagNames(names []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range names {
+		name = database.NormalizeTagName(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// lowerAll returns a lowercased copy of names, for matching against LOWER(tags.name).
+func lowerAll(names []string) []string {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	return lowered
+}
+
+// GetAllTags retrieves every tag with the number of live recipes using it, ordered by category and name.
+// An empty category returns tags of all categories.
+func (s *Store) GetAllTags(ctx context.Context, category string) ([]models.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT t.id, t.name, COALESCE(t.category, ''), COUNT(r.id), t.created_at, t.updated_at
+		FROM tags t
+		LEFT JOIN recipe_tags rt ON rt.tag_id = t.id
+		LEFT JOIN recipes r ON r.id = rt.recipe_id AND r.deleted_at IS NULL
+		WHERE (?1 = '' OR LOWER(t.category) = LOWER(?1))
+		GROUP BY t.id
+		ORDER BY COALESCE(t.category, '') ASC, LOWER(t.name) ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Category, &tag.RecipeCount, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+	return tags, nil
+}
+
+// GetTagByID retrieves a single tag and its recipe count.
+func (s *Store) GetTagByID(ctx context.Context, tagID string) (*models.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var tag models.Tag
+	query := `SELECT t.id, t.name, COALESCE(t.category, ''),
+			(SELECT COUNT(*) FROM recipe_tags rt JOIN recipes r ON r.id = rt.recipe_id
+				WHERE rt.tag_id = t.id AND r.deleted_at IS NULL), t.created_at, t.updated_at
+		FROM tags t
+		WHERE t.id = ?`
+	err := s.db.QueryRowContext(ctx, query, tagID).Scan(&tag.ID, &tag.Name, &tag.Category, &tag.RecipeCount, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag with ID %s not found", tagID)
+		}
+		return nil, fmt.Errorf("failed to query tag with ID %s: %w", tagID, err)
+	}
+	return &tag, nil
+}
+
+// CreateTag inserts a new tag. Names must be unique regardless of case.
+func (s *Store) CreateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if tag.ID == "" {
+		tag.ID = uuid.NewString()
+	}
+	tag.CreatedAt = now()
+	tag.UpdatedAt = tag.CreatedAt
+
+	query := `INSERT INTO tags (id, name, category, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := s.db.ExecContext(ctx, query, tag.ID, tag.Name, nullIfEmpty(tag.Category), tag.CreatedAt, tag.UpdatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+		}
+		return nil, fmt.Errorf("failed to insert tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// UpdateTag renames or recategorizes an existing tag.
+func (s *Store) UpdateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `SELECT created_at FROM tags WHERE id = ?`, tag.ID).Scan(&tag.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag with ID %s not found for update", tag.ID)
+		}
+		return nil, fmt.Errorf("failed to update tag with ID %s: %w", tag.ID, err)
+	}
+
+	tag.UpdatedAt = now()
+	_, err = tx.ExecContext(ctx, `UPDATE tags SET name = ?, category = ?, updated_at = ? WHERE id = ?`,
+		tag.Name, nullIfEmpty(tag.Category), tag.UpdatedAt, tag.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+		}
+		return nil, fmt.Errorf("failed to update tag with ID %s: %w", tag.ID, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for tag update: %w", err)
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag. Its links to recipes are removed by ON DELETE CASCADE.
+func (s *Store) DeleteTag(ctx context.Context, tagID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, tagID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag with ID %s: %w", tagID, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for tag ID %s: %w", tagID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag with ID %s not found for deletion", tagID)
+	}
+	return nil
+}
+
+// getOrCreateTagByNameTx returns the ID of the tag with the given name (case-insensitive),
+// creating it if it does not exist yet. Operates within a transaction.
+func getOrCreateTagByNameTx(ctx context.Context, tx *sql.Tx, name string, category string) (string, error) {
+	var tagID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE LOWER(name) = LOWER(?)`, name).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		tagID = uuid.NewString()
+		createdAt := now()
+		insertQuery := `INSERT INTO tags (id, name, category, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+		if _, err = tx.ExecContext(ctx, insertQuery, tagID, name, nullIfEmpty(category), createdAt, createdAt); err != nil {
+			return "", fmt.Errorf("failed to insert new tag '%s': %w", name, err)
+		}
+		return tagID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query tag '%s': %w", name, err)
+	}
+	return tagID, nil
+}
+
+// setRecipeTagsTx replaces the tags linked to a recipe with the given tag names,
+// creating tags that do not exist yet. Operates within a transaction.
+func setRecipeTagsTx(ctx context.Context, tx *sql.Tx, recipeID string, names []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recipe_tags WHERE recipe_id = ?`, recipeID); err != nil {
+		return fmt.Errorf("failed to delete old tags for recipe ID %s: %w", recipeID, err)
+	}
+	for _, name := range normalizeTagNames(names) {
+		tagID, err := getOrCreateTagByNameTx(ctx, tx, name, "")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO recipe_tags (recipe_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, recipeID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to link tag '%s' to recipe ID %s: %w", name, recipeID, err)
+		}
+	}
+	return nil
+}
+
+// recipeTagNames returns the names of the tags linked to a recipe, alphabetically.
+func (s *Store) recipeTagNames(ctx context.Context, recipeID string) ([]string, error) {
+	var list string
+	err := s.db.QueryRowContext(ctx, `SELECT `+fmt.Sprintf(tagNamesSQL, "r")+` FROM recipes r WHERE r.id = ?`, recipeID).Scan(&list)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tags for recipe ID %s: %w", recipeID, err)
+	}
+	names, err := jsonStrings(list)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetAllRecipeTags fetches all recipe_tags links, for export.
+func (s *Store) GetAllRecipeTags(ctx context.Context) ([]models.RecipeTag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT recipe_id, tag_id FROM recipe_tags ORDER BY recipe_id ASC, tag_id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying recipe_tags: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.RecipeTag
+	for rows.Next() {
+		var link models.RecipeTag
+		if err := rows.Scan(&link.RecipeID, &link.TagID); err != nil {
+			return nil, fmt.Errorf("error scanning recipe_tag: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe_tag rows: %w", err)
+	}
+	return links, nil
+}
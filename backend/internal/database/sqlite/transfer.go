@@ -0,0 +1,406 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportRecipeDataBundle handles the import of recipes, ingredients and their aliases, and their links,
+// along with tags, photos, revisions, comments and meal plan entries, within a single
+// database transaction. Ingredients are matched by normalized name and recipes by ID;
+// rows created by the import keep the IDs and timestamps from the file, so data moves
+// between this and the PostgreSQL backend unchanged.
+// It returns counts of successfully imported items or an error if the process fails.
+func (s *Store) ImportRecipeDataBundle(ctx context.Context, data models.ExportedData) (importedRecipes int, importedIngredients int, importedLinks int, err error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p) // re-panic after Rollback
+		} else if err != nil {
+			tx.Rollback() // err is non-nil; don't change it
+		} else {
+			err = tx.Commit() // if commit fails, err will be set
+		}
+	}()
+
+	// Maps to store original ID (from JSON) to new/existing DB ID
+	ingredientOriginalIDToDbIDMap := make(map[string]string)
+	recipeOriginalIDToDbIDMap := make(map[string]string)
+	var createdRecipeIDs []string                  // Recipes new to this database, which get a first revision
+	createdRecipePhotos := make(map[string]string) // DB ID of a created recipe to its cover photo
+
+	// 1. Import Ingredients
+	for _, ingFromFile := range data.Ingredients {
+		dbIngredientID, createErr := getOrCreateIngredientTx(ctx, tx, ingFromFile)
+		if createErr != nil {
+			err = fmt.Errorf("error processing ingredient '%s': %w", ingFromFile.Name, createErr)
+			return
+		}
+		ingredientOriginalIDToDbIDMap[ingFromFile.ID] = dbIngredientID
+		importedIngredients++
+	}
+	log.Printf("Processed %d ingredients. Map size: %d", len(data.Ingredients), len(ingredientOriginalIDToDbIDMap))
+	for _, aliasFromFile := range data.IngredientAliases {
+		if createErr := insertImportedAliasTx(ctx, tx, aliasFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing ingredient alias '%s': %w", aliasFromFile.Name, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d ingredient aliases.", len(data.IngredientAliases))
+	for _, ingFromFile := range data.Ingredients {
+		if ingFromFile.ParentID == "" {
+			continue
+		}
+		if createErr := setImportedIngredientParentTx(ctx, tx, ingFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing parent of ingredient '%s': %w", ingFromFile.Name, createErr)
+			return
+		}
+	}
+	for _, mappingFromFile := range data.IngredientFoods {
+		if createErr := insertImportedIngredientFoodTx(ctx, tx, mappingFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing food of ingredient '%s': %w", mappingFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d ingredient food mappings.", len(data.IngredientFoods))
+	for _, flaggedFromFile := range data.IngredientDietaryFlags {
+		if createErr := insertImportedIngredientDietaryFlagsTx(ctx, tx, flaggedFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing dietary flags of ingredient '%s': %w", flaggedFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed dietary flags of %d ingredients.", len(data.IngredientDietaryFlags))
+	for _, subFromFile := range data.Substitutions {
+		if createErr := insertImportedSubstitutionTx(ctx, tx, subFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing substitution '%s' for ingredient '%s': %w", subFromFile.ID, subFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d substitutions.", len(data.Substitutions))
+	for _, itemFromFile := range data.PantryItems {
+		if createErr := insertImportedPantryItemTx(ctx, tx, itemFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing pantry item '%s' for ingredient '%s': %w", itemFromFile.ID, itemFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	for _, stapleFromFile := range data.PantryStaples {
+		if createErr := insertImportedPantryStapleTx(ctx, tx, stapleFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing pantry staple '%s': %w", stapleFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d pantry items and %d staples.", len(data.PantryItems), len(data.PantryStaples))
+	for _, aisleFromFile := range data.IngredientAisles {
+		if createErr := insertImportedIngredientAisleTx(ctx, tx, aisleFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing aisle of ingredient '%s': %w", aisleFromFile.IngredientID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed aisles of %d ingredients.", len(data.IngredientAisles))
+	for _, listFromFile := range data.ShoppingLists {
+		if createErr := insertImportedShoppingListTx(ctx, tx, listFromFile, ingredientOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing shopping list '%s': %w", listFromFile.Name, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d shopping lists.", len(data.ShoppingLists))
+
+	// 2. Import Recipes
+	for _, recFromFile := range data.Recipes {
+		dbRecipeID, created, createErr := getOrCreateRecipeTx(ctx, tx, recFromFile)
+		if createErr != nil {
+			err = fmt.Errorf("error processing recipe '%s': %w", recFromFile.Name, createErr)
+			return
+		}
+		recipeOriginalIDToDbIDMap[recFromFile.ID] = dbRecipeID
+		if created {
+			createdRecipeIDs = append(createdRecipeIDs, dbRecipeID)
+			createdRecipePhotos[dbRecipeID] = recFromFile.PhotoFilename
+		}
+		importedRecipes++
+	}
+	log.Printf("Processed %d recipes. Map size: %d", len(data.Recipes), len(recipeOriginalIDToDbIDMap))
+
+	// 3. Import Recipe-Ingredient Links
+	for _, riFromFile := range data.RecipeIngredients {
+		createErr := insertRecipeIngredientLinkTx(ctx, tx, riFromFile, recipeOriginalIDToDbIDMap, ingredientOriginalIDToDbIDMap)
+		if createErr != nil {
+			err = fmt.Errorf("error processing recipe_ingredient link for recipe '%s' and ingredient '%s': %w", riFromFile.RecipeID, riFromFile.IngredientID, createErr)
+			return
+		}
+		importedLinks++
+	}
+	log.Printf("Processed %d recipe_ingredient links.", len(data.RecipeIngredients))
+
+	// 4. Import Tags and Recipe-Tag Links
+	tagOriginalIDToDbIDMap := make(map[string]string)
+	for _, tagFromFile := range data.Tags {
+		name := database.NormalizeTagName(tagFromFile.Name)
+		if name == "" {
+			continue
+		}
+		tagFromFile.Name = name
+		dbTagID, createErr := getOrCreateImportedTagTx(ctx, tx, tagFromFile)
+		if createErr != nil {
+			err = fmt.Errorf("error processing tag '%s': %w", tagFromFile.Name, createErr)
+			return
+		}
+		tagOriginalIDToDbIDMap[tagFromFile.ID] = dbTagID
+	}
+	for _, linkFromFile := range data.RecipeTags {
+		createErr := insertRecipeTagLinkTx(ctx, tx, linkFromFile, recipeOriginalIDToDbIDMap, tagOriginalIDToDbIDMap)
+		if createErr != nil {
+			err = fmt.Errorf("error processing recipe_tag link for recipe '%s' and tag '%s': %w", linkFromFile.RecipeID, linkFromFile.TagID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d tags and %d recipe_tag links.", len(data.Tags), len(data.RecipeTags))
+
+	// 5. Import Recipe Photos and Dietary Overrides
+	for _, photoFromFile := range data.RecipePhotos {
+		if createErr := insertRecipePhotoTx(ctx, tx, photoFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing photo '%s' for recipe '%s': %w", photoFromFile.Filename, photoFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d recipe photos.", len(data.RecipePhotos))
+	for _, overridesFromFile := range data.RecipeDietaryOverrides {
+		if createErr := insertImportedRecipeDietaryOverridesTx(ctx, tx, overridesFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing dietary overrides of recipe '%s': %w", overridesFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed dietary overrides of %d recipes.", len(data.RecipeDietaryOverrides))
+
+	// 6. Import Comments and Meal Plan Entries
+	for _, commentFromFile := range data.Comments {
+		if createErr := insertImportedCommentTx(ctx, tx, commentFromFile, recipeOriginalIDToDbIDMap); createErr != nil {
+			err = fmt.Errorf("error processing comment '%s' for recipe '%s': %w", commentFromFile.ID, commentFromFile.RecipeID, createErr)
+			return
+		}
+	}
+	for _, entryFromFile := range data.MealPlanEntries {
+		if dbRecipeID, ok := recipeOriginalIDToDbIDMap[entryFromFile.RecipeID]; ok {
+			entryFromFile.RecipeID = dbRecipeID
+		}
+		if createErr := insertImportedMealPlanEntryTx(ctx, tx, entryFromFile); createErr != nil {
+			err = fmt.Errorf("error processing meal plan entry '%s': %w", entryFromFile.ID, createErr)
+			return
+		}
+	}
+	log.Printf("Processed %d comments and %d meal plan entries.", len(data.Comments), len(data.MealPlanEntries))
+
+	// 7. Set the cover of newly created recipes, now that their ingredients, tags and photos are
+	// linked, and bring over their history. Recipes exported without revisions get a first one.
+	revisionsByRecipe := make(map[string][]models.RecipeRevision)
+	for _, revFromFile := range data.RecipeRevisions {
+		dbRecipeID, ok := recipeOriginalIDToDbIDMap[revFromFile.RecipeID]
+		if !ok {
+			err = fmt.Errorf("error processing revision %d of recipe '%s': could not find DB ID for original recipe ID '%s'", revFromFile.Revision, revFromFile.RecipeID, revFromFile.RecipeID)
+			return
+		}
+		revisionsByRecipe[dbRecipeID] = append(revisionsByRecipe[dbRecipeID], revFromFile)
+	}
+	for _, recipeID := range createdRecipeIDs {
+		if coverErr := syncCoverPhotoTx(ctx, tx, recipeID, createdRecipePhotos[recipeID]); coverErr != nil {
+			err = coverErr
+			return
+		}
+		if len(revisionsByRecipe[recipeID]) == 0 {
+			if revErr := insertRecipeRevisionTx(ctx, tx, recipeID, "import"); revErr != nil {
+				err = revErr
+				return
+			}
+		}
+		for _, rev := range revisionsByRecipe[recipeID] {
+			if revErr := insertImportedRevisionTx(ctx, tx, rev, recipeID); revErr != nil {
+				err = revErr
+				return
+			}
+		}
+	}
+
+	// 8. Imported links and food mappings can change the nutrition of existing recipes
+	if _, execErr := tx.ExecContext(ctx, `DELETE FROM recipe_nutrition`); execErr != nil {
+		err = fmt.Errorf("failed to clear cached recipe nutrition: %w", execErr)
+		return
+	}
+
+	return // err will be nil if commit succeeds, or set by defer if commit fails or rollback occurs
+}
+
+// importedID returns an ID from an import file if it is a valid UUID, and a new UUID otherwise.
+func importedID(id string) string {
+	if _, err := uuid.Parse(id); err != nil {
+		return uuid.NewString()
+	}
+	return id
+}
+
+// importedIDTx returns the ID an imported row is stored under in table: its ID from the import
+// file if that is a valid UUID no other row uses yet, and a new UUID otherwise.
+// Operates within a transaction.
+func importedIDTx(ctx context.Context, tx *sql.Tx, table string, id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return uuid.NewString(), nil
+	}
+	var taken bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = ?)`, id).Scan(&taken); err != nil {
+		return "", fmt.Errorf("failed to check for existing %s ID %s: %w", table, id, err)
+	}
+	if taken {
+		return uuid.NewString(), nil
+	}
+	return id, nil
+}
+
+// timeOrNow returns t in UTC, or the current time if t is zero (missing from an import file).
+func timeOrNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return now()
+	}
+	return t.UTC()
+}
+
+// getOrCreateIngredientTx finds an ingredient by its normalized name or creates it if not found,
+// keeping the imported ID and timestamps. Files with no normalized name are matched on the
+// name normalized as the trigger would. Operates within a transaction.
+func getOrCreateIngredientTx(ctx context.Context, tx *sql.Tx, ingredient models.Ingredient) (string, error) {
+	normalizedName := ingredient.NormalizedName
+	if normalizedName == "" {
+		normalizedName = database.NormalizeIngredientName(ingredient.Name)
+	}
+
+	var dbIngredientID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM ingredients WHERE normalized_name = ?`, normalizedName).Scan(&dbIngredientID)
+	if err == sql.ErrNoRows {
+		newID, idErr := importedIDTx(ctx, tx, "ingredients", ingredient.ID)
+		if idErr != nil {
+			return "", idErr
+		}
+		// normalized_name is set by a trigger from name.
+		_, err = tx.ExecContext(ctx, `INSERT INTO ingredients (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+			newID, ingredient.Name, timeOrNow(ingredient.CreatedAt), timeOrNow(ingredient.UpdatedAt))
+		if err != nil {
+			return "", fmt.Errorf("failed to insert new ingredient '%s': %w", ingredient.Name, err)
+		}
+		log.Printf("Created new ingredient: Name='%s', DB_ID='%s'", ingredient.Name, newID)
+		return newID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query for existing ingredient '%s' (normalized: '%s'): %w", ingredient.Name, normalizedName, err)
+	}
+
+	log.Printf("Found existing ingredient: Name='%s', DB_ID='%s', Normalized='%s'", ingredient.Name, dbIngredientID, normalizedName)
+	return dbIngredientID, nil
+}
+
+// getOrCreateRecipeTx finds a recipe by the ID it was exported with or creates it if not found.
+// Recipes are not matched by name, which two of them can share. Operates within a transaction.
+// Returns the database ID of the recipe and whether it was created.
+func getOrCreateRecipeTx(ctx context.Context, tx *sql.Tx, recipe models.Recipe) (string, bool, error) {
+	var dbRecipeID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM recipes WHERE id = ?`, recipe.ID).Scan(&dbRecipeID)
+	if err == sql.ErrNoRows {
+		newID, idErr := importedIDTx(ctx, tx, "recipes", recipe.ID)
+		if idErr != nil {
+			return "", false, idErr
+		}
+		insertQuery := `INSERT INTO recipes (id, name, method, servings, yield, prep_time_minutes, cook_time_minutes, rest_time_minutes,
+				photo_filename, created_at, updated_at, deleted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.ExecContext(ctx, insertQuery, newID, recipe.Name, recipe.Method, nullIfZero(recipe.Servings), nullIfEmpty(recipe.Yield),
+			nullIfZero(recipe.PrepTimeMinutes), nullIfZero(recipe.CookTimeMinutes), nullIfZero(recipe.RestTimeMinutes),
+			nullIfEmpty(recipe.PhotoFilename), timeOrNow(recipe.CreatedAt), timeOrNow(recipe.UpdatedAt), utcPtr(recipe.DeletedAt))
+		if err != nil {
+			return "", false, fmt.Errorf("failed to insert new recipe '%s': %w", recipe.Name, err)
+		}
+		log.Printf("Created new recipe: Name='%s', DB_ID='%s'", recipe.Name, newID)
+		return newID, true, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to query for existing recipe '%s': %w", recipe.Name, err)
+	}
+	log.Printf("Found existing recipe: Name='%s', DB_ID='%s'", recipe.Name, dbRecipeID)
+	return dbRecipeID, false, nil
+}
+
+// insertRecipeIngredientLinkTx inserts a link between a recipe and an ingredient.
+// Operates within a transaction. Uses maps to resolve original JSON IDs to current DB IDs.
+func insertRecipeIngredientLinkTx(ctx context.Context, tx *sql.Tx, ri models.RecipeIngredient, recipeOriginalIDToDbIDMap map[string]string, ingredientOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, okRecipe := recipeOriginalIDToDbIDMap[ri.RecipeID]
+	if !okRecipe {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", ri.RecipeID)
+	}
+	dbIngredientID, okIngredient := ingredientOriginalIDToDbIDMap[ri.IngredientID]
+	if !okIngredient {
+		return fmt.Errorf("could not find DB ID for original ingredient ID '%s'", ri.IngredientID)
+	}
+
+	newLinkID, err := importedIDTx(ctx, tx, "recipe_ingredients", ri.ID)
+	if err != nil {
+		return err
+	}
+	insertQuery := `INSERT INTO recipe_ingredients
+			(id, recipe_id, ingredient_id, original_text, quantity_text, quantity, quantity_max, unit, preparation, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (recipe_id, sort_order) DO NOTHING`
+	_, err = tx.ExecContext(ctx, insertQuery, newLinkID, dbRecipeID, dbIngredientID,
+		nullIfEmpty(ri.OriginalText), nullIfEmpty(ri.QuantityText), ri.Quantity, ri.QuantityMax,
+		nullIfEmpty(ri.Unit), nullIfEmpty(ri.Preparation), ri.SortOrder)
+	if err != nil {
+		return fmt.Errorf("failed to insert recipe_ingredient link (RecipeDB_ID: %s, IngredientDB_ID: %s): %w", dbRecipeID, dbIngredientID, err)
+	}
+	return nil
+}
+
+// getOrCreateImportedTagTx returns the ID of the tag with the imported tag's name
+// (case-insensitive), creating it with the imported ID, category and timestamps if it does
+// not exist yet. Operates within a transaction.
+func getOrCreateImportedTagTx(ctx context.Context, tx *sql.Tx, tag models.Tag) (string, error) {
+	var tagID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE LOWER(name) = LOWER(?)`, tag.Name).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		if tagID, err = importedIDTx(ctx, tx, "tags", tag.ID); err != nil {
+			return "", err
+		}
+		insertQuery := `INSERT INTO tags (id, name, category, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+		if _, err = tx.ExecContext(ctx, insertQuery, tagID, tag.Name, nullIfEmpty(tag.Category), timeOrNow(tag.CreatedAt), timeOrNow(tag.UpdatedAt)); err != nil {
+			return "", fmt.Errorf("failed to insert new tag '%s': %w", tag.Name, err)
+		}
+		return tagID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query tag '%s': %w", tag.Name, err)
+	}
+	return tagID, nil
+}
+
+// insertRecipeTagLinkTx links an imported recipe to an imported tag, resolving the IDs
+// from the import file to database IDs. Existing links are left untouched.
+func insertRecipeTagLinkTx(ctx context.Context, tx *sql.Tx, link models.RecipeTag, recipeOriginalIDToDbIDMap map[string]string, tagOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, okRecipe := recipeOriginalIDToDbIDMap[link.RecipeID]
+	if !okRecipe {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", link.RecipeID)
+	}
+	dbTagID, okTag := tagOriginalIDToDbIDMap[link.TagID]
+	if !okTag {
+		return fmt.Errorf("could not find DB ID for original tag ID '%s'", link.TagID)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO recipe_tags (recipe_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, dbRecipeID, dbTagID)
+	if err != nil {
+		return fmt.Errorf("failed to insert recipe_tag link (RecipeDB_ID: %s, TagDB_ID: %s): %w", dbRecipeID, dbTagID, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"gorecipes/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := openTestStore(t)
+
+	// Two recipes share a name, so only their IDs tell them apart. Their ingredients are
+	// not among those the migrations seed.
+	pancakes, err := source.CreateRecipe(ctx, &models.Recipe{
+		Name:        "Pancakes",
+		Method:      "Mix, then fry.",
+		Servings:    4,
+		Ingredients: []string{"2 tbsp ghee", "1 cup rice flour", "1 tbsp ghee, for frying"},
+		Tags:        []string{"breakfast", "vegetarian"},
+	}, "ana")
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if _, err := source.CreateRecipe(ctx, &models.Recipe{
+		Name:        "Pancakes",
+		Method:      "Whisk, then bake.",
+		Ingredients: []string{"3 duck eggs", "1/2 cup oat milk"},
+		Tags:        []string{"breakfast"},
+	}, ""); err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	pancakes.Method = "Mix, rest, then fry."
+	if _, err := source.UpdateRecipe(ctx, pancakes, "ben"); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	// Setting the cover records a revision too
+	if _, err := source.AddRecipePhoto(ctx, models.RecipePhoto{RecipeID: pancakes.ID, Filename: "stack.jpg", Caption: "A tall stack"}); err != nil {
+		t.Fatalf("AddRecipePhoto: %v", err)
+	}
+
+	exported := exportAll(ctx, t, source)
+	if len(exported.Recipes) != 2 || len(exported.RecipeRevisions) != 4 || len(exported.RecipePhotos) != 1 {
+		t.Fatalf("exported %d recipes, %d revisions and %d photos; want 2, 4 and 1",
+			len(exported.Recipes), len(exported.RecipeRevisions), len(exported.RecipePhotos))
+	}
+
+	target := openTestStore(t)
+	if _, _, _, err := target.ImportRecipeDataBundle(ctx, exported); err != nil {
+		t.Fatalf("ImportRecipeDataBundle: %v", err)
+	}
+	if reimported := exportAll(ctx, t, target); !reflect.DeepEqual(reimported, exported) {
+		t.Errorf("export after import =\n%+v\nwant\n%+v", reimported, exported)
+	}
+
+	// Importing the same file again matches every recipe by ID and adds nothing
+	if _, _, _, err := target.ImportRecipeDataBundle(ctx, exported); err != nil {
+		t.Fatalf("second ImportRecipeDataBundle: %v", err)
+	}
+	if reimported := exportAll(ctx, t, target); !reflect.DeepEqual(reimported, exported) {
+		t.Errorf("export after second import =\n%+v\nwant\n%+v", reimported, exported)
+	}
+}
+
+// exportAll collects everything the export endpoint writes out, leaving out ingredients no
+// recipe uses: each database creates the seeded ones with IDs of its own.
+func exportAll(ctx context.Context, t *testing.T, store *Store) models.ExportedData {
+	t.Helper()
+	var data models.ExportedData
+	var err error
+	if data.Recipes, err = store.GetAllRecipesForExport(ctx); err != nil {
+		t.Fatalf("GetAllRecipesForExport: %v", err)
+	}
+	if data.Ingredients, err = store.GetAllIngredients(ctx); err != nil {
+		t.Fatalf("GetAllIngredients: %v", err)
+	}
+	if data.RecipeIngredients, err = store.GetAllRecipeIngredients(ctx); err != nil {
+		t.Fatalf("GetAllRecipeIngredients: %v", err)
+	}
+	used := make(map[string]bool)
+	for _, link := range data.RecipeIngredients {
+		used[link.IngredientID] = true
+	}
+	ingredients := data.Ingredients[:0]
+	for _, ingredient := range data.Ingredients {
+		if used[ingredient.ID] {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	data.Ingredients = ingredients
+	if data.Tags, err = store.GetAllTags(ctx, ""); err != nil {
+		t.Fatalf("GetAllTags: %v", err)
+	}
+	if data.RecipeTags, err = store.GetAllRecipeTags(ctx); err != nil {
+		t.Fatalf("GetAllRecipeTags: %v", err)
+	}
+	if data.RecipePhotos, err = store.GetAllRecipePhotos(ctx); err != nil {
+		t.Fatalf("GetAllRecipePhotos: %v", err)
+	}
+	if data.RecipeRevisions, err = store.GetAllRecipeRevisions(ctx); err != nil {
+		t.Fatalf("GetAllRecipeRevisions: %v", err)
+	}
+	if data.Comments, err = store.GetAllComments(ctx); err != nil {
+		t.Fatalf("GetAllComments: %v", err)
+	}
+	if data.MealPlanEntries, err = store.GetAllMealPlanEntries(ctx); err != nil {
+		t.Fatalf("GetAllMealPlanEntries: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// NormalizeTagName trims a tag name and collapses internal whitespace.
+// Tag names are compared case-insensitively, but stored as first written.
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// normalizeTagNames normalizes a list of tag names, dropping blanks and
+// case-insensitive duplicates while keeping the first spelling.
+func normalizeTagNames(names []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// lowerAll returns a lowercased copy of names, for matching against LOWER(tags.name).
+func lowerAll(names []string) []string {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	return lowered
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key violation.
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
+
+// GetAllTags retrieves every tag with the number of live recipes using it, ordered by category and name.
+// An empty category returns tags of all categories.
+func GetAllTags(ctx context.Context, category string) ([]models.Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT t.id, t.name, COALESCE(t.category, ''), COUNT(r.id), t.created_at, t.updated_at
+		FROM tags t
+		LEFT JOIN recipe_tags rt ON rt.tag_id = t.id
+		LEFT JOIN recipes r ON r.id = rt.recipe_id AND r.deleted_at IS NULL
+		WHERE ($1 = '' OR LOWER(t.category) = LOWER($1))
+		GROUP BY t.id
+		ORDER BY COALESCE(t.category, '') ASC, LOWER(t.name) ASC`
+
+	rows, err := DB.QueryContext(ctx, query, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Category, &tag.RecipeCount, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+	return tags, nil
+}
+
+// GetTagByID retrieves a single tag and its recipe count.
+func GetTagByID(ctx context.Context, tagID string) (*models.Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var tag models.Tag
+	query := `SELECT t.id, t.name, COALESCE(t.category, ''),
+			(SELECT COUNT(*) FROM recipe_tags rt JOIN recipes r ON r.id = rt.recipe_id
+				WHERE rt.tag_id = t.id AND r.deleted_at IS NULL), t.created_at, t.updated_at
+		FROM tags t
+		WHERE t.id = $1`
+	err := DB.QueryRowContext(ctx, query, tagID).Scan(&tag.ID, &tag.Name, &tag.Category, &tag.RecipeCount, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag with ID %s not found", tagID)
+		}
+		return nil, fmt.Errorf("failed to query tag with ID %s: %w", tagID, err)
+	}
+	return &tag, nil
+}
+
+// CreateTag inserts a new tag. Names must be unique regardless of case.
+func CreateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	if tag.ID == "" {
+		tag.ID = uuid.NewString()
+	}
+	tag.CreatedAt = time.Now().UTC()
+	tag.UpdatedAt = tag.CreatedAt
+
+	query := `INSERT INTO tags (id, name, category, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	if _, err := DB.ExecContext(ctx, query, tag.ID, tag.Name, nullIfEmpty(tag.Category), tag.CreatedAt, tag.UpdatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+		}
+		return nil, fmt.Errorf("failed to insert tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// UpdateTag renames or recategorizes an existing tag.
+func UpdateTag(ctx context.Context, tag models.Tag) (*models.Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tag.UpdatedAt = time.Now().UTC()
+	query := `UPDATE tags SET name = $1, category = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING created_at`
+	err := DB.QueryRowContext(ctx, query, tag.Name, nullIfEmpty(tag.Category), tag.UpdatedAt, tag.ID).Scan(&tag.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag with ID %s not found for update", tag.ID)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag named '%s' already exists", tag.Name)
+		}
+		return nil, fmt.Errorf("failed to update tag with ID %s: %w", tag.ID, err)
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag. Its links to recipes are removed by ON DELETE CASCADE.
+func DeleteTag(ctx context.Context, tagID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	res, err := DB.ExecContext(ctx, `DELETE FROM tags WHERE id = $1`, tagID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag with ID %s: %w", tagID, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for tag ID %s: %w", tagID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag with ID %s not found for deletion", tagID)
+	}
+	return nil
+}
+
+// getOrCreateTagByNameTx returns the ID of the tag with the given name (case-insensitive),
+// creating it without a category if it does not exist yet. Operates within a transaction.
+func getOrCreateTagByNameTx(ctx context.Context, tx *sql.Tx, name string, category string) (string, error) {
+	var tagID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE LOWER(name) = LOWER($1)`, name).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		tagID = uuid.NewString()
+		now := time.Now().UTC()
+		insertQuery := `INSERT INTO tags (id, name, category, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
+		if _, err = tx.ExecContext(ctx, insertQuery, tagID, name, nullIfEmpty(category), now, now); err != nil {
+			return "", fmt.Errorf("failed to insert new tag '%s': %w", name, err)
+		}
+		return tagID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query tag '%s': %w", name, err)
+	}
+	return tagID, nil
+}
+
+// getOrCreateImportedTagTx returns the ID of the tag with the imported tag's name
+// (case-insensitive), creating it with the imported ID, category and timestamps if it does
+// not exist yet. Operates within a transaction.
+func getOrCreateImportedTagTx(ctx context.Context, tx *sql.Tx, tag models.Tag) (string, error) {
+	var tagID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE LOWER(name) = LOWER($1)`, tag.Name).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		if tagID, err = importedIDTx(ctx, tx, "tags", tag.ID); err != nil {
+			return "", err
+		}
+		insertQuery := `INSERT INTO tags (id, name, category, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
+		if _, err = tx.ExecContext(ctx, insertQuery, tagID, tag.Name, nullIfEmpty(tag.Category), timeOrNow(tag.CreatedAt), timeOrNow(tag.UpdatedAt)); err != nil {
+			return "", fmt.Errorf("failed to insert new tag '%s': %w", tag.Name, err)
+		}
+		return tagID, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query tag '%s': %w", tag.Name, err)
+	}
+	return tagID, nil
+}
+
+// setRecipeTagsTx replaces the tags linked to a recipe with the given tag names,
+// creating tags that do not exist yet. Operates within a transaction.
+func setRecipeTagsTx(ctx context.Context, tx *sql.Tx, recipeID string, names []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recipe_tags WHERE recipe_id = $1`, recipeID); err != nil {
+		return fmt.Errorf("failed to delete old tags for recipe ID %s: %w", recipeID, err)
+	}
+	for _, name := range normalizeTagNames(names) {
+		tagID, err := getOrCreateTagByNameTx(ctx, tx, name, "")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO recipe_tags (recipe_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, recipeID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to link tag '%s' to recipe ID %s: %w", name, recipeID, err)
+		}
+	}
+	return nil
+}
+
+// getRecipeTagNames returns the names of the tags linked to a recipe, alphabetically.
+func getRecipeTagNames(ctx context.Context, recipeID string) ([]string, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT t.name FROM recipe_tags rt
+		JOIN tags t ON rt.tag_id = t.id
+		WHERE rt.recipe_id = $1
+		ORDER BY LOWER(t.name) ASC`, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tags for recipe ID %s: %w", recipeID, err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("error scanning tag for recipe ID %s: %w", recipeID, err)
+		}
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags for recipe ID %s: %w", recipeID, err)
+	}
+	return names, nil
+}
+
+// GetAllRecipeTags fetches all recipe_tags links, for export.
+func GetAllRecipeTags(ctx context.Context) ([]models.RecipeTag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT recipe_id, tag_id FROM recipe_tags ORDER BY recipe_id ASC, tag_id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying recipe_tags: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.RecipeTag
+	for rows.Next() {
+		var link models.RecipeTag
+		if err := rows.Scan(&link.RecipeID, &link.TagID); err != nil {
+			return nil, fmt.Errorf("error scanning recipe_tag: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recipe_tag rows: %w", err)
+	}
+	return links, nil
+}
+
+// insertRecipeTagLinkTx links an imported recipe to an imported tag, resolving the IDs
+// from the import file to database IDs. Existing links are left untouched.
+func insertRecipeTagLinkTx(ctx context.Context, tx *sql.Tx, link models.RecipeTag, recipeOriginalIDToDbIDMap map[string]string, tagOriginalIDToDbIDMap map[string]string) error {
+	dbRecipeID, okRecipe := recipeOriginalIDToDbIDMap[link.RecipeID]
+	if !okRecipe {
+		return fmt.Errorf("could not find DB ID for original recipe ID '%s'", link.RecipeID)
+	}
+	dbTagID, okTag := tagOriginalIDToDbIDMap[link.TagID]
+	if !okTag {
+		return fmt.Errorf("could not find DB ID for original tag ID '%s'", link.TagID)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO recipe_tags (recipe_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, dbRecipeID, dbTagID)
+	if err != nil {
+		return fmt.Errorf("failed to insert recipe_tag link (RecipeDB_ID: %s, TagDB_ID: %s): %w", dbRecipeID, dbTagID, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"sort"
+	"strings"
+
+	"gorecipes/backend/internal/models"
+)
+
+// GroupByHierarchy orders ingredients for listing by the taxonomy: every ingredient is
+// followed by the ingredients below it, depth first, and ingredients sharing a parent are
+// sorted by name ignoring case. An ingredient whose parent is not listed counts as a root.
+func GroupByHierarchy(ingredients []models.IngredientSummary) []models.IngredientSummary {
+	listed := make(map[string]bool, len(ingredients))
+	for _, ingredient := range ingredients {
+		listed[ingredient.ID] = true
+	}
+	children := make(map[string][]models.IngredientSummary)
+	for _, ingredient := range ingredients {
+		parentID := ingredient.ParentID
+		if !listed[parentID] {
+			parentID = ""
+		}
+		children[parentID] = append(children[parentID], ingredient)
+	}
+	for _, siblings := range children {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			a, b := strings.ToLower(siblings[i].Name), strings.ToLower(siblings[j].Name)
+			if a != b {
+				return a < b
+			}
+			return siblings[i].Name < siblings[j].Name
+		})
+	}
+
+	grouped := make([]models.IngredientSummary, 0, len(ingredients))
+	var visit func(parentID string)
+	visit = func(parentID string) {
+		for _, ingredient := range children[parentID] {
+			grouped = append(grouped, ingredient)
+			visit(ingredient.ID)
+		}
+	}
+	visit("")
+	return grouped
+}
+
+// IngredientTree nests ingredients under their parents, siblings sorted like GroupByHierarchy.
+// An ingredient whose parent is not given becomes a root.
+func IngredientTree(ingredients []models.IngredientSummary) []models.IngredientTreeNode {
+	listed := make(map[string]bool, len(ingredients))
+	for _, ingredient := range ingredients {
+		listed[ingredient.ID] = true
+	}
+	children := make(map[string][]models.IngredientSummary)
+	for _, ingredient := range GroupByHierarchy(ingredients) {
+		parentID := ingredient.ParentID
+		if !listed[parentID] {
+			parentID = ""
+		}
+		children[parentID] = append(children[parentID], ingredient)
+	}
+
+	var build func(parentID string) []models.IngredientTreeNode
+	build = func(parentID string) []models.IngredientTreeNode {
+		nodes := []models.IngredientTreeNode{}
+		for _, ingredient := range children[parentID] {
+			nodes = append(nodes, models.IngredientTreeNode{IngredientSummary: ingredient, Children: build(ingredient.ID)})
+		}
+		return nodes
+	}
+	return build("")
+}
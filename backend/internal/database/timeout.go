@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// QueryTimeout limits how long one repository call may spend in the database, across every
+// statement it runs. It is set from DB_QUERY_TIMEOUT at startup; zero disables the limit.
+var QueryTimeout = 30 * time.Second
+
+// WithQueryTimeout returns a copy of ctx that expires after QueryTimeout, for a repository
+// call to run its statements under. Canceling ctx, as happens when the client of the HTTP
+// request goes away, cancels the statements too.
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, QueryTimeout)
+}
+
+// IsTimeout reports whether err means the database did not answer in time: a statement ran
+// past its deadline or was canceled by the server (query_canceled), or SQLite stayed locked
+// by another writer.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "57014" {
+		return true
+	}
+	return strings.Contains(err.Error(), "database is locked")
+}
+
+// IsCanceled reports whether err means a statement was stopped because its context was
+// canceled, as when a client disconnects or the server shuts down.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorecipes/backend/internal/models"
+	"log"
+	"time"
+)
+
+// GetDeletedRecipes retrieves the recipes in the trash, most recently deleted first.
+// Ingredients are not loaded; the trash only needs enough to recognize a recipe.
+func GetDeletedRecipes(ctx context.Context) ([]models.Recipe, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT `+recipeSelectColumns+`
+		FROM recipes r
+		WHERE r.deleted_at IS NOT NULL
+		ORDER BY r.deleted_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying deleted recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.Recipe
+	for rows.Next() {
+		var recipe models.Recipe
+		if err := rows.Scan(recipeScanDest(&recipe)...); err != nil {
+			return nil, fmt.Errorf("error scanning deleted recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted recipe rows: %w", err)
+	}
+	return recipes, nil
+}
+
+// RestoreDeletedRecipe takes a recipe out of the trash.
+func RestoreDeletedRecipe(ctx context.Context, id string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	res, err := DB.ExecContext(ctx, `UPDATE recipes SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore recipe ID %s: %w", id, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for recipe ID %s: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("recipe with ID %s not found in trash", id)
+	}
+
+	log.Printf("Recipe restored from trash: ID=%s", id)
+	return nil
+}
+
+// GetDeletedRecipeIDsBefore returns the IDs of recipes that were moved to the trash before cutoff.
+func GetDeletedRecipeIDsBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.QueryContext(ctx, `SELECT id FROM recipes WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("error querying expired deleted recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning expired deleted recipe: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired deleted recipes: %w", err)
+	}
+	return ids, nil
+}
+
+// PurgeRecipe permanently removes a recipe that is in the trash, together with its
+// ingredient links, tags, revisions, comments and meal plan entries.
+// It returns the photo filenames the recipe and its revisions referenced, so the caller
+// can remove the files.
+func PurgeRecipe(ctx context.Context, id string) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the row so a concurrent restore can't race the purge
+	var currentPhoto string
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(photo_filename, '') FROM recipes
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		FOR UPDATE`, id).Scan(&currentPhoto)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("recipe with ID %s not found in trash", id)
+		}
+		return nil, fmt.Errorf("failed to query deleted recipe ID %s: %w", id, err)
+	}
+
+	photoFilenames := []string{}
+	if currentPhoto != "" {
+		photoFilenames = append(photoFilenames, currentPhoto)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT photo_filename FROM recipe_revisions
+			WHERE recipe_id = $1 AND photo_filename IS NOT NULL AND photo_filename <> '' AND photo_filename <> $2
+		UNION
+		SELECT filename FROM recipe_photos
+			WHERE recipe_id = $1 AND filename <> $2`, id, currentPhoto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revision and gallery photos for recipe ID %s: %w", id, err)
+	}
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan revision photo: %w", err)
+		}
+		photoFilenames = append(photoFilenames, filename)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revision photos: %w", err)
+	}
+
+	// Dependent rows (recipe_ingredients, recipe_tags, recipe_revisions, recipe_photos,
+	// comments, meal_plan_entries) are removed by ON DELETE CASCADE.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recipes WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to purge recipe ID %s: %w", id, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for recipe purge: %w", err)
+	}
+
+	log.Printf("Recipe purged from trash: ID=%s", id)
+	return photoFilenames, nil
+}
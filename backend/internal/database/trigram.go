@@ -0,0 +1,47 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TrigramSimilarityThreshold is the similarity from which a name counts as a fuzzy match,
+// pg_trgm's default similarity_threshold, which the % operator compares against.
+const TrigramSimilarityThreshold = 0.3
+
+// TrigramSimilarity mirrors pg_trgm's similarity(), for stores without the extension: the
+// share of distinct trigrams the two strings have in common. Like pg_trgm it ignores case
+// and non-alphanumeric characters, and pads every word with two spaces in front and one behind.
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// trigrams returns the set of pg_trgm trigrams of s.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// EscapeLike escapes the LIKE wildcards in s, for queries using ESCAPE '\'.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
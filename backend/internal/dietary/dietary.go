@@ -0,0 +1,163 @@
+// Package dietary derives allergen flags and diet labels from the dietary flags of a
+// recipe's ingredients.
+//
+// Ingredients are flagged by hand in the ingredient_dietary_flags table, and inherit the
+// flags of the ingredients above them in the taxonomy, so flagging "cheese" as dairy covers
+// cheddar and brie. Besides the allergens, "meat" and "fish" are flags too: they are not
+// allergens but decide the vegetarian and pescatarian labels.
+package dietary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Allergens, in the order they are listed.
+const (
+	Gluten    = "gluten"
+	Dairy     = "dairy"
+	Egg       = "egg"
+	Nuts      = "nuts" // Tree nuts
+	Peanuts   = "peanuts"
+	Soy       = "soy"
+	Shellfish = "shellfish"
+	Sesame    = "sesame"
+)
+
+// Flags that are not allergens.
+const (
+	Meat = "meat" // Including poultry
+	Fish = "fish"
+)
+
+// Diets a recipe can be labelled with.
+const (
+	Vegan       = "vegan"
+	Vegetarian  = "vegetarian"
+	Pescatarian = "pescatarian"
+	GlutenFree  = "gluten-free"
+)
+
+// Allergens lists the allergen flags.
+var Allergens = []string{Gluten, Dairy, Egg, Nuts, Peanuts, Soy, Shellfish, Sesame}
+
+// Flags lists every dietary flag an ingredient can carry: the allergens, then meat and fish.
+var Flags = append(append([]string{}, Allergens...), Meat, Fish)
+
+// Diets lists the diet labels.
+var Diets = []string{Vegan, Vegetarian, Pescatarian, GlutenFree}
+
+// dietExcludes maps each diet to the flags a recipe must not carry to be labelled with it.
+// Honey and the like are not flagged, so "vegan" is as good as the ingredient flags.
+var dietExcludes = map[string][]string{
+	Vegan:       {Dairy, Egg, Shellfish, Meat, Fish},
+	Vegetarian:  {Shellfish, Meat, Fish},
+	Pescatarian: {Meat},
+	GlutenFree:  {Gluten},
+}
+
+// flagOrder is the position of each flag in Flags.
+var flagOrder = func() map[string]int {
+	order := make(map[string]int, len(Flags))
+	for i, flag := range Flags {
+		order[flag] = i
+	}
+	return order
+}()
+
+// IsFlag reports whether flag is a known dietary flag.
+func IsFlag(flag string) bool {
+	_, ok := flagOrder[flag]
+	return ok
+}
+
+// IsAllergen reports whether flag is one of the allergens.
+func IsAllergen(flag string) bool {
+	return IsFlag(flag) && flag != Meat && flag != Fish
+}
+
+// SortFlags orders known flags as in Flags, in place.
+func SortFlags(flags []string) {
+	sort.SliceStable(flags, func(i, j int) bool { return flagOrder[flags[i]] < flagOrder[flags[j]] })
+}
+
+// NormalizeFlags lowercases and trims flags, dropping blanks and duplicates, and returns them
+// ordered as in Flags. Unknown flags are an error.
+func NormalizeFlags(flags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, flag := range flags {
+		flag = strings.ToLower(strings.TrimSpace(flag))
+		if flag == "" || seen[flag] {
+			continue
+		}
+		if !IsFlag(flag) {
+			return nil, fmt.Errorf("unknown dietary flag '%s'", flag)
+		}
+		seen[flag] = true
+		normalized = append(normalized, flag)
+	}
+	SortFlags(normalized)
+	return normalized, nil
+}
+
+// ExcludedFlags returns the flags a recipe must not carry to fit every one of the given diets.
+// Unknown diets are an error.
+func ExcludedFlags(diets []string) ([]string, error) {
+	var excluded []string
+	for _, diet := range diets {
+		flags, ok := dietExcludes[strings.ToLower(strings.TrimSpace(diet))]
+		if !ok {
+			return nil, fmt.Errorf("unknown diet '%s'", diet)
+		}
+		excluded = append(excluded, flags...)
+	}
+	return NormalizeFlags(excluded)
+}
+
+// Apply returns the flags of a recipe whose ingredients carry derived, after its manual
+// overrides: a flag overridden with true is added, one overridden with false removed.
+func Apply(derived []string, overrides map[string]bool) []string {
+	present := make(map[string]bool)
+	for _, flag := range derived {
+		present[flag] = true
+	}
+	for flag, value := range overrides {
+		present[flag] = value
+	}
+	flags := []string{}
+	for flag, value := range present {
+		if value {
+			flags = append(flags, flag)
+		}
+	}
+	SortFlags(flags)
+	return flags
+}
+
+// Label splits the flags of a recipe into its allergens and the diets it fits.
+func Label(flags []string) (allergens []string, diets []string) {
+	present := make(map[string]bool)
+	allergens = []string{}
+	for _, flag := range flags {
+		present[flag] = true
+		if IsAllergen(flag) {
+			allergens = append(allergens, flag)
+		}
+	}
+	diets = []string{}
+	for _, diet := range Diets {
+		fits := true
+		for _, flag := range dietExcludes[diet] {
+			if present[flag] {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			diets = append(diets, diet)
+		}
+	}
+	return allergens, diets
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"gorecipes/backend/internal/database"
 	"gorecipes/backend/internal/models"
 	"io"
 	"log"
@@ -22,7 +21,7 @@ type ImportRecipesResponse struct {
 }
 
 // ImportRecipes handles the POST /api/v1/admin/import endpoint.
-func ImportRecipes(c *gin.Context) {
+func (h *AdminHandler) ImportRecipes(c *gin.Context) {
 	response := ImportRecipesResponse{}
 
 	file, header, err := c.Request.FormFile("recipes_file")
@@ -87,7 +86,7 @@ func ImportRecipes(c *gin.Context) {
 		// Ingredients can be an empty slice, so no check needed unless specific validation is added.
 
 		// Check for Duplicates using PostgreSQL version
-		exists, err := database.RecipeExistsByID(recipeFromFile.ID)
+		exists, err := h.Recipes.RecipeExistsByID(c.Request.Context(), recipeFromFile.ID)
 		if err != nil {
 			log.Printf("[ImportRecipes] Error checking recipe existence for ID %s with PostgreSQL: %v. Skipping.", recipeFromFile.ID, err)
 			response.SkippedMalformedCount++ // Treat DB error during check as a reason to skip
@@ -101,13 +100,19 @@ func ImportRecipes(c *gin.Context) {
 
 		// Prepare for Save - FilterableIngredientNames is deprecated and handled by CreateRecipe
 		recipeToSave := models.Recipe{
-			ID:            recipeFromFile.ID,
-			Name:          recipeFromFile.Name,
-			Ingredients:   recipeFromFile.Ingredients, // CreateRecipe will process these
-			Method:        recipeFromFile.Method,
-			PhotoFilename: "", // Ignored as per plan, CreateRecipe will handle default if necessary
-			CreatedAt:     recipeFromFile.CreatedAt, // Preserve timestamps from import
-			UpdatedAt:     recipeFromFile.UpdatedAt, // Preserve timestamps from import
+			ID:              recipeFromFile.ID,
+			Name:            recipeFromFile.Name,
+			Ingredients:     recipeFromFile.Ingredients, // CreateRecipe will process these
+			Method:          recipeFromFile.Method,
+			Servings:        recipeFromFile.Servings,
+			Yield:           recipeFromFile.Yield,
+			PrepTimeMinutes: recipeFromFile.PrepTimeMinutes,
+			CookTimeMinutes: recipeFromFile.CookTimeMinutes,
+			RestTimeMinutes: recipeFromFile.RestTimeMinutes,
+			Tags:            recipeFromFile.Tags,
+			PhotoFilename:   "",                       // Ignored as per plan, CreateRecipe will handle default if necessary
+			CreatedAt:       recipeFromFile.CreatedAt, // Preserve timestamps from import
+			UpdatedAt:       recipeFromFile.UpdatedAt, // Preserve timestamps from import
 		}
 		// If recipeFromFile.Ingredients is nil, ensure it's an empty slice for CreateRecipe
 		if recipeToSave.Ingredients == nil {
@@ -117,7 +122,7 @@ func ImportRecipes(c *gin.Context) {
 		// Save to Database using PostgreSQL CreateRecipe
 		// CreateRecipe handles ingredient processing and linking.
 		// It also sets CreatedAt/UpdatedAt if they are zero, but here we provide them.
-		createdRecipe, err := database.CreateRecipe(&recipeToSave) 
+		createdRecipe, err := h.Recipes.CreateRecipe(c.Request.Context(), &recipeToSave, "import")
 		if err != nil {
 			log.Printf("[ImportRecipes] Error saving recipe ID %s with PostgreSQL CreateRecipe: %v. Skipping.", recipeToSave.ID, err)
 			response.SkippedMalformedCount++
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"strings"
 
-	"gorecipes/backend/internal/database"
 	"gorecipes/backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +23,7 @@ import (
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes/{id}/comments [post]
-func CreateCommentHandler(c *gin.Context) {
+func (h *CommentHandler) CreateCommentHandler(c *gin.Context) {
 	recipeID := c.Param("id")
 	if recipeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe ID is required"})
@@ -58,10 +57,10 @@ func CreateCommentHandler(c *gin.Context) {
 		Content:  reqBody.Content,
 	}
 
-	createdComment, err := database.CreateComment(comment)
+	createdComment, err := h.Comments.CreateComment(c.Request.Context(), comment)
 	if err != nil {
 		log.Printf("Error creating comment in database: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to create comment"})
 		return
 	}
 
@@ -78,17 +77,17 @@ func CreateCommentHandler(c *gin.Context) {
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes/{id}/comments [get]
-func GetCommentsByRecipeIDHandler(c *gin.Context) {
+func (h *CommentHandler) GetCommentsByRecipeIDHandler(c *gin.Context) {
 	recipeID := c.Param("id")
 	if recipeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe ID is required"})
 		return
 	}
 
-	comments, err := database.GetCommentsByRecipeID(recipeID)
+	comments, err := h.Comments.GetCommentsByRecipeID(c.Request.Context(), recipeID)
 	if err != nil {
 		log.Printf("Error retrieving comments for recipe %s from database: %v", recipeID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve comments"})
 		return
 	}
 
@@ -111,7 +110,7 @@ func GetCommentsByRecipeIDHandler(c *gin.Context) {
 // @Failure 404 {object} map[string]string "Comment not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /comments/{id} [put]
-func UpdateCommentHandler(c *gin.Context) {
+func (h *CommentHandler) UpdateCommentHandler(c *gin.Context) {
 	commentID := c.Param("id")
 	if commentID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment ID is required"})
@@ -134,24 +133,24 @@ func UpdateCommentHandler(c *gin.Context) {
 	}
 
 	// Fetch existing comment to ensure it exists and get other fields
-	existingComment, err := database.GetCommentByID(commentID)
+	existingComment, err := h.Comments.GetCommentByID(c.Request.Context(), commentID)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "not found") || strings.Contains(err.Error(), "no rows in result set") {
 			log.Printf("Comment with ID %s not found for update: %v", commentID, err)
 			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 		} else {
 			log.Printf("Error retrieving comment %s for update: %v", commentID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment for update"})
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve comment for update"})
 		}
 		return
 	}
 
 	existingComment.Content = reqBody.Content
 
-	updatedComment, err := database.UpdateComment(*existingComment)
+	updatedComment, err := h.Comments.UpdateComment(c.Request.Context(), *existingComment)
 	if err != nil {
 		log.Printf("Error updating comment %s in database: %v", commentID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to update comment"})
 		return
 	}
 
@@ -168,21 +167,21 @@ func UpdateCommentHandler(c *gin.Context) {
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /comments/{id} [delete]
-func DeleteCommentHandler(c *gin.Context) {
+func (h *CommentHandler) DeleteCommentHandler(c *gin.Context) {
 	commentID := c.Param("id")
 	if commentID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment ID is required"})
 		return
 	}
 
-	err := database.DeleteComment(commentID)
+	err := h.Comments.DeleteComment(c.Request.Context(), commentID)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "not found") || strings.Contains(err.Error(), "no rows in result set") {
 			log.Printf("Comment with ID %s not found (already deleted or never existed): %v", commentID, err)
 			c.Status(http.StatusNoContent) // Comment is gone, so operation is effectively successful.
 		} else {
 			log.Printf("Error deleting comment %s from database: %v", commentID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete comment"})
 		}
 		return
 	}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// withDietaryLabels fills in the allergens and diets of a recipe from its flags.
+func withDietaryLabels(result *models.RecipeDietary) *models.RecipeDietary {
+	result.Allergens, result.Diets = dietary.Label(result.Flags)
+	return result
+}
+
+// @Summary Get a recipe's allergens and diets
+// @Description Get the allergens a recipe contains and the diets it fits (vegan, vegetarian, pescatarian, gluten-free). They are derived from the dietary flags of its ingredients, including flags inherited from broader ingredients in the taxonomy, and corrected by the recipe's manual overrides. Ingredients that carry no flags count as free of every allergen; common ones are flagged from the start.
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 200 {object} models.RecipeDietary "Recipe allergens and diets"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/dietary [get]
+func (h *DietaryHandler) GetRecipeDietaryHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	if _, err := uuid.Parse(recipeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	result, err := h.Dietary.GetRecipeDietary(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving dietary flags of recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe allergens"})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	c.JSON(http.StatusOK, withDietaryLabels(result))
+}
+
+// @Summary Override a recipe's allergens
+// @Description Replace the manual corrections of a recipe's dietary flags, for what its ingredients do not tell: {"nuts": true} marks a recipe as containing nuts, {"gluten": false} clears gluten derived from an ingredient, e.g. when gluten-free flour is used. An empty object removes every override.
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param body body object{overrides=map[string]bool} true "Flags to set (true) or clear (false)"
+// @Success 200 {object} models.RecipeDietary "Recipe allergens and diets with the overrides applied"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/dietary [put]
+func (h *DietaryHandler) SetRecipeDietaryOverridesHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	if _, err := uuid.Parse(recipeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	var reqBody struct {
+		Overrides map[string]bool `json:"overrides"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	overrides := make(map[string]bool, len(reqBody.Overrides))
+	for flag, present := range reqBody.Overrides {
+		flag = strings.ToLower(strings.TrimSpace(flag))
+		if !dietary.IsFlag(flag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown dietary flag '" + flag + "'"})
+			return
+		}
+		overrides[flag] = present
+	}
+
+	result, err := h.Dietary.SetRecipeDietaryOverrides(c.Request.Context(), recipeID, overrides)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+			return
+		}
+		log.Printf("Error overriding dietary flags of recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to override recipe allergens"})
+		return
+	}
+
+	log.Printf("Dietary overrides of recipe %s set to %v", recipeID, overrides)
+	c.JSON(http.StatusOK, withDietaryLabels(result))
+}
+
+// @Summary List ingredient dietary flags
+// @Description List every ingredient that carries dietary flags itself, with its flags. Ingredients below these in the taxonomy inherit the flags without being listed.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.IngredientDietaryFlags "Flagged ingredients"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/dietary-flags [get]
+func (h *DietaryHandler) ListIngredientDietaryFlagsHandler(c *gin.Context) {
+	flagged, err := h.Dietary.GetAllIngredientDietaryFlags(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing ingredient dietary flags: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list ingredient dietary flags"})
+		return
+	}
+	c.JSON(http.StatusOK, flagged)
+}
+
+// @Summary Set an ingredient's dietary flags
+// @Description Replace the dietary flags an ingredient carries: the allergens gluten, dairy, egg, nuts, peanuts, soy, shellfish and sesame, and meat and fish, which decide the vegetarian and pescatarian labels. Flagging a broad ingredient such as "cheese" covers the ingredients below it. An empty list removes every flag.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Ingredient ID"
+// @Param body body object{flags=[]string} true "Dietary flags"
+// @Success 200 {object} models.IngredientDietaryFlags "Flags set successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Ingredient not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/dietary-flags [put]
+func (h *DietaryHandler) SetIngredientDietaryFlagsHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var reqBody struct {
+		Flags []string `json:"flags"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	flags, err := dietary.NormalizeFlags(reqBody.Flags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flagged, err := h.Dietary.SetIngredientDietaryFlags(c.Request.Context(), ingredientID, flags)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+			return
+		}
+		log.Printf("Error setting dietary flags of ingredient %s: %v", ingredientID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to set ingredient dietary flags"})
+		return
+	}
+
+	log.Printf("Dietary flags of ingredient %s set to %v", ingredientID, flagged.Flags)
+	c.JSON(http.StatusOK, flagged)
+}
@@ -0,0 +1,10 @@
+package handlers
+
+// Unexported helpers under test in package handlers_test.
+var (
+	AggregateShoppingItems = aggregateShoppingItems
+	ItemText               = itemText
+	SortByAisle            = sortByAisle
+)
+
+type ShoppingLine = shoppingLine
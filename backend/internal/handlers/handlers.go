@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gorecipes/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecipeHandler serves the recipe routes: recipes themselves, scaling, revisions,
+// photos, tags and the trash.
+type RecipeHandler struct {
+	Recipes database.RecipeRepository
+}
+
+// IngredientHandler serves the ingredient routes.
+type IngredientHandler struct {
+	Ingredients database.IngredientRepository
+}
+
+// CommentHandler serves the comment routes.
+type CommentHandler struct {
+	Comments database.CommentRepository
+}
+
+// MealPlanHandler serves the meal planner routes.
+type MealPlanHandler struct {
+	MealPlans database.MealPlanRepository
+}
+
+// NutritionHandler serves recipe nutrition and the admin routes mapping ingredients to foods.
+type NutritionHandler struct {
+	Recipes   database.RecipeRepository
+	Nutrition database.NutritionRepository
+}
+
+// DietaryHandler serves recipes' allergens and diets and the routes flagging ingredients.
+type DietaryHandler struct {
+	Dietary database.DietaryRepository
+}
+
+// SubstitutionHandler serves the substitutions for a recipe and the admin routes editing the
+// substitutions knowledge base.
+type SubstitutionHandler struct {
+	Recipes       database.RecipeRepository
+	Ingredients   database.IngredientRepository
+	Dietary       database.DietaryRepository
+	Substitutions database.SubstitutionRepository
+}
+
+// PantryHandler serves the pantry and staples routes and the recipes cookable from the pantry.
+type PantryHandler struct {
+	Pantry database.PantryRepository
+}
+
+// ShoppingListHandler serves the shopping lists, generated from the meal plan or kept by hand,
+// and the admin routes putting ingredients in store aisles.
+type ShoppingListHandler struct {
+	MealPlans     database.MealPlanRepository
+	Recipes       database.RecipeRepository
+	Ingredients   database.IngredientRepository
+	Pantry        database.PantryRepository
+	ShoppingLists database.ShoppingListRepository
+}
+
+// AdminHandler serves the admin routes, which export and import data across repositories.
+type AdminHandler struct {
+	Recipes       database.RecipeRepository
+	Ingredients   database.IngredientRepository
+	Comments      database.CommentRepository
+	MealPlans     database.MealPlanRepository
+	Nutrition     database.NutritionRepository
+	Dietary       database.DietaryRepository
+	Substitutions database.SubstitutionRepository
+	Pantry        database.PantryRepository
+	ShoppingLists database.ShoppingListRepository
+}
+
+// dbErrorStatus returns the status for a failed repository call: 503 when the request
+// was canceled, 504 when a query timed out and 500 for anything else.
+func dbErrorStatus(c *gin.Context, err error) int {
+	switch {
+	case database.IsCanceled(c.Request.Context().Err()) || database.IsCanceled(err):
+		return http.StatusServiceUnavailable
+	case database.IsTimeout(err):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
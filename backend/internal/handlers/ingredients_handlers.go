@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxIngredientNameLength matches the ingredients.name column size.
+const maxIngredientNameLength = 255
+
+// @Summary List ingredients
+// @Description Get every ingredient with the number of recipes using it and its aliases, the other names it is recognized by. Ingredients are grouped by the taxonomy: each one is followed by the ingredients below it.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.IngredientSummary "Successfully retrieved ingredients"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients [get]
+func (h *IngredientHandler) ListIngredientsHandler(c *gin.Context) {
+	ingredients, err := h.Ingredients.GetIngredientSummaries(c.Request.Context())
+	if err != nil {
+		log.Printf("Error retrieving ingredients from database: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve ingredients"})
+		return
+	}
+
+	if ingredients == nil {
+		ingredients = []models.IngredientSummary{}
+	}
+	c.JSON(http.StatusOK, ingredients)
+}
+
+// @Summary Get the ingredient taxonomy
+// @Description Get every ingredient nested under its parent, e.g. dairy > cheese > cheddar. Ingredients without a parent are the roots.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.IngredientTreeNode "Successfully retrieved the taxonomy"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/tree [get]
+func (h *IngredientHandler) IngredientTreeHandler(c *gin.Context) {
+	ingredients, err := h.Ingredients.GetIngredientSummaries(c.Request.Context())
+	if err != nil {
+		log.Printf("Error retrieving ingredients from database: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve ingredients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, database.IngredientTree(ingredients))
+}
+
+// @Summary Rename an ingredient
+// @Description Change an ingredient's name. The previous name becomes an alias, so ingredient lines using it still resolve to this ingredient.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Ingredient ID"
+// @Param body body object{name=string} true "New name"
+// @Success 200 {object} models.IngredientSummary "Ingredient renamed successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Ingredient not found"
+// @Failure 409 {object} map[string]string "Another ingredient already has this name or alias"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id} [put]
+func (h *IngredientHandler) RenameIngredientHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var reqBody struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqBody); err != nil {
+		log.Printf("Error decoding request body for RenameIngredient: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(reqBody.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ingredient name cannot be empty"})
+		return
+	}
+	if len(name) > maxIngredientNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ingredient name is too long"})
+		return
+	}
+
+	ingredient, err := h.Ingredients.RenameIngredient(c.Request.Context(), ingredientID, name)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		case strings.Contains(err.Error(), "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Error renaming ingredient %s: %v", ingredientID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to rename ingredient"})
+		}
+		return
+	}
+
+	log.Printf("Ingredient %s renamed to '%s'", ingredientID, ingredient.Name)
+	c.JSON(http.StatusOK, ingredient)
+}
+
+// @Summary Merge ingredients
+// @Description Fold duplicate ingredients into a canonical one. Recipes using a source ingredient are relinked to the target, keeping every ingredient line as written, and their IDs are returned in affected_recipe_ids. The source names become aliases of the target and the sources are deleted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body object{target_id=string,source_ids=[]string} true "Canonical ingredient and the duplicates to merge into it"
+// @Success 200 {object} models.IngredientMergeResult "Merged ingredient and the relinked recipes"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Ingredient not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/merge [post]
+func (h *IngredientHandler) MergeIngredientsHandler(c *gin.Context) {
+	var reqBody struct {
+		TargetID  string   `json:"target_id" binding:"required"`
+		SourceIDs []string `json:"source_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	for _, id := range append([]string{reqBody.TargetID}, reqBody.SourceIDs...) {
+		if _, err := uuid.Parse(strings.TrimSpace(id)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ingredient ID: " + id})
+			return
+		}
+	}
+	targetID := strings.TrimSpace(reqBody.TargetID)
+
+	merged, err := h.Ingredients.MergeIngredients(c.Request.Context(), targetID, reqBody.SourceIDs)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "cannot merge"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Error merging ingredients into %s: %v", targetID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to merge ingredients"})
+		}
+		return
+	}
+
+	log.Printf("Ingredients %v merged into %s, relinking %d recipe(s)", reqBody.SourceIDs, targetID, len(merged.AffectedRecipeIDs))
+	c.JSON(http.StatusOK, merged)
+}
+
+// @Summary Set an ingredient's parent
+// @Description Place an ingredient below a broader one in the taxonomy (cheddar below cheese), or make it a root with a null parent_id. Filtering recipes by an ingredient with include_descendants=true also finds the ingredients below it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Ingredient ID"
+// @Param body body object{parent_id=string} true "Parent ingredient ID, or null"
+// @Success 200 {object} models.IngredientSummary "Parent set successfully"
+// @Failure 400 {object} map[string]string "Bad Request, e.g. the parent is below the ingredient"
+// @Failure 404 {object} map[string]string "Ingredient or parent not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/parent [put]
+func (h *IngredientHandler) SetIngredientParentHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var reqBody struct {
+		ParentID *string `json:"parent_id"`
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqBody); err != nil {
+		log.Printf("Error decoding request body for SetIngredientParent: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	parentID := ""
+	if reqBody.ParentID != nil {
+		parentID = strings.TrimSpace(*reqBody.ParentID)
+	}
+	if parentID != "" {
+		if _, err := uuid.Parse(parentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ingredient ID: " + parentID})
+			return
+		}
+	}
+
+	ingredient, err := h.Ingredients.SetIngredientParent(c.Request.Context(), ingredientID, parentID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "cannot place"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "parent ingredient") && strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Parent ingredient not found"})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		default:
+			log.Printf("Error setting parent of ingredient %s: %v", ingredientID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to set ingredient parent"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredient)
+}
@@ -1,22 +1,67 @@
 package handlers
 
 import (
-	"gorecipes/backend/internal/database"
+	"encoding/json"
 	"gorecipes/backend/internal/models"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 const dateLayout = "2006-01-02" // For parsing YYYY-MM-DD
 
+const maxMealSlotLength = 50 // Length of meal_plan_entries.slot
+
+const maxMealTitleLength = 200 // For free-text entries such as "Leftovers"
+
+// cleanMealSlot lower-cases a meal slot name and collapses its whitespace. A blank slot is
+// dinner; other names than the standard slots are kept as custom slots.
+func cleanMealSlot(slot string) (string, string) {
+	slot = strings.ToLower(strings.Join(strings.Fields(slot), " "))
+	if slot == "" {
+		return models.MealSlotDinner, ""
+	}
+	if len(slot) > maxMealSlotLength {
+		return "", "slot must be at most 50 characters"
+	}
+	return slot, ""
+}
+
+// respondMealPlanError writes the response for an error returned by the meal plan repository.
+// Planning a recipe or title twice for one meal is a 409.
+func respondMealPlanError(c *gin.Context, operation string, action string, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "already planned"):
+		log.Printf("[MealPlanner] %s: %v", operation, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "This is already planned for that meal."})
+	case strings.Contains(message, "meal plan entry with ID") && strings.Contains(message, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan entry not found"})
+	case strings.Contains(message, "recipe with ID") && strings.Contains(message, "not found"):
+		log.Printf("[MealPlanner] %s: %v", operation, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	default:
+		log.Printf("[MealPlanner] %s: Error trying to %s: %v", operation, action, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to " + action + "."})
+	}
+}
+
 // CreateMealPlanEntryHandler handles POST /api/v1/mealplanner/entries
-func CreateMealPlanEntryHandler(c *gin.Context) {
+// An entry plans either a recipe, by recipe_id, or a free-text title such as "Leftovers" or
+// "Eat out". The slot defaults to dinner, and servings to the recipe's own. Planning a recipe
+// or title twice for the same meal on the same day is a 409.
+func (h *MealPlanHandler) CreateMealPlanEntryHandler(c *gin.Context) {
 	var req struct {
 		Date     string `json:"date" binding:"required"`
-		RecipeID string `json:"recipe_id" binding:"required"`
+		RecipeID string `json:"recipe_id"`
+		Title    string `json:"title"`
+		Slot     string `json:"slot"`     // breakfast, lunch, dinner, snack or a custom name
+		Servings *int   `json:"servings"` // Servings the meal is planned for
+		Notes    string `json:"notes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,31 +76,65 @@ func CreateMealPlanEntryHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Please use YYYY-MM-DD."})
 		return
 	}
+	slot, problem := cleanMealSlot(req.Slot)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	servings := 0
+	if req.Servings != nil {
+		if *req.Servings < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servings must be a positive whole number"})
+			return
+		}
+		servings = *req.Servings
+	}
 
-	// Skip recipe existence check to allow custom recipe names
-	// Custom recipes (text-only entries) can be added to meal plans without existing in the recipe database
-	log.Printf("[MealPlanner] Create: Adding recipe/custom entry '%s' to meal plan for date %s", req.RecipeID, req.Date)
+	title := strings.Join(strings.Fields(req.Title), " ")
+	if _, err := uuid.Parse(req.RecipeID); err != nil && req.RecipeID != "" {
+		if title != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recipe_id must be a recipe ID"})
+			return
+		}
+		// Older clients sent the free text of custom entries as the recipe ID
+		req.RecipeID, title = "", strings.Join(strings.Fields(req.RecipeID), " ")
+	}
+	switch {
+	case req.RecipeID == "" && title == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either recipe_id or title is required."})
+		return
+	case req.RecipeID != "" && title != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either recipe_id or title, not both."})
+		return
+	case len(title) > maxMealTitleLength:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title must be at most 200 characters"})
+		return
+	}
+	log.Printf("[MealPlanner] Create: Adding entry (recipe '%s', title %q) to meal plan for %s on %s", req.RecipeID, title, slot, req.Date)
 
 	// Prepare the entry. ID and CreatedAt will be set by the database.CreateMealPlanEntry function.
 	// The Date field in entry will also be normalized to UTC midnight by CreateMealPlanEntry.
 	entryData := models.MealPlanEntry{
 		Date:     parsedDate, // Pass the parsed date; normalization happens in DB func
 		RecipeID: req.RecipeID,
+		Title:    title,
+		Slot:     slot,
+		Servings: servings,
+		Notes:    strings.TrimSpace(req.Notes),
 	}
 
-	createdEntry, err := database.CreateMealPlanEntry(&entryData)
+	createdEntry, err := h.MealPlans.CreateMealPlanEntry(c.Request.Context(), &entryData)
 	if err != nil {
-		log.Printf("[MealPlanner] Create: Error saving meal plan entry with PostgreSQL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save meal plan entry."})
+		respondMealPlanError(c, "Create", "save meal plan entry", err)
 		return
 	}
 
-	log.Printf("[MealPlanner] Create: Successfully created meal plan entry ID %s for Recipe %s on %s using PostgreSQL", createdEntry.ID, createdEntry.RecipeID, createdEntry.Date.Format(dateLayout))
+	log.Printf("[MealPlanner] Create: Successfully created meal plan entry ID %s for %q on %s", createdEntry.ID, createdEntry.Title, createdEntry.Date.Format(dateLayout))
 	c.JSON(http.StatusCreated, createdEntry)
 }
 
 // ListMealPlanEntriesHandler handles GET /api/v1/mealplanner/entries
-func ListMealPlanEntriesHandler(c *gin.Context) {
+func (h *MealPlanHandler) ListMealPlanEntriesHandler(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 
@@ -88,10 +167,10 @@ func ListMealPlanEntriesHandler(c *gin.Context) {
 		return
 	}
 
-	entries, err := database.GetMealPlanEntriesByDateRange(normalizedStartDate, normalizedEndDate)
+	entries, err := h.MealPlans.GetMealPlanEntriesByDateRange(c.Request.Context(), normalizedStartDate, normalizedEndDate)
 	if err != nil {
 		log.Printf("[MealPlanner] List: Error fetching meal plan entries: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve meal plan entries."})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve meal plan entries."})
 		return
 	}
 
@@ -104,7 +183,7 @@ func ListMealPlanEntriesHandler(c *gin.Context) {
 }
 
 // DeleteMealPlanEntryHandler handles DELETE /api/v1/mealplanner/entries/:entry_id
-func DeleteMealPlanEntryHandler(c *gin.Context) {
+func (h *MealPlanHandler) DeleteMealPlanEntryHandler(c *gin.Context) {
 	entryID := c.Param("entry_id")
 	if entryID == "" {
 		log.Printf("[MealPlanner] Delete: entry_id parameter is missing.")
@@ -115,12 +194,155 @@ func DeleteMealPlanEntryHandler(c *gin.Context) {
 	// Optional: Check if entry exists before attempting delete if you want to return 404 specifically
 	// For now, DeleteMealPlanEntry in database layer handles non-existent key gracefully (logs it).
 
-	if err := database.DeleteMealPlanEntry(entryID); err != nil {
+	if err := h.MealPlans.DeleteMealPlanEntry(c.Request.Context(), entryID); err != nil {
 		log.Printf("[MealPlanner] Delete: Error deleting meal plan entry ID %s: %v", entryID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meal plan entry."})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete meal plan entry."})
 		return
 	}
 
 	log.Printf("[MealPlanner] Delete: Successfully deleted (or confirmed non-existent) meal plan entry ID %s", entryID)
 	c.Status(http.StatusNoContent)
 }
+
+// UpdateMealPlanEntryHandler handles PATCH /api/v1/mealplanner/entries/:entry_id
+// It changes any of an entry's date, slot, servings (null for the recipe's own) and notes,
+// and its sort_order, the position from 0 within its meal on the day. An entry moved to
+// another date or slot without a sort_order goes last there. The recipe or title stays.
+func (h *MealPlanHandler) UpdateMealPlanEntryHandler(c *gin.Context) {
+	entryID := c.Param("entry_id")
+	if _, err := uuid.Parse(entryID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan entry not found"})
+		return
+	}
+
+	var req struct {
+		Date      *string         `json:"date"`
+		Slot      *string         `json:"slot"`
+		Servings  json.RawMessage `json:"servings"` // null plans the recipe's own servings
+		Notes     *string         `json:"notes"`
+		SortOrder *int            `json:"sort_order"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[MealPlanner] Update: Bad request format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+
+	entry, err := h.MealPlans.GetMealPlanEntry(c.Request.Context(), entryID)
+	if err != nil {
+		respondMealPlanError(c, "Update", "update meal plan entry", err)
+		return
+	}
+	date, slot := entry.Date, entry.Slot
+
+	if req.Date != nil {
+		if entry.Date, err = time.Parse(dateLayout, *req.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Please use YYYY-MM-DD."})
+			return
+		}
+	}
+	if req.Slot != nil {
+		var problem string
+		if entry.Slot, problem = cleanMealSlot(*req.Slot); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+	}
+	if len(req.Servings) > 0 {
+		var servings *int
+		if err := json.Unmarshal(req.Servings, &servings); err != nil || (servings != nil && *servings < 1) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servings must be a positive whole number or null"})
+			return
+		}
+		entry.Servings = 0
+		if servings != nil {
+			entry.Servings = *servings
+		}
+	}
+	if req.Notes != nil {
+		entry.Notes = strings.TrimSpace(*req.Notes)
+	}
+	switch {
+	case req.SortOrder != nil && *req.SortOrder < 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort_order must not be negative"})
+		return
+	case req.SortOrder != nil:
+		entry.SortOrder = *req.SortOrder
+	case !entry.Date.Equal(date) || entry.Slot != slot:
+		entry.SortOrder = -1 // Last in its new meal
+	}
+
+	updated, err := h.MealPlans.UpdateMealPlanEntry(c.Request.Context(), entry)
+	if err != nil {
+		respondMealPlanError(c, "Update", "update meal plan entry", err)
+		return
+	}
+
+	log.Printf("[MealPlanner] Update: Updated meal plan entry ID %s, now %s on %s at position %d", updated.ID, updated.Slot, updated.Date.Format(dateLayout), updated.SortOrder)
+	c.JSON(http.StatusOK, updated)
+}
+
+const maxMealPlanOperations = 500 // Per batch
+
+// BatchMealPlanEntriesHandler handles POST /api/v1/mealplanner/entries/batch
+// It moves or copies several entries in one transaction, so either all of them change or
+// none does; copying a whole week to the next is one batch. Each operation names an entry and
+// a new date, slot or both; moved entries that change meal and copies go last in their meal.
+// Responds with the moved entries and the copies, in operation order.
+func (h *MealPlanHandler) BatchMealPlanEntriesHandler(c *gin.Context) {
+	var req struct {
+		Operations []struct {
+			Action  string `json:"action" binding:"required"` // move or copy
+			EntryID string `json:"entry_id" binding:"required"`
+			Date    string `json:"date"` // New date; empty keeps the entry's
+			Slot    string `json:"slot"` // New slot; empty keeps the entry's
+		} `json:"operations" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[MealPlanner] Batch: Bad request format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxMealPlanOperations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operations must list between 1 and 500 moves or copies"})
+		return
+	}
+
+	operations := make([]models.MealPlanEntryOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		action := strings.ToLower(strings.TrimSpace(op.Action))
+		if action != models.MealPlanMove && action != models.MealPlanCopy {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be move or copy"})
+			return
+		}
+		if _, err := uuid.Parse(op.EntryID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan entry not found"})
+			return
+		}
+		operations[i] = models.MealPlanEntryOperation{Action: action, EntryID: op.EntryID}
+		if op.Date != "" {
+			date, err := time.Parse(dateLayout, op.Date)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Please use YYYY-MM-DD."})
+				return
+			}
+			operations[i].Date = date
+		}
+		if strings.TrimSpace(op.Slot) != "" {
+			var problem string
+			if operations[i].Slot, problem = cleanMealSlot(op.Slot); problem != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+				return
+			}
+		}
+	}
+
+	entries, err := h.MealPlans.ApplyMealPlanEntryOperations(c.Request.Context(), operations)
+	if err != nil {
+		respondMealPlanError(c, "Batch", "move or copy meal plan entries", err)
+		return
+	}
+
+	log.Printf("[MealPlanner] Batch: Applied %d moves and copies", len(operations))
+	c.JSON(http.StatusOK, entries)
+}
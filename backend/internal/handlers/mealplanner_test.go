@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"gorecipes/backend/internal/models"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// planMeal plans a free-text meal and returns the created entry.
+func planMeal(t *testing.T, server *gin.Engine, date, slot, title string) models.MealPlanEntry {
+	t.Helper()
+	var entry models.MealPlanEntry
+	body := map[string]interface{}{"date": date, "slot": slot, "title": title}
+	if status := doJSON(t, server, http.MethodPost, "/api/v1/mealplanner/entries", body, &entry); status != http.StatusCreated {
+		t.Fatalf("planning %s: status %d, want %d", title, status, http.StatusCreated)
+	}
+	return entry
+}
+
+// plannedWeek lists the entries planned in the week of 2026-10-19.
+func plannedWeek(t *testing.T, server *gin.Engine) []models.MealPlanEntry {
+	t.Helper()
+	var entries []models.MealPlanEntry
+	if status := doJSON(t, server, http.MethodGet, "/api/v1/mealplanner/entries?start_date=2026-10-19&end_date=2026-10-25", nil, &entries); status != http.StatusOK {
+		t.Fatalf("listing entries: status %d, want %d", status, http.StatusOK)
+	}
+	return entries
+}
+
+// plannedMeals returns the titles planned for each meal of the week of 2026-10-19, keyed by
+// date and slot, and fails the test unless every meal is numbered from 0.
+func plannedMeals(t *testing.T, server *gin.Engine) map[string][]string {
+	t.Helper()
+	meals := make(map[string][]string)
+	for _, entry := range plannedWeek(t, server) {
+		meal := entry.Date.Format("2006-01-02") + " " + entry.Slot
+		if entry.SortOrder != len(meals[meal]) {
+			t.Errorf("%s in %s: sort_order %d, want %d", entry.Title, meal, entry.SortOrder, len(meals[meal]))
+		}
+		meals[meal] = append(meals[meal], entry.Title)
+	}
+	return meals
+}
+
+func TestMealPlanBatchIsAllOrNothing(t *testing.T) {
+	server := newTestServer()
+	soup := planMeal(t, server, "2026-10-19", "dinner", "Soup")
+	planMeal(t, server, "2026-10-19", "dinner", "Pasta")
+	salad := planMeal(t, server, "2026-10-19", "lunch", "Salad")
+	before := plannedWeek(t, server)
+
+	batch := map[string]interface{}{"operations": []map[string]interface{}{
+		{"action": "move", "entry_id": soup.ID, "date": "2026-10-20"},
+		{"action": "copy", "entry_id": salad.ID, "date": "2026-10-21", "slot": "dinner"},
+		{"action": "move", "entry_id": uuid.NewString(), "slot": "lunch"},
+	}}
+	if status := doJSON(t, server, http.MethodPost, "/api/v1/mealplanner/entries/batch", batch, nil); status != http.StatusNotFound {
+		t.Fatalf("batch with an unknown entry: status %d, want %d", status, http.StatusNotFound)
+	}
+	if after := plannedWeek(t, server); !reflect.DeepEqual(after, before) {
+		t.Errorf("entries after the failed batch = %+v, want them unchanged: %+v", after, before)
+	}
+}
+
+func TestMealPlanReorder(t *testing.T) {
+	server := newTestServer()
+	soup := planMeal(t, server, "2026-10-19", "dinner", "Soup")
+	planMeal(t, server, "2026-10-19", "dinner", "Pasta")
+	curry := planMeal(t, server, "2026-10-19", "dinner", "Curry")
+	planMeal(t, server, "2026-10-19", "lunch", "Salad")
+
+	tests := []struct {
+		name  string
+		entry models.MealPlanEntry
+		body  map[string]interface{}
+		want  map[string][]string
+	}{
+		{"within a slot", curry, map[string]interface{}{"sort_order": 0}, map[string][]string{
+			"2026-10-19 lunch":  {"Salad"},
+			"2026-10-19 dinner": {"Curry", "Soup", "Pasta"},
+		}},
+		// The meal the entry leaves is numbered from 0 again
+		{"across slots", soup, map[string]interface{}{"slot": "lunch", "sort_order": 0}, map[string][]string{
+			"2026-10-19 lunch":  {"Soup", "Salad"},
+			"2026-10-19 dinner": {"Curry", "Pasta"},
+		}},
+		{"across days", curry, map[string]interface{}{"date": "2026-10-20"}, map[string][]string{
+			"2026-10-19 lunch":  {"Soup", "Salad"},
+			"2026-10-19 dinner": {"Pasta"},
+			"2026-10-20 dinner": {"Curry"},
+		}},
+	}
+	for _, tt := range tests {
+		path := "/api/v1/mealplanner/entries/" + tt.entry.ID
+		if status := doJSON(t, server, http.MethodPatch, path, tt.body, nil); status != http.StatusOK {
+			t.Fatalf("%s: status %d, want %d", tt.name, status, http.StatusOK)
+		}
+		if got := plannedMeals(t, server); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: meals = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/nutrition"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary Get a recipe's nutrition
+// @Description Get the calories, protein, fat, carbohydrate, fiber and sodium of a recipe, in total and per serving, computed from its ingredient quantities and the foods the ingredients are mapped to. Ingredient lines that cannot be weighed, e.g. with no quantity or no food mapped, are listed with the reason and the result is marked incomplete. Results are cached until the recipe or an ingredient's food mapping changes.
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 200 {object} models.RecipeNutrition "Recipe nutrition"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/nutrition [get]
+func (h *NutritionHandler) GetRecipeNutritionHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	if _, err := uuid.Parse(recipeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	recipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving recipe %s for nutrition: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe"})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	cached, err := h.Nutrition.GetRecipeNutrition(c.Request.Context(), recipeID)
+	if err != nil {
+		// The cache is an optimization; compute afresh when it cannot be read
+		log.Printf("Error retrieving cached nutrition of recipe %s: %v", recipeID, err)
+	} else if cached != nil && cached.RecipeUpdatedAt.Equal(recipe.UpdatedAt) {
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	var ingredientIDs []string
+	for _, line := range recipe.StructuredIngredients {
+		if line.IngredientID != "" {
+			ingredientIDs = append(ingredientIDs, line.IngredientID)
+		}
+	}
+	mappings, err := h.Nutrition.GetIngredientFoods(c.Request.Context(), ingredientIDs)
+	if err != nil {
+		log.Printf("Error retrieving ingredient foods for recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve ingredient foods"})
+		return
+	}
+	foods := make(map[string]models.IngredientFood, len(mappings))
+	for _, mapping := range mappings {
+		foods[mapping.IngredientID] = mapping
+	}
+
+	result := nutrition.Compute(recipe, foods)
+	if err := h.Nutrition.SaveRecipeNutrition(c.Request.Context(), result); err != nil {
+		log.Printf("Error caching nutrition of recipe %s: %v", recipeID, err)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Search foods
+// @Description Search the loaded food composition dataset for foods whose description contains every word of the query, to map ingredients to. Shorter, more generic descriptions come first.
+// @Tags admin
+// @Produce json
+// @Param q query string true "Words of the food description"
+// @Param limit query int false "Maximum number of foods" default(10)
+// @Success 200 {array} models.Food "Matching foods, with nutrients per 100 g"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/foods [get]
+func (h *NutritionHandler) SearchFoodsHandler(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusOK, []models.Food{})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSuggestionLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+
+	foods, err := h.Nutrition.SearchFoods(c.Request.Context(), query, limit)
+	if err != nil {
+		log.Printf("Error searching foods: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to search foods"})
+		return
+	}
+	c.JSON(http.StatusOK, foods)
+}
+
+// @Summary Map an ingredient to a food
+// @Description Set the food whose composition an ingredient has, replacing any earlier mapping. Amounts by mass convert directly; amounts by volume need grams_per_ml (the density, 1 for water) and counted amounts such as "2 eggs" need grams_per_piece.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Ingredient ID"
+// @Param body body object{food_id=string,grams_per_ml=number,grams_per_piece=number} true "Food and conversion weights"
+// @Success 200 {object} models.IngredientFood "Ingredient mapped successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Ingredient or food not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/food [put]
+func (h *NutritionHandler) SetIngredientFoodHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var reqBody struct {
+		FoodID        string   `json:"food_id" binding:"required"`
+		GramsPerML    *float64 `json:"grams_per_ml"`
+		GramsPerPiece *float64 `json:"grams_per_piece"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if (reqBody.GramsPerML != nil && *reqBody.GramsPerML <= 0) || (reqBody.GramsPerPiece != nil && *reqBody.GramsPerPiece <= 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grams_per_ml and grams_per_piece must be positive"})
+		return
+	}
+
+	mapping, err := h.Nutrition.SetIngredientFood(c.Request.Context(), models.IngredientFood{
+		IngredientID:  ingredientID,
+		FoodID:        strings.TrimSpace(reqBody.FoodID),
+		GramsPerML:    reqBody.GramsPerML,
+		GramsPerPiece: reqBody.GramsPerPiece,
+	})
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "food with ID") && strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Food not found"})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		default:
+			log.Printf("Error mapping ingredient %s to a food: %v", ingredientID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to map ingredient to food"})
+		}
+		return
+	}
+
+	log.Printf("Ingredient %s mapped to food %s", ingredientID, mapping.FoodID)
+	c.JSON(http.StatusOK, mapping)
+}
+
+// @Summary Remove an ingredient's food
+// @Description Remove the food mapping of an ingredient, leaving it out of recipe nutrition.
+// @Tags admin
+// @Param id path string true "Ingredient ID"
+// @Success 204 "Mapping removed"
+// @Failure 404 {object} map[string]string "Ingredient has no food mapped"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/food [delete]
+func (h *NutritionHandler) DeleteIngredientFoodHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient has no food mapped"})
+		return
+	}
+
+	if err := h.Nutrition.DeleteIngredientFood(c.Request.Context(), ingredientID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient has no food mapped"})
+			return
+		}
+		log.Printf("Error removing food of ingredient %s: %v", ingredientID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to remove ingredient food"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaginatedCookableRecipesResponse is a page of recipes ranked by how much of them the pantry covers.
+type PaginatedCookableRecipesResponse struct {
+	Recipes      []models.CookableRecipe `json:"recipes"`
+	TotalRecipes int                     `json:"total_recipes"`
+	Page         int                     `json:"page"`
+	Limit        int                     `json:"limit"`
+	TotalPages   int                     `json:"total_pages"`
+}
+
+// pantryItemRequest is the body of the routes adding and updating pantry items. The
+// ingredient is given by canonical name, and created when missing.
+type pantryItemRequest struct {
+	Ingredient string   `json:"ingredient"`
+	Quantity   *float64 `json:"quantity"`
+	Unit       string   `json:"unit"`
+	ExpiresOn  string   `json:"expires_on"` // YYYY-MM-DD
+	Notes      string   `json:"notes"`
+}
+
+// toPantryItem validates a request body and turns it into a pantry item.
+func (req pantryItemRequest) toPantryItem() (models.PantryItem, string) {
+	item := models.PantryItem{
+		IngredientName: parser.CanonicalName(req.Ingredient),
+		Quantity:       req.Quantity,
+		Notes:          strings.TrimSpace(req.Notes),
+	}
+	if item.IngredientName == "" {
+		return item, "ingredient must not be blank"
+	}
+	if item.Quantity != nil && *item.Quantity < 0 {
+		return item, "quantity must not be negative"
+	}
+	var ok bool
+	if item.Unit, ok = canonicalUnit(req.Unit); !ok {
+		return item, "unknown unit '" + req.Unit + "'"
+	}
+	if expiresOn := strings.TrimSpace(req.ExpiresOn); expiresOn != "" {
+		day, err := time.Parse(dateLayout, expiresOn)
+		if err != nil {
+			return item, "expires_on must be a date in YYYY-MM-DD format"
+		}
+		item.ExpiresOn = &day
+	}
+	return item, ""
+}
+
+// @Summary List the pantry
+// @Description List the ingredients we have at home, with the amount left and the expiry date when known.
+// @Tags pantry
+// @Produce json
+// @Success 200 {array} models.PantryItem "Pantry items"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry [get]
+func (h *PantryHandler) ListPantryItemsHandler(c *gin.Context) {
+	items, err := h.Pantry.GetPantryItems(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing pantry items: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list pantry items"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// @Summary Add a pantry item
+// @Description Add an ingredient to the pantry. The ingredient is given by name, normalized like the ingredients of recipes and created when missing. Quantity and expiry date are optional; an item with a quantity of 0 or past its expiry date does not count as owned.
+// @Tags pantry
+// @Accept json
+// @Produce json
+// @Param body body object{ingredient=string,quantity=number,unit=string,expires_on=string,notes=string} true "Ingredient, amount left and expiry date (YYYY-MM-DD)"
+// @Success 201 {object} models.PantryItem "Pantry item created successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "The ingredient already is in the pantry"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry [post]
+func (h *PantryHandler) CreatePantryItemHandler(c *gin.Context) {
+	var reqBody pantryItemRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	item, problem := reqBody.toPantryItem()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	created, err := h.Pantry.CreatePantryItem(c.Request.Context(), item)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": "'" + item.IngredientName + "' is already in the pantry"})
+			return
+		}
+		log.Printf("Error adding %s to the pantry: %v", item.IngredientName, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to create pantry item"})
+		return
+	}
+
+	log.Printf("Pantry item %s created for %s", created.ID, created.IngredientName)
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Update a pantry item
+// @Description Replace a pantry item, e.g. to record what is left after cooking.
+// @Tags pantry
+// @Accept json
+// @Produce json
+// @Param id path string true "Pantry item ID"
+// @Param body body object{ingredient=string,quantity=number,unit=string,expires_on=string,notes=string} true "Ingredient, amount left and expiry date (YYYY-MM-DD)"
+// @Success 200 {object} models.PantryItem "Pantry item updated successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Pantry item not found"
+// @Failure 409 {object} map[string]string "Another pantry item has this ingredient"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry/{id} [put]
+func (h *PantryHandler) UpdatePantryItemHandler(c *gin.Context) {
+	itemID := c.Param("id")
+	if _, err := uuid.Parse(itemID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+		return
+	}
+
+	var reqBody pantryItemRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	item, problem := reqBody.toPantryItem()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	item.ID = itemID
+
+	updated, err := h.Pantry.UpdatePantryItem(c.Request.Context(), item)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+		case strings.Contains(err.Error(), "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": "'" + item.IngredientName + "' is already in the pantry"})
+		default:
+			log.Printf("Error updating pantry item %s: %v", itemID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to update pantry item"})
+		}
+		return
+	}
+
+	log.Printf("Pantry item %s updated", itemID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Delete a pantry item
+// @Description Remove an ingredient from the pantry.
+// @Tags pantry
+// @Param id path string true "Pantry item ID"
+// @Success 204 "Pantry item deleted"
+// @Failure 404 {object} map[string]string "Pantry item not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry/{id} [delete]
+func (h *PantryHandler) DeletePantryItemHandler(c *gin.Context) {
+	itemID := c.Param("id")
+	if _, err := uuid.Parse(itemID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+		return
+	}
+
+	if err := h.Pantry.DeletePantryItem(c.Request.Context(), itemID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+			return
+		}
+		log.Printf("Error deleting pantry item %s: %v", itemID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete pantry item"})
+		return
+	}
+
+	log.Printf("Pantry item %s deleted", itemID)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List the staples
+// @Description List the ingredients that always count as owned, such as salt and water, without being kept in the pantry.
+// @Tags pantry
+// @Produce json
+// @Success 200 {array} models.PantryStaple "Staples"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry/staples [get]
+func (h *PantryHandler) ListPantryStaplesHandler(c *gin.Context) {
+	staples, err := h.Pantry.GetPantryStaples(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing pantry staples: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list pantry staples"})
+		return
+	}
+	c.JSON(http.StatusOK, staples)
+}
+
+// @Summary Set the staples
+// @Description Replace the ingredients that always count as owned. Ingredients are given by name and created when missing; an empty list removes every staple.
+// @Tags pantry
+// @Accept json
+// @Produce json
+// @Param body body object{ingredients=[]string} true "Staple ingredient names"
+// @Success 200 {array} models.PantryStaple "Staples set successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /pantry/staples [put]
+func (h *PantryHandler) SetPantryStaplesHandler(c *gin.Context) {
+	var reqBody struct {
+		Ingredients []string `json:"ingredients"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	names := []string{}
+	for _, ingredient := range reqBody.Ingredients {
+		name := parser.CanonicalName(ingredient)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ingredient must not be blank"})
+			return
+		}
+		names = append(names, name)
+	}
+
+	staples, err := h.Pantry.SetPantryStaples(c.Request.Context(), names)
+	if err != nil {
+		log.Printf("Error setting pantry staples: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to set pantry staples"})
+		return
+	}
+
+	log.Printf("Pantry staples set to %v", names)
+	c.JSON(http.StatusOK, staples)
+}
+
+// @Summary List recipes cookable from the pantry
+// @Description Rank recipes by the fraction of their ingredients we already have, then by the number missing, listing the missing ones for each. An ingredient counts as owned when it is in the pantry, neither used up nor expired, when it is a staple, or when a narrower ingredient is owned: cheddar in the pantry covers a recipe calling for cheese.
+// @Tags recipes
+// @Produce json
+// @Param max_missing query int false "Skip recipes missing more ingredients than this"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(25)
+// @Success 200 {object} PaginatedCookableRecipesResponse "Recipes ranked by pantry coverage"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/cookable [get]
+func (h *PantryHandler) ListCookableRecipesHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultPageLimit
+	}
+	maxMissing := -1
+	if value := c.Query("max_missing"); value != "" {
+		if maxMissing, err = parseOptionalInt(value, "max_missing"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	recipes, totalCount, err := h.Pantry.GetCookableRecipes(c.Request.Context(), time.Now(), maxMissing, page, limit)
+	if err != nil {
+		log.Printf("Error ranking cookable recipes: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve cookable recipes"})
+		return
+	}
+
+	totalPages := 0
+	if totalCount > 0 {
+		totalPages = int(math.Ceil(float64(totalCount) / float64(limit)))
+	}
+	c.JSON(http.StatusOK, PaginatedCookableRecipesResponse{
+		Recipes:      recipes,
+		TotalRecipes: totalCount,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   totalPages,
+	})
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"gorecipes/backend/internal/images"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PhotoURLPath is the URL path ServePhotoHandler is routed under.
+const PhotoURLPath = "/uploads/images/"
+
+// PhotoStore is where recipe photos are kept. It is set from PHOTO_STORE at startup.
+var PhotoStore storage.PhotoStore = &storage.LocalStore{Dir: "uploads/images", BaseURL: PhotoURLPath}
+
+// photoURLs returns the URLs of the size variants of a stored photo, or nil when there is none.
+func photoURLs(key string) *models.PhotoURLs {
+	if key == "" {
+		return nil
+	}
+	return &models.PhotoURLs{
+		Thumb: PhotoStore.URL(images.VariantKey(key, images.VariantThumb)),
+		Card:  PhotoStore.URL(images.VariantKey(key, images.VariantCard)),
+		Full:  PhotoStore.URL(images.VariantKey(key, images.VariantFull)),
+	}
+}
+
+// ServePhotoHandler streams a stored photo from PhotoStore, whichever backend it uses.
+// Keys are never reused for different content, so photos may be cached indefinitely.
+func ServePhotoHandler(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	photo, info, err := PhotoStore.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		log.Printf("Error opening photo %s: %v", key, err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer photo.Close()
+
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	}
+	c.Header("Cache-Control", "public, max-age=31536000")
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime, photo)
+}
+
+// withPhotoURLs fills in the variant URLs of the given photos before they are returned.
+func withPhotoURLs(photos []models.RecipePhoto) []models.RecipePhoto {
+	for i := range photos {
+		photos[i].URLs = photoURLs(photos[i].Filename)
+	}
+	return photos
+}
+
+// withRecipePhotoURLs fills in the variant URLs of a recipe's cover and gallery before it is returned.
+func withRecipePhotoURLs(recipe *models.Recipe) *models.Recipe {
+	recipe.PhotoURLs = photoURLs(recipe.PhotoFilename)
+	withPhotoURLs(recipe.Photos)
+	return recipe
+}
+
+// respondPhotoError writes the response for an error returned by the database photo functions.
+func respondPhotoError(c *gin.Context, recipeID string, action string, err error) {
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "photo with id") && strings.Contains(message, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+	case strings.Contains(message, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	case strings.Contains(message, "exactly once"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo_ids must list every photo of the recipe exactly once"})
+	default:
+		log.Printf("Error trying to %s for recipe %s: %v", action, recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to " + action})
+	}
+}
+
+// @Summary List recipe photos
+// @Description Get every photo of a recipe in display order. The cover photo is also the recipe's photo_filename.
+// @Tags photos
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 200 {array} models.RecipePhoto "Successfully retrieved photos"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/photos [get]
+func (h *RecipeHandler) ListRecipePhotosHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	exists, err := h.Recipes.RecipeExistsByID(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error checking recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve photos"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	photos, err := h.Recipes.GetRecipePhotos(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving photos for recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve photos"})
+		return
+	}
+	if photos == nil {
+		photos = []models.RecipePhoto{}
+	}
+
+	c.JSON(http.StatusOK, withPhotoURLs(photos))
+}
+
+// @Summary Upload a recipe photo
+// @Description Add a photo to the end of a recipe's gallery. The first photo of a recipe, or one uploaded with cover=true, becomes the cover.
+// @Tags photos
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param photo formData file true "Photo file"
+// @Param caption formData string false "Photo caption"
+// @Param cover formData bool false "Make this photo the cover"
+// @Success 201 {object} models.RecipePhoto "Photo uploaded successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/photos [post]
+func (h *RecipeHandler) UploadRecipePhotoHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A photo file is required"})
+		return
+	}
+
+	photo := models.RecipePhoto{
+		RecipeID: recipeID,
+		Caption:  strings.TrimSpace(c.PostForm("caption")),
+	}
+	if coverStr := c.PostForm("cover"); coverStr != "" {
+		if photo.IsCover, err = strconv.ParseBool(coverStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cover must be true or false"})
+			return
+		}
+	}
+
+	exists, err := h.Recipes.RecipeExistsByID(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error checking recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to upload photo"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	photo.Filename, err = saveUploadedPhoto(c.Request.Context(), file, recipeID)
+	if err != nil {
+		respondPhotoSaveError(c, recipeID, err)
+		return
+	}
+
+	savedPhoto, err := h.Recipes.AddRecipePhoto(c.Request.Context(), photo)
+	if err != nil {
+		removeRecipePhotos(c.Request.Context(), recipeID, []string{photo.Filename})
+		respondPhotoError(c, recipeID, "upload photo", err)
+		return
+	}
+
+	log.Printf("Photo %s added to recipe %s", savedPhoto.Filename, recipeID)
+	savedPhoto.URLs = photoURLs(savedPhoto.Filename)
+	c.JSON(http.StatusCreated, savedPhoto)
+}
+
+// @Summary Reorder recipe photos
+// @Description Set the display order of a recipe's photos. photo_ids must list every photo of the recipe exactly once.
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param body body object{photo_ids=[]string} true "Photo IDs, first to last"
+// @Success 200 {array} models.RecipePhoto "Photos in their new order"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/photos/order [put]
+func (h *RecipeHandler) ReorderRecipePhotosHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	var reqBody struct {
+		PhotoIDs []string `json:"photo_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	photos, err := h.Recipes.ReorderRecipePhotos(c.Request.Context(), recipeID, reqBody.PhotoIDs)
+	if err != nil {
+		respondPhotoError(c, recipeID, "reorder photos", err)
+		return
+	}
+	if photos == nil {
+		photos = []models.RecipePhoto{}
+	}
+
+	c.JSON(http.StatusOK, withPhotoURLs(photos))
+}
+
+// @Summary Update a recipe photo
+// @Description Change a photo's caption and/or make it the cover. Fields left out are unchanged.
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param photo_id path string true "Photo ID"
+// @Param body body object{caption=string,is_cover=bool} true "Fields to change"
+// @Success 200 {object} models.RecipePhoto "Photo updated successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe or photo not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/photos/{photo_id} [patch]
+func (h *RecipeHandler) UpdateRecipePhotoHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	photoID := c.Param("photo_id")
+
+	var reqBody struct {
+		Caption *string `json:"caption"`
+		IsCover *bool   `json:"is_cover"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if reqBody.IsCover != nil && !*reqBody.IsCover {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Make another photo the cover instead of unsetting this one"})
+		return
+	}
+	if reqBody.Caption != nil {
+		caption := strings.TrimSpace(*reqBody.Caption)
+		reqBody.Caption = &caption
+	}
+
+	photo, err := h.Recipes.UpdateRecipePhoto(c.Request.Context(), recipeID, photoID, reqBody.Caption, reqBody.IsCover != nil)
+	if err != nil {
+		respondPhotoError(c, recipeID, "update photo", err)
+		return
+	}
+
+	photo.URLs = photoURLs(photo.Filename)
+	c.JSON(http.StatusOK, photo)
+}
+
+// @Summary Delete a recipe photo
+// @Description Remove a photo and its file. If it was the cover, the next photo becomes the cover, or the placeholder when none is left.
+// @Tags photos
+// @Param id path string true "Recipe ID"
+// @Param photo_id path string true "Photo ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string "Recipe or photo not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/photos/{photo_id} [delete]
+func (h *RecipeHandler) DeleteRecipePhotoHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	photoID := c.Param("photo_id")
+
+	filename, err := h.Recipes.DeleteRecipePhoto(c.Request.Context(), recipeID, photoID)
+	if err != nil {
+		respondPhotoError(c, recipeID, "delete photo", err)
+		return
+	}
+
+	removeRecipePhotos(c.Request.Context(), recipeID, []string{filename})
+	c.Status(http.StatusNoContent)
+}
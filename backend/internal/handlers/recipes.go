@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt" // Added for Pexels integration
 	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/images"
 	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
 	"io"
 	"log"
 	"math" // Added for pagination (Ceil)
@@ -12,8 +17,6 @@ import (
 	"net/http"
 	"net/url" // Added for Pexels integration (URL encoding)
 	"os"
-	"path/filepath"
-	"regexp"  // Added for ingredient parsing
 	"strconv" // Added for pagination
 	"strings"
 	"time"
@@ -22,10 +25,11 @@ import (
 	"github.com/google/uuid"
 )
 
-const uploadsDir = "uploads/images/" // Relative to backend directory
 const defaultPageLimit = 25
+const defaultSuggestionLimit = 10
+const maxSuggestionLimit = 50
 const pexelsAPIURL = "https://api.pexels.com/v1/search"
-const placeholderImage = "placeholder.jpg"
+const placeholderImage = models.PlaceholderPhotoFilename
 
 // Pexels API Response Structures
 type PexelsPhotoSource struct {
@@ -59,7 +63,7 @@ type PexelsSearchResponse struct {
 
 // fetchAndSaveImageFromPexels tries to fetch an image from Pexels based on the query,
 // download it, and save it. It returns the saved filename or an error.
-func fetchAndSaveImageFromPexels(query string, recipeID string, apiKey string) (string, error) {
+func fetchAndSaveImageFromPexels(ctx context.Context, query string, recipeID string, apiKey string) (string, error) {
 	if apiKey == "" {
 		return "", fmt.Errorf("Pexels API key is not configured")
 	}
@@ -116,264 +120,294 @@ func fetchAndSaveImageFromPexels(query string, recipeID string, apiKey string) (
 		return "", fmt.Errorf("failed to download image from Pexels, status: %d", imgResp.StatusCode)
 	}
 
-	// 6. Determine File Extension
-	contentType := imgResp.Header.Get("Content-Type")
-	var extension string
-	switch contentType {
-	case "image/jpeg":
-		extension = ".jpg"
-	case "image/png":
-		extension = ".png"
-	default:
-		// Try to infer from URL, or default
-		ext := filepath.Ext(imageURL)
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" {
-			extension = ext
-		} else {
-			extension = ".jpg" // Default to .jpg if unsure
-			log.Printf("Warning: Unknown content type '%s' for Pexels image, defaulting to .jpg", contentType)
-		}
-	}
-
-	// 7. Generate Unique Filename
-	savedFilename := recipeID + "_pexels" + extension
-	dstPath := filepath.Join(uploadsDir, savedFilename)
-
-	// 8. Save Image
-	// Ensure the destination directory exists
-	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory for Pexels image: %w", err)
-	}
-	outFile, err := os.Create(dstPath)
+	// 6. Save Image through the pipeline, which also sniffs the format
+	savedFilename, err := images.Process(ctx, imgResp.Body, PhotoStore, newPhotoKeyBase(recipeID))
 	if err != nil {
-		return "", fmt.Errorf("failed to create file for Pexels image: %w", err)
-	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, imgResp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to save Pexels image to file: %w", err)
+		return "", fmt.Errorf("failed to save Pexels image: %w", err)
 	}
 
 	log.Printf("Successfully fetched and saved image from Pexels for recipe %s as %s", recipeID, savedFilename)
 	return savedFilename, nil
 }
 
-// Helper function to save uploaded file
-func saveUploadedFile(file *multipart.FileHeader, dst string) error {
+// newPhotoKeyBase returns a fresh storage key prefix for a photo of a recipe;
+// the image pipeline appends the variant name and extension.
+func newPhotoKeyBase(recipeID string) string {
+	return "recipes/" + recipeID + "/" + uuid.New().String()
+}
+
+// saveUploadedPhoto runs an uploaded photo of a recipe through the image pipeline into
+// PhotoStore. It returns the key recipes store.
+func saveUploadedPhoto(ctx context.Context, file *multipart.FileHeader, recipeID string) (string, error) {
 	src, err := file.Open()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer src.Close()
 
-	// Ensure the destination directory exists
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return err
-	}
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, src)
-	return err
+	return images.Process(ctx, src, PhotoStore, newPhotoKeyBase(recipeID))
 }
 
-var (
-	// commonUnits is a list of common units of measurement to remove.
-	// This list can be expanded.
-	commonUnits = []string{
-		"g", "kg", "mg", "oz", "lb", "lbs",
-		"ml", "l", "cl", "dl",
-		"tsp", "tbsp", "fl oz", "cup", "cups", "pt", "qt", "gal",
-		"pinch", "dash", "clove", "cloves", "head", "heads",
-		"slice", "slices", "piece", "pieces",
-		// Fractional words
-		"half", "quarter",
-	}
-
-	// commonDescriptors is a list of common adjectives or preparation states to remove.
-	// This list can be expanded.
-	commonDescriptors = []string{
-		"fresh", "dried", "frozen", "canned", "cooked", "uncooked", "raw",
-		"chopped", "diced", "sliced", "minced", "grated", "crushed", "peeled", "seeded",
-		"large", "medium", "small",
-		"ripe", "unripe",
-		"optional", "to taste", "for garnish",
-		"plain", "all-purpose", "self-raising", "whole", "ground", "granulated", "powdered",
-		"boneless", "skinless",
-		"finely", "coarsely", "roughly",
-		"hot", "cold", "warm", "chilled",
-		"sweet", "unsweetened", "salted", "unsalted",
-	}
-
-	// commonStopWords are words that are generally not useful for filtering.
-	// This list can be expanded.
-	commonStopWords = []string{
-		"a", "an", "the", "of", "and", "or", "with", "without", "in", "on", "at", "for", "to", "from",
-		"some", "any", "about", "into", "over", "under",
+// respondPhotoSaveError writes the response for an error returned by saveUploadedPhoto.
+// Files that can't be decoded are the client's fault; anything else is ours.
+func respondPhotoSaveError(c *gin.Context, recipeID string, err error) {
+	if errors.Is(err, images.ErrInvalidImage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo: " + err.Error()})
+		return
 	}
-)
-
-// normalizeAndCleanToken prepares a token by lowercasing and trimming.
-func normalizeAndCleanToken(token string) string {
-	return strings.ToLower(strings.TrimSpace(token))
+	log.Printf("Error saving photo for recipe %s: %v", recipeID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
 }
 
-// removeSubstrings removes all occurrences of given substrings from a string.
-func removeSubstrings(s string, substrings []string) string {
-	for _, sub := range substrings {
-		// Use word boundaries for units and descriptors to avoid partial matches in words
-		// e.g., removing "g" from "garlic"
-		// Regex for word boundary: \b
-		// However, simple Replace might be okay for a first pass if lists are curated.
-		// For more precision, regex would be better: regexp.MustCompile(`\b` + regexp.QuoteMeta(sub) + `\b`)
-		// For now, let's do a simpler replace, but be mindful of this.
-		// To be safer, add spaces around the substrings to be removed if they are standalone words.
-		s = strings.ReplaceAll(s, " "+sub+" ", " ") // for words in the middle
-		s = strings.ReplaceAll(s, sub+" ", " ")     // for words at the beginning (after number removal)
-		s = strings.ReplaceAll(s, " "+sub, " ")     // for words at the end
-	}
-	return s
+// commonStopWords are words that are generally not useful for filtering.
+// This list can be expanded.
+var commonStopWords = []string{
+	"a", "an", "the", "of", "and", "or", "with", "without", "in", "on", "at", "for", "to", "from",
+	"some", "any", "about", "into", "over", "under",
 }
 
-// extractFilterableNames attempts to extract core ingredient names from a full ingredient string.
+// extractFilterableNames extracts the ingredient names a recipe can be filtered by
+// from a full ingredient string. It relies on the same parser used to store
+// recipe ingredients, so quantities, units and preparation notes are dropped.
 // Example: "180g plain flour, finely chopped" -> ["plain flour", "flour"]
 func extractFilterableNames(fullIngredient string) []string {
-	if strings.TrimSpace(fullIngredient) == "" {
+	parsed := parser.ParseIngredient(fullIngredient)
+	if parsed.Name == "" {
 		return nil
 	}
 
-	// 1. Lowercase
-	processed := strings.ToLower(fullIngredient)
-
-	// 2. Remove quantities (numbers and common fractions like 1/2, 1 1/2)
-	// Regex to remove numbers, fractions, and mixed numbers.
-	// This regex handles: "1", "1.5", "1/2", "1 1/2", "1-2"
-	reNum := regexp.MustCompile(`\d+(\s*[-/]\s*\d+)?(\.\d+)?(\s+\d+/\d+)?`)
-	processed = reNum.ReplaceAllString(processed, "")
-
-	// 3. Remove common units
-	processed = removeSubstrings(processed, commonUnits)
-
-	// 4. Remove common descriptors
-	processed = removeSubstrings(processed, commonDescriptors)
-
-	// 5. Split into words, remove stop words, and collect remaining.
-	// Also, consider multi-word ingredient names that might remain.
-	potentialNames := make(map[string]bool)
-
-	// Add the processed string as a whole, if it's meaningful
-	trimmedProcessed := strings.TrimSpace(processed)
-	// Replace multiple spaces with a single space
-	trimmedProcessed = regexp.MustCompile(`\s+`).ReplaceAllString(trimmedProcessed, " ")
-	if len(trimmedProcessed) > 2 { // Arbitrary length to avoid very short/meaningless "names"
-		potentialNames[trimmedProcessed] = true
+	result := []string{parsed.Name}
+	words := strings.Fields(parsed.Name)
+	if len(words) == 1 {
+		return result
 	}
-
-	// Split into individual words and add them if not stop words
-	words := strings.Fields(trimmedProcessed)
 	for _, word := range words {
-		cleanedWord := normalizeAndCleanToken(word)
 		isStopWord := false
 		for _, stop := range commonStopWords {
-			if cleanedWord == stop {
+			if word == stop {
 				isStopWord = true
 				break
 			}
 		}
-		if !isStopWord && len(cleanedWord) > 2 { // Arbitrary length
-			potentialNames[cleanedWord] = true
+		if !isStopWord && len(word) > 2 { // Arbitrary length
+			result = append(result, word)
 		}
 	}
+	return result
+}
+
+// recipeInput holds the editable recipe fields accepted by CreateRecipe and UpdateRecipe,
+// either as multipart form fields or as a JSON body. Optional fields are nil when they were
+// not sent, so an update keeps their current values.
+type recipeInput struct {
+	Name            string   `json:"name"`
+	Method          string   `json:"method"`
+	Ingredients     []string `json:"ingredients"`
+	Servings        *int     `json:"servings"`
+	Yield           *string  `json:"yield"`
+	PrepTimeMinutes *int     `json:"prep_time_minutes"`
+	CookTimeMinutes *int     `json:"cook_time_minutes"`
+	RestTimeMinutes *int     `json:"rest_time_minutes"`
+	Tags            []string `json:"tags"`
+	Editor          string   `json:"editor"` // Who made the change, recorded in the revision history
+}
 
-	if len(potentialNames) == 0 && len(words) > 0 {
-		// Fallback: if after all filtering nothing is left, but there were words,
-		// maybe the original string (just lowercased and trimmed) is the best we can do.
-		// This handles cases where an ingredient is just "salt" or "pepper".
-		originalTrimmed := normalizeAndCleanToken(fullIngredient)
-		// Remove numbers from this fallback too
-		originalTrimmed = reNum.ReplaceAllString(originalTrimmed, "")
-		originalTrimmed = regexp.MustCompile(`\s+`).ReplaceAllString(strings.TrimSpace(originalTrimmed), " ")
-		if len(originalTrimmed) > 1 {
-			potentialNames[originalTrimmed] = true
+// isJSONRequest reports whether the request body is JSON rather than multipart form data.
+func isJSONRequest(c *gin.Context) bool {
+	return c.ContentType() == "application/json"
+}
+
+// bindRecipeInput reads and validates recipe fields from a JSON body or multipart form.
+// Ingredients are trimmed and blank lines dropped.
+func bindRecipeInput(c *gin.Context) (recipeInput, error) {
+	var input recipeInput
+	if isJSONRequest(c) {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			return input, fmt.Errorf("Invalid request body: %v", err)
+		}
+	} else {
+		input.Name = c.PostForm("name")
+		input.Method = c.PostForm("method")
+		input.Editor = c.PostForm("editor")
+		if yield, ok := c.GetPostForm("yield"); ok {
+			input.Yield = &yield
+		}
+		if tagsStr, ok := c.GetPostForm("tags"); ok {
+			input.Tags = splitCommaList(tagsStr)
+		}
+		if ingredientsStr, ok := c.GetPostForm("ingredients"); ok {
+			input.Ingredients = strings.Split(ingredientsStr, "\n")
+		}
+		var err error
+		if input.Servings, err = formOptionalInt(c, "servings"); err != nil {
+			return input, err
+		}
+		if input.PrepTimeMinutes, err = formOptionalInt(c, "prep_time_minutes"); err != nil {
+			return input, err
+		}
+		if input.CookTimeMinutes, err = formOptionalInt(c, "cook_time_minutes"); err != nil {
+			return input, err
+		}
+		if input.RestTimeMinutes, err = formOptionalInt(c, "rest_time_minutes"); err != nil {
+			return input, err
 		}
 	}
 
-	// Convert map to slice
-	var result []string
-	for name := range potentialNames {
-		result = append(result, name)
+	if input.Yield != nil {
+		yield := strings.TrimSpace(*input.Yield)
+		input.Yield = &yield
 	}
-	return result
+	input.Editor = strings.TrimSpace(input.Editor)
+	if input.Ingredients != nil {
+		ingredients := []string{}
+		for _, ing := range input.Ingredients {
+			if trimmedIng := strings.TrimSpace(ing); trimmedIng != "" {
+				ingredients = append(ingredients, trimmedIng)
+			}
+		}
+		input.Ingredients = ingredients
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		return input, fmt.Errorf("Recipe name cannot be empty")
+	}
+	if strings.TrimSpace(input.Method) == "" {
+		return input, fmt.Errorf("Recipe method cannot be empty")
+	}
+	for _, n := range []*int{input.Servings, input.PrepTimeMinutes, input.CookTimeMinutes, input.RestTimeMinutes} {
+		if n != nil && *n < 0 {
+			return input, fmt.Errorf("Servings and times cannot be negative")
+		}
+	}
+	return input, nil
+}
+
+// applyTo copies the input fields onto a recipe, leaving the ones that were not sent as they are.
+func (input recipeInput) applyTo(recipe *models.Recipe) {
+	recipe.Name = input.Name
+	recipe.Method = input.Method
+	if input.Ingredients != nil {
+		recipe.Ingredients = input.Ingredients
+	}
+	if input.Servings != nil {
+		recipe.Servings = *input.Servings
+	}
+	if input.Yield != nil {
+		recipe.Yield = *input.Yield
+	}
+	if input.PrepTimeMinutes != nil {
+		recipe.PrepTimeMinutes = *input.PrepTimeMinutes
+	}
+	if input.CookTimeMinutes != nil {
+		recipe.CookTimeMinutes = *input.CookTimeMinutes
+	}
+	if input.RestTimeMinutes != nil {
+		recipe.RestTimeMinutes = *input.RestTimeMinutes
+	}
+	recipe.ComputeTotalTime()
+	if input.Tags != nil {
+		recipe.Tags = input.Tags
+	}
+}
+
+// splitCommaList splits a comma-separated value into trimmed, non-empty items.
+// It never returns nil, so an empty value still means "provided but empty".
+func splitCommaList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// parseOptionalInt parses an optional non-negative whole number form value.
+// An empty value means unknown (0).
+func parseOptionalInt(value string, field string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative whole number", field)
+	}
+	return n, nil
+}
+
+// formOptionalInt reads an optional non-negative whole number form field. It returns nil
+// when the field was not sent; an empty value means unknown (0).
+func formOptionalInt(c *gin.Context, field string) (*int, error) {
+	value, ok := c.GetPostForm(field)
+	if !ok {
+		return nil, nil
+	}
+	n, err := parseOptionalInt(value, field)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// formPhoto returns the uploaded "photo" file. JSON requests never carry a photo.
+func formPhoto(c *gin.Context) (*multipart.FileHeader, error) {
+	if isJSONRequest(c) {
+		return nil, http.ErrMissingFile
+	}
+	return c.FormFile("photo")
 }
 
 // @Summary Create a new recipe
-// @Description Create a new recipe with name, method, ingredients, and an optional photo.
+// @Description Create a new recipe with name, method, ingredients, servings, times, and an optional photo. Also accepts a JSON body with the same fields (ingredients as an array, no photo).
 // @Tags recipes
-// @Accept multipart/form-data
+// @Accept multipart/form-data,json
 // @Produce json
 // @Param name formData string true "Name of the recipe"
 // @Param method formData string true "Cooking method"
 // @Param ingredients formData string false "Newline-separated list of ingredients"
+// @Param servings formData int false "Number of servings the recipe makes"
+// @Param yield formData string false "Free-text yield, e.g. \"24 cookies\""
+// @Param prep_time_minutes formData int false "Preparation time in minutes"
+// @Param cook_time_minutes formData int false "Cooking time in minutes"
+// @Param rest_time_minutes formData int false "Resting time in minutes"
+// @Param tags formData string false "Comma-separated list of tag names; unknown tags are created"
+// @Param editor formData string false "Name of the person making the change, kept in the revision history"
 // @Param photo formData file false "Recipe photo"
 // @Success 201 {object} models.Recipe "Recipe created successfully"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes [post]
-func CreateRecipe(c *gin.Context) {
+func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 	var recipe models.Recipe
 	// Generate ID in handler for use in photo filename generation before DB call.
 	// database.CreateRecipe will use this ID if provided.
 	recipe.ID = uuid.New().String()
 
-	recipe.Name = c.PostForm("name")
-	recipe.Method = c.PostForm("method")
-	ingredientsStr := c.PostForm("ingredients")
-
-	if strings.TrimSpace(recipe.Name) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe name cannot be empty"})
-		return
-	}
-	if strings.TrimSpace(recipe.Method) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe method cannot be empty"})
+	input, err := bindRecipeInput(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input.applyTo(&recipe)
 
-	// Process ingredients from comma-separated string to []string
+	// Drop duplicate ingredient lines
 	recipe.Ingredients = []string{}
-	if ingredientsStr != "" {
-		rawIngredients := strings.Split(ingredientsStr, "\n")
-		uniqueIngredients := make(map[string]bool)
-		for _, ing := range rawIngredients {
-			trimmedIng := strings.TrimSpace(ing)
-			if trimmedIng != "" && !uniqueIngredients[trimmedIng] {
-				recipe.Ingredients = append(recipe.Ingredients, trimmedIng)
-				uniqueIngredients[trimmedIng] = true
-			}
+	uniqueIngredients := make(map[string]bool)
+	for _, ing := range input.Ingredients {
+		if !uniqueIngredients[ing] {
+			recipe.Ingredients = append(recipe.Ingredients, ing)
+			uniqueIngredients[ing] = true
 		}
 	}
 
 	// Handle photo upload / Pexels integration
-	file, errFile := c.FormFile("photo")
+	file, errFile := formPhoto(c)
 	if errFile == nil {
 		// User uploaded a photo
-		photoFilename := recipe.ID + filepath.Ext(file.Filename)
-		// Ensure uploadsDir exists
-		if _, err := os.Stat(uploadsDir); os.IsNotExist(err) {
-			if err := os.MkdirAll(uploadsDir, os.ModePerm); err != nil {
-				log.Printf("Error creating uploads directory %s: %v", uploadsDir, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
-				return
-			}
-		}
-		dst := filepath.Join(uploadsDir, photoFilename)
-		if err := saveUploadedFile(file, dst); err != nil {
-			log.Printf("Error saving uploaded file for new recipe %s: %v", recipe.ID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+		photoFilename, err := saveUploadedPhoto(c.Request.Context(), file, recipe.ID)
+		if err != nil {
+			respondPhotoSaveError(c, recipe.ID, err)
 			return
 		}
 		recipe.PhotoFilename = photoFilename
@@ -387,7 +421,7 @@ func CreateRecipe(c *gin.Context) {
 		pexelsAPIKey := os.Getenv("PEXELS_API_KEY")
 		if pexelsAPIKey != "" && recipe.Name != "" {
 			log.Printf("No photo uploaded for recipe %s. Attempting to fetch from Pexels...", recipe.ID)
-			fetchedFilename, errPexels := fetchAndSaveImageFromPexels(recipe.Name, recipe.ID, pexelsAPIKey)
+			fetchedFilename, errPexels := fetchAndSaveImageFromPexels(c.Request.Context(), recipe.Name, recipe.ID, pexelsAPIKey)
 			if errPexels == nil && fetchedFilename != "" {
 				recipe.PhotoFilename = fetchedFilename
 				log.Printf("Successfully used Pexels image %s for recipe %s", fetchedFilename, recipe.ID)
@@ -413,15 +447,15 @@ func CreateRecipe(c *gin.Context) {
 	// Timestamps (CreatedAt, UpdatedAt) will be set by the database.CreateRecipe function.
 
 	// Save recipe to PostgreSQL database
-	createdRecipe, errDb := database.CreateRecipe(&recipe)
+	createdRecipe, errDb := h.Recipes.CreateRecipe(c.Request.Context(), &recipe, input.Editor)
 	if errDb != nil {
 		log.Printf("Error saving recipe to database (ID attempted: %s): %v", recipe.ID, errDb)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recipe"})
+		c.JSON(dbErrorStatus(c, errDb), gin.H{"error": "Failed to save recipe"})
 		return
 	}
 
 	log.Printf("Recipe created successfully: ID=%s, Name=%s", createdRecipe.ID, createdRecipe.Name)
-	c.JSON(http.StatusCreated, createdRecipe)
+	c.JSON(http.StatusCreated, withRecipePhotoURLs(createdRecipe))
 }
 
 // PaginatedRecipesResponse defines the structure for paginated recipe results.
@@ -441,11 +475,20 @@ type PaginatedRecipesResponse struct {
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(25)
 // @Param search query string false "Search term for recipe name or method"
-// @Param tags query string false "Comma-separated list of ingredient tags to filter by"
+// @Param tags query string false "Comma-separated list of ingredients to filter by (all must match)"
+// @Param include_descendants query bool false "Let ingredient filters also match the ingredients below them in the taxonomy, e.g. cheese matches cheddar"
+// @Param with_tags query string false "Comma-separated recipe tags that must all be present"
+// @Param any_tags query string false "Comma-separated recipe tags of which at least one must be present"
+// @Param without_tags query string false "Comma-separated recipe tags that must not be present"
+// @Param max_total_time query int false "Only recipes with a known total time of at most this many minutes"
+// @Param servings query int false "Only recipes that serve exactly this many people"
+// @Param exclude_allergens query string false "Comma-separated allergens the recipe must not contain: gluten, dairy, egg, nuts, peanuts, soy, shellfish, sesame (or meat, fish)"
+// @Param diet query string false "Comma-separated diets the recipe must fit: vegan, vegetarian, pescatarian, gluten-free"
 // @Success 200 {object} PaginatedRecipesResponse "Successfully retrieved recipes"
+// @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes [get]
-func ListRecipes(c *gin.Context) {
+func (h *RecipeHandler) ListRecipes(c *gin.Context) {
 	// Parse query parameters for pagination
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit))
@@ -475,19 +518,65 @@ func ListRecipes(c *gin.Context) {
 		}
 	}
 
-	log.Printf("[ListRecipes] Query Params: page=%d, limit=%d, search='%s', tags=%v", page, limit, searchTerm, ingredientFilters)
+	maxTotalTime, err := parseOptionalInt(c.Query("max_total_time"), "max_total_time")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	servings, err := parseOptionalInt(c.Query("servings"), "servings")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	includeDescendants := false
+	if value := c.Query("include_descendants"); value != "" {
+		if includeDescendants, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_descendants must be true or false"})
+			return
+		}
+	}
+
+	excludeFlags, err := dietary.NormalizeFlags(splitCommaList(c.Query("exclude_allergens")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exclude_allergens: " + err.Error()})
+		return
+	}
+	dietFlags, err := dietary.ExcludedFlags(splitCommaList(c.Query("diet")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "diet: " + err.Error()})
+		return
+	}
+	excludeFlags, _ = dietary.NormalizeFlags(append(excludeFlags, dietFlags...))
+
+	log.Printf("[ListRecipes] Query Params: page=%d, limit=%d, search='%s', tags=%v, include_descendants=%t, max_total_time=%d, servings=%d, with_tags='%s', any_tags='%s', without_tags='%s', exclude_allergens='%s', diet='%s'",
+		page, limit, searchTerm, ingredientFilters, includeDescendants, maxTotalTime, servings, c.Query("with_tags"), c.Query("any_tags"), c.Query("without_tags"), c.Query("exclude_allergens"), c.Query("diet"))
+
+	filter := database.RecipeFilter{
+		SearchTerm:         searchTerm,
+		IngredientFilters:  ingredientFilters,
+		IncludeDescendants: includeDescendants,
+		MaxTotalTime:       maxTotalTime,
+		Servings:           servings,
+		TagsAll:            splitCommaList(c.Query("with_tags")),
+		TagsAny:            splitCommaList(c.Query("any_tags")),
+		TagsNone:           splitCommaList(c.Query("without_tags")),
+		ExcludeFlags:       excludeFlags,
+	}
 
 	// Fetch recipes from PostgreSQL database
-	recipes, totalCount, err := database.GetAllRecipes(searchTerm, ingredientFilters, page, limit)
+	recipes, totalCount, err := h.Recipes.GetAllRecipes(c.Request.Context(), filter, page, limit)
 	if err != nil {
 		log.Printf("Error retrieving recipes from database: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recipes"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipes"})
 		return
 	}
 
 	if recipes == nil {
 		recipes = []models.Recipe{} // Ensure we return an empty array, not null
 	}
+	for i := range recipes {
+		withRecipePhotoURLs(&recipes[i])
+	}
 
 	totalPages := 0
 	if totalCount > 0 && limit > 0 {
@@ -545,7 +634,7 @@ func containsAnyTag(recipeID string, recipeIngredients []string, filterTags []st
 // @Failure 404 {object} map[string]string "Recipe not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes/{id} [get]
-func GetRecipe(c *gin.Context) {
+func (h *RecipeHandler) GetRecipe(c *gin.Context) {
 	recipeID := c.Param("id")
 
 	if recipeID == "" {
@@ -553,7 +642,7 @@ func GetRecipe(c *gin.Context) {
 		return
 	}
 
-	recipe, err := database.GetRecipeByID(recipeID)
+	recipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
 	if err != nil {
 		// Check if the error is due to the recipe not being found.
 		// database.GetRecipeByID is expected to return an error that can be identified as 'not found'.
@@ -566,30 +655,41 @@ func GetRecipe(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		} else {
 			log.Printf("Error retrieving recipe %s from database: %v", recipeID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recipe"})
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe"})
 		}
 		return
 	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, recipe)
+	c.JSON(http.StatusOK, withRecipePhotoURLs(recipe))
 }
 
 // @Summary Update an existing recipe
-// @Description Update an existing recipe by its ID with new name, method, ingredients, and optional photo.
+// @Description Update an existing recipe by its ID with new name, method, ingredients, servings, times, and optional photo. Also accepts a JSON body with the same fields (ingredients as an array, no photo). Ingredients, servings, yield, times and tags that are left out keep their current values; send an empty value or 0 to clear one.
 // @Tags recipes
-// @Accept multipart/form-data
+// @Accept multipart/form-data,json
 // @Produce json
 // @Param id path string true "Recipe ID"
 // @Param name formData string true "Name of the recipe"
 // @Param method formData string true "Cooking method"
 // @Param ingredients formData string false "Newline-separated list of ingredients"
+// @Param servings formData int false "Number of servings the recipe makes"
+// @Param yield formData string false "Free-text yield, e.g. \"24 cookies\""
+// @Param prep_time_minutes formData int false "Preparation time in minutes"
+// @Param cook_time_minutes formData int false "Cooking time in minutes"
+// @Param rest_time_minutes formData int false "Resting time in minutes"
+// @Param tags formData string false "Comma-separated list of tag names replacing the current ones; omit to keep them"
+// @Param editor formData string false "Name of the person making the change, kept in the revision history"
 // @Param photo formData file false "New recipe photo"
 // @Success 200 {object} models.Recipe "Recipe updated successfully"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 404 {object} map[string]string "Recipe not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes/{id} [put]
-func UpdateRecipe(c *gin.Context) {
+func (h *RecipeHandler) UpdateRecipe(c *gin.Context) {
 	recipeID := c.Param("id")
 	if recipeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe ID cannot be empty"})
@@ -597,74 +697,44 @@ func UpdateRecipe(c *gin.Context) {
 	}
 
 	// Fetch existing recipe to get current photo filename and other details
-	existingRecipe, err := database.GetRecipeByID(recipeID)
+	existingRecipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "not found") || strings.Contains(err.Error(), "no rows in result set") {
 			log.Printf("Recipe with ID %s not found for update: %v", recipeID, err)
 			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		} else {
 			log.Printf("Error retrieving recipe %s for update: %v", recipeID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recipe for update"})
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe for update"})
 		}
 		return
 	}
+	if existingRecipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
 
 	// Create a recipe model to hold updated values
 	recipeToUpdate := *existingRecipe // Start with existing values
 
 	// Update fields from form data
-	recipeToUpdate.Name = c.PostForm("name")
-	recipeToUpdate.Method = c.PostForm("method")
-	ingredientsStr := c.PostForm("ingredients")
-
-	if strings.TrimSpace(recipeToUpdate.Name) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe name cannot be empty"})
-		return
-	}
-	if strings.TrimSpace(recipeToUpdate.Method) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe method cannot be empty"})
+	input, err := bindRecipeInput(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input.applyTo(&recipeToUpdate) // Fields that were not sent keep their current values
 
-	// Process ingredients
-	var updatedIngredients []string
-	if ingredientsStr != "" {
-		rawIngredients := strings.Split(ingredientsStr, "\n")
-		for _, ing := range rawIngredients {
-			trimmedIng := strings.TrimSpace(ing)
-			if trimmedIng != "" {
-				updatedIngredients = append(updatedIngredients, trimmedIng)
-			}
-		}
-	}
-	recipeToUpdate.Ingredients = updatedIngredients // Can be empty if ingredientsStr was empty or all spaces
-
-	// Handle photo update
-	oldPhotoFilename := existingRecipe.PhotoFilename
-	newPhotoUploaded := false
-
-	file, errUpload := c.FormFile("photo")
+	// Handle photo update. The previous photo file is kept, since older revisions still reference it.
+	file, errUpload := formPhoto(c)
 	if errUpload == nil {
 		// New photo uploaded
-		newPhotoFilename := recipeID + "_updated_" + uuid.New().String() + filepath.Ext(file.Filename)
-		// Ensure uploadsDir exists
-		if _, errStat := os.Stat(uploadsDir); os.IsNotExist(errStat) {
-			if errMkdir := os.MkdirAll(uploadsDir, os.ModePerm); errMkdir != nil {
-				log.Printf("Error creating uploads directory %s during update: %v", uploadsDir, errMkdir)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
-				return
-			}
-		}
-		dst := filepath.Join(uploadsDir, newPhotoFilename)
-		if errSave := saveUploadedFile(file, dst); errSave != nil {
-			log.Printf("Error saving updated photo file for recipe %s: %v", recipeID, errSave)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save updated photo"})
-			return // Or decide to proceed without photo update
-		} else {
-			recipeToUpdate.PhotoFilename = newPhotoFilename
-			newPhotoUploaded = true
-			log.Printf("New photo saved for recipe %s: %s", recipeID, newPhotoFilename)
+		newPhotoFilename, errSave := saveUploadedPhoto(c.Request.Context(), file, recipeID)
+		if errSave != nil {
+			respondPhotoSaveError(c, recipeID, errSave)
+			return
 		}
+		recipeToUpdate.PhotoFilename = newPhotoFilename
+		log.Printf("New photo saved for recipe %s: %s", recipeID, newPhotoFilename)
 	} else if errUpload != http.ErrMissingFile {
 		// Error other than 'no file'
 		log.Printf("Error retrieving photo from form during update for recipe %s: %v", recipeID, errUpload)
@@ -673,18 +743,6 @@ func UpdateRecipe(c *gin.Context) {
 	}
 	// If no new photo was uploaded (errUpload == http.ErrMissingFile), recipeToUpdate.PhotoFilename remains existingRecipe.PhotoFilename
 
-	// If a new photo was successfully uploaded and there was an old one (not placeholder, not the same as new),
-	// delete the old photo from the filesystem.
-	if newPhotoUploaded && oldPhotoFilename != "" && oldPhotoFilename != placeholderImage && oldPhotoFilename != recipeToUpdate.PhotoFilename {
-		oldPhotoPath := filepath.Join(uploadsDir, oldPhotoFilename)
-		if errRemove := os.Remove(oldPhotoPath); errRemove != nil {
-			log.Printf("Error deleting old photo %s for recipe %s: %v", oldPhotoPath, recipeID, errRemove)
-			// Non-fatal, just log it.
-		} else {
-			log.Printf("Old photo deleted for recipe %s: %s", recipeID, oldPhotoPath)
-		}
-	}
-
 	// If after all, PhotoFilename is empty (e.g. was placeholder and no new upload), ensure it's set to placeholder.
 	if recipeToUpdate.PhotoFilename == "" {
 		recipeToUpdate.PhotoFilename = placeholderImage
@@ -692,7 +750,7 @@ func UpdateRecipe(c *gin.Context) {
 
 	// Timestamps (UpdatedAt) will be handled by database.UpdateRecipe
 
-	updatedRecipe, errDb := database.UpdateRecipe(&recipeToUpdate)
+	updatedRecipe, errDb := h.Recipes.UpdateRecipe(c.Request.Context(), &recipeToUpdate, input.Editor)
 	if errDb != nil {
 		// database.UpdateRecipe might also return a 'not found' error if the ID doesn't exist at the time of update.
 		if strings.Contains(strings.ToLower(errDb.Error()), "not found") || strings.Contains(errDb.Error(), "no rows in result set") {
@@ -700,17 +758,17 @@ func UpdateRecipe(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found for update"})
 		} else {
 			log.Printf("Error updating recipe %s in database: %v", recipeID, errDb)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
+			c.JSON(dbErrorStatus(c, errDb), gin.H{"error": "Failed to update recipe"})
 		}
 		return
 	}
 
 	log.Printf("Recipe updated successfully: ID=%s, Name=%s", updatedRecipe.ID, updatedRecipe.Name)
-	c.JSON(http.StatusOK, updatedRecipe)
+	c.JSON(http.StatusOK, withRecipePhotoURLs(updatedRecipe))
 }
 
 // @Summary Delete a recipe
-// @Description Delete a recipe by its unique ID.
+// @Description Move a recipe to the trash. It disappears from listings but can be restored from /trash until it is purged.
 // @Tags recipes
 // @Accept json
 // @Produce json
@@ -719,118 +777,195 @@ func UpdateRecipe(c *gin.Context) {
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /recipes/{id} [delete]
-func DeleteRecipe(c *gin.Context) {
+func (h *RecipeHandler) DeleteRecipe(c *gin.Context) {
 	recipeID := c.Param("id")
 	if recipeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe ID cannot be empty"})
 		return
 	}
 
-	// Step 1: Fetch the recipe to get its photo filename before deleting from DB.
-	recipeToDelete, err := database.GetRecipeByID(recipeID)
-	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "not found") || strings.Contains(err.Error(), "no rows in result set") {
-			log.Printf("Recipe with ID %s not found (already deleted or never existed): %v", recipeID, err)
-			c.Status(http.StatusNoContent) // Recipe is gone, so operation is effectively successful.
-		} else {
-			log.Printf("Error retrieving recipe %s for deletion: %v", recipeID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recipe before deletion"})
-		}
+	// Photos are kept until the recipe is purged from the trash.
+	if err := h.Recipes.DeleteRecipe(c.Request.Context(), recipeID); err != nil {
+		log.Printf("Error deleting recipe %s from database: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete recipe from database"})
 		return
 	}
 
-	// Step 2: Delete the recipe from the database.
-	errDbDelete := database.DeleteRecipe(recipeID)
-	if errDbDelete != nil {
-		// If GetRecipeByID succeeded, a "not found" here would be unusual but handle defensively.
-		if strings.Contains(strings.ToLower(errDbDelete.Error()), "not found") || strings.Contains(errDbDelete.Error(), "no rows in result set") {
-			log.Printf("Recipe with ID %s was not found during DB deletion (possibly deleted concurrently): %v", recipeID, errDbDelete)
-			// Proceed to photo deletion if recipeToDelete has photo info, then return 204.
-		} else {
-			log.Printf("Error deleting recipe %s from database: %v", recipeID, errDbDelete)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipe from database"})
-			return
-		}
-	}
-
-	// Step 3: If recipe was fetched and had a photo (and it's not the placeholder), delete the photo file.
-	if recipeToDelete != nil && recipeToDelete.PhotoFilename != "" && recipeToDelete.PhotoFilename != placeholderImage {
-		photoPath := filepath.Join(uploadsDir, recipeToDelete.PhotoFilename)
-		// Ensure uploadsDir exists before trying to remove a file from it (though unlikely to be an issue here)
-		if _, errStat := os.Stat(uploadsDir); os.IsNotExist(errStat) {
-			log.Printf("Uploads directory %s does not exist, cannot delete photo %s", uploadsDir, photoPath)
-		} else {
-			if errRemove := os.Remove(photoPath); errRemove != nil {
-				// Log error but don't fail the overall operation if DB deletion was successful.
-				log.Printf("Error deleting photo file %s for deleted recipe %s: %v", photoPath, recipeID, errRemove)
-			} else {
-				log.Printf("Photo file deleted for recipe %s: %s", recipeID, photoPath)
-			}
-		}
-	}
-
-	log.Printf("Recipe deleted successfully: %s", recipeID)
+	log.Printf("Recipe moved to trash: %s", recipeID)
 	c.Status(http.StatusNoContent)
 }
 
 // GetIngredientsAutocomplete handles fetching ingredient suggestions.
 // GET /api/v1/ingredients?q=<query>
-func GetIngredientsAutocomplete(c *gin.Context) {
-	query := strings.ToLower(c.Query("q"))
-	var matchingIngredients []string
-
+// @Summary Suggest ingredients
+// @Description Suggest ingredients whose name or alias starts with the query, has a word starting with it, or is similar to it (trigram matching). Prefix matches come first; within each group ingredients used by more recipes rank higher.
+// @Tags ingredients
+// @Produce json
+// @Param q query string true "Text typed so far"
+// @Param limit query int false "Maximum number of suggestions" default(10)
+// @Success 200 {array} models.IngredientSuggestion
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /ingredients [get]
+func (h *IngredientHandler) GetIngredientsAutocomplete(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
 	if query == "" {
-		c.JSON(http.StatusOK, matchingIngredients)
+		c.JSON(http.StatusOK, []models.IngredientSuggestion{})
 		return
 	}
 
-	// TODO: Implement PostgreSQL specific logic to query the 'ingredients' table
-	// For now, returning empty to avoid build errors.
-	log.Println("[GetIngredientsAutocomplete] BadgerDB logic removed. Needs PostgreSQL implementation.")
-	var err error // Keep err declared for the check below
-	// err = fmt.Errorf("PostgreSQL implementation pending") // Example of setting an error
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSuggestionLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
 
+	suggestions, err := h.Ingredients.SearchIngredients(c.Request.Context(), query, limit)
 	if err != nil {
 		log.Printf("Error searching ingredients: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search ingredients"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to search ingredients"})
 		return
 	}
-
-	if matchingIngredients == nil {
-		matchingIngredients = []string{}
-	}
-	c.JSON(http.StatusOK, matchingIngredients)
+	c.JSON(http.StatusOK, suggestions)
 }
 
 // ExportData handles exporting all recipe and related data.
 // POST /api/v1/admin/export
-func ExportData(c *gin.Context) {
+func (h *AdminHandler) ExportData(c *gin.Context) {
 	var exportedData models.ExportedData
 	var err error
 
-	exportedData.Recipes, err = database.GetAllRecipesForExport()
+	exportedData.Recipes, err = h.Recipes.GetAllRecipesForExport(c.Request.Context())
 	if err != nil {
 		log.Printf("Error fetching recipes for export: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes for export"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipes for export"})
 		return
 	}
 
-	exportedData.Ingredients, err = database.GetAllIngredients()
+	exportedData.Ingredients, err = h.Ingredients.GetAllIngredients(c.Request.Context())
 	if err != nil {
 		log.Printf("Error fetching ingredients for export: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ingredients for export"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch ingredients for export"})
+		return
+	}
+
+	exportedData.IngredientAliases, err = h.Ingredients.GetAllIngredientAliases(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching ingredient aliases for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch ingredient aliases for export"})
 		return
 	}
 
-	exportedData.RecipeIngredients, err = database.GetAllRecipeIngredients()
+	exportedData.IngredientFoods, err = h.Nutrition.GetAllIngredientFoods(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching ingredient foods for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch ingredient foods for export"})
+		return
+	}
+
+	exportedData.IngredientDietaryFlags, err = h.Dietary.GetAllIngredientDietaryFlags(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching ingredient dietary flags for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch ingredient dietary flags for export"})
+		return
+	}
+
+	exportedData.RecipeIngredients, err = h.Ingredients.GetAllRecipeIngredients(c.Request.Context())
 	if err != nil {
 		log.Printf("Error fetching recipe ingredients for export: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipe ingredients for export"})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipe ingredients for export"})
+		return
+	}
+
+	exportedData.Tags, err = h.Recipes.GetAllTags(c.Request.Context(), "")
+	if err != nil {
+		log.Printf("Error fetching tags for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch tags for export"})
+		return
+	}
+
+	exportedData.RecipeTags, err = h.Recipes.GetAllRecipeTags(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching recipe tags for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipe tags for export"})
+		return
+	}
+
+	exportedData.RecipePhotos, err = h.Recipes.GetAllRecipePhotos(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching recipe photos for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipe photos for export"})
+		return
+	}
+
+	exportedData.RecipeRevisions, err = h.Recipes.GetAllRecipeRevisions(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching recipe revisions for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipe revisions for export"})
+		return
+	}
+
+	exportedData.RecipeDietaryOverrides, err = h.Dietary.GetAllRecipeDietaryOverrides(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching recipe dietary overrides for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch recipe dietary overrides for export"})
+		return
+	}
+
+	exportedData.Substitutions, err = h.Substitutions.GetAllSubstitutions(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching substitutions for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch substitutions for export"})
+		return
+	}
+
+	exportedData.PantryItems, err = h.Pantry.GetPantryItems(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching pantry items for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch pantry items for export"})
+		return
+	}
+
+	exportedData.PantryStaples, err = h.Pantry.GetPantryStaples(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching pantry staples for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch pantry staples for export"})
+		return
+	}
+
+	exportedData.IngredientAisles, err = h.ShoppingLists.GetAllIngredientAisles(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching ingredient aisles for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch ingredient aisles for export"})
+		return
+	}
+
+	exportedData.ShoppingLists, err = h.ShoppingLists.GetAllShoppingLists(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching shopping lists for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch shopping lists for export"})
+		return
+	}
+
+	exportedData.Comments, err = h.Comments.GetAllComments(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching comments for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch comments for export"})
+		return
+	}
+
+	exportedData.MealPlanEntries, err = h.MealPlans.GetAllMealPlanEntries(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching meal plan entries for export: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to fetch meal plan entries for export"})
 		return
 	}
 
-	log.Printf("Successfully fetched data for export. Recipes: %d, Ingredients: %d, RecipeIngredients: %d",
-		len(exportedData.Recipes), len(exportedData.Ingredients), len(exportedData.RecipeIngredients))
+	log.Printf("Successfully fetched data for export. Recipes: %d, Ingredients: %d, RecipeIngredients: %d, Tags: %d, RecipeTags: %d, RecipePhotos: %d, RecipeRevisions: %d, Comments: %d, MealPlanEntries: %d",
+		len(exportedData.Recipes), len(exportedData.Ingredients), len(exportedData.RecipeIngredients),
+		len(exportedData.Tags), len(exportedData.RecipeTags), len(exportedData.RecipePhotos),
+		len(exportedData.RecipeRevisions), len(exportedData.Comments), len(exportedData.MealPlanEntries))
 
 	c.Header("Content-Disposition", "attachment; filename=gorecipes_export.json")
 	c.Header("Content-Type", "application/json")
@@ -839,7 +974,7 @@ func ExportData(c *gin.Context) {
 
 // ImportData handles importing data from a JSON file.
 // POST /api/v1/admin/import
-func ImportData(c *gin.Context) {
+func (h *AdminHandler) ImportData(c *gin.Context) {
 	file, err := c.FormFile("importFile")
 	if err != nil {
 		log.Printf("Error getting import file: %v", err)
@@ -869,13 +1004,15 @@ func ImportData(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Successfully parsed import file. Recipes: %d, Ingredients: %d, RecipeIngredients: %d",
-		len(dataToImport.Recipes), len(dataToImport.Ingredients), len(dataToImport.RecipeIngredients))
+	log.Printf("Successfully parsed import file. Recipes: %d, Ingredients: %d, RecipeIngredients: %d, Tags: %d, RecipeTags: %d, RecipePhotos: %d, RecipeRevisions: %d, Comments: %d, MealPlanEntries: %d",
+		len(dataToImport.Recipes), len(dataToImport.Ingredients), len(dataToImport.RecipeIngredients),
+		len(dataToImport.Tags), len(dataToImport.RecipeTags), len(dataToImport.RecipePhotos),
+		len(dataToImport.RecipeRevisions), len(dataToImport.Comments), len(dataToImport.MealPlanEntries))
 
-	importedRecipes, importedIngredients, importedLinks, err := database.ImportRecipeDataBundle(dataToImport)
+	importedRecipes, importedIngredients, importedLinks, err := h.Recipes.ImportRecipeDataBundle(c.Request.Context(), dataToImport)
 	if err != nil {
 		log.Printf("Error importing data to database: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to import data: %v", err)})
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": fmt.Sprintf("Failed to import data: %v", err)})
 		return
 	}
 
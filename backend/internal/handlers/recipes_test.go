@@ -0,0 +1,251 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"gorecipes/backend/internal/database/memory"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/router"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Unsetenv("PEXELS_API_KEY") // New recipes get the placeholder photo instead of a download
+	os.Exit(m.Run())
+}
+
+// newTestServer returns a router backed by empty in-memory repositories.
+func newTestServer() *gin.Engine {
+	return router.SetupRouter(memory.NewRepositories())
+}
+
+// doJSON sends a request with body encoded as JSON, unless it is nil, and decodes the
+// response into out, unless it is nil. It returns the response status.
+func doJSON(t *testing.T, server http.Handler, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serve(t, server, req, out)
+}
+
+// doForm sends fields as a multipart form, as the frontend does, and decodes the response into out.
+func doForm(t *testing.T, server http.Handler, method, path string, fields map[string]string, out interface{}) int {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("writing form field %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("closing form: %v", err)
+	}
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return serve(t, server, req, out)
+}
+
+func serve(t *testing.T, server http.Handler, req *http.Request, out interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decoding response %q: %v", req.Method, req.URL, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// createTestRecipe creates a recipe with every optional field set and returns it.
+func createTestRecipe(t *testing.T, server http.Handler) models.Recipe {
+	t.Helper()
+	var created models.Recipe
+	status := doJSON(t, server, http.MethodPost, "/api/v1/recipes", map[string]interface{}{
+		"name":              "Pancakes",
+		"method":            "Mix, rest, then fry.",
+		"ingredients":       []string{"200 g flour", "2 eggs", "300 ml milk", "1 tbsp butter, for frying"},
+		"servings":          4,
+		"yield":             "12 pancakes",
+		"prep_time_minutes": 10,
+		"cook_time_minutes": 20,
+		"rest_time_minutes": 30,
+		"tags":              []string{"breakfast"},
+	}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("creating recipe: status %d, want %d", status, http.StatusCreated)
+	}
+	return created
+}
+
+func TestRecipeCRUD(t *testing.T) {
+	server := newTestServer()
+
+	created := createTestRecipe(t, server)
+	if created.ID == "" || created.Name != "Pancakes" || created.TotalTimeMinutes != 60 {
+		t.Errorf("created recipe = %+v, want an ID, the name and a total time of 60", created)
+	}
+
+	var got models.Recipe
+	if status := doJSON(t, server, http.MethodGet, "/api/v1/recipes/"+created.ID, nil, &got); status != http.StatusOK {
+		t.Fatalf("GET: status %d, want %d", status, http.StatusOK)
+	}
+	wantIngredients := []string{"200 g flour", "2 eggs", "300 ml milk", "1 tbsp butter, for frying"}
+	if !reflect.DeepEqual(got.Ingredients, wantIngredients) {
+		t.Errorf("GET ingredients = %q, want %q", got.Ingredients, wantIngredients)
+	}
+	if got.Servings != 4 || got.Yield != "12 pancakes" || !reflect.DeepEqual(got.Tags, []string{"breakfast"}) {
+		t.Errorf("GET recipe = %+v, want 4 servings, a yield and the breakfast tag", got)
+	}
+
+	var updated models.Recipe
+	status := doJSON(t, server, http.MethodPut, "/api/v1/recipes/"+created.ID, map[string]interface{}{
+		"name":              "Fluffy pancakes",
+		"method":            "Mix, rest, then fry slowly.",
+		"ingredients":       []string{"250 g flour", "2 eggs"},
+		"servings":          6,
+		"cook_time_minutes": 25,
+	}, &updated)
+	if status != http.StatusOK {
+		t.Fatalf("PUT: status %d, want %d", status, http.StatusOK)
+	}
+	if updated.Name != "Fluffy pancakes" || updated.Servings != 6 || updated.CookTimeMinutes != 25 ||
+		!reflect.DeepEqual(updated.Ingredients, []string{"250 g flour", "2 eggs"}) {
+		t.Errorf("PUT recipe = %+v, want the new name, servings, cook time and ingredients", updated)
+	}
+
+	if status := doJSON(t, server, http.MethodDelete, "/api/v1/recipes/"+created.ID, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d, want %d", status, http.StatusNoContent)
+	}
+	if status := doJSON(t, server, http.MethodGet, "/api/v1/recipes/"+created.ID, nil, nil); status != http.StatusNotFound {
+		t.Errorf("GET after DELETE: status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestRecipeNotFound(t *testing.T) {
+	server := newTestServer()
+	missing := "/api/v1/recipes/" + uuid.NewString()
+
+	if status := doJSON(t, server, http.MethodGet, missing, nil, nil); status != http.StatusNotFound {
+		t.Errorf("GET: status %d, want %d", status, http.StatusNotFound)
+	}
+	update := map[string]interface{}{"name": "Soup", "method": "Simmer."}
+	if status := doJSON(t, server, http.MethodPut, missing, update, nil); status != http.StatusNotFound {
+		t.Errorf("PUT: status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestRecipeCreateValidation(t *testing.T) {
+	server := newTestServer()
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing name", map[string]interface{}{"method": "Simmer."}},
+		{"missing method", map[string]interface{}{"name": "Soup"}},
+		{"negative servings", map[string]interface{}{"name": "Soup", "method": "Simmer.", "servings": -2}},
+		{"negative time", map[string]interface{}{"name": "Soup", "method": "Simmer.", "rest_time_minutes": -5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, server, http.MethodPost, "/api/v1/recipes", tt.body, nil); status != http.StatusBadRequest {
+				t.Errorf("status %d, want %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestRecipeUpdateKeepsFieldsNotSent(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(t *testing.T, server http.Handler, path string, out *models.Recipe) int
+	}{
+		{"edit form", func(t *testing.T, server http.Handler, path string, out *models.Recipe) int {
+			// The frontend edit page sends only these fields
+			return doForm(t, server, http.MethodPut, path, map[string]string{
+				"name":        "Fluffy pancakes",
+				"method":      "Mix, rest, then fry slowly.",
+				"ingredients": "250 g flour\n2 eggs",
+			}, out)
+		}},
+		{"JSON", func(t *testing.T, server http.Handler, path string, out *models.Recipe) int {
+			return doJSON(t, server, http.MethodPut, path, map[string]interface{}{
+				"name":        "Fluffy pancakes",
+				"method":      "Mix, rest, then fry slowly.",
+				"ingredients": []string{"250 g flour", "2 eggs"},
+			}, out)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer()
+			created := createTestRecipe(t, server)
+
+			var updated models.Recipe
+			if status := tt.update(t, server, "/api/v1/recipes/"+created.ID, &updated); status != http.StatusOK {
+				t.Fatalf("PUT: status %d, want %d", status, http.StatusOK)
+			}
+			var got models.Recipe
+			if status := doJSON(t, server, http.MethodGet, "/api/v1/recipes/"+created.ID, nil, &got); status != http.StatusOK {
+				t.Fatalf("GET: status %d, want %d", status, http.StatusOK)
+			}
+			for _, recipe := range []models.Recipe{updated, got} {
+				if recipe.Name != "Fluffy pancakes" || !reflect.DeepEqual(recipe.Ingredients, []string{"250 g flour", "2 eggs"}) {
+					t.Errorf("recipe = %+v, want the new name and ingredients", recipe)
+				}
+				if recipe.Servings != 4 || recipe.Yield != "12 pancakes" || recipe.PrepTimeMinutes != 10 ||
+					recipe.CookTimeMinutes != 20 || recipe.RestTimeMinutes != 30 || recipe.TotalTimeMinutes != 60 {
+					t.Errorf("recipe = %+v, want servings, yield and times kept", recipe)
+				}
+				if !reflect.DeepEqual(recipe.Tags, []string{"breakfast"}) {
+					t.Errorf("tags = %q, want [breakfast] kept", recipe.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestRecipeUpdateClearsFieldsSentEmpty(t *testing.T) {
+	server := newTestServer()
+	created := createTestRecipe(t, server)
+
+	var updated models.Recipe
+	status := doForm(t, server, http.MethodPut, "/api/v1/recipes/"+created.ID, map[string]string{
+		"name":              "Pancakes",
+		"method":            "Mix, rest, then fry.",
+		"servings":          "",
+		"yield":             "",
+		"rest_time_minutes": "",
+	}, &updated)
+	if status != http.StatusOK {
+		t.Fatalf("PUT: status %d, want %d", status, http.StatusOK)
+	}
+	if updated.Servings != 0 || updated.Yield != "" || updated.RestTimeMinutes != 0 || updated.TotalTimeMinutes != 30 {
+		t.Errorf("recipe = %+v, want servings, yield and rest time cleared", updated)
+	}
+	if len(updated.Ingredients) != 4 {
+		t.Errorf("ingredients = %q, want the 4 existing lines kept", updated.Ingredients)
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorecipes/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRevisionNumber parses a revision number from a path or query value.
+func parseRevisionNumber(value string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// respondRevisionError writes the response for an error returned by database.GetRecipeRevision.
+func respondRevisionError(c *gin.Context, recipeID string, err error) {
+	if strings.Contains(strings.ToLower(err.Error()), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	log.Printf("Error retrieving revision of recipe %s: %v", recipeID, err)
+	c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve revision"})
+}
+
+// @Summary List recipe revisions
+// @Description Get every saved revision of a recipe, newest first. Revision 1 is the recipe as created.
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 200 {array} models.RecipeRevision "Successfully retrieved revisions"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/revisions [get]
+func (h *RecipeHandler) ListRecipeRevisionsHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	revisions, err := h.Recipes.GetRecipeRevisions(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving revisions for recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve revisions"})
+		return
+	}
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// @Summary Get a recipe revision
+// @Description Get a single revision of a recipe by its number.
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.RecipeRevision "Successfully retrieved revision"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/revisions/{rev} [get]
+func (h *RecipeHandler) GetRecipeRevisionHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	revNumber, ok := parseRevisionNumber(c.Param("rev"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revision must be a positive whole number"})
+		return
+	}
+
+	revision, err := h.Recipes.GetRecipeRevision(c.Request.Context(), recipeID, revNumber)
+	if err != nil {
+		respondRevisionError(c, recipeID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// @Summary Compare two recipe revisions
+// @Description Get a field-level diff between two revisions of a recipe. Ingredient and tag changes list the added and removed lines.
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param from query int true "Older revision number"
+// @Param to query int false "Newer revision number (defaults to the latest revision)"
+// @Success 200 {object} models.RevisionDiff "Differences between the two revisions"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/revisions/diff [get]
+func (h *RecipeHandler) DiffRecipeRevisionsHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	fromNumber, ok := parseRevisionNumber(c.Query("from"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a positive revision number"})
+		return
+	}
+	toNumber := 0 // Latest
+	if toStr := c.Query("to"); toStr != "" {
+		if toNumber, ok = parseRevisionNumber(toStr); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a positive revision number"})
+			return
+		}
+	}
+
+	fromRevision, err := h.Recipes.GetRecipeRevision(c.Request.Context(), recipeID, fromNumber)
+	if err != nil {
+		respondRevisionError(c, recipeID, err)
+		return
+	}
+	toRevision, err := h.Recipes.GetRecipeRevision(c.Request.Context(), recipeID, toNumber)
+	if err != nil {
+		respondRevisionError(c, recipeID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DiffRevisions(*fromRevision, *toRevision))
+}
+
+// @Summary Restore a recipe revision
+// @Description Make an earlier revision the current version of the recipe. The restore is itself saved as a new revision, so it can be undone. If the revision's photo no longer exists, the current photo is kept.
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param rev path int true "Revision number to restore"
+// @Param body body object{editor=string} false "Who is restoring the revision"
+// @Success 200 {object} models.Recipe "Recipe restored successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/revisions/{rev}/restore [post]
+func (h *RecipeHandler) RestoreRecipeRevisionHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	revNumber, ok := parseRevisionNumber(c.Param("rev"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revision must be a positive whole number"})
+		return
+	}
+
+	var reqBody struct {
+		Editor string `json:"editor"`
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqBody); err != nil && err != io.EOF {
+		log.Printf("Error decoding request body for RestoreRecipeRevision: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	existingRecipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving recipe %s for restore: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe"})
+		return
+	}
+	if existingRecipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	revision, err := h.Recipes.GetRecipeRevision(c.Request.Context(), recipeID, revNumber)
+	if err != nil {
+		respondRevisionError(c, recipeID, err)
+		return
+	}
+
+	recipeToRestore := *existingRecipe
+	revision.ApplyTo(&recipeToRestore)
+
+	// Photos from revisions saved before photos were kept, or deleted from the gallery since,
+	// may be gone; keep the current one then.
+	if recipeToRestore.PhotoFilename == "" {
+		recipeToRestore.PhotoFilename = placeholderImage
+	} else if recipeToRestore.PhotoFilename != placeholderImage {
+		if exists, errExists := PhotoStore.Exists(c.Request.Context(), recipeToRestore.PhotoFilename); errExists != nil || !exists {
+			log.Printf("Photo %s of revision %d of recipe %s is missing; keeping current photo", recipeToRestore.PhotoFilename, revNumber, recipeID)
+			recipeToRestore.PhotoFilename = existingRecipe.PhotoFilename
+		}
+	}
+
+	restoredRecipe, err := h.Recipes.UpdateRecipe(c.Request.Context(), &recipeToRestore, strings.TrimSpace(reqBody.Editor))
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		} else {
+			log.Printf("Error restoring revision %d of recipe %s: %v", revNumber, recipeID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to restore revision"})
+		}
+		return
+	}
+
+	log.Printf("Recipe %s restored to revision %d", recipeID, revNumber)
+	c.JSON(http.StatusOK, withRecipePhotoURLs(restoredRecipe))
+}
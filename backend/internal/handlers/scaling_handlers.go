@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/units"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxScaleFactor guards against accidental requests like servings=40000.
+const maxScaleFactor = 100
+
+// ScaledRecipeResponse is a recipe whose ingredient quantities have been multiplied out.
+type ScaledRecipeResponse struct {
+	models.Recipe
+	ScaleFactor      float64 `json:"scale_factor"`
+	OriginalServings int     `json:"original_servings,omitempty"`
+}
+
+// @Summary Get a scaled recipe
+// @Description Get a recipe with every ingredient quantity multiplied for a target number of servings or by a factor. Amounts are re-expressed in sensible units (48 tsp becomes 1 cup, 1500 g becomes 1.5 kg).
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param servings query int false "Target number of servings (requires the recipe to have servings set)"
+// @Param factor query number false "Multiplier to apply, e.g. 1.5"
+// @Success 200 {object} ScaledRecipeResponse "Scaled recipe"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/scaled [get]
+func (h *RecipeHandler) GetScaledRecipe(c *gin.Context) {
+	recipeID := c.Param("id")
+	if recipeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe ID cannot be empty"})
+		return
+	}
+
+	servingsStr := strings.TrimSpace(c.Query("servings"))
+	factorStr := strings.TrimSpace(c.Query("factor"))
+	if (servingsStr == "") == (factorStr == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide exactly one of the servings or factor query parameters"})
+		return
+	}
+
+	recipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
+	if err != nil {
+		log.Printf("Error retrieving recipe %s for scaling: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe"})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	var factor float64
+	if servingsStr != "" {
+		servings, err := strconv.Atoi(servingsStr)
+		if err != nil || servings < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servings must be a positive whole number"})
+			return
+		}
+		if recipe.Servings == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe has no servings set; scale it with the factor parameter instead"})
+			return
+		}
+		factor = float64(servings) / float64(recipe.Servings)
+	} else {
+		factor, err = strconv.ParseFloat(factorStr, 64)
+		if err != nil || math.IsNaN(factor) || math.IsInf(factor, 0) || factor <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "factor must be a positive number"})
+			return
+		}
+	}
+	if factor > maxScaleFactor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scale factor is too large"})
+		return
+	}
+
+	response := ScaledRecipeResponse{
+		Recipe:           scaleRecipe(*withRecipePhotoURLs(recipe), factor),
+		ScaleFactor:      factor,
+		OriginalServings: recipe.Servings,
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// scaleRecipe returns a copy of recipe with all parsed quantities multiplied by factor.
+// Ingredient lines without a parsed quantity ("salt, to taste") are left unchanged.
+func scaleRecipe(recipe models.Recipe, factor float64) models.Recipe {
+	scaled := recipe
+	if recipe.Servings > 0 {
+		scaled.Servings = int(float64(recipe.Servings)*factor + 0.5)
+	}
+	scaled.StructuredIngredients = make([]models.StructuredIngredient, len(recipe.StructuredIngredients))
+	scaled.Ingredients = make([]string, len(recipe.StructuredIngredients))
+	for i, si := range recipe.StructuredIngredients {
+		scaled.StructuredIngredients[i] = scaleIngredient(si, factor)
+		scaled.Ingredients[i] = scaled.StructuredIngredients[i].Original
+	}
+	return scaled
+}
+
+// scaleIngredient multiplies a single ingredient's quantity (and range upper bound)
+// and rewrites its quantity text and display line accordingly.
+func scaleIngredient(si models.StructuredIngredient, factor float64) models.StructuredIngredient {
+	if si.Quantity == nil {
+		return si
+	}
+
+	amount, unit := units.Scale(*si.Quantity, si.Unit, factor)
+	si.Quantity = &amount
+	si.QuantityText = units.FormatAmount(amount, unit)
+	if si.QuantityMax != nil {
+		// Express the upper bound in the same unit as the lower bound.
+		maxAmount := *si.QuantityMax * factor
+		if from, ok := units.ByName(si.Unit); ok {
+			if to, ok := units.ByName(unit); ok {
+				if converted, err := units.Convert(maxAmount, from, to); err == nil {
+					maxAmount = converted
+				}
+			}
+		}
+		si.QuantityMax = &maxAmount
+		si.QuantityText += "-" + units.FormatAmount(maxAmount, unit)
+	}
+	if unit != "" {
+		displayAmount := amount
+		if si.QuantityMax != nil {
+			displayAmount = *si.QuantityMax
+		}
+		si.QuantityText += " " + units.Plural(unit, displayAmount)
+	}
+	si.Unit = unit
+
+	line := si.QuantityText + " " + si.Name
+	if si.Preparation != "" {
+		line += ", " + si.Preparation
+	}
+	si.Original = line
+	return si
+}
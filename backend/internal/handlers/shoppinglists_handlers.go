@@ -0,0 +1,903 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/units"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// unassignedAisle groups the items of ingredients without an aisle, after every other aisle.
+const unassignedAisle = "Other"
+
+// maxAisleLength is the longest aisle name ingredient_aisles accepts.
+const maxAisleLength = 100
+
+// shoppingListConflictMessage answers a change made against an outdated version of a list or item.
+const shoppingListConflictMessage = "The shopping list was changed by someone else; reload it and try again"
+
+// shoppingLine is an ingredient line of a planned recipe.
+type shoppingLine struct {
+	Recipe string // Name of the recipe the line belongs to
+	models.StructuredIngredient
+}
+
+// ingredientTotal accumulates what the planned recipes need of one ingredient: an amount per
+// kind of unit, and the recipes calling for it without an amount.
+type ingredientTotal struct {
+	id      string
+	name    string
+	amounts map[string]*shoppingAmount // By amountKey
+	recipes []string
+}
+
+// shoppingAmount is a sum of amounts expressed in unit, and the recipes it is for.
+type shoppingAmount struct {
+	amount  float64
+	unit    string
+	recipes []string
+}
+
+// amountKey tells which amounts of an ingredient can be added up: volumes with volumes,
+// masses with masses, and anything else only with the same unit.
+func amountKey(unitName string) string {
+	if u, ok := units.ByName(unitName); ok && u.Dimension != units.Count {
+		return string(u.Dimension)
+	}
+	return "unit:" + unitName
+}
+
+// appendOnce appends name to names unless it is already there.
+func appendOnce(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+// aggregateShoppingItems adds up the amounts of each ingredient across recipe lines. Amounts
+// that convert into one another are merged in the unit of the first and humanized, so 200 g
+// and 0.5 kg of butter make 700 g; ranges count for their upper bound. Lines without an amount
+// only add their recipe to the ingredient's items.
+func aggregateShoppingItems(lines []shoppingLine) []models.ShoppingListItem {
+	totals := make(map[string]*ingredientTotal)
+	for _, line := range lines {
+		key := line.IngredientID
+		if key == "" {
+			key = "name:" + line.Name
+		}
+		total, ok := totals[key]
+		if !ok {
+			total = &ingredientTotal{id: line.IngredientID, name: line.Name, amounts: make(map[string]*shoppingAmount)}
+			totals[key] = total
+		}
+		if line.Quantity == nil {
+			total.recipes = appendOnce(total.recipes, line.Recipe)
+			continue
+		}
+
+		amount := *line.Quantity
+		if line.QuantityMax != nil {
+			amount = *line.QuantityMax
+		}
+		sum, ok := total.amounts[amountKey(line.Unit)]
+		if !ok {
+			sum = &shoppingAmount{unit: line.Unit}
+			total.amounts[amountKey(line.Unit)] = sum
+		} else if line.Unit != sum.unit {
+			from, _ := units.ByName(line.Unit)
+			to, _ := units.ByName(sum.unit)
+			converted, err := units.Convert(amount, from, to)
+			if err != nil {
+				continue // Not reached: amounts sharing a key convert
+			}
+			amount = converted
+		}
+		sum.amount += amount
+		sum.recipes = appendOnce(sum.recipes, line.Recipe)
+	}
+
+	items := []models.ShoppingListItem{}
+	for _, total := range totals {
+		if len(total.amounts) == 0 {
+			items = append(items, models.ShoppingListItem{IngredientID: total.id, Name: total.name, Recipes: total.recipes})
+			continue
+		}
+		for _, sum := range total.amounts {
+			amount, unitName := sum.amount, sum.unit
+			if u, ok := units.ByName(unitName); ok {
+				amount, u = units.Humanize(amount, u)
+				unitName = u.Name
+			}
+			amount = math.Round(amount*1000) / 1000
+			recipes := sum.recipes
+			for _, recipe := range total.recipes {
+				recipes = appendOnce(recipes, recipe)
+			}
+			items = append(items, models.ShoppingListItem{
+				IngredientID: total.id,
+				Name:         total.name,
+				Quantity:     &amount,
+				Unit:         unitName,
+				Recipes:      recipes,
+			})
+		}
+	}
+	return items
+}
+
+// sortByAisle puts shopping list items in the aisles of their ingredients, or of the nearest
+// ingredients above them in the taxonomy, marks those the pantry covers and sorts them for
+// shopping: by aisle with unassignedAisle last, then by name.
+func sortByAisle(items []models.ShoppingListItem, ingredients []models.Ingredient, aisles []models.IngredientAisle, ownedIDs []string) []models.ShoppingListItem {
+	byID := make(map[string]models.Ingredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		byID[ingredient.ID] = ingredient
+	}
+	aisleOf := make(map[string]string, len(aisles))
+	for _, aisle := range aisles {
+		aisleOf[aisle.IngredientID] = aisle.Aisle
+	}
+	owned := make(map[string]bool, len(ownedIDs))
+	for _, id := range ownedIDs {
+		owned[id] = true
+	}
+
+	for i := range items {
+		item := &items[i]
+		item.Aisle = unassignedAisle
+		visited := make(map[string]bool)
+		for id := item.IngredientID; id != "" && !visited[id]; id = byID[id].ParentID {
+			visited[id] = true
+			if name, ok := aisleOf[id]; ok {
+				item.Aisle = name
+				break
+			}
+		}
+		if ingredient, ok := byID[item.IngredientID]; ok {
+			item.Name = ingredient.Name
+		}
+		item.InPantry = owned[item.IngredientID]
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if (items[i].Aisle == unassignedAisle) != (items[j].Aisle == unassignedAisle) {
+			return items[j].Aisle == unassignedAisle
+		}
+		if items[i].Aisle != items[j].Aisle {
+			return items[i].Aisle < items[j].Aisle
+		}
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].Unit < items[j].Unit
+	})
+	return items
+}
+
+// buildShoppingList collects the recipes planned between start and end, inclusive, and lists
+// what they need. A recipe planned twice counts twice, and one planned for more or fewer
+// servings than it makes is scaled to them; free-text entries and recipes that were deleted
+// are skipped.
+func (h *ShoppingListHandler) buildShoppingList(ctx context.Context, start time.Time, end time.Time) (*models.ShoppingList, error) {
+	entries, err := h.MealPlans.GetMealPlanEntriesByDateRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.ShoppingList{StartDate: &start, EndDate: &end, Recipes: []string{}}
+	recipes := make(map[string]*models.Recipe)
+	var lines []shoppingLine
+	for _, entry := range entries {
+		if entry.RecipeID == "" {
+			continue
+		}
+		recipe, seen := recipes[entry.RecipeID]
+		if !seen {
+			if recipe, err = h.Recipes.GetRecipeByID(ctx, entry.RecipeID); err != nil {
+				return nil, err
+			}
+			recipes[entry.RecipeID] = recipe
+			if recipe != nil {
+				list.Recipes = append(list.Recipes, recipe.Name)
+			}
+		}
+		if recipe == nil {
+			continue
+		}
+		for _, si := range recipe.StructuredIngredients {
+			if entry.Servings > 0 && recipe.Servings > 0 && entry.Servings != recipe.Servings {
+				si = scaleIngredient(si, float64(entry.Servings)/float64(recipe.Servings))
+			}
+			lines = append(lines, shoppingLine{Recipe: recipe.Name, StructuredIngredient: si})
+		}
+	}
+
+	ingredients, err := h.Ingredients.GetAllIngredients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aisles, err := h.ShoppingLists.GetAllIngredientAisles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := h.Pantry.GetOwnedIngredientIDs(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	list.Items = sortByAisle(aggregateShoppingItems(lines), ingredients, aisles, owned)
+	return list, nil
+}
+
+// itemText renders a shopping list item for display, e.g. "700 g butter".
+func itemText(item models.ShoppingListItem) string {
+	if item.Quantity == nil {
+		return item.Name
+	}
+	return units.FormatQuantity(*item.Quantity, item.Unit) + " " + item.Name
+}
+
+// withItemTexts fills in the display text of a shopping list's items before it is returned.
+func withItemTexts(list *models.ShoppingList) *models.ShoppingList {
+	for i := range list.Items {
+		list.Items[i].Text = itemText(list.Items[i])
+	}
+	return list
+}
+
+// parseShoppingListID reads the :id parameter. It writes a 404 and returns false when the ID
+// cannot name a list.
+func parseShoppingListID(c *gin.Context) (string, bool) {
+	listID := c.Param("id")
+	if _, err := uuid.Parse(listID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list not found"})
+		return "", false
+	}
+	return listID, true
+}
+
+// respondShoppingListError writes the response for an error returned by the shopping list
+// repository. A version conflict is a 409, so the client can reload the list and retry.
+func respondShoppingListError(c *gin.Context, listID string, action string, err error) {
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "version conflict"):
+		c.JSON(http.StatusConflict, gin.H{"error": shoppingListConflictMessage})
+	case strings.Contains(message, "item with id") && strings.Contains(message, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list item not found"})
+	case strings.Contains(message, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list not found"})
+	case strings.Contains(message, "exactly once"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "item_ids must list every item of the shopping list exactly once"})
+	default:
+		log.Printf("Error trying to %s for shopping list %s: %v", action, listID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to " + action})
+	}
+}
+
+// cleanAisle collapses the whitespace of an aisle name and checks its length.
+func cleanAisle(aisle string) (string, string) {
+	aisle = strings.Join(strings.Fields(aisle), " ")
+	if aisle == "" {
+		return "", "aisle must not be blank"
+	}
+	if len(aisle) > maxAisleLength {
+		return "", "aisle must be at most 100 characters"
+	}
+	return aisle, ""
+}
+
+// @Summary Create a shopping list
+// @Description Store a new shopping list. Given a date range, it lists what the recipes planned between the two dates, inclusive, need: recipes planned for a number of servings are scaled to it, and the amounts of each ingredient are added up, so 200 g and 0.5 kg of butter make 700 g, while amounts that do not convert into one another, such as cloves and grams of garlic, are listed separately. Items are ordered by the store aisle of their ingredient, with ingredients without an aisle under "Other" last, and marked when the pantry or staples already cover them. Without dates the list starts empty.
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Param body body object{name=string,start_date=string,end_date=string} false "Name, and date range (YYYY-MM-DD) to generate the list from"
+// @Success 201 {object} models.ShoppingList "Shopping list created successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists [post]
+func (h *ShoppingListHandler) CreateShoppingListHandler(c *gin.Context) {
+	var req struct {
+		Name      string `json:"name"`
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+		return
+	}
+
+	list := &models.ShoppingList{Name: name, Recipes: []string{}}
+	if req.StartDate != "" || req.EndDate != "" {
+		if req.StartDate == "" || req.EndDate == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Give both start_date and end_date, or neither for an empty list."})
+			return
+		}
+		start, err := time.Parse(dateLayout, req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Please use YYYY-MM-DD."})
+			return
+		}
+		end, err := time.Parse(dateLayout, req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Please use YYYY-MM-DD."})
+			return
+		}
+		if end.Before(start) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date cannot be before start_date."})
+			return
+		}
+		if list, err = h.buildShoppingList(c.Request.Context(), start, end); err != nil {
+			log.Printf("Error generating shopping list for %s to %s: %v", req.StartDate, req.EndDate, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to generate shopping list"})
+			return
+		}
+		list.Name = name
+		if list.Name == "" {
+			list.Name = "Shopping list " + req.StartDate + " to " + req.EndDate
+		}
+	}
+	if list.Name == "" {
+		list.Name = "Shopping list"
+	}
+
+	created, err := h.ShoppingLists.CreateShoppingList(c.Request.Context(), *list)
+	if err != nil {
+		log.Printf("Error creating shopping list %q: %v", list.Name, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to create shopping list"})
+		return
+	}
+
+	log.Printf("Shopping list created successfully: ID=%s, Items=%d", created.ID, len(created.Items))
+	c.JSON(http.StatusCreated, withItemTexts(created))
+}
+
+// @Summary List shopping lists
+// @Description List every shopping list, newest first, with how many of its items are checked off. Items are left out; get a single list for them.
+// @Tags shopping-lists
+// @Produce json
+// @Success 200 {array} models.ShoppingList "Shopping lists"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists [get]
+func (h *ShoppingListHandler) ListShoppingListsHandler(c *gin.Context) {
+	lists, err := h.ShoppingLists.GetShoppingLists(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing shopping lists: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list shopping lists"})
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+// @Summary Get a shopping list
+// @Description Get a shopping list with its items in display order. Its version must be sent back with every change to the list, and an item's version with every change to that item.
+// @Tags shopping-lists
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Success 200 {object} models.ShoppingList "Shopping list"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id} [get]
+func (h *ShoppingListHandler) GetShoppingListHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.ShoppingLists.GetShoppingList(c.Request.Context(), listID)
+	if err != nil {
+		respondShoppingListError(c, listID, "retrieve shopping list", err)
+		return
+	}
+	c.JSON(http.StatusOK, withItemTexts(list))
+}
+
+// @Summary Rename a shopping list
+// @Description Rename a shopping list. version must be the list's current version; if someone else changed the list since, nothing is changed and 409 is returned.
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Param body body object{name=string,version=int} true "New name and the version it was made against"
+// @Success 200 {object} models.ShoppingList "Shopping list renamed successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 409 {object} map[string]string "The list was changed since version"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id} [put]
+func (h *ShoppingListHandler) UpdateShoppingListHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody struct {
+		Name    string `json:"name"`
+		Version *int   `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	name := strings.TrimSpace(reqBody.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+	if len(name) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+		return
+	}
+
+	list, err := h.ShoppingLists.RenameShoppingList(c.Request.Context(), listID, *reqBody.Version, name)
+	if err != nil {
+		respondShoppingListError(c, listID, "rename shopping list", err)
+		return
+	}
+	c.JSON(http.StatusOK, withItemTexts(list))
+}
+
+// @Summary Delete a shopping list
+// @Description Delete a shopping list and its items.
+// @Tags shopping-lists
+// @Param id path string true "Shopping list ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingListHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ShoppingLists.DeleteShoppingList(c.Request.Context(), listID); err != nil {
+		respondShoppingListError(c, listID, "delete shopping list", err)
+		return
+	}
+
+	log.Printf("Shopping list deleted successfully: ID=%s", listID)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Add an item to a shopping list
+// @Description Add an item by hand, such as "kitchen roll", at the end of the list. Quantity, unit and aisle are optional; the aisle defaults to "Other". version must be the list's current version; if someone else changed the list since, nothing is changed and 409 is returned.
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Param body body object{name=string,quantity=number,unit=string,aisle=string,version=int} true "Item and the version it was made against"
+// @Success 201 {object} models.ShoppingList "Item added successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 409 {object} map[string]string "The list was changed since version"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id}/items [post]
+func (h *ShoppingListHandler) AddShoppingListItemHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody struct {
+		Name     string   `json:"name"`
+		Quantity *float64 `json:"quantity"`
+		Unit     string   `json:"unit"`
+		Aisle    string   `json:"aisle"`
+		Version  *int     `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	item := models.ShoppingListItem{Name: strings.TrimSpace(reqBody.Name), Quantity: reqBody.Quantity, Aisle: unassignedAisle, Manual: true}
+	if item.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+	if item.Quantity != nil && *item.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must not be negative"})
+		return
+	}
+	var known bool
+	if item.Unit, known = canonicalUnit(reqBody.Unit); !known {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown unit '" + reqBody.Unit + "'"})
+		return
+	}
+	if strings.TrimSpace(reqBody.Aisle) != "" {
+		var problem string
+		if item.Aisle, problem = cleanAisle(reqBody.Aisle); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+	}
+
+	list, err := h.ShoppingLists.AddShoppingListItem(c.Request.Context(), listID, *reqBody.Version, item)
+	if err != nil {
+		respondShoppingListError(c, listID, "add shopping list item", err)
+		return
+	}
+	c.JSON(http.StatusCreated, withItemTexts(list))
+}
+
+// @Summary Update a shopping list item
+// @Description Check an item off or on, note who bought it, or correct its name, amount or aisle. Fields left out are unchanged; an empty bought_by clears it, and a null quantity removes the amount. version must be the item's current version, not the list's; if someone else changed the same item since, nothing is changed and 409 is returned, so two people shopping at once never undo each other's ticks. Changes to other items, and reordering the list, do not conflict with it.
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Param item_id path string true "Item ID"
+// @Param body body object{checked=bool,bought_by=string,name=string,quantity=number,unit=string,aisle=string,version=int} true "Fields to change and the item version they were made against"
+// @Success 200 {object} models.ShoppingList "Item updated successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list or item not found"
+// @Failure 409 {object} map[string]string "The item was changed since version"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id}/items/{item_id} [patch]
+func (h *ShoppingListHandler) UpdateShoppingListItemHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+	itemID := c.Param("item_id")
+
+	var reqBody struct {
+		Version  *int            `json:"version" binding:"required"` // The item's version
+		Checked  *bool           `json:"checked"`
+		BoughtBy *string         `json:"bought_by"`
+		Name     *string         `json:"name"`
+		Quantity json.RawMessage `json:"quantity"` // null removes the amount
+		Unit     *string         `json:"unit"`
+		Aisle    *string         `json:"aisle"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	list, err := h.ShoppingLists.GetShoppingList(c.Request.Context(), listID)
+	if err != nil {
+		respondShoppingListError(c, listID, "update shopping list item", err)
+		return
+	}
+	var item *models.ShoppingListItem
+	for i := range list.Items {
+		if list.Items[i].ID == itemID {
+			item = &list.Items[i]
+		}
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shopping list item not found"})
+		return
+	}
+	if item.Version != *reqBody.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": shoppingListConflictMessage})
+		return
+	}
+
+	// The changes are applied to the item as stored; the version check makes sure it is the
+	// item the client saw, and the repository checks it again as it saves
+	if reqBody.Checked != nil {
+		item.Checked = *reqBody.Checked
+	}
+	if reqBody.BoughtBy != nil {
+		item.BoughtBy = strings.TrimSpace(*reqBody.BoughtBy)
+		if len(item.BoughtBy) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bought_by must be at most 100 characters"})
+			return
+		}
+	}
+	if reqBody.Name != nil {
+		if item.Name = strings.TrimSpace(*reqBody.Name); item.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+			return
+		}
+	}
+	if len(reqBody.Quantity) > 0 {
+		var quantity *float64
+		if err := json.Unmarshal(reqBody.Quantity, &quantity); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be a number or null"})
+			return
+		}
+		if quantity != nil && *quantity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must not be negative"})
+			return
+		}
+		item.Quantity = quantity
+	}
+	if reqBody.Unit != nil {
+		var known bool
+		if item.Unit, known = canonicalUnit(*reqBody.Unit); !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown unit '" + *reqBody.Unit + "'"})
+			return
+		}
+	}
+	if reqBody.Aisle != nil {
+		var problem string
+		if item.Aisle, problem = cleanAisle(*reqBody.Aisle); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+	}
+
+	list, err = h.ShoppingLists.UpdateShoppingListItem(c.Request.Context(), listID, *item)
+	if err != nil {
+		respondShoppingListError(c, listID, "update shopping list item", err)
+		return
+	}
+	c.JSON(http.StatusOK, withItemTexts(list))
+}
+
+// @Summary Delete a shopping list item
+// @Description Remove an item from a shopping list. version, the list's current version, is given as a query parameter; if someone else changed the list since, nothing is changed and 409 is returned.
+// @Tags shopping-lists
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Param item_id path string true "Item ID"
+// @Param version query int true "Version of the list the removal was made against"
+// @Success 200 {object} models.ShoppingList "Item removed successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list or item not found"
+// @Failure 409 {object} map[string]string "The list was changed since version"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id}/items/{item_id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingListItemHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+	itemID := c.Param("item_id")
+
+	version, err := strconv.Atoi(c.Query("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be the list's current version"})
+		return
+	}
+
+	list, err := h.ShoppingLists.DeleteShoppingListItem(c.Request.Context(), listID, version, itemID)
+	if err != nil {
+		respondShoppingListError(c, listID, "delete shopping list item", err)
+		return
+	}
+	c.JSON(http.StatusOK, withItemTexts(list))
+}
+
+// @Summary Reorder shopping list items
+// @Description Set the display order of a shopping list's items, e.g. to follow the route through the store. item_ids must list every item of the list exactly once. version must be the list's current version; if someone else changed the list since, nothing is changed and 409 is returned.
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Shopping list ID"
+// @Param body body object{item_ids=[]string,version=int} true "Item IDs, first to last, and the version they were ordered against"
+// @Success 200 {object} models.ShoppingList "Items in their new order"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 409 {object} map[string]string "The list was changed since version"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id}/items/order [put]
+func (h *ShoppingListHandler) ReorderShoppingListItemsHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody struct {
+		ItemIDs []string `json:"item_ids" binding:"required"`
+		Version *int     `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	list, err := h.ShoppingLists.ReorderShoppingListItems(c.Request.Context(), listID, *reqBody.Version, reqBody.ItemIDs)
+	if err != nil {
+		respondShoppingListError(c, listID, "reorder shopping list items", err)
+		return
+	}
+	c.JSON(http.StatusOK, withItemTexts(list))
+}
+
+// formatShoppingList renders a shopping list as Markdown, with a task list per aisle, or as
+// plain text with the same layout. Aisles appear in the order of their first item.
+func formatShoppingList(list *models.ShoppingList, markdown bool) string {
+	var b strings.Builder
+	if markdown {
+		b.WriteString("# " + list.Name + "\n")
+	} else {
+		b.WriteString(list.Name + "\n")
+	}
+	if list.StartDate != nil && list.EndDate != nil {
+		b.WriteString("\n" + list.StartDate.Format(dateLayout) + " to " + list.EndDate.Format(dateLayout))
+		if len(list.Recipes) > 0 {
+			b.WriteString(": " + strings.Join(list.Recipes, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	var aisles []string
+	byAisle := make(map[string][]models.ShoppingListItem)
+	for _, item := range list.Items {
+		if _, ok := byAisle[item.Aisle]; !ok {
+			aisles = append(aisles, item.Aisle)
+		}
+		byAisle[item.Aisle] = append(byAisle[item.Aisle], item)
+	}
+	for _, aisle := range aisles {
+		if markdown {
+			b.WriteString("\n## " + aisle + "\n\n")
+		} else {
+			b.WriteString("\n" + aisle + "\n")
+		}
+		for _, item := range byAisle[aisle] {
+			box := "[ ] "
+			if item.Checked {
+				box = "[x] "
+			}
+			if markdown {
+				b.WriteString("- ")
+			}
+			b.WriteString(box + itemText(item))
+			var notes []string
+			if item.InPantry {
+				notes = append(notes, "in pantry")
+			}
+			if item.BoughtBy != "" {
+				notes = append(notes, "bought by "+item.BoughtBy)
+			}
+			if len(notes) > 0 {
+				b.WriteString(" (" + strings.Join(notes, "; ") + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// @Summary Export a shopping list
+// @Description Download a shopping list as Markdown, with a task list per aisle, or as plain text. Checked items are ticked, and items the pantry covers or someone bought are noted.
+// @Tags shopping-lists
+// @Produce plain
+// @Param id path string true "Shopping list ID"
+// @Param format query string false "markdown (default) or text"
+// @Success 200 {string} string "The shopping list"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Shopping list not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /shopping-lists/{id}/export [get]
+func (h *ShoppingListHandler) ExportShoppingListHandler(c *gin.Context) {
+	listID, ok := parseShoppingListID(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "markdown")
+	if format != "markdown" && format != "text" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be markdown or text"})
+		return
+	}
+
+	list, err := h.ShoppingLists.GetShoppingList(c.Request.Context(), listID)
+	if err != nil {
+		respondShoppingListError(c, listID, "export shopping list", err)
+		return
+	}
+
+	contentType, extension := "text/markdown; charset=utf-8", "md"
+	if format == "text" {
+		contentType, extension = "text/plain; charset=utf-8", "txt"
+	}
+	c.Header("Content-Disposition", "attachment; filename=shopping_list."+extension)
+	c.Data(http.StatusOK, contentType, []byte(formatShoppingList(list, format == "markdown")))
+}
+
+// @Summary List ingredient aisles
+// @Description List every ingredient given a store aisle, by aisle. Ingredients below these in the taxonomy are shopped in the same aisle without being listed.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.IngredientAisle "Ingredients with their aisle"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/aisles [get]
+func (h *ShoppingListHandler) ListIngredientAislesHandler(c *gin.Context) {
+	aisles, err := h.ShoppingLists.GetAllIngredientAisles(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing ingredient aisles: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list ingredient aisles"})
+		return
+	}
+	c.JSON(http.StatusOK, aisles)
+}
+
+// @Summary Set an ingredient's aisle
+// @Description Put an ingredient in a store aisle, such as "Dairy" or "Produce", by which shopping lists are grouped. Setting the aisle of a broad ingredient such as "cheese" covers the ingredients below it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Ingredient ID"
+// @Param body body object{aisle=string} true "Aisle name"
+// @Success 200 {object} models.IngredientAisle "Aisle set successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Ingredient not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/aisle [put]
+func (h *ShoppingListHandler) SetIngredientAisleHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+
+	var reqBody struct {
+		Aisle string `json:"aisle"`
+	}
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	aisle, problem := cleanAisle(reqBody.Aisle)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	saved, err := h.ShoppingLists.SetIngredientAisle(c.Request.Context(), ingredientID, aisle)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+			return
+		}
+		log.Printf("Error setting aisle of ingredient %s: %v", ingredientID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to set ingredient aisle"})
+		return
+	}
+
+	log.Printf("Ingredient %s put in aisle %q", ingredientID, saved.Aisle)
+	c.JSON(http.StatusOK, saved)
+}
+
+// @Summary Remove an ingredient's aisle
+// @Description Remove the aisle an ingredient was put in. It is then shopped in the aisle of the nearest ingredient above it in the taxonomy, or under "Other".
+// @Tags admin
+// @Param id path string true "Ingredient ID"
+// @Success 204 "Aisle removed"
+// @Failure 404 {object} map[string]string "Ingredient has no aisle"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/ingredients/{id}/aisle [delete]
+func (h *ShoppingListHandler) DeleteIngredientAisleHandler(c *gin.Context) {
+	ingredientID := c.Param("id")
+	if _, err := uuid.Parse(ingredientID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient has no aisle"})
+		return
+	}
+
+	if err := h.ShoppingLists.DeleteIngredientAisle(c.Request.Context(), ingredientID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient has no aisle"})
+			return
+		}
+		log.Printf("Error removing aisle of ingredient %s: %v", ingredientID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to remove ingredient aisle"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
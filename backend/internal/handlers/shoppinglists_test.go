@@ -0,0 +1,176 @@
+package handlers_test
+
+import (
+	"gorecipes/backend/internal/handlers"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// line parses an ingredient line of the named recipe.
+func line(recipe string, text string) handlers.ShoppingLine {
+	return handlers.ShoppingLine{Recipe: recipe, StructuredIngredient: parser.ParseIngredient(text)}
+}
+
+func TestAggregateShoppingItems(t *testing.T) {
+	smidgen := func(recipe string, amount float64) handlers.ShoppingLine {
+		return handlers.ShoppingLine{Recipe: recipe, StructuredIngredient: models.StructuredIngredient{Quantity: &amount, Unit: "smidgen", Name: "saffron"}}
+	}
+
+	tests := []struct {
+		name  string
+		lines []handlers.ShoppingLine
+		want  []string // Each item as displayed, sorted
+	}{
+		{"mass units add up", []handlers.ShoppingLine{line("Pancakes", "200 g butter"), line("Cake", "0.5 kg butter")}, []string{"700 g butter"}},
+		{"ranges count at their upper bound", []handlers.ShoppingLine{line("Pesto", "2-3 cloves garlic"), line("Soup", "1 clove garlic")}, []string{"4 cloves garlic"}},
+		{"totals are humanized", []handlers.ShoppingLine{line("Tea", "3 tsp sugar"), line("Cake", "3 tsp sugar")}, []string{"2 tbsp sugar"}},
+		{"mass and volume stay apart", []handlers.ShoppingLine{line("Bread", "200 g flour"), line("Pancakes", "1 cup flour")}, []string{"1 cup flour", "200 g flour"}},
+		{"lines without an amount", []handlers.ShoppingLine{line("Soup", "salt, to taste"), line("Bread", "salt")}, []string{"salt"}},
+		{"unknown units add up", []handlers.ShoppingLine{smidgen("Paella", 2), smidgen("Risotto", 1), line("Tea", "1 pinch saffron")}, []string{"1 pinch saffron", "3 smidgen saffron"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range handlers.AggregateShoppingItems(tt.lines) {
+				got = append(got, handlers.ItemText(item))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateShoppingItemsRecipes(t *testing.T) {
+	items := handlers.AggregateShoppingItems([]handlers.ShoppingLine{
+		line("Pancakes", "200 g butter"),
+		line("Pancakes", "1 tbsp butter, for frying"),
+		line("Cake", "0.5 kg butter"),
+		line("Toast", "butter, to taste"),
+		line("Soup", "salt, to taste"),
+		line("Bread", "salt"),
+	})
+	for i := range items {
+		items[i].Text = handlers.ItemText(items[i])
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Text < items[j].Text })
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3: %+v", len(items), items)
+	}
+
+	// Recipes without an amount are listed with every amount of the ingredient
+	if items[0].Text != "1 tbsp butter" || !reflect.DeepEqual(items[0].Recipes, []string{"Pancakes", "Toast"}) {
+		t.Errorf("first item = %q for %q, want 1 tbsp butter for Pancakes and Toast", items[0].Text, items[0].Recipes)
+	}
+	if q := items[1].Quantity; items[1].Text != "700 g butter" || q == nil || *q != 700 || items[1].Unit != "g" ||
+		!reflect.DeepEqual(items[1].Recipes, []string{"Pancakes", "Cake", "Toast"}) {
+		t.Errorf("second item = %+v, want 700 g butter for Pancakes, Cake and Toast", items[1])
+	}
+	if items[2].Quantity != nil || !reflect.DeepEqual(items[2].Recipes, []string{"Soup", "Bread"}) {
+		t.Errorf("salt = %+v, want no amount, for Soup and Bread", items[2])
+	}
+}
+
+func TestSortByAisle(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{ID: "dairy", Name: "dairy"},
+		{ID: "cheese", Name: "cheese", ParentID: "dairy"},
+		{ID: "cheddar", Name: "cheddar", ParentID: "cheese"},
+		{ID: "butter", Name: "butter", ParentID: "dairy"},
+		{ID: "yeast", Name: "yeast"},
+		{ID: "a", Name: "a", ParentID: "b"}, // A cycle must not hang the walk up the taxonomy
+		{ID: "b", Name: "b", ParentID: "a"},
+	}
+	aisles := []models.IngredientAisle{
+		{IngredientID: "dairy", Aisle: "Dairy"},
+		{IngredientID: "butter", Aisle: "Baking"},
+	}
+	items := []models.ShoppingListItem{
+		{IngredientID: "a", Name: "a"},
+		{IngredientID: "cheddar", Name: "Cheddar", Unit: "g"},
+		{IngredientID: "butter", Name: "butter", Unit: "tbsp"},
+		{IngredientID: "yeast", Name: "yeast"},
+		{IngredientID: "cheddar", Name: "Cheddar", Unit: "cup"},
+		{Name: "saffron"},
+	}
+
+	type entry struct {
+		aisle, name, unit string
+		inPantry          bool
+	}
+	var got []entry
+	for _, item := range handlers.SortByAisle(items, ingredients, aisles, []string{"yeast"}) {
+		got = append(got, entry{item.Aisle, item.Name, item.Unit, item.InPantry})
+	}
+	// Aisles are ordered by name, with ingredients that have none last
+	want := []entry{
+		{"Baking", "butter", "tbsp", false},
+		{"Dairy", "cheddar", "cup", false},
+		{"Dairy", "cheddar", "g", false},
+		{"Other", "a", "", false},
+		{"Other", "saffron", "", false},
+		{"Other", "yeast", "", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+}
+
+func TestShoppingListCheckOffIsVersionedPerItem(t *testing.T) {
+	server := newTestServer()
+
+	var list models.ShoppingList
+	if status := doJSON(t, server, http.MethodPost, "/api/v1/shopping-lists", map[string]interface{}{"name": "Saturday"}, &list); status != http.StatusCreated {
+		t.Fatalf("creating list: status %d, want %d", status, http.StatusCreated)
+	}
+	for _, name := range []string{"milk", "bread"} {
+		status := doJSON(t, server, http.MethodPost, "/api/v1/shopping-lists/"+list.ID+"/items",
+			map[string]interface{}{"name": name, "version": list.Version}, &list)
+		if status != http.StatusCreated {
+			t.Fatalf("adding %s: status %d, want %d", name, status, http.StatusCreated)
+		}
+	}
+	// Both shoppers load the list as it is now
+	seen := list
+	milk, bread := seen.Items[0], seen.Items[1]
+	itemPath := func(item models.ShoppingListItem) string {
+		return "/api/v1/shopping-lists/" + list.ID + "/items/" + item.ID
+	}
+
+	// Ticks on different items made against the same view both land
+	if status := doJSON(t, server, http.MethodPatch, itemPath(milk), map[string]interface{}{"checked": true, "version": milk.Version}, &list); status != http.StatusOK {
+		t.Fatalf("checking off milk: status %d, want %d", status, http.StatusOK)
+	}
+	if status := doJSON(t, server, http.MethodPatch, itemPath(bread), map[string]interface{}{"checked": true, "bought_by": "Sam", "version": bread.Version}, &list); status != http.StatusOK {
+		t.Fatalf("checking off bread: status %d, want %d", status, http.StatusOK)
+	}
+	if list.CheckedCount != 2 || list.Version != seen.Version {
+		t.Errorf("list = %+v, want both items checked and the list version %d kept", list, seen.Version)
+	}
+	if got := list.Items[0].Version; got != milk.Version+1 {
+		t.Errorf("milk version = %d, want %d", got, milk.Version+1)
+	}
+
+	// Unticking the same item from the outdated view is refused
+	if status := doJSON(t, server, http.MethodPatch, itemPath(milk), map[string]interface{}{"checked": false, "version": milk.Version}, nil); status != http.StatusConflict {
+		t.Errorf("unchecking milk from an outdated view: status %d, want %d", status, http.StatusConflict)
+	}
+
+	// Reordering against the list version seen before the ticks still works
+	order := map[string]interface{}{"item_ids": []string{bread.ID, milk.ID}, "version": seen.Version}
+	if status := doJSON(t, server, http.MethodPut, "/api/v1/shopping-lists/"+list.ID+"/items/order", order, &list); status != http.StatusOK {
+		t.Fatalf("reordering: status %d, want %d", status, http.StatusOK)
+	}
+	if list.Items[0].ID != bread.ID || !list.Items[0].Checked || !list.Items[1].Checked {
+		t.Errorf("items = %+v, want bread first and both still checked", list.Items)
+	}
+	// and moves the list on, so a second reorder from the same view conflicts
+	if status := doJSON(t, server, http.MethodPut, "/api/v1/shopping-lists/"+list.ID+"/items/order", order, nil); status != http.StatusConflict {
+		t.Errorf("reordering from an outdated view: status %d, want %d", status, http.StatusConflict)
+	}
+}
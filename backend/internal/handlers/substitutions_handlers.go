@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"gorecipes/backend/internal/dietary"
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/parser"
+	"gorecipes/backend/internal/units"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RecipeSubstitutionsResponse lists the substitutions available for a recipe's ingredients.
+type RecipeSubstitutionsResponse struct {
+	RecipeID string                    `json:"recipe_id"`
+	Lines    []IngredientSubstitutions `json:"lines"`
+}
+
+// IngredientSubstitutions is an ingredient line of a recipe with the ways to replace it.
+type IngredientSubstitutions struct {
+	Original        string                  `json:"original"` // The line as written in the recipe
+	IngredientID    string                  `json:"ingredient_id"`
+	Name            string                  `json:"name"`
+	ExcludedBecause string                  `json:"excluded_because,omitempty"` // The excluded ingredient or dietary flag the line matches
+	Substitutions   []SuggestedSubstitution `json:"substitutions"`
+}
+
+// SuggestedSubstitution is a substitution with its replacements in the amounts the recipe
+// line calls for. Scaled is false when the line's amount could not be converted to the
+// substitution's unit; the replacements then keep the knowledge base's amounts.
+type SuggestedSubstitution struct {
+	SubstitutionID string                        `json:"substitution_id"`
+	Replaces       string                        `json:"replaces"` // The ingredient the substitution is for; an ancestor of the line's in the taxonomy, or the same
+	Notes          string                        `json:"notes,omitempty"`
+	Scaled         bool                          `json:"scaled"`
+	Replacements   []models.StructuredIngredient `json:"replacements"`
+}
+
+// substitutionRequest is the body of the admin routes creating and updating substitutions.
+// Ingredients are given by canonical name, and created when missing.
+type substitutionRequest struct {
+	Ingredient   string  `json:"ingredient"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	Notes        string  `json:"notes"`
+	Replacements []struct {
+		Ingredient string  `json:"ingredient"`
+		Quantity   float64 `json:"quantity"`
+		Unit       string  `json:"unit"`
+	} `json:"replacements"`
+}
+
+// canonicalUnit resolves a unit as written ("Tablespoons", "tbsp.") to its canonical name.
+// An empty unit stands for counted items.
+func canonicalUnit(unit string) (string, bool) {
+	if strings.TrimSpace(unit) == "" {
+		return "", true
+	}
+	u, ok := units.Lookup(unit)
+	return u.Name, ok
+}
+
+// toSubstitution validates a request body and turns it into a substitution.
+func (req substitutionRequest) toSubstitution() (models.Substitution, string) {
+	sub := models.Substitution{
+		IngredientName: parser.CanonicalName(req.Ingredient),
+		Quantity:       req.Quantity,
+		Notes:          strings.TrimSpace(req.Notes),
+	}
+	if sub.IngredientName == "" {
+		return sub, "ingredient must not be blank"
+	}
+	if sub.Quantity <= 0 {
+		return sub, "quantity must be positive"
+	}
+	var ok bool
+	if sub.Unit, ok = canonicalUnit(req.Unit); !ok {
+		return sub, "unknown unit '" + req.Unit + "'"
+	}
+	if len(req.Replacements) == 0 {
+		return sub, "at least one replacement is required"
+	}
+	for _, r := range req.Replacements {
+		replacement := models.SubstitutionReplacement{IngredientName: parser.CanonicalName(r.Ingredient), Quantity: r.Quantity}
+		if replacement.IngredientName == "" {
+			return sub, "replacement ingredient must not be blank"
+		}
+		if replacement.IngredientName == sub.IngredientName {
+			return sub, "an ingredient cannot replace itself"
+		}
+		if replacement.Quantity <= 0 {
+			return sub, "replacement quantity must be positive"
+		}
+		if replacement.Unit, ok = canonicalUnit(r.Unit); !ok {
+			return sub, "unknown unit '" + r.Unit + "'"
+		}
+		sub.Replacements = append(sub.Replacements, replacement)
+	}
+	return sub, ""
+}
+
+// substitutionFactor returns what to multiply a substitution's replacement amounts by to
+// replace a recipe line, and for ranges the factor for the upper bound. It reports false
+// when the line has no amount or its unit cannot be converted to the substitution's.
+func substitutionFactor(line models.StructuredIngredient, sub models.Substitution) (factor float64, maxFactor float64, ok bool) {
+	if line.Quantity == nil {
+		return 0, 0, false
+	}
+	convert := func(amount float64) (float64, bool) {
+		if line.Unit == sub.Unit {
+			return amount, true
+		}
+		from, okFrom := units.ByName(line.Unit)
+		to, okTo := units.ByName(sub.Unit)
+		if !okFrom || !okTo {
+			return 0, false
+		}
+		converted, err := units.Convert(amount, from, to)
+		return converted, err == nil
+	}
+	amount, ok := convert(*line.Quantity)
+	if !ok {
+		return 0, 0, false
+	}
+	factor = amount / sub.Quantity
+	maxFactor = factor
+	if line.QuantityMax != nil {
+		if maxAmount, ok := convert(*line.QuantityMax); ok {
+			maxFactor = maxAmount / sub.Quantity
+		}
+	}
+	return factor, maxFactor, true
+}
+
+// suggestSubstitution scales a substitution's replacements to a recipe line.
+func suggestSubstitution(line models.StructuredIngredient, sub models.Substitution) SuggestedSubstitution {
+	factor, maxFactor, scaled := substitutionFactor(line, sub)
+	suggestion := SuggestedSubstitution{
+		SubstitutionID: sub.ID,
+		Replaces:       sub.IngredientName,
+		Notes:          sub.Notes,
+		Scaled:         scaled,
+		Replacements:   []models.StructuredIngredient{},
+	}
+	for _, r := range sub.Replacements {
+		quantity := r.Quantity
+		si := models.StructuredIngredient{Quantity: &quantity, Unit: r.Unit, Name: r.IngredientName, IngredientID: r.IngredientID}
+		if !scaled {
+			factor = 1
+		} else if maxFactor != factor {
+			maxQuantity := r.Quantity * maxFactor / factor
+			si.QuantityMax = &maxQuantity
+		}
+		suggestion.Replacements = append(suggestion.Replacements, scaleIngredient(si, factor))
+	}
+	return suggestion
+}
+
+// exclusions decides which ingredients a cook wants to avoid: those named, those below a
+// named ingredient in the taxonomy, and those carrying an excluded dietary flag themselves
+// or through an ancestor.
+type exclusions struct {
+	names       map[string]bool
+	flags       map[string]bool
+	ingredients map[string]models.Ingredient
+	flagged     map[string][]string // Ingredient ID to the flags it carries itself
+}
+
+// ancestry returns an ingredient and the ingredients above it in the taxonomy, nearest first.
+func (e exclusions) ancestry(ingredientID string) []models.Ingredient {
+	var chain []models.Ingredient
+	visited := make(map[string]bool)
+	for id := ingredientID; id != "" && !visited[id]; {
+		visited[id] = true
+		ingredient, ok := e.ingredients[id]
+		if !ok {
+			break
+		}
+		chain = append(chain, ingredient)
+		id = ingredient.ParentID
+	}
+	return chain
+}
+
+// reason returns the excluded name or flag an ingredient matches, or "" if it is not excluded.
+func (e exclusions) reason(ingredientID string) string {
+	chain := e.ancestry(ingredientID)
+	for _, ingredient := range chain {
+		if e.names[ingredient.Name] {
+			return ingredient.Name
+		}
+	}
+	for _, ingredient := range chain {
+		for _, flag := range e.flagged[ingredient.ID] {
+			if e.flags[flag] {
+				return flag
+			}
+		}
+	}
+	return ""
+}
+
+// @Summary List substitutions for a recipe
+// @Description List the substitutions available for a recipe's ingredients, with the replacement amounts scaled to what each line calls for, e.g. "2 cups buttermilk" becomes "2 cups milk" and "2 tbsp lemon juice". Substitutions defined for a broader ingredient in the taxonomy apply to the ingredients below it. Given ingredients, allergens or diets to avoid, only the lines that conflict with them are listed, and substitutions whose replacements conflict too are left out. Without any, every line that has substitutions is listed.
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param exclude query string false "Comma-separated ingredients to avoid, e.g. butter,milk; ingredients below them in the taxonomy are avoided too"
+// @Param exclude_allergens query string false "Comma-separated allergens to avoid: gluten, dairy, egg, nuts, peanuts, soy, shellfish, sesame (or meat, fish)"
+// @Param diet query string false "Comma-separated diets to fit: vegan, vegetarian, pescatarian, gluten-free"
+// @Success 200 {object} RecipeSubstitutionsResponse "Ingredient lines with their substitutions"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Recipe not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /recipes/{id}/substitutions [get]
+func (h *SubstitutionHandler) GetRecipeSubstitutionsHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+	if _, err := uuid.Parse(recipeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	excludeFlags, err := dietary.NormalizeFlags(splitCommaList(c.Query("exclude_allergens")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exclude_allergens: " + err.Error()})
+		return
+	}
+	dietFlags, err := dietary.ExcludedFlags(splitCommaList(c.Query("diet")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "diet: " + err.Error()})
+		return
+	}
+	excluded := exclusions{names: make(map[string]bool), flags: make(map[string]bool)}
+	for _, name := range splitCommaList(c.Query("exclude")) {
+		if name = parser.CanonicalName(name); name != "" {
+			excluded.names[name] = true
+		}
+	}
+	for _, flag := range append(excludeFlags, dietFlags...) {
+		excluded.flags[flag] = true
+	}
+	filtering := len(excluded.names) > 0 || len(excluded.flags) > 0
+
+	ctx := c.Request.Context()
+	recipe, err := h.Recipes.GetRecipeByID(ctx, recipeID)
+	if err != nil {
+		log.Printf("Error retrieving recipe %s for substitutions: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve recipe"})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	ingredients, err := h.Ingredients.GetAllIngredients(ctx)
+	if err != nil {
+		log.Printf("Error retrieving ingredients for substitutions of recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve ingredients"})
+		return
+	}
+	excluded.ingredients = make(map[string]models.Ingredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		excluded.ingredients[ingredient.ID] = ingredient
+	}
+	flagged, err := h.Dietary.GetAllIngredientDietaryFlags(ctx)
+	if err != nil {
+		log.Printf("Error retrieving dietary flags for substitutions of recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve ingredient dietary flags"})
+		return
+	}
+	excluded.flagged = make(map[string][]string, len(flagged))
+	for _, f := range flagged {
+		excluded.flagged[f.IngredientID] = f.Flags
+	}
+
+	// Lines to list, and the ingredients whose substitutions apply to them: their own and
+	// their ancestors'
+	var lines []models.StructuredIngredient
+	var reasons []string
+	var lookup []string
+	for _, line := range recipe.StructuredIngredients {
+		if line.IngredientID == "" {
+			continue
+		}
+		reason := excluded.reason(line.IngredientID)
+		if filtering && reason == "" {
+			continue
+		}
+		lines = append(lines, line)
+		reasons = append(reasons, reason)
+		for _, ingredient := range excluded.ancestry(line.IngredientID) {
+			lookup = append(lookup, ingredient.ID)
+		}
+	}
+	substitutions, err := h.Substitutions.GetSubstitutionsForIngredients(ctx, lookup)
+	if err != nil {
+		log.Printf("Error retrieving substitutions for recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve substitutions"})
+		return
+	}
+	byIngredient := make(map[string][]models.Substitution)
+	for _, sub := range substitutions {
+		byIngredient[sub.IngredientID] = append(byIngredient[sub.IngredientID], sub)
+	}
+
+	result := RecipeSubstitutionsResponse{RecipeID: recipeID, Lines: []IngredientSubstitutions{}}
+	for i, line := range lines {
+		listed := IngredientSubstitutions{
+			Original:        line.Original,
+			IngredientID:    line.IngredientID,
+			Name:            line.Name,
+			ExcludedBecause: reasons[i],
+			Substitutions:   []SuggestedSubstitution{},
+		}
+		for _, ingredient := range excluded.ancestry(line.IngredientID) {
+		candidates:
+			for _, sub := range byIngredient[ingredient.ID] {
+				// A replacement that is to be avoided as well is no use
+				for _, r := range sub.Replacements {
+					if excluded.reason(r.IngredientID) != "" {
+						continue candidates
+					}
+				}
+				listed.Substitutions = append(listed.Substitutions, suggestSubstitution(line, sub))
+			}
+		}
+		if filtering || len(listed.Substitutions) > 0 {
+			result.Lines = append(result.Lines, listed)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary List substitutions
+// @Description List the substitutions knowledge base: for an amount of an ingredient, the ingredients that can replace it and their amounts.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Substitution "Substitutions"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/substitutions [get]
+func (h *SubstitutionHandler) ListSubstitutionsHandler(c *gin.Context) {
+	substitutions, err := h.Substitutions.GetAllSubstitutions(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing substitutions: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to list substitutions"})
+		return
+	}
+	c.JSON(http.StatusOK, substitutions)
+}
+
+// @Summary Create a substitution
+// @Description Add a substitution to the knowledge base, e.g. 1 cup buttermilk replaced with 1 cup milk and 1 tbsp lemon juice, or 1 cup butter with 3/4 cup oil. Ingredients are given by name and created when missing; units by any name the ingredient parser knows, or none for counted items.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body object{ingredient=string,quantity=number,unit=string,notes=string,replacements=[]object{ingredient=string,quantity=number,unit=string}} true "Ingredient, amount and replacements"
+// @Success 201 {object} models.Substitution "Substitution created successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/substitutions [post]
+func (h *SubstitutionHandler) CreateSubstitutionHandler(c *gin.Context) {
+	var reqBody substitutionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	sub, problem := reqBody.toSubstitution()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	created, err := h.Substitutions.CreateSubstitution(c.Request.Context(), sub)
+	if err != nil {
+		log.Printf("Error creating substitution for %s: %v", sub.IngredientName, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to create substitution"})
+		return
+	}
+
+	log.Printf("Substitution %s created for %s", created.ID, created.IngredientName)
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Update a substitution
+// @Description Replace a substitution of the knowledge base, replacements included.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Substitution ID"
+// @Param body body object{ingredient=string,quantity=number,unit=string,notes=string,replacements=[]object{ingredient=string,quantity=number,unit=string}} true "Ingredient, amount and replacements"
+// @Success 200 {object} models.Substitution "Substitution updated successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Substitution not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/substitutions/{id} [put]
+func (h *SubstitutionHandler) UpdateSubstitutionHandler(c *gin.Context) {
+	substitutionID := c.Param("id")
+	if _, err := uuid.Parse(substitutionID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Substitution not found"})
+		return
+	}
+
+	var reqBody substitutionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	sub, problem := reqBody.toSubstitution()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	sub.ID = substitutionID
+
+	updated, err := h.Substitutions.UpdateSubstitution(c.Request.Context(), sub)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Substitution not found"})
+			return
+		}
+		log.Printf("Error updating substitution %s: %v", substitutionID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to update substitution"})
+		return
+	}
+
+	log.Printf("Substitution %s updated", substitutionID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Delete a substitution
+// @Description Remove a substitution from the knowledge base.
+// @Tags admin
+// @Param id path string true "Substitution ID"
+// @Success 204 "Substitution deleted"
+// @Failure 404 {object} map[string]string "Substitution not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/substitutions/{id} [delete]
+func (h *SubstitutionHandler) DeleteSubstitutionHandler(c *gin.Context) {
+	substitutionID := c.Param("id")
+	if _, err := uuid.Parse(substitutionID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Substitution not found"})
+		return
+	}
+
+	if err := h.Substitutions.DeleteSubstitution(c.Request.Context(), substitutionID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Substitution not found"})
+			return
+		}
+		log.Printf("Error deleting substitution %s: %v", substitutionID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete substitution"})
+		return
+	}
+
+	log.Printf("Substitution %s deleted", substitutionID)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"gorecipes/backend/internal/database"
+	"gorecipes/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxTagNameLength matches the tags.name column size.
+const maxTagNameLength = 100
+
+// tagRequest is the request body accepted by CreateTagHandler and UpdateTagHandler.
+type tagRequest struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// validate normalizes the tag name and category and checks they fit the schema.
+func (req *tagRequest) validate() string {
+	req.Name = database.NormalizeTagName(req.Name)
+	req.Category = strings.TrimSpace(req.Category)
+	if req.Name == "" {
+		return "Tag name cannot be empty"
+	}
+	if len(req.Name) > maxTagNameLength {
+		return "Tag name is too long"
+	}
+	if strings.Contains(req.Name, ",") {
+		return "Tag name cannot contain commas"
+	}
+	if len(req.Category) > 50 {
+		return "Tag category is too long"
+	}
+	return ""
+}
+
+// @Summary List tags
+// @Description Get all recipe tags with the number of recipes using each one, optionally limited to one category.
+// @Tags tags
+// @Produce json
+// @Param category query string false "Only tags in this category, e.g. \"cuisine\""
+// @Success 200 {array} models.Tag "Successfully retrieved tags"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags [get]
+func (h *RecipeHandler) ListTagsHandler(c *gin.Context) {
+	tags, err := h.Recipes.GetAllTags(c.Request.Context(), strings.TrimSpace(c.Query("category")))
+	if err != nil {
+		log.Printf("Error retrieving tags from database: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve tags"})
+		return
+	}
+
+	if tags == nil {
+		tags = []models.Tag{} // Ensure we return an empty array, not null
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Get a tag
+// @Description Get a single tag by its ID.
+// @Tags tags
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 200 {object} models.Tag "Successfully retrieved tag"
+// @Failure 404 {object} map[string]string "Tag not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags/{id} [get]
+func (h *RecipeHandler) GetTagHandler(c *gin.Context) {
+	tagID := c.Param("id")
+
+	tag, err := h.Recipes.GetTagByID(c.Request.Context(), tagID)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		} else {
+			log.Printf("Error retrieving tag %s: %v", tagID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve tag"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// @Summary Create a tag
+// @Description Create a new recipe tag. Names are unique regardless of case.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body object{name=string,category=string} true "Tag to create"
+// @Success 201 {object} models.Tag "Tag created successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "A tag with this name already exists"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags [post]
+func (h *RecipeHandler) CreateTagHandler(c *gin.Context) {
+	var req tagRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body for CreateTag: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	tag := models.Tag{
+		ID:       uuid.New().String(),
+		Name:     req.Name,
+		Category: req.Category,
+	}
+
+	createdTag, err := h.Recipes.CreateTag(c.Request.Context(), tag)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error creating tag in database: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdTag)
+}
+
+// @Summary Update a tag
+// @Description Rename or recategorize a tag. Recipes using the tag keep it.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param tag body object{name=string,category=string} true "New tag name and category"
+// @Success 200 {object} models.Tag "Tag updated successfully"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Tag not found"
+// @Failure 409 {object} map[string]string "A tag with this name already exists"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags/{id} [put]
+func (h *RecipeHandler) UpdateTagHandler(c *gin.Context) {
+	tagID := c.Param("id")
+
+	var req tagRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body for UpdateTag: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	updatedTag, err := h.Recipes.UpdateTag(c.Request.Context(), models.Tag{ID: tagID, Name: req.Name, Category: req.Category})
+	if err != nil {
+		switch {
+		case strings.Contains(strings.ToLower(err.Error()), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		case strings.Contains(err.Error(), "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Error updating tag %s in database: %v", tagID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to update tag"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTag)
+}
+
+// @Summary Delete a tag
+// @Description Delete a tag and remove it from every recipe.
+// @Tags tags
+// @Param id path string true "Tag ID"
+// @Success 204 "No Content"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags/{id} [delete]
+func (h *RecipeHandler) DeleteTagHandler(c *gin.Context) {
+	tagID := c.Param("id")
+
+	if err := h.Recipes.DeleteTag(c.Request.Context(), tagID); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			log.Printf("Tag with ID %s not found (already deleted or never existed): %v", tagID, err)
+			c.Status(http.StatusNoContent) // Tag is gone, so operation is effectively successful.
+		} else {
+			log.Printf("Error deleting tag %s from database: %v", tagID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to delete tag"})
+		}
+		return
+	}
+
+	log.Printf("Tag deleted successfully: %s", tagID)
+	c.Status(http.StatusNoContent)
+}
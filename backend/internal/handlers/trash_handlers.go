@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorecipes/backend/internal/images"
+	"gorecipes/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashRetention is how long a deleted recipe stays in the trash before it is purged.
+// It is set from TRASH_RETENTION_DAYS at startup.
+var TrashRetention = 30 * 24 * time.Hour
+
+// TrashedRecipe is a recipe in the trash, with the time it becomes eligible for purging.
+type TrashedRecipe struct {
+	models.Recipe
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// removeRecipePhotos deletes every size variant of photos of a recipe that are no longer
+// referenced, skipping the shared placeholder. Failures are logged, since the rows are already gone.
+func removeRecipePhotos(ctx context.Context, recipeID string, photoKeys []string) {
+	for _, photoKey := range photoKeys {
+		if photoKey == placeholderImage {
+			continue
+		}
+		for _, variantKey := range images.VariantKeys(photoKey) {
+			if errDelete := PhotoStore.Delete(ctx, variantKey); errDelete != nil {
+				log.Printf("Error deleting photo %s of recipe %s: %v", variantKey, recipeID, errDelete)
+				continue
+			}
+			log.Printf("Photo deleted for recipe %s: %s", recipeID, variantKey)
+		}
+	}
+}
+
+// purgeRecipe permanently deletes a recipe from the trash along with its photos.
+func (h *RecipeHandler) purgeRecipe(ctx context.Context, recipeID string) error {
+	photoKeys, err := h.Recipes.PurgeRecipe(ctx, recipeID)
+	if err != nil {
+		return err
+	}
+	removeRecipePhotos(ctx, recipeID, photoKeys)
+	return nil
+}
+
+// PurgeExpiredTrash permanently deletes every recipe that has been in the trash
+// longer than TrashRetention. It returns the number of recipes purged.
+func (h *RecipeHandler) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-TrashRetention)
+	recipeIDs, err := h.Recipes.GetDeletedRecipeIDsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, recipeID := range recipeIDs {
+		if err := h.purgeRecipe(ctx, recipeID); err != nil {
+			// Restored in the meantime, or a transient error; it will be retried on the next run.
+			log.Printf("[Trash] Could not purge recipe %s: %v", recipeID, err)
+			continue
+		}
+		purged++
+	}
+	if purged > 0 {
+		log.Printf("[Trash] Purged %d recipe(s) deleted before %s", purged, cutoff.Format(time.RFC3339))
+	}
+	return purged, nil
+}
+
+// @Summary List deleted recipes
+// @Description Get the recipes in the trash, most recently deleted first, with the time each one will be purged.
+// @Tags trash
+// @Produce json
+// @Success 200 {array} TrashedRecipe "Recipes in the trash"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /trash [get]
+func (h *RecipeHandler) ListTrashHandler(c *gin.Context) {
+	recipes, err := h.Recipes.GetDeletedRecipes(c.Request.Context())
+	if err != nil {
+		log.Printf("Error retrieving deleted recipes: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to retrieve trash"})
+		return
+	}
+
+	trashed := make([]TrashedRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		item := TrashedRecipe{Recipe: *withRecipePhotoURLs(&recipe)}
+		if recipe.DeletedAt != nil {
+			item.PurgeAt = recipe.DeletedAt.Add(TrashRetention)
+		}
+		trashed = append(trashed, item)
+	}
+
+	c.JSON(http.StatusOK, trashed)
+}
+
+// @Summary Restore a deleted recipe
+// @Description Take a recipe out of the trash, with its ingredients, tags, comments and meal plan entries.
+// @Tags trash
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 200 {object} models.Recipe "Recipe restored successfully"
+// @Failure 404 {object} map[string]string "Recipe not found in trash"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /trash/{id}/restore [post]
+func (h *RecipeHandler) RestoreTrashedRecipeHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	if err := h.Recipes.RestoreDeletedRecipe(c.Request.Context(), recipeID); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found in trash"})
+		} else {
+			log.Printf("Error restoring recipe %s from trash: %v", recipeID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to restore recipe"})
+		}
+		return
+	}
+
+	recipe, err := h.Recipes.GetRecipeByID(c.Request.Context(), recipeID)
+	if err != nil || recipe == nil {
+		log.Printf("Error retrieving restored recipe %s: %v", recipeID, err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Recipe restored but could not be retrieved"})
+		return
+	}
+
+	c.JSON(http.StatusOK, withRecipePhotoURLs(recipe))
+}
+
+// @Summary Purge a deleted recipe
+// @Description Permanently delete a recipe from the trash now, including its photos, comments and meal plan entries.
+// @Tags trash
+// @Param id path string true "Recipe ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string "Recipe not found in trash"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /trash/{id} [delete]
+func (h *RecipeHandler) PurgeTrashedRecipeHandler(c *gin.Context) {
+	recipeID := c.Param("id")
+
+	if err := h.purgeRecipe(c.Request.Context(), recipeID); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found in trash"})
+		} else {
+			log.Printf("Error purging recipe %s: %v", recipeID, err)
+			c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to purge recipe"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Purge expired recipes from the trash
+// @Description Permanently delete every recipe that has been in the trash longer than the retention period. This also runs periodically in the background.
+// @Tags trash
+// @Produce json
+// @Success 200 {object} map[string]int "Number of recipes purged"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /trash/purge [post]
+func (h *RecipeHandler) PurgeExpiredTrashHandler(c *gin.Context) {
+	purged, err := h.PurgeExpiredTrash(c.Request.Context())
+	if err != nil {
+		log.Printf("Error purging expired trash: %v", err)
+		c.JSON(dbErrorStatus(c, err), gin.H{"error": "Failed to purge trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
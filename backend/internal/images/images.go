@@ -0,0 +1,208 @@
+// Package images turns uploaded recipe photos into resized, metadata-free variants.
+// Every photo is decoded, rotated upright according to its EXIF orientation and
+// re-encoded, which drops EXIF (including GPS position), XMP and ICC data.
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gorecipes/backend/internal/storage"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // Accept WebP uploads
+)
+
+// Variant is one stored size of a photo. The image is scaled down, never up,
+// to fit within MaxSize x MaxSize pixels.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// Variant names, as used in storage keys and in the photo_urls JSON object.
+const (
+	VariantThumb = "thumb"
+	VariantCard  = "card"
+	VariantFull  = "full"
+)
+
+// Variants lists every size stored for a photo, smallest first.
+var Variants = []Variant{
+	{Name: VariantThumb, MaxSize: 240},
+	{Name: VariantCard, MaxSize: 640},
+	{Name: VariantFull, MaxSize: 1600},
+}
+
+// Format is the file format variants are written in.
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatWebP Format = "webp" // Encoded with the cwebp tool, which must be on PATH
+)
+
+// Extension returns the file extension, with leading dot, for the format.
+func (f Format) Extension() string {
+	if f == FormatWebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatWebP {
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
+// ParseFormat parses a format name as used in IMAGE_FORMAT. An empty name means JPEG.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "webp":
+		return FormatWebP, nil
+	}
+	return "", fmt.Errorf("unsupported image format %q (expected jpeg or webp)", name)
+}
+
+// WebPAvailable reports whether the cwebp encoder needed for FormatWebP is installed.
+func WebPAvailable() bool {
+	_, err := exec.LookPath("cwebp")
+	return err == nil
+}
+
+// OutputFormat is the format new photos are stored in. It is set from IMAGE_FORMAT at startup.
+var OutputFormat = FormatJPEG
+
+// Quality is the lossy encoding quality (1-100) used for both JPEG and WebP.
+var Quality = 85
+
+// MaxUploadBytes caps how much of an upload is read, and MaxPixels how large a decoded image
+// may be, so a small file can't expand into gigabytes of memory.
+const (
+	MaxUploadBytes = 25 << 20
+	MaxPixels      = 50_000_000
+)
+
+// ErrInvalidImage is wrapped by Process errors caused by the upload itself rather than the server.
+var ErrInvalidImage = errors.New("invalid image")
+
+// Process decodes the image read from r and stores every variant in store, under the
+// key base + "_" + variant + extension. It returns the key of the full variant, which
+// is what recipes store; the other variants are found with VariantKey.
+func Process(ctx context.Context, r io.Reader, store storage.PhotoStore, base string) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxUploadBytes {
+		return "", fmt.Errorf("%w: file is larger than %d MB", ErrInvalidImage, MaxUploadBytes>>20)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: unsupported or corrupt file: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return "", fmt.Errorf("%w: %dx%d pixels is too large", ErrInvalidImage, cfg.Width, cfg.Height)
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return "", fmt.Errorf("%w: unsupported or corrupt file: %v", ErrInvalidImage, err)
+	}
+	if OutputFormat == FormatJPEG {
+		// JPEG has no alpha channel; put transparent images on white rather than black
+		img = imaging.Overlay(imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White), img, image.Pt(0, 0), 1)
+	}
+
+	var stored []string
+	for _, variant := range Variants {
+		key := base + "_" + variant.Name + OutputFormat.Extension()
+		resized := imaging.Fit(img, variant.MaxSize, variant.MaxSize, imaging.Lanczos)
+		encoded, err := encode(resized)
+		if err == nil {
+			err = store.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), OutputFormat.ContentType())
+		}
+		if err != nil {
+			for _, done := range stored {
+				store.Delete(ctx, done)
+			}
+			return "", fmt.Errorf("failed to store %s variant: %w", variant.Name, err)
+		}
+		stored = append(stored, key)
+	}
+	return base + "_" + VariantFull + OutputFormat.Extension(), nil
+}
+
+// encode encodes img in OutputFormat.
+func encode(img image.Image) ([]byte, error) {
+	if OutputFormat == FormatWebP {
+		return encodeWebP(img)
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(Quality)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeWebP encodes img by handing a lossless PNG to cwebp.
+func encodeWebP(img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "cwebp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "in.png")
+	dst := filepath.Join(dir, "out.webp")
+	if err := imaging.Save(img, src, imaging.PNGCompressionLevel(png.BestSpeed)); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("cwebp", "-quiet", "-metadata", "none", "-q", fmt.Sprint(Quality), src, "-o", dst)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return os.ReadFile(dst)
+}
+
+// VariantKey returns the storage key of the named variant of a stored photo.
+// Photos stored before the pipeline existed (and the placeholder) only have the
+// original, which is returned for every variant.
+func VariantKey(key string, variant string) string {
+	ext := path.Ext(key)
+	stem, ok := strings.CutSuffix(strings.TrimSuffix(key, ext), "_"+VariantFull)
+	if !ok {
+		return key
+	}
+	return stem + "_" + variant + ext
+}
+
+// VariantKeys returns the storage keys of every variant of a stored photo,
+// e.g. to delete them all.
+func VariantKeys(key string) []string {
+	if VariantKey(key, VariantThumb) == key {
+		return []string{key}
+	}
+	keys := make([]string, 0, len(Variants))
+	for _, variant := range Variants {
+		keys = append(keys, VariantKey(key, variant.Name))
+	}
+	return keys
+}
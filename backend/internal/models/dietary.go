@@ -0,0 +1,25 @@
+package models
+
+// IngredientDietaryFlags are the dietary flags an ingredient carries itself, such as "dairy"
+// or "meat"; see package dietary. Ingredients below it in the taxonomy inherit them.
+type IngredientDietaryFlags struct {
+	IngredientID string   `json:"ingredient_id"`
+	Flags        []string `json:"flags"`
+}
+
+// RecipeDietaryOverrides are the manual corrections of a recipe's dietary flags: a flag set
+// to true is present whatever the ingredients, one set to false is absent.
+type RecipeDietaryOverrides struct {
+	RecipeID  string          `json:"recipe_id"`
+	Overrides map[string]bool `json:"overrides"`
+}
+
+// RecipeDietary is what a recipe contains and which diets it fits, derived from the flags of
+// its ingredients and its overrides.
+type RecipeDietary struct {
+	RecipeID  string          `json:"recipe_id"`
+	Flags     []string        `json:"flags"`     // Every flag present, allergens as well as meat and fish
+	Allergens []string        `json:"allergens"` // The allergens among Flags
+	Diets     []string        `json:"diets"`     // e.g. "vegetarian", "gluten-free"
+	Overrides map[string]bool `json:"overrides"`
+}
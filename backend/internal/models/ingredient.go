@@ -7,16 +7,77 @@ type Ingredient struct {
 	ID             string    `json:"id"`
 	Name           string    `json:"name"`
 	NormalizedName string    `json:"normalized_name"`
+	ParentID       string    `json:"parent_id,omitempty"` // Broader ingredient this one is a kind of: cheddar -> cheese
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// IngredientAlias is another name an ingredient is found by, such as the name of an
+// ingredient merged into it. Ingredient lines naming an alias link to its ingredient.
+type IngredientAlias struct {
+	ID             string    `json:"id"`
+	IngredientID   string    `json:"ingredient_id"`
+	Name           string    `json:"name"`
+	NormalizedName string    `json:"normalized_name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// IngredientSummary is an ingredient as administered: with the number of live recipes
+// using it and the names of its aliases.
+type IngredientSummary struct {
+	Ingredient
+	UsageCount int      `json:"usage_count"`
+	Aliases    []string `json:"aliases"`
+}
+
+// IngredientMergeResult is the ingredient others were merged into, with the recipes whose
+// ingredient lines were relinked to it.
+type IngredientMergeResult struct {
+	IngredientSummary
+	AffectedRecipeIDs []string `json:"affected_recipe_ids"`
+}
+
+// IngredientTreeNode is an ingredient in the taxonomy, with the narrower ingredients below it.
+type IngredientTreeNode struct {
+	IngredientSummary
+	Children []IngredientTreeNode `json:"children"`
+}
+
+// IngredientSuggestion is an ingredient offered by autocomplete, with the number of live
+// recipes using it.
+type IngredientSuggestion struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	UsageCount   int    `json:"usage_count"`
+	MatchedAlias string `json:"matched_alias,omitempty"` // Set when the query matched an alias rather than the name
+}
+
 // RecipeIngredient represents the link between a recipe and an ingredient,
 // including quantity and order.
 type RecipeIngredient struct {
-	ID           string `json:"id"`
-	RecipeID     string `json:"recipe_id"`
-	IngredientID string `json:"ingredient_id"`
-	QuantityText string `json:"quantity_text,omitempty"`
-	SortOrder    int    `json:"sort_order"`
+	ID           string   `json:"id"`
+	RecipeID     string   `json:"recipe_id"`
+	IngredientID string   `json:"ingredient_id"`
+	OriginalText string   `json:"original_text,omitempty"`
+	QuantityText string   `json:"quantity_text,omitempty"`
+	Quantity     *float64 `json:"quantity,omitempty"`
+	QuantityMax  *float64 `json:"quantity_max,omitempty"`
+	Unit         string   `json:"unit,omitempty"`
+	Preparation  string   `json:"preparation,omitempty"`
+	SortOrder    int      `json:"sort_order"`
+}
+
+// StructuredIngredient is a single ingredient line of a recipe broken down into
+// its quantity, unit, canonical ingredient name and preparation notes.
+// For "1-2 cups finely chopped tomatoes" this is Quantity=1, QuantityMax=2,
+// Unit="cup", Name="tomato", Preparation="finely chopped".
+type StructuredIngredient struct {
+	Original     string   `json:"original"`                // The line as entered by the user
+	QuantityText string   `json:"quantity_text,omitempty"` // Quantity and unit as written, e.g. "1-2 cups"
+	Quantity     *float64 `json:"quantity,omitempty"`      // Nil when the line has no amount ("salt to taste")
+	QuantityMax  *float64 `json:"quantity_max,omitempty"`  // Upper bound for ranges like "1-2"
+	Unit         string   `json:"unit,omitempty"`          // Canonical unit name, see package units
+	Name         string   `json:"name"`                    // Canonical ingredient name
+	Preparation  string   `json:"preparation,omitempty"`   // e.g. "finely chopped", "to taste"
+	IngredientID string   `json:"ingredient_id,omitempty"` // Set when read back from the database
 }
@@ -2,13 +2,55 @@ package models
 
 import "time"
 
-// MealPlanEntry represents a single recipe planned for a specific date.
-// Each assignment of a recipe to a day is a unique entry.
+// Standard meal slots, in the order they are listed within a day. Any other
+// non-empty name, such as "brunch", is a custom slot, listed after these.
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotDinner    = "dinner"
+	MealSlotSnack     = "snack"
+)
+
+// StandardMealSlots lists the standard meal slots in order.
+var StandardMealSlots = []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner, MealSlotSnack}
+
+// MealPlanEntry represents a single recipe, or a free-text meal such as "Leftovers", planned
+// for a meal on a specific date. A recipe or title can be planned once per meal: the same soup
+// can be lunch and dinner on one day.
 type MealPlanEntry struct {
-	ID        string    `json:"id"`         // Unique ID for this meal plan entry (e.g., UUID)
-	Date      time.Time `json:"date"`       // The specific date (YYYY-MM-DD), time part normalized to UTC midnight
-	RecipeID  string    `json:"recipe_id"`  // ID of the planned recipe
-	CreatedAt time.Time `json:"created_at"` // Timestamp of when the entry was created
-	Notes     string    `json:"notes,omitempty"` // Optional notes for the entry
+	ID        string    `json:"id"`                  // Unique ID for this meal plan entry (e.g., UUID)
+	Date      time.Time `json:"date"`                // The specific date (YYYY-MM-DD), time part normalized to UTC midnight
+	Slot      string    `json:"slot"`                // Meal slot, lower case: one of StandardMealSlots or a custom name
+	RecipeID  string    `json:"recipe_id,omitempty"` // ID of the planned recipe; empty for free-text entries
+	Title     string    `json:"title"`               // The free text, or the planned recipe's name
+	Servings  int       `json:"servings,omitempty"`  // Servings the meal is planned for; 0 means the recipe's own
+	Notes     string    `json:"notes,omitempty"`     // Optional notes for the entry
+	SortOrder int       `json:"sort_order"`          // Order within its meal on the day, from 0
+	CreatedAt time.Time `json:"created_at"`          // Timestamp of when the entry was created
 	// UserID    string    `json:"user_id"`    // Future: For multi-user support
 }
+
+// Meal plan entry operations, applied together by a batch.
+const (
+	MealPlanMove = "move" // Move the entry to another date or slot
+	MealPlanCopy = "copy" // Plan a copy of the entry on another date or in another slot
+)
+
+// MealPlanEntryOperation moves or copies a meal plan entry. A zero Date or empty Slot keeps
+// the entry's own. The moved entry or the copy goes last in its meal.
+type MealPlanEntryOperation struct {
+	Action  string // MealPlanMove or MealPlanCopy
+	EntryID string
+	Date    time.Time
+	Slot    string
+}
+
+// MealSlotRank orders meal slots within a day: the standard slots in order, then custom ones.
+func MealSlotRank(slot string) int {
+	for i, standard := range StandardMealSlots {
+		if slot == standard {
+			return i
+		}
+	}
+	return len(StandardMealSlots)
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Nutrients are the nutrition facts tracked for foods and recipes.
+type Nutrients struct {
+	Calories      float64 `json:"calories"` // kcal
+	ProteinG      float64 `json:"protein_g"`
+	FatG          float64 `json:"fat_g"`
+	CarbohydrateG float64 `json:"carbohydrate_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumMg      float64 `json:"sodium_mg"`
+}
+
+// Food is an entry of a food composition dataset, such as USDA FoodData Central, with its
+// nutrients per 100 g.
+type Food struct {
+	ID          string `json:"id"` // Identifier in the dataset, e.g. the FoodData Central fdc_id
+	Description string `json:"description"`
+	Source      string `json:"source"` // Dataset the food was loaded from, e.g. "usda_fdc"
+	Nutrients
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IngredientFood maps an ingredient to the food whose composition it has, with the weights
+// needed to convert amounts that are not given by mass.
+type IngredientFood struct {
+	IngredientID  string    `json:"ingredient_id"`
+	FoodID        string    `json:"food_id"`
+	GramsPerML    *float64  `json:"grams_per_ml,omitempty"`    // Density, for amounts by volume; 1 for water
+	GramsPerPiece *float64  `json:"grams_per_piece,omitempty"` // Weight of one item or counted unit: "2 eggs", "1 clove"
+	Food          *Food     `json:"food,omitempty"`            // Set when read back with the food
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IngredientNutrition is the contribution of one ingredient line to a recipe's nutrition.
+type IngredientNutrition struct {
+	Original     string     `json:"original"`
+	IngredientID string     `json:"ingredient_id,omitempty"`
+	FoodID       string     `json:"food_id,omitempty"`
+	Grams        float64    `json:"grams,omitempty"`
+	Nutrients    *Nutrients `json:"nutrients,omitempty"`
+	Skipped      string     `json:"skipped,omitempty"` // Why the line is left out of the totals, e.g. "no quantity"
+}
+
+// RecipeNutrition is the nutrition of a recipe computed from its ingredient quantities.
+type RecipeNutrition struct {
+	RecipeID        string                `json:"recipe_id"`
+	Servings        int                   `json:"servings,omitempty"`
+	Total           Nutrients             `json:"total"`
+	PerServing      *Nutrients            `json:"per_serving,omitempty"` // Nil when the recipe's servings are unknown
+	Complete        bool                  `json:"complete"`              // Every ingredient line is counted
+	Ingredients     []IngredientNutrition `json:"ingredients"`
+	RecipeUpdatedAt time.Time             `json:"-"` // Version of the recipe the result was computed for
+	ComputedAt      time.Time             `json:"computed_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// PantryItem is an ingredient we have at home, with how much of it is left and when it
+// expires when known. Items whose quantity is zero or whose expiry date has passed do not
+// count as owned.
+type PantryItem struct {
+	ID             string     `json:"id"`
+	IngredientID   string     `json:"ingredient_id"`
+	IngredientName string     `json:"ingredient"`
+	Quantity       *float64   `json:"quantity,omitempty"`   // Nil when not tracked
+	Unit           string     `json:"unit,omitempty"`       // Canonical unit name, see package units; empty for counted items
+	ExpiresOn      *time.Time `json:"expires_on,omitempty"` // Last usable day, time part normalized to UTC midnight
+	Notes          string     `json:"notes,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PantryStaple is an ingredient that always counts as owned, such as salt or water, without
+// being kept in the pantry.
+type PantryStaple struct {
+	IngredientID   string `json:"ingredient_id"`
+	IngredientName string `json:"ingredient"`
+}
+
+// CookableRecipe is a recipe ranked by how much of it the pantry covers: the ingredients it
+// calls for that are owned, as staples, or through a narrower ingredient in the pantry, such
+// as cheddar for cheese.
+type CookableRecipe struct {
+	RecipeID        string              `json:"recipe_id"`
+	Name            string              `json:"name"`
+	IngredientCount int                 `json:"ingredient_count"`
+	OwnedCount      int                 `json:"owned_count"`
+	OwnedFraction   float64             `json:"owned_fraction"` // OwnedCount / IngredientCount
+	Missing         []MissingIngredient `json:"missing"`        // In recipe order
+}
+
+// MissingIngredient is an ingredient of a recipe the pantry does not cover.
+type MissingIngredient struct {
+	IngredientID   string `json:"ingredient_id"`
+	IngredientName string `json:"ingredient"`
+	OriginalText   string `json:"original_text,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PlaceholderPhotoFilename is stored as a recipe's photo when it has no photo of its own.
+const PlaceholderPhotoFilename = "placeholder.jpg"
+
+// RecipePhoto is one photo in a recipe's gallery. The cover photo is also exposed
+// as Recipe.PhotoFilename. Filename holds the photo's opaque key in the photo store
+// (a plain filename for photos stored before keys were introduced).
+type RecipePhoto struct {
+	ID        string     `json:"id"`
+	RecipeID  string     `json:"recipe_id"`
+	Filename  string     `json:"filename"`
+	Caption   string     `json:"caption,omitempty"`
+	SortOrder int        `json:"sort_order"`
+	IsCover   bool       `json:"is_cover"`
+	URLs      *PhotoURLs `json:"urls,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PhotoURLs holds the URL of each stored size of a photo, so list views can
+// load a small variant instead of the full image.
+type PhotoURLs struct {
+	Thumb string `json:"thumb"`
+	Card  string `json:"card"`
+	Full  string `json:"full"`
+}
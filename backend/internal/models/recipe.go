@@ -4,11 +4,27 @@ import "time"
 
 // Recipe represents a cooking recipe
 type Recipe struct {
-	ID                        string    `json:"id"`
-	Name                      string    `json:"name"`
-	Ingredients               []string  `json:"ingredients"`
-	Method                    string    `json:"method"`
-	PhotoFilename             string    `json:"photo_filename,omitempty"` // omitempty if no photo
-	CreatedAt                 time.Time `json:"created_at"`
-	UpdatedAt                 time.Time `json:"updated_at"`
+	ID                    string                 `json:"id"`
+	Name                  string                 `json:"name"`
+	Ingredients           []string               `json:"ingredients"`
+	StructuredIngredients []StructuredIngredient `json:"structured_ingredients,omitempty"` // Parsed form of Ingredients
+	Method                string                 `json:"method"`
+	Servings              int                    `json:"servings,omitempty"` // Number of servings as written, 0 if unknown
+	Yield                 string                 `json:"yield,omitempty"`    // e.g. "24 cookies", for recipes not measured in servings
+	PrepTimeMinutes       int                    `json:"prep_time_minutes,omitempty"`
+	CookTimeMinutes       int                    `json:"cook_time_minutes,omitempty"`
+	RestTimeMinutes       int                    `json:"rest_time_minutes,omitempty"`  // Resting, proving or marinating time
+	TotalTimeMinutes      int                    `json:"total_time_minutes,omitempty"` // Computed: prep + cook + rest
+	Tags                  []string               `json:"tags,omitempty"`               // Tag names, see Tag
+	PhotoFilename         string                 `json:"photo_filename,omitempty"`     // omitempty if no photo; the cover of Photos
+	PhotoURLs             *PhotoURLs             `json:"photo_urls,omitempty"`         // Sized variants of the cover
+	Photos                []RecipePhoto          `json:"photos,omitempty"`             // Gallery in display order
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+	DeletedAt             *time.Time             `json:"deleted_at,omitempty"` // Set while the recipe is in the trash
+}
+
+// ComputeTotalTime sets TotalTimeMinutes from the prep, cook and rest times.
+func (r *Recipe) ComputeTotalTime() {
+	r.TotalTimeMinutes = r.PrepTimeMinutes + r.CookTimeMinutes + r.RestTimeMinutes
 }
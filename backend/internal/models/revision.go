@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// RecipeRevision is a snapshot of a recipe's editable fields as saved at one point in time.
+// Revision 1 is the recipe as created; every update adds the next number.
+type RecipeRevision struct {
+	ID              string    `json:"id"`
+	RecipeID        string    `json:"recipe_id"`
+	Revision        int       `json:"revision"`
+	Name            string    `json:"name"`
+	Method          string    `json:"method"`
+	Ingredients     []string  `json:"ingredients"`
+	Servings        int       `json:"servings,omitempty"`
+	Yield           string    `json:"yield,omitempty"`
+	PrepTimeMinutes int       `json:"prep_time_minutes,omitempty"`
+	CookTimeMinutes int       `json:"cook_time_minutes,omitempty"`
+	RestTimeMinutes int       `json:"rest_time_minutes,omitempty"`
+	Tags            []string  `json:"tags"`
+	PhotoFilename   string    `json:"photo_filename,omitempty"`
+	Editor          string    `json:"editor,omitempty"` // Who saved the revision, empty if unknown
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ApplyTo copies the revision's fields onto a recipe, e.g. to restore it.
+func (rev RecipeRevision) ApplyTo(recipe *Recipe) {
+	recipe.Name = rev.Name
+	recipe.Method = rev.Method
+	recipe.Ingredients = append([]string{}, rev.Ingredients...)
+	recipe.Servings = rev.Servings
+	recipe.Yield = rev.Yield
+	recipe.PrepTimeMinutes = rev.PrepTimeMinutes
+	recipe.CookTimeMinutes = rev.CookTimeMinutes
+	recipe.RestTimeMinutes = rev.RestTimeMinutes
+	recipe.Tags = append([]string{}, rev.Tags...)
+	recipe.PhotoFilename = rev.PhotoFilename
+	recipe.ComputeTotalTime()
+}
+
+// RevisionFieldChange describes how a single field differs between two revisions.
+// For list fields (ingredients, tags) Added and Removed hold the individual lines.
+type RevisionFieldChange struct {
+	Field   string      `json:"field"`
+	From    interface{} `json:"from"`
+	To      interface{} `json:"to"`
+	Added   []string    `json:"added,omitempty"`
+	Removed []string    `json:"removed,omitempty"`
+}
+
+// RevisionDiff lists the fields that changed between two revisions of a recipe.
+type RevisionDiff struct {
+	RecipeID     string                `json:"recipe_id"`
+	FromRevision int                   `json:"from_revision"`
+	ToRevision   int                   `json:"to_revision"`
+	Changes      []RevisionFieldChange `json:"changes"`
+}
+
+// DiffRevisions compares two revisions field by field. Fields that are equal are left out.
+func DiffRevisions(from, to RecipeRevision) RevisionDiff {
+	diff := RevisionDiff{
+		RecipeID:     to.RecipeID,
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		Changes:      []RevisionFieldChange{},
+	}
+
+	addScalar := func(field string, a, b interface{}) {
+		if a != b {
+			diff.Changes = append(diff.Changes, RevisionFieldChange{Field: field, From: a, To: b})
+		}
+	}
+	addList := func(field string, a, b []string) {
+		added, removed := diffLines(a, b)
+		if len(added) > 0 || len(removed) > 0 || !equalLines(a, b) {
+			diff.Changes = append(diff.Changes, RevisionFieldChange{Field: field, From: a, To: b, Added: added, Removed: removed})
+		}
+	}
+
+	addScalar("name", from.Name, to.Name)
+	addScalar("method", from.Method, to.Method)
+	addList("ingredients", from.Ingredients, to.Ingredients)
+	addScalar("servings", from.Servings, to.Servings)
+	addScalar("yield", from.Yield, to.Yield)
+	addScalar("prep_time_minutes", from.PrepTimeMinutes, to.PrepTimeMinutes)
+	addScalar("cook_time_minutes", from.CookTimeMinutes, to.CookTimeMinutes)
+	addScalar("rest_time_minutes", from.RestTimeMinutes, to.RestTimeMinutes)
+	addList("tags", from.Tags, to.Tags)
+	addScalar("photo_filename", from.PhotoFilename, to.PhotoFilename)
+	return diff
+}
+
+// diffLines returns the lines present only in b (added) and only in a (removed),
+// counting repeated lines individually.
+func diffLines(a, b []string) (added, removed []string) {
+	counts := make(map[string]int)
+	for _, line := range a {
+		counts[line]++
+	}
+	for _, line := range b {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added = append(added, line)
+		}
+	}
+	for _, line := range a {
+		if counts[line] > 0 {
+			counts[line]--
+			removed = append(removed, line)
+		}
+	}
+	return added, removed
+}
+
+// equalLines reports whether two lists hold the same lines in the same order.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// IngredientAisle is the store aisle an ingredient is shopped in, such as "Dairy".
+// Ingredients below it in the taxonomy are shopped in the same aisle unless they have their own.
+type IngredientAisle struct {
+	IngredientID   string    `json:"ingredient_id"`
+	IngredientName string    `json:"ingredient"`
+	Aisle          string    `json:"aisle"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ShoppingList is a list of things to buy, generated from the recipes planned between two
+// dates or started empty. Version is bumped when the list is renamed or items are added,
+// removed or reordered, and such changes name the version they were made against, so
+// concurrent edits are not lost. Items carry their own version for checking them off.
+type ShoppingList struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	StartDate    *time.Time         `json:"start_date,omitempty"` // Meal plan range the list was generated from
+	EndDate      *time.Time         `json:"end_date,omitempty"`
+	Recipes      []string           `json:"recipes"` // Names of the planned recipes, once each
+	Version      int                `json:"version"`
+	ItemCount    int                `json:"item_count"`
+	CheckedCount int                `json:"checked_count"`
+	Items        []ShoppingListItem `json:"items,omitempty"` // In display order; omitted when listing lists
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// ShoppingListItem is something to buy: the total amount of an ingredient needed by the
+// planned recipes, or an item added by hand. An ingredient called for in amounts that cannot
+// be converted into one another, such as cloves and grams of garlic, gets one item per kind
+// of amount. Version is bumped whenever the item is checked off or edited, so two people
+// shopping at once only conflict when they change the same item.
+type ShoppingListItem struct {
+	ID           string   `json:"id"`
+	IngredientID string   `json:"ingredient_id,omitempty"` // Empty for items added by hand
+	Name         string   `json:"name"`
+	Quantity     *float64 `json:"quantity,omitempty"` // Nil when no amount is given ("salt to taste")
+	Unit         string   `json:"unit,omitempty"`     // Canonical unit name, see package units
+	Text         string   `json:"text,omitempty"`     // For display, e.g. "700 g butter"; not stored
+	Aisle        string   `json:"aisle"`
+	InPantry     bool     `json:"in_pantry"` // The pantry or staples covered the ingredient when the list was generated
+	Recipes      []string `json:"recipes"`   // Names of the recipes calling for it
+	Manual       bool     `json:"manual"`    // Added by hand rather than generated
+	Checked      bool     `json:"checked"`
+	BoughtBy     string   `json:"bought_by,omitempty"` // Who bought it, or who will
+	SortOrder    int      `json:"sort_order"`
+	Version      int      `json:"version"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Substitution is an entry of the substitutions knowledge base: Quantity Unit of an ingredient
+// can be replaced with the given amounts of other ingredients. "1 cup buttermilk" becomes
+// "1 cup milk" and "1 tbsp lemon juice"; "1 cup butter" becomes "3/4 cup oil".
+type Substitution struct {
+	ID             string                    `json:"id"`
+	IngredientID   string                    `json:"ingredient_id"`
+	IngredientName string                    `json:"ingredient"`
+	Quantity       float64                   `json:"quantity"`       // Amount of the ingredient the replacements stand for
+	Unit           string                    `json:"unit,omitempty"` // Canonical unit name, see package units; empty for counted items
+	Notes          string                    `json:"notes,omitempty"`
+	Replacements   []SubstitutionReplacement `json:"replacements"`
+	CreatedAt      time.Time                 `json:"created_at"`
+	UpdatedAt      time.Time                 `json:"updated_at"`
+}
+
+// SubstitutionReplacement is one of the ingredients a substitution uses instead, with its
+// amount per the substitution's Quantity.
+type SubstitutionReplacement struct {
+	IngredientID   string  `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Tag is a free-form label attached to recipes, such as "vegetarian", "weeknight" or "Italian".
+// Unlike ingredient filters, tags are chosen by the user.
+type Tag struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Category    string    `json:"category,omitempty"`     // Optional grouping, e.g. "diet", "cuisine", "course"
+	RecipeCount int       `json:"recipe_count,omitempty"` // Number of recipes using the tag, set by listings
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RecipeTag represents the link between a recipe and a tag.
+type RecipeTag struct {
+	RecipeID string `json:"recipe_id"`
+	TagID    string `json:"tag_id"`
+}
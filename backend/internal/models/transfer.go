@@ -1,8 +1,24 @@
 package models
 
 // ExportedData is a container for all data to be exported or imported.
+// IDs and timestamps are kept, so data can be moved between storage backends without loss.
 type ExportedData struct {
-	Recipes           []Recipe           `json:"recipes"`
-	Ingredients       []Ingredient       `json:"ingredients"`
-	RecipeIngredients []RecipeIngredient `json:"recipe_ingredients"`
+	Recipes                []Recipe                 `json:"recipes"`
+	Ingredients            []Ingredient             `json:"ingredients"`
+	RecipeIngredients      []RecipeIngredient       `json:"recipe_ingredients"`
+	IngredientAliases      []IngredientAlias        `json:"ingredient_aliases,omitempty"`
+	IngredientFoods        []IngredientFood         `json:"ingredient_foods,omitempty"` // With the food each names
+	IngredientDietaryFlags []IngredientDietaryFlags `json:"ingredient_dietary_flags,omitempty"`
+	Tags                   []Tag                    `json:"tags,omitempty"`
+	RecipeTags             []RecipeTag              `json:"recipe_tags,omitempty"`
+	RecipePhotos           []RecipePhoto            `json:"recipe_photos,omitempty"`
+	RecipeRevisions        []RecipeRevision         `json:"recipe_revisions,omitempty"`
+	RecipeDietaryOverrides []RecipeDietaryOverrides `json:"recipe_dietary_overrides,omitempty"`
+	Substitutions          []Substitution           `json:"substitutions,omitempty"`
+	PantryItems            []PantryItem             `json:"pantry_items,omitempty"`
+	PantryStaples          []PantryStaple           `json:"pantry_staples,omitempty"`
+	IngredientAisles       []IngredientAisle        `json:"ingredient_aisles,omitempty"`
+	ShoppingLists          []ShoppingList           `json:"shopping_lists,omitempty"` // With their items
+	Comments               []Comment                `json:"comments,omitempty"`
+	MealPlanEntries        []MealPlanEntry          `json:"meal_plan_entries,omitempty"`
 }
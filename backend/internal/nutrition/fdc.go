@@ -0,0 +1,145 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorecipes/backend/internal/models"
+)
+
+// SourceFDC is the Food.Source of foods read from USDA FoodData Central.
+const SourceFDC = "usda_fdc"
+
+// FoodData Central nutrient IDs (nutrient.csv) of the tracked nutrients, per 100 g.
+const (
+	fdcEnergyKcal        = 1008
+	fdcEnergyAtwaterGen  = 2047 // Foundation foods report energy by Atwater factors instead
+	fdcEnergyAtwaterSpec = 2048
+	fdcProtein           = 1003
+	fdcFat               = 1004
+	fdcCarbohydrate      = 1005
+	fdcFiber             = 1079
+	fdcSodium            = 1093
+)
+
+// ParseFDC reads foods from the food.csv and food_nutrient.csv files of a FoodData Central
+// CSV export. Nutrients other than the tracked ones are ignored, and foods without any
+// tracked nutrient are left out. Columns are found by their header names.
+func ParseFDC(foodCSV io.Reader, foodNutrientCSV io.Reader) ([]models.Food, error) {
+	foods := make(map[string]*models.Food)
+	var order []string
+	err := readCSV(foodCSV, []string{"fdc_id", "description"}, func(row []string) error {
+		id := strings.TrimSpace(row[0])
+		if id == "" || foods[id] != nil {
+			return nil
+		}
+		foods[id] = &models.Food{ID: id, Description: strings.TrimSpace(row[1]), Source: SourceFDC}
+		order = append(order, id)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("food.csv: %w", err)
+	}
+
+	found := make(map[string]bool)
+	atwater := make(map[string]float64) // Energy of foods reporting no kcal, by Atwater factors
+	err = readCSV(foodNutrientCSV, []string{"fdc_id", "nutrient_id", "amount"}, func(row []string) error {
+		food := foods[strings.TrimSpace(row[0])]
+		if food == nil {
+			return nil
+		}
+		nutrientID, err := strconv.Atoi(strings.TrimSpace(row[1]))
+		if err != nil {
+			return nil
+		}
+		amount, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil {
+			return nil
+		}
+		switch nutrientID {
+		case fdcEnergyKcal:
+			food.Calories = amount
+		case fdcEnergyAtwaterGen, fdcEnergyAtwaterSpec:
+			atwater[food.ID] = amount
+		case fdcProtein:
+			food.ProteinG = amount
+		case fdcFat:
+			food.FatG = amount
+		case fdcCarbohydrate:
+			food.CarbohydrateG = amount
+		case fdcFiber:
+			food.FiberG = amount
+		case fdcSodium:
+			food.SodiumMg = amount
+		default:
+			return nil
+		}
+		found[food.ID] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("food_nutrient.csv: %w", err)
+	}
+
+	var result []models.Food
+	for _, id := range order {
+		if !found[id] {
+			continue
+		}
+		food := foods[id]
+		if food.Calories == 0 {
+			food.Calories = atwater[id]
+		}
+		result = append(result, *food)
+	}
+	return result, nil
+}
+
+// readCSV calls fn with the given columns of every record of a CSV file with a header row.
+func readCSV(r io.Reader, columns []string, fn func(row []string) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		indexes[i] = -1
+		for j, name := range header {
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), column) {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return fmt.Errorf("missing column %q", column)
+		}
+	}
+
+	row := make([]string, len(columns))
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		for i, index := range indexes {
+			row[i] = ""
+			if index < len(record) {
+				row[i] = record[index]
+			}
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
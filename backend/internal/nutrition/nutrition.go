@@ -0,0 +1,140 @@
+// Package nutrition computes recipe nutrition from structured ingredient quantities and a
+// food composition dataset, and reads that dataset from USDA FoodData Central CSV files.
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/units"
+)
+
+// Reasons an ingredient line is left out of a recipe's totals.
+const (
+	SkippedNoFood     = "no food mapped to the ingredient"
+	SkippedNoQuantity = "no quantity"
+)
+
+// Compute adds up the nutrition of a recipe's ingredient lines. foods maps ingredient IDs to
+// their food mapping, with Food set. Ranges such as "1-2 cups" count as their midpoint.
+// Lines that cannot be weighed are listed with the reason and the result is marked incomplete.
+func Compute(recipe *models.Recipe, foods map[string]models.IngredientFood) models.RecipeNutrition {
+	result := models.RecipeNutrition{
+		RecipeID:        recipe.ID,
+		Servings:        recipe.Servings,
+		Complete:        true,
+		Ingredients:     []models.IngredientNutrition{},
+		RecipeUpdatedAt: recipe.UpdatedAt,
+		ComputedAt:      time.Now().UTC(),
+	}
+
+	for _, line := range recipe.StructuredIngredients {
+		item := models.IngredientNutrition{Original: line.Original, IngredientID: line.IngredientID}
+		mapping, ok := foods[line.IngredientID]
+		if ok && mapping.Food != nil {
+			item.FoodID = mapping.FoodID
+			grams, err := Grams(line, mapping)
+			if err != nil {
+				item.Skipped = err.Error()
+			} else {
+				item.Grams = round(grams)
+				nutrients := scale(mapping.Food.Nutrients, grams/100)
+				add(&result.Total, nutrients)
+				rounded := roundAll(nutrients)
+				item.Nutrients = &rounded
+			}
+		} else {
+			item.Skipped = SkippedNoFood
+		}
+		if item.Skipped != "" {
+			result.Complete = false
+		}
+		result.Ingredients = append(result.Ingredients, item)
+	}
+
+	if recipe.Servings > 0 {
+		perServing := roundAll(scale(result.Total, 1/float64(recipe.Servings)))
+		result.PerServing = &perServing
+	}
+	result.Total = roundAll(result.Total)
+	return result
+}
+
+// Grams returns the weight of an ingredient line. Amounts by mass convert directly, amounts
+// by volume need the mapping's density, and counted amounts ("2 eggs", "1 clove") the weight
+// of one piece.
+func Grams(line models.StructuredIngredient, mapping models.IngredientFood) (float64, error) {
+	if line.Quantity == nil {
+		return 0, errors.New(SkippedNoQuantity)
+	}
+	amount := *line.Quantity
+	if line.QuantityMax != nil {
+		amount = (amount + *line.QuantityMax) / 2
+	}
+
+	if line.Unit == "" {
+		if mapping.GramsPerPiece == nil {
+			return 0, errors.New("no weight per piece for counted amounts")
+		}
+		return amount * *mapping.GramsPerPiece, nil
+	}
+	unit, ok := units.ByName(line.Unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", line.Unit)
+	}
+	switch unit.Dimension {
+	case units.Mass:
+		return amount * unit.ToBase, nil
+	case units.Volume:
+		if mapping.GramsPerML == nil {
+			return 0, fmt.Errorf("no density for amounts in %s", unit.Name)
+		}
+		return amount * unit.ToBase * *mapping.GramsPerML, nil
+	default:
+		if mapping.GramsPerPiece == nil {
+			return 0, fmt.Errorf("no weight per %s", unit.Name)
+		}
+		return amount * *mapping.GramsPerPiece, nil
+	}
+}
+
+// scale multiplies every nutrient by factor.
+func scale(n models.Nutrients, factor float64) models.Nutrients {
+	return models.Nutrients{
+		Calories:      n.Calories * factor,
+		ProteinG:      n.ProteinG * factor,
+		FatG:          n.FatG * factor,
+		CarbohydrateG: n.CarbohydrateG * factor,
+		FiberG:        n.FiberG * factor,
+		SodiumMg:      n.SodiumMg * factor,
+	}
+}
+
+// add adds n to total.
+func add(total *models.Nutrients, n models.Nutrients) {
+	total.Calories += n.Calories
+	total.ProteinG += n.ProteinG
+	total.FatG += n.FatG
+	total.CarbohydrateG += n.CarbohydrateG
+	total.FiberG += n.FiberG
+	total.SodiumMg += n.SodiumMg
+}
+
+// roundAll rounds every nutrient to one decimal.
+func roundAll(n models.Nutrients) models.Nutrients {
+	return models.Nutrients{
+		Calories:      round(n.Calories),
+		ProteinG:      round(n.ProteinG),
+		FatG:          round(n.FatG),
+		CarbohydrateG: round(n.CarbohydrateG),
+		FiberG:        round(n.FiberG),
+		SodiumMg:      round(n.SodiumMg),
+	}
+}
+
+func round(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,326 @@
+// Package parser turns free-text recipe ingredient lines such as
+// "1 ½ cups finely chopped tomatoes" into structured data.
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorecipes/backend/internal/models"
+	"gorecipes/backend/internal/units"
+)
+
+// unicodeFractions maps vulgar fraction characters to their ASCII form.
+var unicodeFractions = map[rune]string{
+	'¼': "1/4", '½': "1/2", '¾': "3/4",
+	'⅐': "1/7", '⅑': "1/9", '⅒': "1/10",
+	'⅓': "1/3", '⅔': "2/3",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5",
+	'⅙': "1/6", '⅚': "5/6",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// numberWords are spelled-out amounts accepted at the start of a line.
+var numberWords = map[string]float64{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"dozen": 12, "half": 0.5,
+}
+
+// preparationWords describe how an ingredient is prepared rather than what it is.
+var preparationWords = map[string]bool{
+	"chopped": true, "diced": true, "sliced": true, "minced": true, "grated": true,
+	"crushed": true, "peeled": true, "seeded": true, "deseeded": true, "cored": true,
+	"halved": true, "quartered": true, "cubed": true, "shredded": true, "julienned": true,
+	"zested": true, "juiced": true, "trimmed": true, "rinsed": true, "drained": true,
+	"softened": true, "melted": true, "beaten": true, "whisked": true, "sifted": true,
+	"toasted": true, "roasted": true, "cooked": true, "uncooked": true, "thawed": true,
+	"mashed": true, "pitted": true, "packed": true, "divided": true, "room-temperature": true,
+	"fresh": true, "large": true, "medium": true, "small": true,
+}
+
+// preparationAdverbs only count as preparation when followed by a preparation word
+// ("finely chopped"), so that e.g. "freshly ground pepper" keeps "ground pepper".
+var preparationAdverbs = map[string]bool{
+	"finely": true, "roughly": true, "coarsely": true, "thinly": true, "thickly": true,
+	"freshly": true, "lightly": true, "well": true, "very": true, "loosely": true,
+	"firmly": true, "lightly-beaten": true,
+}
+
+// trailingNotes are phrases that end an ingredient line and belong in the preparation.
+var trailingNotes = []string{
+	"or to taste", "to taste", "for garnish", "for garnishing", "for serving", "to serve",
+	"for frying", "for greasing", "for dusting", "for brushing", "for drizzling",
+	"as needed", "if needed", "optional", "plus extra",
+}
+
+// singularExceptions are ingredient nouns that must not be singularized.
+var singularExceptions = map[string]bool{
+	"asparagus": true, "couscous": true, "hummus": true, "molasses": true,
+	"oats": true, "grits": true, "greens": true, "swiss": true, "brussels": true,
+	"citrus": true, "octopus": true, "series": true, "species": true, "herbes": true,
+}
+
+// irregularPlurals maps plurals that the simple suffix rules get wrong.
+var irregularPlurals = map[string]string{
+	"leaves": "leaf", "loaves": "loaf", "halves": "half", "knives": "knife",
+	"cookies": "cookie", "mice": "mouse", "geese": "goose",
+}
+
+var (
+	numberPattern   = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?`
+	reQuantity      = regexp.MustCompile(`^(` + numberPattern + `)(?:\s*(?:-|to)\s*(` + numberPattern + `))?`)
+	reParenthetical = regexp.MustCompile(`\(([^)]*)\)`)
+	reWhitespace    = regexp.MustCompile(`\s+`)
+	reMixedUnicode  = regexp.MustCompile(`(\d)\s*([¼½¾⅐⅑⅒⅓⅔⅕⅖⅗⅘⅙⅚⅛⅜⅝⅞])`)
+)
+
+// ParseIngredient splits a single ingredient line into quantity, unit,
+// canonical ingredient name and preparation. It never fails: anything it
+// cannot interpret ends up in the ingredient name.
+func ParseIngredient(line string) models.StructuredIngredient {
+	result := models.StructuredIngredient{Original: strings.TrimSpace(line)}
+	if result.Original == "" {
+		return result
+	}
+
+	s := normalizeText(result.Original)
+
+	// Parenthetical notes ("(14 oz)", "(optional)") are kept as preparation.
+	var notes []string
+	for _, m := range reParenthetical.FindAllStringSubmatch(s, -1) {
+		if note := strings.TrimSpace(m[1]); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	s = strings.TrimSpace(reParenthetical.ReplaceAllString(s, " "))
+
+	// Quantity
+	rest := s
+	var quantityWords []string
+	if m := reQuantity.FindStringSubmatchIndex(s); m != nil {
+		if q, ok := ParseNumber(s[m[2]:m[3]]); ok {
+			result.Quantity = &q
+			if m[4] >= 0 {
+				if qMax, ok := ParseNumber(s[m[4]:m[5]]); ok && qMax > q {
+					result.QuantityMax = &qMax
+				}
+			}
+			quantityWords = append(quantityWords, s[:m[1]])
+			rest = strings.TrimSpace(s[m[1]:])
+		}
+	} else if fields := strings.Fields(s); len(fields) > 0 {
+		first := strings.ToLower(fields[0])
+		if q, ok := numberWords[first]; ok {
+			result.Quantity = &q
+			quantityWords = append(quantityWords, fields[0])
+			fields = fields[1:]
+			// "half a lemon", "a dozen eggs"
+			if len(fields) > 0 && (strings.EqualFold(fields[0], "a") || strings.EqualFold(fields[0], "an")) {
+				quantityWords = append(quantityWords, fields[0])
+				fields = fields[1:]
+			}
+			rest = strings.Join(fields, " ")
+		} else if (first == "a" || first == "an") && len(fields) > 1 {
+			// "a pinch of salt" only counts as a quantity when a unit follows.
+			if _, n := matchUnit(fields[1:]); n > 0 {
+				one := 1.0
+				result.Quantity = &one
+				quantityWords = append(quantityWords, fields[0])
+				rest = strings.Join(fields[1:], " ")
+			}
+		}
+	}
+
+	// Unit
+	amountText := strings.Join(quantityWords, " ")
+	fields := strings.Fields(rest)
+	if unit, n := matchUnit(fields); n > 0 && (result.Quantity != nil || n < len(fields)) {
+		result.Unit = unit.Name
+		quantityWords = append(quantityWords, fields[:n]...)
+		fields = fields[n:]
+		if len(fields) > 0 && strings.EqualFold(fields[0], "of") {
+			fields = fields[1:]
+		}
+	}
+	result.QuantityText = strings.TrimSpace(strings.Join(quantityWords, " "))
+	rest = strings.Join(fields, " ")
+
+	// "tomatoes, finely chopped" -> name part and preparation part
+	namePart, prepPart := rest, ""
+	if idx := strings.Index(rest, ","); idx >= 0 {
+		namePart, prepPart = rest[:idx], strings.TrimSpace(rest[idx+1:])
+	}
+
+	namePart, trailing := stripTrailingNotes(strings.ToLower(namePart))
+	leading, nameWords := splitLeadingPreparation(strings.Fields(namePart))
+
+	var preparation []string
+	preparation = appendIfNotEmpty(preparation, leading)
+	preparation = appendIfNotEmpty(preparation, prepPart)
+	preparation = appendIfNotEmpty(preparation, trailing)
+	preparation = append(preparation, notes...)
+	result.Preparation = strings.Join(preparation, ", ")
+
+	result.Name = CanonicalName(strings.Join(nameWords, " "))
+	if result.Name == "" {
+		// "4 cloves" or "2 large": fall back to the unit or the whole line.
+		if result.Unit != "" {
+			result.Name = CanonicalName(result.Unit)
+			result.Unit = ""
+			result.QuantityText = amountText
+		} else {
+			result.Name = CanonicalName(strings.ToLower(rest))
+		}
+	}
+	return result
+}
+
+// ParseNumber parses "2", "1.5", "1,5", "3/4" and "1 1/2" into a float.
+func ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if parts := strings.Fields(s); len(parts) == 2 {
+		whole, ok1 := ParseNumber(parts[0])
+		frac, ok2 := ParseNumber(parts[1])
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return whole + frac, true
+	}
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	f, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// CanonicalName lowercases an ingredient name, collapses whitespace, strips
+// surrounding punctuation and singularizes the final word, so that
+// "Cherry Tomatoes" and "cherry tomato" resolve to the same ingredient.
+func CanonicalName(name string) string {
+	name = strings.ToLower(reWhitespace.ReplaceAllString(strings.TrimSpace(name), " "))
+	name = strings.Trim(name, " .,;:-*")
+	if name == "" {
+		return ""
+	}
+	words := strings.Fields(name)
+	words[len(words)-1] = singularize(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// normalizeText rewrites unicode fractions, fraction slashes and dashes into
+// the ASCII forms the quantity pattern understands.
+func normalizeText(s string) string {
+	s = reMixedUnicode.ReplaceAllString(s, "$1 $2") // "1½" -> "1 ½"
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicodeFractions[r] != "":
+			b.WriteString(unicodeFractions[r])
+		case r == '⁄':
+			b.WriteRune('/')
+		case r == '–' || r == '—':
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return reWhitespace.ReplaceAllString(b.String(), " ")
+}
+
+// matchUnit tries the longest unit alias at the start of fields and returns
+// the unit and the number of fields it consumed (0 when there is no unit).
+func matchUnit(fields []string) (units.Unit, int) {
+	for n := units.MaxAliasWords; n >= 1; n-- {
+		if n > len(fields) {
+			continue
+		}
+		if u, ok := units.Lookup(strings.Join(fields[:n], " ")); ok {
+			return u, n
+		}
+	}
+	return units.Unit{}, 0
+}
+
+// stripTrailingNotes removes phrases such as "to taste" from the end of a name.
+func stripTrailingNotes(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	var found []string
+	for changed := true; changed; {
+		changed = false
+		for _, note := range trailingNotes {
+			if strings.HasSuffix(name, note) && (len(name) == len(note) || name[len(name)-len(note)-1] == ' ') {
+				found = append([]string{note}, found...)
+				name = strings.TrimSpace(strings.TrimSuffix(name, note))
+				changed = true
+			}
+		}
+	}
+	return name, strings.Join(found, ", ")
+}
+
+// splitLeadingPreparation separates leading words like "large finely chopped"
+// from the ingredient name that follows them.
+func splitLeadingPreparation(words []string) (string, []string) {
+	i := 0
+	for i < len(words)-1 {
+		w := strings.Trim(words[i], ",")
+		if preparationWords[w] {
+			i++
+			continue
+		}
+		if preparationAdverbs[w] && preparationWords[strings.Trim(words[i+1], ",")] {
+			i += 2
+			continue
+		}
+		if w == "and" && i > 0 {
+			i++
+			continue
+		}
+		break
+	}
+	return strings.Join(words[:i], " "), words[i:]
+}
+
+// singularize applies a few conservative English plural rules to a noun.
+func singularize(word string) string {
+	if singularExceptions[word] || len(word) <= 3 {
+		return word
+	}
+	if s, ok := irregularPlurals[word]; ok {
+		return s
+	}
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+func appendIfNotEmpty(list []string, s string) []string {
+	if s = strings.TrimSpace(s); s != "" {
+		return append(list, s)
+	}
+	return list
+}
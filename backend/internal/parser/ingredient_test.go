@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseIngredient(t *testing.T) {
+	tests := []struct {
+		line        string
+		quantity    float64 // 0 when the line has no quantity
+		quantityMax float64 // 0 when the line is not a range
+		unit        string
+		name        string
+		preparation string
+	}{
+		// Fractions
+		{"1 1/2 cups flour", 1.5, 0, "cup", "flour", ""},
+		{"3/4 cup sugar", 0.75, 0, "cup", "sugar", ""},
+		{"½ tsp salt", 0.5, 0, "tsp", "salt", ""},
+		{"1½ cups milk", 1.5, 0, "cup", "milk", ""},
+		{"1 ¼ lb potatoes", 1.25, 0, "lb", "potato", ""},
+		{"2,5 dl cream", 2.5, 0, "dl", "cream", ""},
+
+		// Ranges
+		{"2-3 cloves garlic", 2, 3, "clove", "garlic", ""},
+		{"2 – 3 tbsp olive oil", 2, 3, "tbsp", "olive oil", ""},
+		{"1 to 2 tsp chili flakes", 1, 2, "tsp", "chili flake", ""},
+		{"3-2 eggs", 3, 0, "", "egg", ""},
+
+		// Multi-word units
+		{"8 fl oz milk", 8, 0, "fl oz", "milk", ""},
+		{"2 fluid ounces cream", 2, 0, "fl oz", "cream", ""},
+		{"1 fl. oz rum", 1, 0, "fl oz", "rum", ""},
+
+		// Trailing "." on units
+		{"2 tbsp. sugar", 2, 0, "tbsp", "sugar", ""},
+		{"1 tsp. vanilla extract", 1, 0, "tsp", "vanilla extract", ""},
+		{"4 oz. cheddar", 4, 0, "oz", "cheddar", ""},
+
+		// Preparation
+		{"1 onion, finely chopped", 1, 0, "", "onion", "finely chopped"},
+		{"2 cups tomatoes, peeled and diced", 2, 0, "cup", "tomato", "peeled and diced"},
+		{"3 large eggs, beaten", 3, 0, "", "egg", "large, beaten"},
+		{"salt, to taste", 0, 0, "", "salt", "to taste"},
+		{"freshly ground black pepper", 0, 0, "", "freshly ground black pepper", ""},
+		{"1 can (14 oz) chickpeas, drained", 1, 0, "can", "chickpea", "drained, 14 oz"},
+
+		// Spelled-out amounts and counts
+		{"a pinch of salt", 1, 0, "pinch", "salt", ""},
+		{"half a lemon", 0.5, 0, "", "lemon", ""},
+		{"4 cloves", 4, 0, "", "clove", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := ParseIngredient(tt.line)
+			if got.Original != tt.line {
+				t.Errorf("Original = %q, want %q", got.Original, tt.line)
+			}
+			if !equalQuantity(got.Quantity, tt.quantity) {
+				t.Errorf("Quantity = %v, want %v", deref(got.Quantity), tt.quantity)
+			}
+			if !equalQuantity(got.QuantityMax, tt.quantityMax) {
+				t.Errorf("QuantityMax = %v, want %v", deref(got.QuantityMax), tt.quantityMax)
+			}
+			if got.Unit != tt.unit {
+				t.Errorf("Unit = %q, want %q", got.Unit, tt.unit)
+			}
+			if got.Name != tt.name {
+				t.Errorf("Name = %q, want %q", got.Name, tt.name)
+			}
+			if got.Preparation != tt.preparation {
+				t.Errorf("Preparation = %q, want %q", got.Preparation, tt.preparation)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"2", 2, true},
+		{"1.5", 1.5, true},
+		{"1,5", 1.5, true},
+		{"3/4", 0.75, true},
+		{"1 1/2", 1.5, true},
+		{" 2 ", 2, true},
+		{"1/0", 0, false},
+		{"", 0, false},
+		{"a few", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseNumber(tt.in)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ParseNumber(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Cherry Tomatoes", "cherry tomato"},
+		{"  fresh   berries. ", "fresh berry"},
+		{"bay leaves", "bay leaf"},
+		{"peaches", "peach"},
+		{"asparagus", "asparagus"},
+		{"molasses", "molasses"},
+		{"egg", "egg"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := CanonicalName(tt.in); got != tt.want {
+			t.Errorf("CanonicalName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func equalQuantity(got *float64, want float64) bool {
+	if got == nil {
+		return want == 0
+	}
+	return math.Abs(*got-want) < 1e-9
+}
+
+func deref(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"gorecipes/backend/internal/database"
 	"gorecipes/backend/internal/handlers"
 	"time"
 
@@ -10,10 +11,21 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// SetupRouter initializes and returns a new Gin router.
-func SetupRouter() *gin.Engine {
+// SetupRouter initializes and returns a new Gin router whose handlers use the given repositories.
+func SetupRouter(repos database.Repositories) *gin.Engine {
 	router := gin.Default()
 
+	recipeHandler := &handlers.RecipeHandler{Recipes: repos.Recipes}
+	ingredientHandler := &handlers.IngredientHandler{Ingredients: repos.Ingredients}
+	commentHandler := &handlers.CommentHandler{Comments: repos.Comments}
+	mealPlanHandler := &handlers.MealPlanHandler{MealPlans: repos.MealPlans}
+	nutritionHandler := &handlers.NutritionHandler{Recipes: repos.Recipes, Nutrition: repos.Nutrition}
+	dietaryHandler := &handlers.DietaryHandler{Dietary: repos.Dietary}
+	substitutionHandler := &handlers.SubstitutionHandler{Recipes: repos.Recipes, Ingredients: repos.Ingredients, Dietary: repos.Dietary, Substitutions: repos.Substitutions}
+	pantryHandler := &handlers.PantryHandler{Pantry: repos.Pantry}
+	shoppingListHandler := &handlers.ShoppingListHandler{MealPlans: repos.MealPlans, Recipes: repos.Recipes, Ingredients: repos.Ingredients, Pantry: repos.Pantry, ShoppingLists: repos.ShoppingLists}
+	adminHandler := &handlers.AdminHandler{Recipes: repos.Recipes, Ingredients: repos.Ingredients, Comments: repos.Comments, MealPlans: repos.MealPlans, Nutrition: repos.Nutrition, Dietary: repos.Dietary, Substitutions: repos.Substitutions, Pantry: repos.Pantry, ShoppingLists: repos.ShoppingLists}
+
 	// CORS Middleware Configuration
 	// Allows requests from SvelteKit dev server (typically http://localhost:5173)
 	// and common production/preview ports.
@@ -33,59 +45,142 @@ func SetupRouter() *gin.Engine {
 		// Recipe routes
 		recipesBase := apiV1.Group("/recipes")
 		{
-			recipesBase.POST("", handlers.CreateRecipe) // POST /api/v1/recipes
-			recipesBase.GET("", handlers.ListRecipes)   // GET  /api/v1/recipes
-			recipesBase.POST("/process-photo", handlers.ProcessRecipePhoto) // POST /api/v1/recipes/process-photo
+			recipesBase.POST("", recipeHandler.CreateRecipe)                       // POST /api/v1/recipes
+			recipesBase.GET("", recipeHandler.ListRecipes)                         // GET  /api/v1/recipes
+			recipesBase.POST("/process-photo", handlers.ProcessRecipePhoto)        // POST /api/v1/recipes/process-photo
+			recipesBase.GET("/cookable", pantryHandler.ListCookableRecipesHandler) // GET /api/v1/recipes/cookable?max_missing=2
 
 			// Routes for a specific recipe, e.g., /api/v1/recipes/:id
 			recipeWithID := recipesBase.Group("/:id")
 			{
-				recipeWithID.GET("", handlers.GetRecipe)       // GET    /api/v1/recipes/:id
-				recipeWithID.PUT("", handlers.UpdateRecipe)    // PUT    /api/v1/recipes/:id
-				recipeWithID.DELETE("", handlers.DeleteRecipe) // DELETE /api/v1/recipes/:id
+				recipeWithID.GET("", recipeHandler.GetRecipe)                                            // GET    /api/v1/recipes/:id
+				recipeWithID.PUT("", recipeHandler.UpdateRecipe)                                         // PUT    /api/v1/recipes/:id
+				recipeWithID.DELETE("", recipeHandler.DeleteRecipe)                                      // DELETE /api/v1/recipes/:id
+				recipeWithID.GET("/scaled", recipeHandler.GetScaledRecipe)                               // GET /api/v1/recipes/:id/scaled?servings=N or ?factor=1.5
+				recipeWithID.GET("/nutrition", nutritionHandler.GetRecipeNutritionHandler)               // GET /api/v1/recipes/:id/nutrition
+				recipeWithID.GET("/dietary", dietaryHandler.GetRecipeDietaryHandler)                     // GET /api/v1/recipes/:id/dietary
+				recipeWithID.PUT("/dietary", dietaryHandler.SetRecipeDietaryOverridesHandler)            // PUT /api/v1/recipes/:id/dietary
+				recipeWithID.GET("/substitutions", substitutionHandler.GetRecipeSubstitutionsHandler)    // GET /api/v1/recipes/:id/substitutions?exclude=butter&exclude_allergens=dairy&diet=vegan
+				recipeWithID.GET("/revisions", recipeHandler.ListRecipeRevisionsHandler)                 // GET  /api/v1/recipes/:id/revisions
+				recipeWithID.GET("/revisions/diff", recipeHandler.DiffRecipeRevisionsHandler)            // GET  /api/v1/recipes/:id/revisions/diff?from=1&to=3
+				recipeWithID.GET("/revisions/:rev", recipeHandler.GetRecipeRevisionHandler)              // GET  /api/v1/recipes/:id/revisions/:rev
+				recipeWithID.POST("/revisions/:rev/restore", recipeHandler.RestoreRecipeRevisionHandler) // POST /api/v1/recipes/:id/revisions/:rev/restore
+				recipeWithID.GET("/photos", recipeHandler.ListRecipePhotosHandler)                       // GET    /api/v1/recipes/:id/photos
+				recipeWithID.POST("/photos", recipeHandler.UploadRecipePhotoHandler)                     // POST   /api/v1/recipes/:id/photos
+				recipeWithID.PUT("/photos/order", recipeHandler.ReorderRecipePhotosHandler)              // PUT    /api/v1/recipes/:id/photos/order
+				recipeWithID.PATCH("/photos/:photo_id", recipeHandler.UpdateRecipePhotoHandler)          // PATCH  /api/v1/recipes/:id/photos/:photo_id
+				recipeWithID.DELETE("/photos/:photo_id", recipeHandler.DeleteRecipePhotoHandler)         // DELETE /api/v1/recipes/:id/photos/:photo_id
 				// recipeWithID.POST("/image", handlers.UploadRecipeImage) // Example for specific image upload
 			}
 			// Comment routes nested under a specific recipe
-			recipeWithID.POST("/comments", handlers.CreateCommentHandler)        // POST /api/v1/recipes/:id/comments
-			recipeWithID.GET("/comments", handlers.GetCommentsByRecipeIDHandler) // GET /api/v1/recipes/:id/comments
+			recipeWithID.POST("/comments", commentHandler.CreateCommentHandler)        // POST /api/v1/recipes/:id/comments
+			recipeWithID.GET("/comments", commentHandler.GetCommentsByRecipeIDHandler) // GET /api/v1/recipes/:id/comments
 		}
 
 		// Comment routes (for specific comment operations)
 		comments := apiV1.Group("/comments")
 		{
-			comments.PUT("/:id", handlers.UpdateCommentHandler)    // PUT    /api/v1/comments/:id
-			comments.DELETE("/:id", handlers.DeleteCommentHandler) // DELETE /api/v1/comments/:id
+			comments.PUT("/:id", commentHandler.UpdateCommentHandler)    // PUT    /api/v1/comments/:id
+			comments.DELETE("/:id", commentHandler.DeleteCommentHandler) // DELETE /api/v1/comments/:id
+		}
+
+		// Tag routes
+		tags := apiV1.Group("/tags")
+		{
+			tags.GET("", recipeHandler.ListTagsHandler)         // GET    /api/v1/tags
+			tags.POST("", recipeHandler.CreateTagHandler)       // POST   /api/v1/tags
+			tags.GET("/:id", recipeHandler.GetTagHandler)       // GET    /api/v1/tags/:id
+			tags.PUT("/:id", recipeHandler.UpdateTagHandler)    // PUT    /api/v1/tags/:id
+			tags.DELETE("/:id", recipeHandler.DeleteTagHandler) // DELETE /api/v1/tags/:id
+		}
+
+		// Trash routes (soft-deleted recipes)
+		trash := apiV1.Group("/trash")
+		{
+			trash.GET("", recipeHandler.ListTrashHandler)                         // GET    /api/v1/trash
+			trash.POST("/purge", recipeHandler.PurgeExpiredTrashHandler)          // POST   /api/v1/trash/purge
+			trash.POST("/:id/restore", recipeHandler.RestoreTrashedRecipeHandler) // POST   /api/v1/trash/:id/restore
+			trash.DELETE("/:id", recipeHandler.PurgeTrashedRecipeHandler)         // DELETE /api/v1/trash/:id
+		}
+
+		// Pantry routes
+		pantry := apiV1.Group("/pantry")
+		{
+			pantry.GET("", pantryHandler.ListPantryItemsHandler)           // GET    /api/v1/pantry
+			pantry.POST("", pantryHandler.CreatePantryItemHandler)         // POST   /api/v1/pantry
+			pantry.GET("/staples", pantryHandler.ListPantryStaplesHandler) // GET    /api/v1/pantry/staples
+			pantry.PUT("/staples", pantryHandler.SetPantryStaplesHandler)  // PUT    /api/v1/pantry/staples
+			pantry.PUT("/:id", pantryHandler.UpdatePantryItemHandler)      // PUT    /api/v1/pantry/:id
+			pantry.DELETE("/:id", pantryHandler.DeletePantryItemHandler)   // DELETE /api/v1/pantry/:id
+		}
+
+		// Shopping list routes
+		shoppingLists := apiV1.Group("/shopping-lists")
+		{
+			shoppingLists.GET("", shoppingListHandler.ListShoppingListsHandler)                            // GET    /api/v1/shopping-lists
+			shoppingLists.POST("", shoppingListHandler.CreateShoppingListHandler)                          // POST   /api/v1/shopping-lists
+			shoppingLists.GET("/:id", shoppingListHandler.GetShoppingListHandler)                          // GET    /api/v1/shopping-lists/:id
+			shoppingLists.PUT("/:id", shoppingListHandler.UpdateShoppingListHandler)                       // PUT    /api/v1/shopping-lists/:id
+			shoppingLists.DELETE("/:id", shoppingListHandler.DeleteShoppingListHandler)                    // DELETE /api/v1/shopping-lists/:id
+			shoppingLists.GET("/:id/export", shoppingListHandler.ExportShoppingListHandler)                // GET    /api/v1/shopping-lists/:id/export
+			shoppingLists.POST("/:id/items", shoppingListHandler.AddShoppingListItemHandler)               // POST   /api/v1/shopping-lists/:id/items
+			shoppingLists.PUT("/:id/items/order", shoppingListHandler.ReorderShoppingListItemsHandler)     // PUT    /api/v1/shopping-lists/:id/items/order
+			shoppingLists.PATCH("/:id/items/:item_id", shoppingListHandler.UpdateShoppingListItemHandler)  // PATCH  /api/v1/shopping-lists/:id/items/:item_id
+			shoppingLists.DELETE("/:id/items/:item_id", shoppingListHandler.DeleteShoppingListItemHandler) // DELETE /api/v1/shopping-lists/:id/items/:item_id
 		}
 
 		// Ingredient routes
 		ingredients := apiV1.Group("/ingredients")
 		{
-			ingredients.GET("", handlers.GetIngredientsAutocomplete) // e.g., /api/v1/ingredients?q=tomato
+			ingredients.GET("", ingredientHandler.GetIngredientsAutocomplete) // e.g., /api/v1/ingredients?q=tomato
 		}
 
-		// Admin routes (currently no admin-specific routes defined)
+		// Admin routes
 		admin := apiV1.Group("/admin")
 		{
-			admin.POST("/export", handlers.ExportData) // POST /api/v1/admin/export
-			admin.POST("/import", handlers.ImportData) // POST /api/v1/admin/import
+			admin.POST("/export", adminHandler.ExportData) // POST /api/v1/admin/export
+			admin.POST("/import", adminHandler.ImportData) // POST /api/v1/admin/import
+
+			admin.GET("/ingredients", ingredientHandler.ListIngredientsHandler)                // GET  /api/v1/admin/ingredients
+			admin.GET("/ingredients/tree", ingredientHandler.IngredientTreeHandler)            // GET  /api/v1/admin/ingredients/tree
+			admin.POST("/ingredients/merge", ingredientHandler.MergeIngredientsHandler)        // POST /api/v1/admin/ingredients/merge
+			admin.PUT("/ingredients/:id", ingredientHandler.RenameIngredientHandler)           // PUT  /api/v1/admin/ingredients/:id
+			admin.PUT("/ingredients/:id/parent", ingredientHandler.SetIngredientParentHandler) // PUT  /api/v1/admin/ingredients/:id/parent
+
+			admin.PUT("/ingredients/:id/food", nutritionHandler.SetIngredientFoodHandler)       // PUT    /api/v1/admin/ingredients/:id/food
+			admin.DELETE("/ingredients/:id/food", nutritionHandler.DeleteIngredientFoodHandler) // DELETE /api/v1/admin/ingredients/:id/food
+			admin.GET("/foods", nutritionHandler.SearchFoodsHandler)                            // GET    /api/v1/admin/foods?q=cheddar
+
+			admin.GET("/dietary-flags", dietaryHandler.ListIngredientDietaryFlagsHandler)                // GET /api/v1/admin/dietary-flags
+			admin.PUT("/ingredients/:id/dietary-flags", dietaryHandler.SetIngredientDietaryFlagsHandler) // PUT /api/v1/admin/ingredients/:id/dietary-flags
+
+			admin.GET("/aisles", shoppingListHandler.ListIngredientAislesHandler)                    // GET    /api/v1/admin/aisles
+			admin.PUT("/ingredients/:id/aisle", shoppingListHandler.SetIngredientAisleHandler)       // PUT    /api/v1/admin/ingredients/:id/aisle
+			admin.DELETE("/ingredients/:id/aisle", shoppingListHandler.DeleteIngredientAisleHandler) // DELETE /api/v1/admin/ingredients/:id/aisle
+
+			admin.GET("/substitutions", substitutionHandler.ListSubstitutionsHandler)         // GET    /api/v1/admin/substitutions
+			admin.POST("/substitutions", substitutionHandler.CreateSubstitutionHandler)       // POST   /api/v1/admin/substitutions
+			admin.PUT("/substitutions/:id", substitutionHandler.UpdateSubstitutionHandler)    // PUT    /api/v1/admin/substitutions/:id
+			admin.DELETE("/substitutions/:id", substitutionHandler.DeleteSubstitutionHandler) // DELETE /api/v1/admin/substitutions/:id
 		}
 
 		// Meal Planner routes
 		mealPlanner := apiV1.Group("/mealplanner")
 		{
-			mealPlanner.POST("/entries", handlers.CreateMealPlanEntryHandler)             // POST /api/v1/mealplanner/entries
-			mealPlanner.GET("/entries", handlers.ListMealPlanEntriesHandler)              // GET  /api/v1/mealplanner/entries
-			mealPlanner.DELETE("/entries/:entry_id", handlers.DeleteMealPlanEntryHandler) // DELETE /api/v1/mealplanner/entries/:entry_id
+			mealPlanner.POST("/entries", mealPlanHandler.CreateMealPlanEntryHandler)             // POST /api/v1/mealplanner/entries
+			mealPlanner.GET("/entries", mealPlanHandler.ListMealPlanEntriesHandler)              // GET  /api/v1/mealplanner/entries
+			mealPlanner.POST("/entries/batch", mealPlanHandler.BatchMealPlanEntriesHandler)      // POST /api/v1/mealplanner/entries/batch
+			mealPlanner.PATCH("/entries/:entry_id", mealPlanHandler.UpdateMealPlanEntryHandler)  // PATCH /api/v1/mealplanner/entries/:entry_id
+			mealPlanner.DELETE("/entries/:entry_id", mealPlanHandler.DeleteMealPlanEntryHandler) // DELETE /api/v1/mealplanner/entries/:entry_id
 		}
 	}
 
 	// Swagger UI route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("http://localhost:8080/swagger/doc.json")))
 
-	// Serve static files (uploaded images)
-	// The path "/uploads/images" will correspond to the "uploads/images" directory in the backend.
-	// Ensure this directory is relative to where the Go binary is run (usually the 'backend' directory).
-	router.Static("/uploads/images", "./uploads/images")
+	// Serve uploaded images from the configured photo store (local directory or S3 bucket)
+	router.GET(handlers.PhotoURLPath+"*key", handlers.ServePhotoHandler)
+	router.HEAD(handlers.PhotoURLPath+"*key", handlers.ServePhotoHandler)
 
 	// Simple health check endpoint (can be outside the API group or within, depending on preference)
 	router.GET("/health", func(c *gin.Context) {
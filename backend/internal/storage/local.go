@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LocalStore keeps photos as files below a directory on the local filesystem.
+type LocalStore struct {
+	Dir     string // Root directory; keys map to paths below it
+	BaseURL string // URL prefix the directory is served under, e.g. "/uploads/images/"
+}
+
+// NewLocalStore returns a store rooted at dir, creating the directory if needed.
+func NewLocalStore(dir string, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create photo directory %s: %w", dir, err)
+	}
+	return &LocalStore{Dir: dir, BaseURL: baseURL}, nil
+}
+
+// path returns the filesystem path of a key.
+func (s *LocalStore) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the photo to a temporary file and renames it into place, so readers
+// never see a partially written photo.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for photo %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create photo %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write photo %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write photo %s: %w", key, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write photo %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to store photo %s: %w", key, err)
+	}
+	return nil
+}
+
+// Open opens the photo file for reading.
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error) {
+	src, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ObjectInfo{}, ErrNotFound
+		}
+		return nil, ObjectInfo{}, fmt.Errorf("failed to open photo %s: %w", key, err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("failed to stat photo %s: %w", key, err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	return f, ObjectInfo{Size: stat.Size(), ContentType: mime.TypeByExtension(path.Ext(key)), ModTime: stat.ModTime()}, nil
+}
+
+// Exists reports whether the photo file exists.
+func (s *LocalStore) Exists(ctx context.Context, key string) (bool, error) {
+	src, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	stat, err := os.Stat(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat photo %s: %w", key, err)
+	}
+	return !stat.IsDir(), nil
+}
+
+// Delete removes the photo file.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	src, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete photo %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns the photo's URL below BaseURL.
+func (s *LocalStore) URL(key string) string {
+	return s.BaseURL + key
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3Store.
+type S3Config struct {
+	Endpoint  string // Host and optional port, e.g. "s3.amazonaws.com" or "minio:9000"
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string // Optional
+	UseSSL    bool
+	// BaseURL is the URL prefix photos are loaded from: a public bucket or CDN URL,
+	// or the backend's own photo route, which streams them from the bucket.
+	BaseURL string
+}
+
+// S3Store keeps photos as objects in an S3-compatible bucket.
+type S3Store struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3Store connects to the bucket described by cfg, creating it if it doesn't exist.
+func NewS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint and bucket are required")
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client for %s: %w", cfg.Endpoint, err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check S3 bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create S3 bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL != "" && !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket, baseURL: baseURL}, nil
+}
+
+// isNoSuchKey reports whether err means the object doesn't exist.
+func isNoSuchKey(err error) bool {
+	return minio.ToErrorResponse(err).Code == minio.NoSuchKey
+}
+
+// Put uploads the photo. Keys are never reused for different content, so the
+// object may be cached indefinitely.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload photo %s: %w", key, err)
+	}
+	return nil
+}
+
+// Open fetches the photo. The returned reader issues ranged requests as it is
+// seeked, so serving part of a photo doesn't download all of it.
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to fetch photo %s: %w", key, err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if isNoSuchKey(err) {
+			return nil, ObjectInfo{}, ErrNotFound
+		}
+		return nil, ObjectInfo{}, fmt.Errorf("failed to stat photo %s: %w", key, err)
+	}
+	return obj, ObjectInfo{Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+// Exists reports whether the object exists.
+func (s *S3Store) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ValidateKey(key); err != nil {
+		return false, err
+	}
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if isNoSuchKey(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat photo %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Delete removes the object. S3 treats deleting a missing object as success.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete photo %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns the photo's URL below the configured base URL.
+func (s *S3Store) URL(key string) string {
+	return s.baseURL + key
+}
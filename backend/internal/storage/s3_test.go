@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestS3Store connects to the bucket configured with the same variables as the server,
+// e.g. the MinIO service of docker-compose.yml, and skips the test when S3_ENDPOINT is unset.
+func newTestS3Store(t *testing.T) *S3Store {
+	t.Helper()
+	cfg := S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		Region:    os.Getenv("S3_REGION"),
+		BaseURL:   "/uploads/images",
+	}
+	if cfg.Endpoint == "" {
+		t.Skip("S3_ENDPOINT is not set; start MinIO with docker-compose --profile s3 up to run this test")
+	}
+	if cfg.Bucket == "" {
+		cfg.Bucket = "gorecipes-test"
+	}
+	if useSSL := os.Getenv("S3_USE_SSL"); useSSL != "" {
+		var err error
+		if cfg.UseSSL, err = strconv.ParseBool(useSSL); err != nil {
+			t.Fatalf("invalid S3_USE_SSL %q: %v", useSSL, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := NewS3Store(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	return store
+}
+
+func TestS3StorePutExistsDelete(t *testing.T) {
+	store := newTestS3Store(t)
+	ctx := context.Background()
+	key := "recipes/" + uuid.NewString() + "/" + uuid.NewString() + "_full.jpg"
+	content := []byte("not really a jpeg")
+	t.Cleanup(func() { store.Delete(context.Background(), key) })
+
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Fatalf("Exists before Put = %v, %v; want false, nil", exists, err)
+	}
+	if err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if exists, err := store.Exists(ctx, key); err != nil || !exists {
+		t.Fatalf("Exists after Put = %v, %v; want true, nil", exists, err)
+	}
+
+	obj, info, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, err := io.ReadAll(obj)
+	obj.Close()
+	if err != nil {
+		t.Fatalf("reading object: %v", err)
+	}
+	if !bytes.Equal(got, content) || info.Size != int64(len(content)) || info.ContentType != "image/jpeg" {
+		t.Errorf("Open = %q, %+v; want %q as image/jpeg", got, info, content)
+	}
+	if url := store.URL(key); url != "/uploads/images/"+key {
+		t.Errorf("URL = %q, want %q", url, "/uploads/images/"+key)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Errorf("Exists after Delete = %v, %v; want false, nil", exists, err)
+	}
+	// Deleting a missing photo is not an error
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing photo: %v", err)
+	}
+}
+
+func TestS3StoreRejectsInvalidKeys(t *testing.T) {
+	store := newTestS3Store(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/absolute.jpg", "recipes/../secret", "recipes\\photo.jpg"} {
+		if err := store.Put(ctx, key, bytes.NewReader(nil), 0, "image/jpeg"); err != ErrInvalidKey {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+		if _, err := store.Exists(ctx, key); err != ErrInvalidKey {
+			t.Errorf("Exists(%q) = %v, want ErrInvalidKey", key, err)
+		}
+		if err := store.Delete(ctx, key); err != ErrInvalidKey {
+			t.Errorf("Delete(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
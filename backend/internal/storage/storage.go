@@ -0,0 +1,55 @@
+// Package storage keeps recipe photos in a blob store, addressed by opaque keys.
+// The local filesystem suits a single server; an S3-compatible bucket (AWS S3,
+// MinIO...) lets several backend replicas share the same photos.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no photo is stored under a key.
+var ErrNotFound = errors.New("photo not found")
+
+// ErrInvalidKey is returned for keys that are empty or try to escape the store,
+// e.g. "../secret" or "/etc/passwd".
+var ErrInvalidKey = errors.New("invalid photo key")
+
+// ObjectInfo describes a stored photo.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// PhotoStore stores photo files under opaque, slash-separated keys such as
+// "recipes/<recipe id>/<uuid>_full.jpg".
+type PhotoStore interface {
+	// Put stores size bytes read from r under key, replacing any existing photo.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the photo stored under key, ready to be served with http.ServeContent.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error)
+	// Exists reports whether a photo is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the photo stored under key. Deleting a missing photo is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL clients load the photo from.
+	URL(key string) string
+}
+
+// ValidateKey checks that key is a clean, relative, slash-separated path.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
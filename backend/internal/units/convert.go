@@ -0,0 +1,175 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ladders list, per measuring system, the units an amount may be re-expressed in,
+// smallest first, together with the smallest amount that reads naturally in that unit.
+// 48 tsp therefore becomes 1 cup, and 1500 g becomes 1.5 kg.
+type rung struct {
+	name string
+	min  float64
+}
+
+var ladders = map[Dimension]map[bool][]rung{
+	Volume: {
+		true:  {{"ml", 0}, {"l", 1}},
+		false: {{"tsp", 0}, {"tbsp", 1}, {"cup", 0.25}},
+	},
+	Mass: {
+		true:  {{"g", 0}, {"kg", 1}},
+		false: {{"oz", 0}, {"lb", 1}},
+	},
+}
+
+// Convert converts an amount between two units of the same dimension.
+func Convert(amount float64, from, to Unit) (float64, error) {
+	if from.Name == to.Name {
+		return amount, nil
+	}
+	if from.Dimension != to.Dimension || from.Dimension == Count {
+		return 0, fmt.Errorf("cannot convert %s to %s", from.Name, to.Name)
+	}
+	return amount * from.ToBase / to.ToBase, nil
+}
+
+// Humanize re-expresses an amount in the most readable unit of the same
+// measuring system, e.g. 48 tsp -> 1 cup, 0.25 tbsp -> 0.75 tsp, 1500 g -> 1.5 kg.
+// Units that are not part of a ladder (count units, fl oz, pints, mg...) are returned as-is.
+func Humanize(amount float64, u Unit) (float64, Unit) {
+	ladder, ok := ladders[u.Dimension][u.Metric]
+	if !ok || !inLadder(ladder, u.Name) {
+		return amount, u
+	}
+	base := amount * u.ToBase
+	best := u
+	bestAmount := amount
+	for _, r := range ladder {
+		candidate := byName[r.name]
+		value := base / candidate.ToBase
+		// The factors are rounded, so 3 tsp comes out a hair under 1 tbsp
+		if value >= r.min*0.999 || r.min == 0 {
+			best, bestAmount = candidate, value
+		}
+	}
+	return bestAmount, best
+}
+
+// Scale multiplies an amount expressed in the named unit by factor and returns
+// it humanized. An empty or unknown unit name is scaled without conversion.
+func Scale(amount float64, unitName string, factor float64) (float64, string) {
+	scaled := amount * factor
+	u, ok := ByName(unitName)
+	if !ok {
+		return scaled, unitName
+	}
+	scaled, u = Humanize(scaled, u)
+	return scaled, u.Name
+}
+
+// FormatAmount renders an amount for display. Imperial and count amounts are
+// shown as kitchen fractions ("1 1/2"), metric amounts as rounded decimals ("1.5").
+// Amounts too small for either keep their first significant digit, so a non-zero
+// amount never reads "0".
+func FormatAmount(amount float64, unitName string) string {
+	u, known := ByName(unitName)
+	if known && u.Metric {
+		return formatDecimal(amount)
+	}
+	if s, ok := formatFraction(amount); ok {
+		return s
+	}
+	return formatDecimal(amount)
+}
+
+// FormatQuantity renders an amount and unit, e.g. "1 1/2 cups" or "1.5 kg".
+func FormatQuantity(amount float64, unitName string) string {
+	text := FormatAmount(amount, unitName)
+	if unitName == "" {
+		return text
+	}
+	return text + " " + Plural(unitName, amount)
+}
+
+// Plural returns the display form of a unit name for the given amount.
+// Abbreviations are never pluralized.
+func Plural(unitName string, amount float64) string {
+	if amount < 1.01 { // tolerate floating point noise from conversions
+		return unitName
+	}
+	switch unitName {
+	case "cup", "pint", "quart", "gallon", "clove", "can", "jar", "package", "head",
+		"slice", "piece", "stick", "sprig", "stalk", "sheet", "handful", "drop":
+		return unitName + "s"
+	case "pinch", "dash", "bunch":
+		return unitName + "es"
+	}
+	return unitName
+}
+
+func inLadder(ladder []rung, name string) bool {
+	for _, r := range ladder {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDecimal rounds to a precision that suits the magnitude of the amount.
+func formatDecimal(amount float64) string {
+	var rounded float64
+	switch {
+	case amount >= 100:
+		rounded = math.Round(amount)
+	case amount >= 10:
+		rounded = math.Round(amount*10) / 10
+	default:
+		rounded = math.Round(amount*100) / 100
+		if rounded == 0 && amount > 0 {
+			scale := math.Pow(10, -math.Floor(math.Log10(amount)))
+			rounded = math.Round(amount*scale) / scale
+		}
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// formatFraction renders amounts close to a multiple of 1/8 or 1/3 as a mixed fraction.
+// Amounts that would round to 0 are left to formatDecimal.
+func formatFraction(amount float64) (string, bool) {
+	whole := math.Floor(amount)
+	frac := amount - whole
+	for _, den := range []float64{2, 3, 4, 8} {
+		num := math.Round(frac * den)
+		if math.Abs(frac-num/den) > 0.02 {
+			continue
+		}
+		if num == den {
+			whole, num = whole+1, 0
+		}
+		if whole == 0 && num == 0 {
+			return "", false
+		}
+		var parts []string
+		if whole > 0 || num == 0 {
+			parts = append(parts, strconv.FormatFloat(whole, 'f', -1, 64))
+		}
+		if num > 0 {
+			g := gcd(int(num), int(den))
+			parts = append(parts, fmt.Sprintf("%d/%d", int(num)/g, int(den)/g))
+		}
+		return strings.Join(parts, " "), true
+	}
+	return "", false
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
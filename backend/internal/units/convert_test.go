@@ -0,0 +1,157 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func mustUnit(t *testing.T, name string) Unit {
+	t.Helper()
+	u, ok := ByName(name)
+	if !ok {
+		t.Fatalf("unknown unit %q", name)
+	}
+	return u
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		alias, want string
+		ok          bool
+	}{
+		{"Tablespoons", "tbsp", true},
+		{"tbsp.", "tbsp", true},
+		{"tsp.", "tsp", true},
+		{"fl oz", "fl oz", true},
+		{"fl. oz", "fl oz", true},
+		{"Fluid Ounces", "fl oz", true},
+		{"kilos", "kg", true},
+		{"tins", "can", true},
+		{"handful.", "handful", true},
+		{"smidgen", "", false},
+	}
+	for _, tt := range tests {
+		u, ok := Lookup(tt.alias)
+		if ok != tt.ok || u.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tt.alias, u.Name, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+	}{
+		// Volume
+		{3, "tsp", "tbsp", 1},
+		{16, "tbsp", "cup", 1},
+		{1, "cup", "ml", 236.588},
+		{2, "pint", "quart", 1},
+		{1, "l", "ml", 1000},
+		{8, "fl oz", "cup", 1},
+
+		// Mass
+		{1500, "g", "kg", 1.5},
+		{16, "oz", "lb", 1},
+		{1, "lb", "g", 453.592},
+		{250, "mg", "g", 0.25},
+
+		{2, "clove", "clove", 2},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.amount, mustUnit(t, tt.from), mustUnit(t, tt.to))
+		if err != nil {
+			t.Errorf("Convert(%v, %s, %s) error: %v", tt.amount, tt.from, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("Convert(%v, %s, %s) = %v, want %v", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestConvertRejectsOtherDimensions(t *testing.T) {
+	tests := []struct{ from, to string }{
+		{"cup", "g"},
+		{"kg", "l"},
+		{"clove", "can"},
+		{"pinch", "tsp"},
+	}
+	for _, tt := range tests {
+		if _, err := Convert(1, mustUnit(t, tt.from), mustUnit(t, tt.to)); err == nil {
+			t.Errorf("Convert(1, %s, %s) succeeded, want an error", tt.from, tt.to)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		unit     string
+		factor   float64
+		want     float64
+		wantUnit string
+	}{
+		// Volume climbs and descends the imperial and metric ladders
+		{"teaspoons to cups", 12, "tsp", 4, 1, "cup"},
+		{"teaspoons to tablespoons", 1, "tsp", 3, 1, "tbsp"},
+		{"tablespoons down to teaspoons", 1, "tbsp", 0.25, 0.75, "tsp"},
+		{"cups stay cups", 1, "cup", 2, 2, "cup"},
+		{"small cups to tablespoons", 1, "cup", 0.125, 2, "tbsp"},
+		{"millilitres to litres", 500, "ml", 3, 1.5, "l"},
+		{"litres to millilitres", 1, "l", 0.5, 500, "ml"},
+
+		// Mass
+		{"grams to kilograms", 500, "g", 3, 1.5, "kg"},
+		{"kilograms to grams", 1, "kg", 0.25, 250, "g"},
+		{"ounces to pounds", 8, "oz", 4, 2, "lb"},
+		{"pounds to ounces", 1, "lb", 0.5, 8, "oz"},
+
+		// Units outside a ladder are only multiplied
+		{"fluid ounces", 4, "fl oz", 2, 8, "fl oz"},
+		{"milligrams", 500, "mg", 4, 2000, "mg"},
+		{"count unit", 2, "clove", 1.5, 3, "clove"},
+		{"no unit", 3, "", 2, 6, ""},
+		{"unknown unit", 1, "smidgen", 2, 2, "smidgen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit := Scale(tt.amount, tt.unit, tt.factor)
+			if unit != tt.wantUnit || math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("Scale(%v, %q, %v) = %v %s, want %v %s", tt.amount, tt.unit, tt.factor, got, unit, tt.want, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		amount float64
+		unit   string
+		want   string
+	}{
+		{1.5, "cup", "1 1/2 cups"},
+		{0.75, "tsp", "3/4 tsp"},
+		{0.333, "cup", "1/3 cup"},
+		{2, "clove", "2 cloves"},
+		{1.5, "kg", "1.5 kg"},
+		{12.34, "g", "12.3 g"},
+		{3, "", "3"},
+		{1, "pinch", "1 pinch"},
+		{2, "pinch", "2 pinches"},
+
+		// Tiny amounts, e.g. 1/8 tsp scaled by 0.1, never show as 0
+		{0.0125, "tsp", "0.01 tsp"},
+		{0.004, "cup", "0.004 cup"},
+		{0.004, "g", "0.004 g"},
+		{0, "tsp", "0 tsp"},
+	}
+	for _, tt := range tests {
+		if got := FormatQuantity(tt.amount, tt.unit); got != tt.want {
+			t.Errorf("FormatQuantity(%v, %q) = %q, want %q", tt.amount, tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package units holds the catalog of cooking units recognised by the ingredient
+// parser, along with the factors needed to convert between units of the same kind.
+package units
+
+import "strings"
+
+// Dimension groups units that can be converted into one another.
+type Dimension string
+
+const (
+	// Volume units convert through millilitres.
+	Volume Dimension = "volume"
+	// Mass units convert through grams.
+	Mass Dimension = "mass"
+	// Count units (cans, cloves, pinches...) are never converted.
+	Count Dimension = "count"
+)
+
+// Unit describes a single canonical unit of measurement.
+type Unit struct {
+	Name      string    // Canonical short name, e.g. "tbsp"
+	Dimension Dimension // What the unit measures
+	ToBase    float64   // Multiplier to the dimension's base unit (ml or g); 0 for Count
+	Metric    bool      // Whether the unit belongs to the metric system
+}
+
+var catalog = []struct {
+	unit    Unit
+	aliases []string
+}{
+	// Volume (base: millilitre)
+	{Unit{"ml", Volume, 1, true}, []string{"ml", "mls", "milliliter", "milliliters", "millilitre", "millilitres"}},
+	{Unit{"cl", Volume, 10, true}, []string{"cl", "centiliter", "centiliters", "centilitre", "centilitres"}},
+	{Unit{"dl", Volume, 100, true}, []string{"dl", "deciliter", "deciliters", "decilitre", "decilitres"}},
+	{Unit{"l", Volume, 1000, true}, []string{"l", "liter", "liters", "litre", "litres", "ltr"}},
+	{Unit{"tsp", Volume, 4.92892, false}, []string{"tsp", "tsps", "teaspoon", "teaspoons"}},
+	{Unit{"tbsp", Volume, 14.7868, false}, []string{"tbsp", "tbsps", "tbs", "tbl", "tablespoon", "tablespoons"}},
+	{Unit{"fl oz", Volume, 29.5735, false}, []string{"fl oz", "fl. oz", "floz", "fluid ounce", "fluid ounces"}},
+	{Unit{"cup", Volume, 236.588, false}, []string{"cup", "cups"}},
+	{Unit{"pint", Volume, 473.176, false}, []string{"pint", "pints", "pt", "pts"}},
+	{Unit{"quart", Volume, 946.353, false}, []string{"quart", "quarts", "qt", "qts"}},
+	{Unit{"gallon", Volume, 3785.41, false}, []string{"gallon", "gallons", "gal", "gals"}},
+
+	// Mass (base: gram)
+	{Unit{"mg", Mass, 0.001, true}, []string{"mg", "milligram", "milligrams", "milligramme", "milligrammes"}},
+	{Unit{"g", Mass, 1, true}, []string{"g", "gr", "grs", "gram", "grams", "gramme", "grammes"}},
+	{Unit{"kg", Mass, 1000, true}, []string{"kg", "kgs", "kilo", "kilos", "kilogram", "kilograms", "kilogramme", "kilogrammes"}},
+	{Unit{"oz", Mass, 28.3495, false}, []string{"oz", "ozs", "ounce", "ounces"}},
+	{Unit{"lb", Mass, 453.592, false}, []string{"lb", "lbs", "pound", "pounds"}},
+
+	// Count-like units that only make sense as-is
+	{Unit{"pinch", Count, 0, false}, []string{"pinch", "pinches"}},
+	{Unit{"dash", Count, 0, false}, []string{"dash", "dashes"}},
+	{Unit{"drop", Count, 0, false}, []string{"drop", "drops"}},
+	{Unit{"clove", Count, 0, false}, []string{"clove", "cloves"}},
+	{Unit{"can", Count, 0, false}, []string{"can", "cans", "tin", "tins"}},
+	{Unit{"jar", Count, 0, false}, []string{"jar", "jars"}},
+	{Unit{"package", Count, 0, false}, []string{"package", "packages", "pkg", "packet", "packets", "pack", "packs"}},
+	{Unit{"bunch", Count, 0, false}, []string{"bunch", "bunches"}},
+	{Unit{"head", Count, 0, false}, []string{"head", "heads"}},
+	{Unit{"slice", Count, 0, false}, []string{"slice", "slices"}},
+	{Unit{"piece", Count, 0, false}, []string{"piece", "pieces"}},
+	{Unit{"stick", Count, 0, false}, []string{"stick", "sticks"}},
+	{Unit{"sprig", Count, 0, false}, []string{"sprig", "sprigs"}},
+	{Unit{"stalk", Count, 0, false}, []string{"stalk", "stalks"}},
+	{Unit{"sheet", Count, 0, false}, []string{"sheet", "sheets"}},
+	{Unit{"handful", Count, 0, false}, []string{"handful", "handfuls"}},
+}
+
+var (
+	byAlias = make(map[string]Unit)
+	byName  = make(map[string]Unit)
+	// MaxAliasWords is the largest number of words used by any alias ("fluid ounces" = 2).
+	MaxAliasWords = 1
+)
+
+func init() {
+	for _, entry := range catalog {
+		byName[entry.unit.Name] = entry.unit
+		for _, alias := range entry.aliases {
+			byAlias[alias] = entry.unit
+			if n := len(strings.Fields(alias)); n > MaxAliasWords {
+				MaxAliasWords = n
+			}
+		}
+	}
+}
+
+// Lookup resolves a unit as written in a recipe ("Tablespoons", "tbsp.") to its canonical Unit.
+func Lookup(alias string) (Unit, bool) {
+	key := strings.ToLower(strings.TrimSpace(alias))
+	key = strings.TrimSuffix(key, ".")
+	u, ok := byAlias[key]
+	return u, ok
+}
+
+// ByName returns the canonical unit with the given name, as stored in the database.
+func ByName(name string) (Unit, bool) {
+	u, ok := byName[name]
+	return u, ok
+}
@@ -5,7 +5,12 @@
 export interface MealPlanEntry {
 	id: string;         // Unique ID for this meal plan entry
 	date: string;       // The specific date as an ISO string (e.g., "2023-10-26T00:00:00Z")
-	recipe_id: string;  // ID of the planned recipe
+	recipe_id?: string; // ID of the planned recipe; absent for free-text entries such as "Leftovers"
+	title: string;      // The free text, or the planned recipe's name
+	slot: string;       // breakfast, lunch, dinner, snack or a custom meal name
+	servings?: number;  // Servings the meal is planned for, when not the recipe's own
+	notes?: string;
+	sort_order: number; // Order within its meal on the day, from 0
 	created_at: string; // Timestamp of when the entry was created (ISO string)
 	// recipe?: Recipe; // Optional: To hold hydrated recipe details if fetched
 }
@@ -172,7 +172,7 @@ export async function addCustomRecipeToMealPlan(date: Date, recipeName: string):
 		const response = await fetch(`${API_BASE_URL}/entries`, {
 			method: 'POST',
 			headers: { 'Content-Type': 'application/json' },
-			body: JSON.stringify({ date: dateStr, recipe_id: recipeName }), // Use recipe name as ID for custom recipes
+			body: JSON.stringify({ date: dateStr, title: recipeName }),
 		});
 		if (!response.ok) {
 			const errorData = await response.json().catch(() => ({ error: 'Failed to add custom recipe to meal plan.' }));
@@ -4,10 +4,17 @@ export interface Recipe {
 	ingredients: string[];
 	method: string;
 	photo_filename?: string; // Optional, as it might be placeholder
+	photo_urls?: PhotoURLs; // Sized variants of the photo
 	created_at: string; // ISO date string
 	updated_at: string; // ISO date string
 }
 
+export interface PhotoURLs {
+	thumb: string;
+	card: string;
+	full: string;
+}
+
 export interface PaginatedRecipesResponse {
 	recipes: Recipe[];
 	total_recipes: number;